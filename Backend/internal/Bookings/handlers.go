@@ -2,14 +2,17 @@ package bookings
 
 import (
 	"encoding/json"
+	"errors"
 	"log"
 	"net/http"
 	"sync"
 
 	repo "hack4good-backend/db/sqlc"
+	"hack4good-backend/internal/auth"
 	jsonutil "hack4good-backend/internal/json"
 
 	chi "github.com/go-chi/chi/v5"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
@@ -27,9 +30,62 @@ func NewHandler(service Service) *GetBooking {
 	}
 }
 
+// user the booking is for (the participant when booked on their behalf)
+func bookingSubject(userID int32, bookedFor pgtype.Int4) int32 {
+	if bookedFor.Valid {
+		return bookedFor.Int32
+	}
+	return userID
+}
+
+// check the signed-in user may act on a booking for subjectID, writing the error response if not
+func (h *GetBooking) authorize(w http.ResponseWriter, r *http.Request, perm auth.ScopedPermission, subjectID int32) bool {
+	ok, err := auth.CanActFor(r.Context(), perm, subjectID, h.service.IsCaregiverOf)
+	if err != nil {
+		log.Println(err)
+		http.Error(w, "failed to check permissions", http.StatusInternalServerError)
+		return false
+	}
+	if !ok {
+		http.Error(w, "forbidden", http.StatusForbidden)
+		return false
+	}
+	return true
+}
+
+// load a booking the signed-in user may act on, writing the error response if not
+func (h *GetBooking) loadBooking(w http.ResponseWriter, r *http.Request, id string, perm auth.ScopedPermission) (repo.Booking, bool) {
+	booking, err := h.service.GetBookingByID(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			http.Error(w, "booking not found", http.StatusNotFound)
+			return repo.Booking{}, false
+		}
+		log.Println(err)
+		http.Error(w, "failed to get booking", http.StatusInternalServerError)
+		return repo.Booking{}, false
+	}
+	if !h.authorize(w, r, perm, bookingSubject(booking.UserID, booking.BookedForUserID)) {
+		return repo.Booking{}, false
+	}
+	return booking, true
+}
+
 // method 
 func (h *GetBooking) ListBookings(w http.ResponseWriter, r *http.Request) {
-	bookings, err := h.service.ListBookings(r.Context())
+	claims, ok := auth.FromContext(r.Context())
+	if !ok {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var bookings []repo.Booking
+	var err error
+	if auth.Can(r.Context(), auth.PermBookingsReadAny) {
+		bookings, err = h.service.ListBookings(r.Context())
+	} else {
+		bookings, err = h.service.ListBookingsByUserID(r.Context(), claims.ID, auth.Can(r.Context(), auth.PermBookingsReadDependents))
+	}
 	if err != nil {
 		log.Println(err)
 		http.Error(w, err.Error(), http.StatusInternalServerError)
@@ -45,8 +101,12 @@ type CreateBooking struct {
 	ActivityID      int32 `json:"activity_id"`
 	UserID          int32    `json:"user_id"`
 	BookedForUserID pgtype.Int4     `json:"booked_for_user_id"`
-	Role            string `json:"role"` // participant or volunteer
+	Role            string `json:"role"` // participant or volunteer, must match the account booked (optional)
 	IsPaid          bool   `json:"is_paid"`
+	CreatedByStaffID pgtype.Int4 `json:"-"` // set when staff book on behalf of the user
+	Waitlist        bool   `json:"waitlist"` // join the waitlist instead when over the weekly quota
+	OverrideQuota   bool   `json:"override_quota"` // staff only
+	QuotaOverrideBy pgtype.Int4 `json:"-"`
 }
 
 func (h *GetBooking) CreateBooking(w http.ResponseWriter, r *http.Request) {
@@ -56,8 +116,44 @@ func (h *GetBooking) CreateBooking(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// only staff may book as someone else or mark a booking paid
+	claims, ok := auth.FromContext(r.Context())
+	if !ok {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	if !auth.Can(r.Context(), auth.PermBookingsWriteAny) {
+		req.UserID = claims.ID
+		req.IsPaid = false
+	}
+	if !h.authorize(w, r, auth.BookingsWrite, bookingSubject(req.UserID, req.BookedForUserID)) {
+		return
+	}
+	if claims.Impersonated() {
+		req.CreatedByStaffID = pgtype.Int4{Int32: claims.ImpersonatorID, Valid: true}
+	}
+	if !h.bookingRole(w, r, &req) {
+		return
+	}
+	if !quotaOverride(w, r, &req) {
+		return
+	}
+
 	// Call service to create booking
 	booking, err := h.service.CreateBooking(r.Context(), req)
+	var quotaErr *QuotaError
+	if errors.As(err, &quotaErr) && req.Waitlist {
+		entry, err := h.service.JoinWaitlist(r.Context(), req)
+		if err != nil {
+			writeWaitlistError(w, err, "failed to join waitlist")
+			return
+		}
+		jsonutil.Write(w, http.StatusAccepted, entry)
+		return
+	}
+	if writeQuotaError(w, err) {
+		return
+	}
 	if err != nil {
 		log.Println(err)
 		http.Error(w, err.Error(), http.StatusInternalServerError)
@@ -75,6 +171,10 @@ func (h *GetBooking) DeleteBookingByID(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if _, ok := h.loadBooking(w, r, id, auth.BookingsWrite); !ok {
+		return
+	}
+
 	// Call service to delete booking
 	err := h.service.DeleteBookingByID(r.Context(), id)
 	if err != nil {
@@ -140,12 +240,32 @@ func (h *GetBooking) UpdateBooking(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	existing, ok := h.loadBooking(w, r, id, auth.BookingsWrite)
+	if !ok {
+		return
+	}
+
 	var req CreateBooking
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		http.Error(w, "invalid request body", http.StatusBadRequest)
 		return
 	}
 
+	// the booking may not be moved to someone the user cannot act for, nor marked paid
+	if !auth.Can(r.Context(), auth.PermBookingsWriteAny) {
+		req.UserID = existing.UserID
+		req.IsPaid = existing.IsPaid
+	}
+	if !h.authorize(w, r, auth.BookingsWrite, bookingSubject(req.UserID, req.BookedForUserID)) {
+		return
+	}
+	if !h.bookingRole(w, r, &req) {
+		return
+	}
+	if !quotaOverride(w, r, &req) {
+		return
+	}
+
 	// Call service to update booking
 	booking, err := h.service.UpdateBooking(r.Context(), id, repo.UpdateBookingParams{
 		ActivityID:      req.ActivityID,
@@ -153,8 +273,15 @@ func (h *GetBooking) UpdateBooking(w http.ResponseWriter, r *http.Request) {
 		BookedForUserID: req.BookedForUserID,
 		Role:            req.Role,
 		IsPaid:          req.IsPaid,
+		QuotaOverrideBy: req.QuotaOverrideBy,
+		// not part of the request, so a PATCH keeps cancellation and attendance as they are
+		AttendanceStatus: existing.AttendanceStatus,
+		CancelledAt:      existing.CancelledAt,
 	})
 
+	if writeQuotaError(w, err) {
+		return
+	}
 	if err != nil {
 		log.Println(err)
 		http.Error(w, "failed to update booking", http.StatusInternalServerError)
@@ -0,0 +1,281 @@
+package bookings
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	repo "hack4good-backend/db/sqlc"
+	"hack4good-backend/internal/env"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// activities a participant may book per ISO week (Monday to Sunday), by participant_profiles.membership_type;
+// membership types not in it have no limit
+type Quotas map[string]int
+
+// BOOKING_QUOTA_* set the limit of each membership type, 0 for none; "Ad hoc" has no set
+// frequency, so it is only limited when BOOKING_QUOTA_AD_HOC is set, and
+// "3 or more times a week" and participants without a membership type are never limited
+func QuotasFromEnv() Quotas {
+	quotas := Quotas{}
+	for membership, limit := range map[string]int{
+		"Ad hoc":       env.GetInt("BOOKING_QUOTA_AD_HOC", 0),
+		"once a week":  env.GetInt("BOOKING_QUOTA_ONCE_A_WEEK", 1),
+		"twice a week": env.GetInt("BOOKING_QUOTA_TWICE_A_WEEK", 2),
+	} {
+		if limit > 0 {
+			quotas[membership] = limit
+		}
+	}
+	return quotas
+}
+
+var (
+	ErrActivityNotFound      = errors.New("activity not found")
+	ErrWaitlistEntryNotFound = errors.New("waitlist entry not found")
+	ErrAlreadyWaitlisted     = errors.New("already on the waitlist for this activity")
+	ErrNotParticipantBooking = errors.New("only participant bookings can be waitlisted")
+	ErrUserNotFound          = errors.New("user not found")
+	ErrNotBookable           = errors.New("only participants and volunteers can be booked")
+	ErrRoleMismatch          = errors.New("role does not match the account being booked")
+)
+
+// a participant booking that would go over the weekly quota of their membership
+type QuotaError struct {
+	MembershipType string `json:"membership_type"`
+	WeeklyLimit    int    `json:"weekly_limit"`
+	Booked         int64  `json:"booked"`     // active bookings in that week already
+	WeekStart      string `json:"week_start"` // Monday, YYYY-MM-DD
+	WeekEnd        string `json:"week_end"`   // Sunday
+}
+
+func (e *QuotaError) Error() string {
+	return fmt.Sprintf("weekly booking limit reached: the %q membership allows %s per week (Monday to Sunday) "+
+		"and %s already booked from %s to %s; join the waitlist or ask staff to make an exception",
+		e.MembershipType, activities(int64(e.WeeklyLimit)), activities(e.Booked), e.WeekStart, e.WeekEnd)
+}
+
+func activities(n int64) string {
+	if n == 1 {
+		return "1 activity"
+	}
+	return fmt.Sprintf("%d activities", n)
+}
+
+// the participant a booking counts against, 0 for volunteer bookings
+func participantOf(userID int32, bookedFor pgtype.Int4, role string) int32 {
+	if role != "participant" {
+		return 0
+	}
+	return bookingSubject(userID, bookedFor)
+}
+
+// non-zero ids, lowest first
+func lockOrder(a, b int32) []int32 {
+	switch {
+	case a == 0 || a == b:
+		return []int32{b}
+	case b == 0:
+		return []int32{a}
+	case a > b:
+		return []int32{b, a}
+	}
+	return []int32{a, b}
+}
+
+func (s *svc) getActivity(ctx context.Context, q *repo.Queries, id int32) (repo.Activity, error) {
+	activity, err := q.GetActivityByID(ctx, id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return repo.Activity{}, ErrActivityNotFound
+	}
+	if err != nil {
+		return repo.Activity{}, fmt.Errorf("failed to get activity %d: %w", id, err)
+	}
+	return activity, nil
+}
+
+// check one more booking of activity fits the participant's weekly quota, leaving out excludeID
+// (a booking being moved). Over the quota it fails with a *QuotaError unless staff override it,
+// and returns the override to record on the booking (none when it fits).
+// q must be in a transaction that holds LockUser for the participant.
+func (s *svc) checkQuota(ctx context.Context, q *repo.Queries, participantID int32, activity repo.Activity, excludeID int32, overrideBy pgtype.Int4) (pgtype.Int4, error) {
+	profile, err := q.GetParticipantProfile(ctx, participantID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return pgtype.Int4{}, nil
+	}
+	if err != nil {
+		return pgtype.Int4{}, fmt.Errorf("failed to get profile of user %d: %w", participantID, err)
+	}
+	limit, ok := s.quotas[profile.MembershipType.String]
+	if !ok {
+		return pgtype.Int4{}, nil
+	}
+
+	booked, err := q.CountParticipantBookingsInWeek(ctx, repo.CountParticipantBookingsInWeekParams{
+		ParticipantID: participantID,
+		WeekOf:        activity.StartTime,
+		ExcludeID:     excludeID,
+	})
+	if err != nil {
+		return pgtype.Int4{}, fmt.Errorf("failed to count bookings of user %d: %w", participantID, err)
+	}
+	if booked < int64(limit) {
+		return pgtype.Int4{}, nil
+	}
+	if overrideBy.Valid {
+		return overrideBy, nil
+	}
+
+	monday := weekStart(activity.StartTime.Time)
+	return pgtype.Int4{}, &QuotaError{
+		MembershipType: profile.MembershipType.String,
+		WeeklyLimit:    limit,
+		Booked:         booked,
+		WeekStart:      monday.Format(time.DateOnly),
+		WeekEnd:        monday.AddDate(0, 0, 6).Format(time.DateOnly),
+	}
+}
+
+// midnight on the Monday of t's ISO week
+func weekStart(t time.Time) time.Time {
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	return day.AddDate(0, 0, -(int(day.Weekday())+6)%7)
+}
+
+// turn the participant's oldest waitlist entries in the week of activityID into bookings while the quota allows
+func (s *svc) promoteWaitlist(ctx context.Context, q *repo.Queries, participantID, activityID int32) error {
+	activity, err := s.getActivity(ctx, q, activityID)
+	if err != nil {
+		return err
+	}
+	entries, err := q.ListParticipantWaitlistInWeek(ctx, repo.ListParticipantWaitlistInWeekParams{
+		ParticipantID: participantID,
+		WeekOf:        activity.StartTime,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to list waitlist of user %d: %w", participantID, err)
+	}
+
+	for _, entry := range entries {
+		_, err := s.checkQuota(ctx, q, participantID, activity, 0, pgtype.Int4{})
+		var quotaErr *QuotaError
+		if errors.As(err, &quotaErr) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if _, err := q.CreateBooking(ctx, waitlistBooking(entry, pgtype.Int4{})); err != nil {
+			return fmt.Errorf("failed to book waitlist entry %d: %w", entry.ID, err)
+		}
+		if err := q.DeleteBookingWaitlistEntry(ctx, entry.ID); err != nil {
+			return fmt.Errorf("failed to remove waitlist entry %d: %w", entry.ID, err)
+		}
+	}
+	return nil
+}
+
+// booking made for a waitlist entry, by whoever asked for it
+func waitlistBooking(entry repo.BookingWaitlist, quotaOverrideBy pgtype.Int4) repo.CreateBookingParams {
+	params := repo.CreateBookingParams{
+		ActivityID:      entry.ActivityID,
+		UserID:          entry.RequestedBy,
+		Role:            "participant",
+		QuotaOverrideBy: quotaOverrideBy,
+	}
+	if entry.RequestedBy != entry.ParticipantID {
+		params.BookedForUserID = pgtype.Int4{Int32: entry.ParticipantID, Valid: true}
+	}
+	return params
+}
+
+// wait for a place within the quota instead of booking now
+func (s *svc) JoinWaitlist(ctx context.Context, req CreateBooking) (repo.BookingWaitlist, error) {
+	participantID := participantOf(req.UserID, req.BookedForUserID, req.Role)
+	if participantID == 0 {
+		return repo.BookingWaitlist{}, ErrNotParticipantBooking
+	}
+
+	entry, err := s.repo.CreateBookingWaitlistEntry(ctx, repo.CreateBookingWaitlistEntryParams{
+		ActivityID:    req.ActivityID,
+		ParticipantID: participantID,
+		RequestedBy:   req.UserID,
+	})
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		switch pgErr.Code {
+		case "23505": // unique_violation
+			return repo.BookingWaitlist{}, ErrAlreadyWaitlisted
+		case "23503": // foreign_key_violation
+			return repo.BookingWaitlist{}, ErrActivityNotFound
+		}
+	}
+	return entry, err
+}
+
+// upcoming entries; all of them, or those of the user and their linked participants
+func (s *svc) ListWaitlist(ctx context.Context, userID int32, all bool) ([]repo.ListBookingWaitlistRow, error) {
+	return s.repo.ListBookingWaitlist(ctx, pgtype.Int4{Int32: userID, Valid: !all})
+}
+
+func (s *svc) GetWaitlistEntry(ctx context.Context, id int32) (repo.BookingWaitlist, error) {
+	entry, err := s.repo.GetBookingWaitlistEntry(ctx, id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return repo.BookingWaitlist{}, ErrWaitlistEntryNotFound
+	}
+	return entry, err
+}
+
+func (s *svc) LeaveWaitlist(ctx context.Context, id int32) error {
+	return s.repo.DeleteBookingWaitlistEntry(ctx, id)
+}
+
+// book a waitlist entry now (by staff), recording an override if it goes over the quota
+func (s *svc) ConfirmWaitlistEntry(ctx context.Context, id, staffID int32) (repo.Booking, error) {
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return repo.Booking{}, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+	q := s.repo.WithTx(tx)
+
+	entry, err := s.GetWaitlistEntry(ctx, id)
+	if err != nil {
+		return repo.Booking{}, err
+	}
+	if err := q.LockUser(ctx, entry.ParticipantID); err != nil {
+		return repo.Booking{}, fmt.Errorf("failed to lock user %d: %w", entry.ParticipantID, err)
+	}
+	// read again under the lock, the entry may have been booked meanwhile
+	if entry, err = q.GetBookingWaitlistEntry(ctx, id); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return repo.Booking{}, ErrWaitlistEntryNotFound
+		}
+		return repo.Booking{}, fmt.Errorf("failed to get waitlist entry %d: %w", id, err)
+	}
+	activity, err := s.getActivity(ctx, q, entry.ActivityID)
+	if err != nil {
+		return repo.Booking{}, err
+	}
+	override, err := s.checkQuota(ctx, q, entry.ParticipantID, activity, 0, pgtype.Int4{Int32: staffID, Valid: true})
+	if err != nil {
+		return repo.Booking{}, err
+	}
+
+	booking, err := q.CreateBooking(ctx, waitlistBooking(entry, override))
+	if err != nil {
+		return repo.Booking{}, fmt.Errorf("failed to book waitlist entry %d: %w", id, err)
+	}
+	if err := q.DeleteBookingWaitlistEntry(ctx, id); err != nil {
+		return repo.Booking{}, fmt.Errorf("failed to remove waitlist entry %d: %w", id, err)
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return repo.Booking{}, fmt.Errorf("failed to commit booking: %w", err)
+	}
+	return booking, nil
+}
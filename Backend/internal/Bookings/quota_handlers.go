@@ -0,0 +1,154 @@
+package bookings
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+
+	"hack4good-backend/internal/auth"
+	jsonutil "hack4good-backend/internal/json"
+
+	chi "github.com/go-chi/chi/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// body of the 409 answer to a booking over the weekly quota
+type quotaResponse struct {
+	Error string `json:"error"`
+	*QuotaError
+}
+
+// set the booking role from the account booked, writing the error response if the request asks for another
+func (h *GetBooking) bookingRole(w http.ResponseWriter, r *http.Request, req *CreateBooking) bool {
+	role, err := h.service.BookingRole(r.Context(), req.UserID, req.BookedForUserID, req.Role)
+	switch {
+	case errors.Is(err, ErrUserNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return false
+	case errors.Is(err, ErrNotBookable), errors.Is(err, ErrRoleMismatch):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return false
+	case err != nil:
+		log.Println(err)
+		http.Error(w, "failed to check booking role", http.StatusInternalServerError)
+		return false
+	}
+	req.Role = role
+	return true
+}
+
+// let staff book beyond the weekly quota with override_quota, writing the error response for anyone else
+func quotaOverride(w http.ResponseWriter, r *http.Request, req *CreateBooking) bool {
+	if !req.OverrideQuota {
+		return true
+	}
+	claims, ok := auth.FromContext(r.Context())
+	if !ok || !auth.Can(r.Context(), auth.PermBookingsWriteAny) {
+		http.Error(w, "only staff can override the weekly booking quota", http.StatusForbidden)
+		return false
+	}
+	req.QuotaOverrideBy = pgtype.Int4{Int32: claims.ID, Valid: true}
+	return true
+}
+
+// write the response for errors of the quota rules, reporting whether err was one
+func writeQuotaError(w http.ResponseWriter, err error) bool {
+	var quotaErr *QuotaError
+	switch {
+	case errors.As(err, &quotaErr):
+		jsonutil.Write(w, http.StatusConflict, quotaResponse{Error: quotaErr.Error(), QuotaError: quotaErr})
+	case errors.Is(err, ErrActivityNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	default:
+		return false
+	}
+	return true
+}
+
+func writeWaitlistError(w http.ResponseWriter, err error, msg string) {
+	switch {
+	case errors.Is(err, ErrWaitlistEntryNotFound), errors.Is(err, ErrActivityNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, ErrAlreadyWaitlisted):
+		http.Error(w, err.Error(), http.StatusConflict)
+	case errors.Is(err, ErrNotParticipantBooking):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	default:
+		if !writeQuotaError(w, err) {
+			log.Println(err)
+			http.Error(w, msg, http.StatusInternalServerError)
+		}
+	}
+}
+
+func waitlistIDParam(w http.ResponseWriter, r *http.Request) (int32, bool) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "invalid waitlist entry id", http.StatusBadRequest)
+		return 0, false
+	}
+	return int32(id), true
+}
+
+// GET /user/bookings/waitlist (own and linked participants', or all for staff)
+func (h *GetBooking) ListWaitlist(w http.ResponseWriter, r *http.Request) {
+	claims, ok := auth.FromContext(r.Context())
+	if !ok {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	entries, err := h.service.ListWaitlist(r.Context(), claims.ID, auth.Can(r.Context(), auth.PermBookingsReadAny))
+	if err != nil {
+		writeWaitlistError(w, err, "failed to list waitlist")
+		return
+	}
+
+	jsonutil.Write(w, http.StatusOK, entries)
+}
+
+// DELETE /user/bookings/waitlist/{id}
+func (h *GetBooking) LeaveWaitlist(w http.ResponseWriter, r *http.Request) {
+	id, ok := waitlistIDParam(w, r)
+	if !ok {
+		return
+	}
+
+	entry, err := h.service.GetWaitlistEntry(r.Context(), id)
+	if err != nil {
+		writeWaitlistError(w, err, "failed to get waitlist entry")
+		return
+	}
+	if !h.authorize(w, r, auth.BookingsWrite, entry.ParticipantID) {
+		return
+	}
+
+	if err := h.service.LeaveWaitlist(r.Context(), id); err != nil {
+		writeWaitlistError(w, err, "failed to leave waitlist")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// POST /dashboard/bookings/waitlist/{id}/confirm (by staff, even over the quota)
+func (h *GetBooking) ConfirmWaitlistEntry(w http.ResponseWriter, r *http.Request) {
+	claims, ok := auth.FromContext(r.Context())
+	if !ok {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	id, ok := waitlistIDParam(w, r)
+	if !ok {
+		return
+	}
+
+	booking, err := h.service.ConfirmWaitlistEntry(r.Context(), id, claims.ID)
+	if err != nil {
+		writeWaitlistError(w, err, "failed to book waitlist entry")
+		return
+	}
+
+	jsonutil.Write(w, http.StatusCreated, booking)
+}
@@ -0,0 +1,111 @@
+package bookings
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+func TestParticipantOf(t *testing.T) {
+	bookedFor := pgtype.Int4{Int32: 7, Valid: true}
+	tests := []struct {
+		name      string
+		userID    int32
+		bookedFor pgtype.Int4
+		role      string
+		want      int32
+	}{
+		{"own participant booking", 3, pgtype.Int4{}, "participant", 3},
+		{"booked by a caregiver", 3, bookedFor, "participant", 7},
+		{"volunteer booking", 3, pgtype.Int4{}, "volunteer", 0},
+		{"volunteer booked by staff", 3, bookedFor, "volunteer", 0},
+		{"no role", 3, pgtype.Int4{}, "", 0},
+	}
+	for _, tt := range tests {
+		if got := participantOf(tt.userID, tt.bookedFor, tt.role); got != tt.want {
+			t.Errorf("%s: participantOf = %d, want %d", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestLockOrder(t *testing.T) {
+	tests := []struct {
+		a, b int32
+		want []int32
+	}{
+		{3, 5, []int32{3, 5}},
+		{5, 3, []int32{3, 5}},
+		{4, 4, []int32{4}},
+		{0, 5, []int32{5}},
+		{5, 0, []int32{5}},
+		{0, 0, []int32{0}},
+	}
+	for _, tt := range tests {
+		if got := lockOrder(tt.a, tt.b); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("lockOrder(%d, %d) = %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestWeekStart(t *testing.T) {
+	sgt := time.FixedZone("SGT", 8*60*60)
+	monday := time.Date(2026, 3, 2, 0, 0, 0, 0, sgt)
+	tests := []struct {
+		name string
+		t    time.Time
+		want time.Time
+	}{
+		{"monday midnight", monday, monday},
+		{"monday evening", time.Date(2026, 3, 2, 18, 30, 0, 0, sgt), monday},
+		{"wednesday", time.Date(2026, 3, 4, 9, 0, 0, 0, sgt), monday},
+		{"sunday night", time.Date(2026, 3, 8, 23, 59, 0, 0, sgt), monday},
+		{"next monday", time.Date(2026, 3, 9, 0, 0, 0, 0, sgt), monday.AddDate(0, 0, 7)},
+		{"across a month", time.Date(2026, 4, 1, 12, 0, 0, 0, sgt), time.Date(2026, 3, 30, 0, 0, 0, 0, sgt)},
+		{"across a year", time.Date(2027, 1, 1, 12, 0, 0, 0, sgt), time.Date(2026, 12, 28, 0, 0, 0, 0, sgt)},
+	}
+	for _, tt := range tests {
+		if got := weekStart(tt.t); !got.Equal(tt.want) {
+			t.Errorf("%s: weekStart(%s) = %s, want %s", tt.name, tt.t, got, tt.want)
+		}
+	}
+}
+
+func TestQuotasFromEnv(t *testing.T) {
+	tests := []struct {
+		name string
+		env  map[string]string
+		want Quotas
+	}{
+		{"defaults", nil, Quotas{"once a week": 1, "twice a week": 2}},
+		{"ad hoc limited", map[string]string{"BOOKING_QUOTA_AD_HOC": "1"}, Quotas{"Ad hoc": 1, "once a week": 1, "twice a week": 2}},
+		{"limit lifted", map[string]string{"BOOKING_QUOTA_TWICE_A_WEEK": "0"}, Quotas{"once a week": 1}},
+		{"changed", map[string]string{"BOOKING_QUOTA_ONCE_A_WEEK": "2", "BOOKING_QUOTA_TWICE_A_WEEK": "3"}, Quotas{"once a week": 2, "twice a week": 3}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, key := range []string{"BOOKING_QUOTA_AD_HOC", "BOOKING_QUOTA_ONCE_A_WEEK", "BOOKING_QUOTA_TWICE_A_WEEK"} {
+				t.Setenv(key, tt.env[key])
+			}
+			if got := QuotasFromEnv(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("QuotasFromEnv = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestQuotaErrorMessage(t *testing.T) {
+	err := &QuotaError{MembershipType: "once a week", WeeklyLimit: 1, Booked: 1, WeekStart: "2026-03-02", WeekEnd: "2026-03-08"}
+	for _, want := range []string{`"once a week"`, "allows 1 activity per week", "1 activity already booked", "2026-03-02 to 2026-03-08"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("QuotaError = %q, missing %q", err.Error(), want)
+		}
+	}
+
+	err = &QuotaError{MembershipType: "twice a week", WeeklyLimit: 2, Booked: 3}
+	if !strings.Contains(err.Error(), "allows 2 activities") || !strings.Contains(err.Error(), "3 activities already") {
+		t.Errorf("QuotaError = %q, want plural counts", err.Error())
+	}
+}
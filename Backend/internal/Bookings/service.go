@@ -2,29 +2,49 @@ package bookings
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"strconv"
 
 	repo "hack4good-backend/db/sqlc"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
 )
 
 type Service interface {
 	ListBookings(ctx context.Context) ([]repo.Booking, error)
+	ListBookingsByUserID(ctx context.Context, userID int32, includeDependents bool) ([]repo.Booking, error)
+	GetBookingByID(ctx context.Context, id string) (repo.Booking, error)
+	IsCaregiverOf(ctx context.Context, caregiverID, participantID int32) (bool, error)
+	BookingRole(ctx context.Context, userID int32, bookedFor pgtype.Int4, requested string) (string, error)
 	CreateBooking(ctx context.Context, req CreateBooking) (repo.Booking, error)
 	DeleteBookingByID(ctx context.Context, id string) error
 	ListBookingsByActivityID(ctx context.Context, activityID string) ([]repo.Booking, error)
 	CountBookingsByActivityID(ctx context.Context, activityID string) (int64, error)
 	UpdateBooking(ctx context.Context, id string, req repo.UpdateBookingParams) (repo.Booking, error)
+	JoinWaitlist(ctx context.Context, req CreateBooking) (repo.BookingWaitlist, error)
+	ListWaitlist(ctx context.Context, userID int32, all bool) ([]repo.ListBookingWaitlistRow, error)
+	GetWaitlistEntry(ctx context.Context, id int32) (repo.BookingWaitlist, error)
+	LeaveWaitlist(ctx context.Context, id int32) error
+	ConfirmWaitlistEntry(ctx context.Context, id, staffID int32) (repo.Booking, error)
 }
 
 // struct
 type svc struct {
-	repo repo.Querier
-
+	db     *pgxpool.Pool // for quota checks, which lock the participant
+	repo   *repo.Queries
+	quotas Quotas
 }
 
 // constructor
-func NewService(repo repo.Querier) Service {
-	return &svc{repo: repo}
+func NewService(db *pgxpool.Pool, quotas Quotas) Service {
+	return &svc{
+		db:     db,
+		repo:   repo.New(db),
+		quotas: quotas,
+	}
 }
 
 // methods
@@ -32,22 +52,126 @@ func (s *svc) ListBookings(ctx context.Context) ([]repo.Booking, error) {
 	return s.repo.ListBookings(ctx)
 }
 
+// bookings made by or for the user, plus those of linked participants for caregivers
+func (s *svc) ListBookingsByUserID(ctx context.Context, userID int32, includeDependents bool) ([]repo.Booking, error) {
+	if includeDependents {
+		return s.repo.ListBookingsForCaregiver(ctx, userID)
+	}
+	return s.repo.ListBookingsByUserID(ctx, userID)
+}
+
+func (s *svc) GetBookingByID(ctx context.Context, id string) (repo.Booking, error) {
+	id64, err := strconv.ParseInt(id, 10, 32)
+    if err != nil {
+        return repo.Booking{}, err // invalid id string
+    }
+	return s.repo.GetBookingByID(ctx, int32(id64))
+}
+
+func (s *svc) IsCaregiverOf(ctx context.Context, caregiverID, participantID int32) (bool, error) {
+	return s.repo.IsCaregiverOf(ctx, repo.IsCaregiverOfParams{
+		CaregiverID:   caregiverID,
+		ParticipantID: participantID,
+	})
+}
+
+// a booking is made in the role of the account booked (the participant when booked on their behalf),
+// so the quota and volunteer hours follow who the person is; a role sent with the request must match it
+func (s *svc) BookingRole(ctx context.Context, userID int32, bookedFor pgtype.Int4, requested string) (string, error) {
+	user, err := s.repo.GetUserByID(ctx, bookingSubject(userID, bookedFor))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return "", ErrUserNotFound
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to get user %d: %w", bookingSubject(userID, bookedFor), err)
+	}
+	if user.Role != "participant" && user.Role != "volunteer" {
+		return "", ErrNotBookable
+	}
+	if requested != "" && requested != user.Role {
+		return "", ErrRoleMismatch
+	}
+	return user.Role, nil
+}
+
+// participant bookings are checked against the weekly quota of the participant's membership
 func (s *svc) CreateBooking(ctx context.Context, req CreateBooking) (repo.Booking, error) {
-	return s.repo.CreateBooking(ctx, repo.CreateBookingParams{
+	params := repo.CreateBookingParams{
 		ActivityID:      req.ActivityID,
 		UserID:          req.UserID,
 		BookedForUserID: req.BookedForUserID,
 		Role:            req.Role,
 		IsPaid:          req.IsPaid,
-	})	
+		CreatedByStaffID: req.CreatedByStaffID,
+	}
+	participantID := participantOf(req.UserID, req.BookedForUserID, req.Role)
+	if participantID == 0 {
+		return s.repo.CreateBooking(ctx, params)
+	}
+
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return repo.Booking{}, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+	q := s.repo.WithTx(tx)
+
+	if err := q.LockUser(ctx, participantID); err != nil {
+		return repo.Booking{}, fmt.Errorf("failed to lock user %d: %w", participantID, err)
+	}
+	activity, err := s.getActivity(ctx, q, req.ActivityID)
+	if err != nil {
+		return repo.Booking{}, err
+	}
+	if params.QuotaOverrideBy, err = s.checkQuota(ctx, q, participantID, activity, 0, req.QuotaOverrideBy); err != nil {
+		return repo.Booking{}, err
+	}
+
+	booking, err := q.CreateBooking(ctx, params)
+	if err != nil {
+		return repo.Booking{}, err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return repo.Booking{}, fmt.Errorf("failed to commit booking: %w", err)
+	}
+	return booking, nil
 }
 
+// a participant booking going away makes room for their waitlist entries that week
 func (s *svc) DeleteBookingByID(ctx context.Context, id string) error {
 	id64, err := strconv.ParseInt(id, 10, 32)
     if err != nil {
         return err // invalid id string
     }
-    return s.repo.DeleteBookingByID(ctx, int32(id64))
+	booking, err := s.repo.GetBookingByID(ctx, int32(id64))
+	if err != nil {
+		return err
+	}
+	participantID := participantOf(booking.UserID, booking.BookedForUserID, booking.Role)
+	if participantID == 0 {
+		return s.repo.DeleteBookingByID(ctx, booking.ID)
+	}
+
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+	q := s.repo.WithTx(tx)
+
+	if err := q.LockUser(ctx, participantID); err != nil {
+		return fmt.Errorf("failed to lock user %d: %w", participantID, err)
+	}
+	if err := q.DeleteBookingByID(ctx, booking.ID); err != nil {
+		return err
+	}
+	if err := s.promoteWaitlist(ctx, q, participantID, booking.ActivityID); err != nil {
+		return err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit booking deletion: %w", err)
+	}
+	return nil
 }
 
 func (s *svc) ListBookingsByActivityID(ctx context.Context, activityID string) ([]repo.Booking, error) {
@@ -66,7 +190,69 @@ func (s *svc) CountBookingsByActivityID(ctx context.Context, activityID string)
 	return s.repo.CountBookingsByActivityID(ctx, int32(id64))
 }
 
+// moving a participant booking to another week or participant (or restoring a cancelled one)
+// is checked against the quota like a new booking; the week it leaves may promote a waitlist entry
 func (s *svc) UpdateBooking(ctx context.Context, id string, req repo.UpdateBookingParams) (repo.Booking, error) {
-	return s.repo.UpdateBooking(ctx, req)
+	id64, err := strconv.ParseInt(id, 10, 32)
+    if err != nil {
+        return repo.Booking{}, err // invalid id string
+    }
+	req.ID = int32(id64)
+
+	existing, err := s.repo.GetBookingByID(ctx, req.ID)
+	if err != nil {
+		return repo.Booking{}, err
+	}
+	oldParticipant := participantOf(existing.UserID, existing.BookedForUserID, existing.Role)
+	newParticipant := participantOf(req.UserID, req.BookedForUserID, req.Role)
+	if oldParticipant == 0 && newParticipant == 0 {
+		return s.repo.UpdateBooking(ctx, req)
+	}
+
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return repo.Booking{}, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+	q := s.repo.WithTx(tx)
+
+	// always in id order, so two moves between the same participants cannot deadlock
+	for _, userID := range lockOrder(oldParticipant, newParticipant) {
+		if err := q.LockUser(ctx, userID); err != nil {
+			return repo.Booking{}, fmt.Errorf("failed to lock user %d: %w", userID, err)
+		}
+	}
+
+	moved := oldParticipant != newParticipant || existing.ActivityID != req.ActivityID ||
+		(existing.CancelledAt.Valid && !req.CancelledAt.Valid)
+	switch {
+	case !moved:
+		req.QuotaOverrideBy = existing.QuotaOverrideBy
+	case newParticipant != 0 && !req.CancelledAt.Valid:
+		activity, err := s.getActivity(ctx, q, req.ActivityID)
+		if err != nil {
+			return repo.Booking{}, err
+		}
+		if req.QuotaOverrideBy, err = s.checkQuota(ctx, q, newParticipant, activity, req.ID, req.QuotaOverrideBy); err != nil {
+			return repo.Booking{}, err
+		}
+	default:
+		req.QuotaOverrideBy = pgtype.Int4{}
+	}
+
+	booking, err := q.UpdateBooking(ctx, req)
+	if err != nil {
+		return repo.Booking{}, err
+	}
+	cancelled := !existing.CancelledAt.Valid && req.CancelledAt.Valid
+	if (moved || cancelled) && oldParticipant != 0 {
+		if err := s.promoteWaitlist(ctx, q, oldParticipant, existing.ActivityID); err != nil {
+			return repo.Booking{}, err
+		}
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return repo.Booking{}, fmt.Errorf("failed to commit booking: %w", err)
+	}
+	return booking, nil
 }
 
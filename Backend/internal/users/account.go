@@ -0,0 +1,192 @@
+package users
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	repo "hack4good-backend/db/sqlc"
+	"hack4good-backend/internal/auth"
+	"net/mail"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const (
+	maxNameLength = 100
+
+	emailChangeTTL = 24 * time.Hour
+
+	// at most this many confirmation links per user within the window
+	maxEmailChanges   = 3
+	emailChangeWindow = time.Hour
+)
+
+var (
+	ErrInvalidName         = errors.New("name must be 1 to 100 characters")
+	ErrInvalidEmail        = errors.New("invalid email address")
+	ErrEmailTaken          = errors.New("email address is already in use")
+	ErrPhoneTaken          = errors.New("phone number is already in use")
+	ErrPhoneRequired       = errors.New("only staff accounts can be without a phone number")
+	ErrInvalidLanguage     = errors.New("language must be one of en, zh, ms, ta")
+	ErrAnonymised          = errors.New("account has been anonymised")
+	ErrInvalidEmailToken   = errors.New("invalid or expired email confirmation link")
+	ErrTooManyEmailChanges = errors.New("too many email changes requested")
+)
+
+// apply the fields sent; a new email only takes effect once confirmed,
+// so the returned token is for the link sent to the new address ("" if none)
+func (s *svc) UpdateUser(ctx context.Context, id int32, req UpdateUserRequest) (repo.User, string, error) {
+	user, err := s.repo.GetUserByID(ctx, id)
+	if err != nil {
+		return repo.User{}, "", err
+	}
+	if user.Status == StatusAnonymised {
+		return repo.User{}, "", ErrAnonymised
+	}
+
+	params := repo.UpdateUserDetailsParams{ID: id}
+	changed := false
+
+	if req.Name != nil {
+		name := strings.TrimSpace(*req.Name)
+		if name == "" || utf8.RuneCountInString(name) > maxNameLength {
+			return repo.User{}, "", ErrInvalidName
+		}
+		params.Name = pgtype.Text{String: name, Valid: true}
+		changed = true
+	}
+
+	if req.Language != nil {
+		if !languages[*req.Language] {
+			return repo.User{}, "", ErrInvalidLanguage
+		}
+		params.Language = pgtype.Text{String: *req.Language, Valid: true}
+		changed = true
+	}
+
+	if req.Phone != nil {
+		raw := strings.TrimSpace(*req.Phone)
+		if raw == "" && user.Role != "staff" {
+			return repo.User{}, "", ErrPhoneRequired
+		}
+		params.PhoneEncrypted, params.PhoneIndex, err = s.protectPhone(raw)
+		if err != nil {
+			return repo.User{}, "", err
+		}
+		params.SetPhone = true
+		changed = true
+	}
+
+	// check the email before changing anything so a bad one fails the whole request
+	newEmail := ""
+	if req.Email != nil {
+		email := strings.TrimSpace(*req.Email)
+		if !strings.EqualFold(email, user.Email) {
+			if err := s.checkNewEmail(ctx, id, email); err != nil {
+				return repo.User{}, "", err
+			}
+			newEmail = email
+		}
+	}
+
+	if changed {
+		user, err = s.repo.UpdateUserDetails(ctx, params)
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" { // unique_violation on phone_index
+			return repo.User{}, "", ErrPhoneTaken
+		}
+		if err != nil {
+			return repo.User{}, "", fmt.Errorf("failed to update user %d: %w", id, err)
+		}
+	}
+
+	if newEmail == "" {
+		return user, "", nil
+	}
+	token, err := s.issueEmailChange(ctx, id, newEmail)
+	if err != nil {
+		return repo.User{}, "", err
+	}
+	return user, token, nil
+}
+
+func (s *svc) checkNewEmail(ctx context.Context, userID int32, email string) error {
+	if addr, err := mail.ParseAddress(email); err != nil || addr.Address != email {
+		return ErrInvalidEmail
+	}
+
+	_, err := s.repo.GetUserByEmail(ctx, email)
+	if err == nil {
+		return ErrEmailTaken
+	}
+	if !errors.Is(err, pgx.ErrNoRows) {
+		return fmt.Errorf("failed to look up email: %w", err)
+	}
+
+	recent, err := s.repo.CountRecentEmailChangeTokens(ctx, repo.CountRecentEmailChangeTokensParams{
+		UserID:    userID,
+		CreatedAt: pgtype.Timestamp{Time: time.Now().Add(-emailChangeWindow), Valid: true},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to count email change tokens: %w", err)
+	}
+	if recent >= maxEmailChanges {
+		return ErrTooManyEmailChanges
+	}
+	return nil
+}
+
+// single-use token for moving the account to newEmail, replacing earlier ones
+func (s *svc) issueEmailChange(ctx context.Context, userID int32, newEmail string) (string, error) {
+	token, hash, err := auth.NewOpaqueToken()
+	if err != nil {
+		return "", fmt.Errorf("failed to generate email change token: %w", err)
+	}
+
+	if err := s.repo.InvalidateEmailChangeTokens(ctx, userID); err != nil {
+		return "", fmt.Errorf("failed to invalidate email change tokens: %w", err)
+	}
+	if _, err := s.repo.CreateEmailChangeToken(ctx, repo.CreateEmailChangeTokenParams{
+		UserID:    userID,
+		NewEmail:  newEmail,
+		TokenHash: hash,
+		ExpiresAt: pgtype.Timestamp{Time: time.Now().Add(emailChangeTTL), Valid: true},
+	}); err != nil {
+		return "", fmt.Errorf("failed to create email change token: %w", err)
+	}
+	return token, nil
+}
+
+// use up the token and switch the account to the confirmed address;
+// also returns the previous address so it can be told about the change
+func (s *svc) ConfirmEmailChange(ctx context.Context, token string) (repo.User, string, error) {
+	t, err := s.repo.ConsumeEmailChangeToken(ctx, auth.HashToken(token))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return repo.User{}, "", ErrInvalidEmailToken
+		}
+		return repo.User{}, "", fmt.Errorf("failed to use email change token: %w", err)
+	}
+
+	old, err := s.repo.GetUserByID(ctx, t.UserID)
+	if err != nil {
+		return repo.User{}, "", fmt.Errorf("failed to get user %d: %w", t.UserID, err)
+	}
+
+	user, err := s.repo.SetUserEmail(ctx, repo.SetUserEmailParams{ID: t.UserID, Email: t.NewEmail})
+	var pgErr *pgconn.PgError
+	switch {
+	case errors.As(err, &pgErr) && pgErr.Code == "23505": // taken since the link was sent
+		return repo.User{}, "", ErrEmailTaken
+	case errors.Is(err, pgx.ErrNoRows): // anonymised since the link was sent
+		return repo.User{}, "", ErrInvalidEmailToken
+	case err != nil:
+		return repo.User{}, "", fmt.Errorf("failed to set email of user %d: %w", t.UserID, err)
+	}
+	return user, old.Email, nil
+}
@@ -0,0 +1,122 @@
+package users
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	repo "hack4good-backend/db/sqlc"
+	"hack4good-backend/internal/auth"
+	"hack4good-backend/internal/json"
+	"hack4good-backend/internal/notify"
+	"hack4good-backend/internal/phone"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/jackc/pgx/v5"
+)
+
+// Update my own name, email, phone or language
+func (h *Handler) UpdateMe(w http.ResponseWriter, r *http.Request) {
+	claims, ok := auth.FromContext(r.Context())
+	if !ok {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	h.updateUser(w, r, claims.ID)
+}
+
+// Update any user with the same rules as UpdateMe (by staff)
+func (h *Handler) UpdateUser(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "invalid user id", http.StatusBadRequest)
+		return
+	}
+	h.updateUser(w, r, int32(id))
+}
+
+func (h *Handler) updateUser(w http.ResponseWriter, r *http.Request, id int32) {
+	var req UpdateUserRequest
+	if err := json.Read(r, &req); err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	user, token, err := h.service.UpdateUser(r.Context(), id, req)
+	if err != nil {
+		writeAccountError(w, err, "failed to update user")
+		return
+	}
+
+	out := UpdatedUser{User: h.toUser(r, user)}
+	if token != "" {
+		newEmail := *req.Email
+		if err := h.sendEmailConfirmation(r.Context(), user, newEmail, token); err != nil {
+			log.Println(err)
+			http.Error(w, "failed to send confirmation email", http.StatusInternalServerError)
+			return
+		}
+		out.PendingEmail = newEmail
+	}
+
+	json.Write(w, http.StatusOK, out)
+}
+
+// the link goes to the new address, so only someone who can read it can move the account there
+func (h *Handler) sendEmailConfirmation(ctx context.Context, user repo.User, newEmail, token string) error {
+	return h.notifier.Email.Send(ctx, notify.Message{
+		To:      newEmail,
+		Subject: "Confirm your new email address",
+		Body: fmt.Sprintf("Hi %s,\n\nConfirm this as the new email address for your account here:\n%s\n\nThe link expires in %d hours. Until then your account keeps using %s.",
+			user.Name, h.config.AppURL+"/confirm-email?token="+url.QueryEscape(token), int(emailChangeTTL.Hours()), user.Email),
+	})
+}
+
+// Switch an account to its new email address with the emailed link
+func (h *Handler) ConfirmEmail(w http.ResponseWriter, r *http.Request) {
+	var req ConfirmEmailRequest
+	if err := json.Read(r, &req); err != nil || req.Token == "" {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	user, oldEmail, err := h.service.ConfirmEmailChange(r.Context(), req.Token)
+	if err != nil {
+		writeAccountError(w, err, "failed to confirm email")
+		return
+	}
+
+	// tell the old address, in case the change was not theirs
+	if err := h.notifier.Email.Send(r.Context(), notify.Message{
+		To:      oldEmail,
+		Subject: "Your email address was changed",
+		Body: fmt.Sprintf("Hi %s,\n\nThe email address for your account has been changed to %s. If you did not ask for this, please contact us.",
+			user.Name, user.Email),
+	}); err != nil {
+		log.Println(err)
+	}
+
+	json.Write(w, http.StatusOK, h.toUser(r, user))
+}
+
+func writeAccountError(w http.ResponseWriter, err error, msg string) {
+	switch {
+	case errors.Is(err, pgx.ErrNoRows):
+		http.Error(w, "user not found", http.StatusNotFound)
+	case errors.Is(err, phone.ErrInvalidPhone):
+		http.Error(w, "invalid phone number", http.StatusBadRequest)
+	case errors.Is(err, ErrInvalidName), errors.Is(err, ErrInvalidEmail), errors.Is(err, ErrPhoneRequired),
+		errors.Is(err, ErrInvalidLanguage), errors.Is(err, ErrInvalidEmailToken):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, ErrEmailTaken), errors.Is(err, ErrPhoneTaken), errors.Is(err, ErrAnonymised):
+		http.Error(w, err.Error(), http.StatusConflict)
+	case errors.Is(err, ErrTooManyEmailChanges):
+		http.Error(w, err.Error(), http.StatusTooManyRequests)
+	default:
+		log.Println(err)
+		http.Error(w, msg, http.StatusInternalServerError)
+	}
+}
@@ -1,23 +1,47 @@
 package users
 
 import (
+	"context"
+	"encoding/base64"
+	stdjson "encoding/json"
+	"errors"
+	repo "hack4good-backend/db/sqlc"
 	"hack4good-backend/internal/auth"
 	"hack4good-backend/internal/json"
+	"hack4good-backend/internal/notify"
+	"hack4good-backend/internal/phone"
+	"hack4good-backend/internal/profiles"
+	"hack4good-backend/internal/volunteers"
 	"log"
 	"net/http"
+	"slices"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/go-chi/chi/v5"
+	"github.com/jackc/pgx/v5"
 )
 
 
+// runs after an account is created (e.g. sends staff a password setup link)
+type Onboarder interface {
+	Onboard(ctx context.Context, user repo.User) error
+}
+
 type Handler struct {
     service Service
+    onboarder Onboarder
+    notifier *notify.Notifier
+    config Config
 }
 
-func NewHandler(service Service) *Handler {
+func NewHandler(service Service, onboarder Onboarder, notifier *notify.Notifier, config Config) *Handler {
     return &Handler{
 		service: service,
+		onboarder: onboarder,
+		notifier: notifier,
+		config: config,
 	}
 }
 
@@ -32,7 +56,23 @@ func (h *Handler) GetMe(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	json.Write(w, http.StatusOK, user)
+	me := Me{User: h.toUser(r, user)}
+
+	// participants also get their profile, if they have one yet
+	if user.Role == "participant" {
+		profile, err := h.service.GetParticipantProfile(r.Context(), user.ID)
+		if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+			log.Println(err)
+			http.Error(w, "Failed to get user", http.StatusInternalServerError)
+			return
+		}
+		if err == nil {
+			p := profiles.ToProfile(profile)
+			me.Profile = &p
+		}
+	}
+
+	json.Write(w, http.StatusOK, me)
 }
 
 // Get user by ID 
@@ -50,7 +90,7 @@ func (h *Handler) GetUserByID(w http.ResponseWriter, r *http.Request) {
         return
     }
 
-    json.Write(w, http.StatusOK, user)
+    json.Write(w, http.StatusOK, h.toUser(r, user))
 }
 
 // Create User (only by staff)
@@ -75,12 +115,22 @@ func (h *Handler) CreateUser(w http.ResponseWriter, r *http.Request) {
 
     user, err := h.service.CreateUser(r.Context(), params)
     if err != nil {
+        if errors.Is(err, phone.ErrInvalidPhone) {
+            http.Error(w, "invalid phone number", http.StatusBadRequest)
+            return
+        }
         log.Println(err)
         http.Error(w, "failed to create user", http.StatusInternalServerError)
         return
     }
 
-    json.Write(w, http.StatusCreated, user)
+    if h.onboarder != nil {
+        if err := h.onboarder.Onboard(r.Context(), user); err != nil {
+            log.Println(err)
+        }
+    }
+
+    json.Write(w, http.StatusCreated, h.toUser(r, user))
 }
 
 // Delete user by ID (by staff)
@@ -93,6 +143,10 @@ func (h *Handler) DeleteUserByID(w http.ResponseWriter, r *http.Request) {
     }
 
     if err := h.service.DeleteUserByID(r.Context(), int32(id)); err != nil {
+        if errors.Is(err, ErrHasHistory) {
+            http.Error(w, err.Error(), http.StatusConflict)
+            return
+        }
         log.Println(err)
         http.Error(w, "failed to delete user", http.StatusInternalServerError)
         return
@@ -101,16 +155,299 @@ func (h *Handler) DeleteUserByID(w http.ResponseWriter, r *http.Request) {
     w.WriteHeader(http.StatusNoContent)
 }
 
-//Get Participants or Volunteers by role
+//Get Participants or Volunteers by role, with the same filters as ListUsers
 func (h *Handler) ListUsersByRole(role string) http.HandlerFunc {
     return func(w http.ResponseWriter, r *http.Request) {
-        users, err := h.service.ListUsersByRole(r.Context(), role)
-        if err != nil {
-            log.Println(err)
-            http.Error(w, "failed to list users", http.StatusInternalServerError)
+        h.listUsers(w, r, role)
+    }
+}
+
+// List users of any role (?role= narrows it down)
+func (h *Handler) ListUsers(w http.ResponseWriter, r *http.Request) {
+    role := r.URL.Query().Get("role")
+    if role != "" && !validRoles[role] {
+        http.Error(w, "invalid role", http.StatusBadRequest)
+        return
+    }
+    h.listUsers(w, r, role)
+}
+
+const (
+    defaultPageSize = 50
+    maxPageSize     = 200
+)
+
+var validRoles = map[string]bool{"participant": true, "volunteer": true, "caregiver": true, "staff": true}
+
+var validStatuses = map[string]bool{
+    StatusPending: true, StatusActive: true, StatusRejected: true, StatusDeactivated: true, StatusAnonymised: true,
+}
+
+func (h *Handler) listUsers(w http.ResponseWriter, r *http.Request, role string) {
+    filter, err := parseUserFilter(r)
+    if err != nil {
+        http.Error(w, err.Error(), http.StatusBadRequest)
+        return
+    }
+    filter.Role = role
+
+    if v := r.URL.Query().Get("activity_id"); v != "" {
+        if !h.forActivity(w, r, &filter, v) {
             return
         }
+    }
+
+    // one extra row tells us whether there is a next page
+    limit := filter.Limit
+    filter.Limit++
+    rows, total, err := h.service.ListUsers(r.Context(), filter)
+    if err != nil {
+        log.Println(err)
+        http.Error(w, "failed to list users", http.StatusInternalServerError)
+        return
+    }
+
+    page := UserPage{Users: make([]ListedUser, 0, len(rows)), Total: total}
+    if int32(len(rows)) > limit {
+        rows = rows[:limit]
+        last := rows[len(rows)-1]
+        page.NextCursor = encodeCursor(Cursor{ID: last.ID, Name: last.Name, CreatedAt: last.CreatedAt.Time})
+    }
+    for _, row := range rows {
+        page.Users = append(page.Users, h.toListedUser(r, row))
+    }
+
+    json.Write(w, http.StatusOK, page)
+}
+
+// read the list query string: q, status, membership_type, the accessibility
+// flags, created_from / created_to, the volunteer filters (skill, venue,
+// certification, available_from / available_to), sort, limit and cursor
+func parseUserFilter(r *http.Request) (UserFilter, error) {
+    q := r.URL.Query()
+    f := UserFilter{
+        Search:         strings.TrimSpace(q.Get("q")),
+        Status:         q.Get("status"),
+        MembershipType: q.Get("membership_type"),
+        Sort:           q.Get("sort"),
+        Limit:          defaultPageSize,
+    }
+
+    switch f.Sort {
+    case "":
+        f.Sort = SortCreatedAtDesc
+    case SortName, SortNameDesc, SortCreatedAt, SortCreatedAtDesc:
+    default:
+        return f, errors.New("invalid sort")
+    }
+
+    if f.Status != "" && !validStatuses[f.Status] {
+        return f, errors.New("invalid status")
+    }
+
+    flags := map[string]**bool{
+        "wheelchair":            &f.Wheelchair,
+        "sign_language":         &f.SignLanguage,
+        "needs_seated_activity": &f.NeedsSeatedActivity,
+        "sensitive_to_light":    &f.SensitiveToLight,
+        "sensitive_to_noise":    &f.SensitiveToNoise,
+    }
+    for name, dst := range flags {
+        v := q.Get(name)
+        if v == "" {
+            continue
+        }
+        b, err := strconv.ParseBool(v)
+        if err != nil {
+            return f, errors.New("invalid " + name)
+        }
+        *dst = &b
+    }
+
+    var err error
+    if f.CreatedFrom, err = parseDateParam(q.Get("created_from"), false); err != nil {
+        return f, errors.New("invalid created_from")
+    }
+    if f.CreatedTo, err = parseDateParam(q.Get("created_to"), true); err != nil {
+        return f, errors.New("invalid created_to")
+    }
+
+    for _, v := range q["skill"] {
+        for _, skill := range strings.Split(v, ",") {
+            skill = strings.TrimSpace(skill)
+            if skill == "" {
+                continue
+            }
+            if !volunteers.ValidSkill(skill) {
+                return f, errors.New("invalid skill")
+            }
+            f.Skills = append(f.Skills, skill)
+        }
+    }
+    f.Venue = strings.TrimSpace(q.Get("venue"))
+    f.Certification = strings.TrimSpace(q.Get("certification"))
+
+    // a volunteer slot, both ends in local time on the same day
+    if from, to := q.Get("available_from"), q.Get("available_to"); from != "" || to != "" {
+        start, err1 := parseLocalTime(from)
+        end, err2 := parseLocalTime(to)
+        if err1 != nil || err2 != nil {
+            return f, errors.New("available_from and available_to must both be set, as YYYY-MM-DDTHH:MM")
+        }
+        slot, ok := SlotOf(start, end)
+        if !ok {
+            return f, errors.New("available_to must be after available_from on the same day")
+        }
+        f.Available = slot
+        f.CertifiedOn = start
+    }
+
+    if v := q.Get("limit"); v != "" {
+        n, err := strconv.Atoi(v)
+        if err != nil || n < 1 {
+            return f, errors.New("invalid limit")
+        }
+        f.Limit = int32(min(n, maxPageSize))
+    }
+
+    if v := q.Get("cursor"); v != "" {
+        c, err := decodeCursor(v)
+        if err != nil {
+            return f, errors.New("invalid cursor")
+        }
+        f.After = &c
+    }
+    return f, nil
+}
 
-        json.Write(w, http.StatusOK, users)
+// RFC 3339 timestamp or a plain date; a plain date used as an upper bound
+// covers that whole day
+func parseDateParam(v string, upper bool) (*time.Time, error) {
+    if v == "" {
+        return nil, nil
+    }
+    if t, err := time.Parse(time.RFC3339, v); err == nil {
+        t = t.UTC()
+        return &t, nil
+    }
+    t, err := time.Parse(time.DateOnly, v)
+    if err != nil {
+        return nil, err
+    }
+    if upper {
+        t = t.AddDate(0, 0, 1)
+    }
+    return &t, nil
+}
+
+// wall-clock time as written, without converting between zones
+func parseLocalTime(v string) (time.Time, error) {
+    for _, layout := range []string{"2006-01-02T15:04", "2006-01-02T15:04:05", time.RFC3339} {
+        if t, err := time.Parse(layout, v); err == nil {
+            return t, nil
+        }
+    }
+    return time.Time{}, errors.New("invalid time")
+}
+
+// narrow the filter to volunteers who can cover an activity: the skills its
+// accessibility needs, a window around its time and certifications valid on its date
+func (h *Handler) forActivity(w http.ResponseWriter, r *http.Request, f *UserFilter, idStr string) bool {
+    id, err := strconv.Atoi(idStr)
+    if err != nil {
+        http.Error(w, "invalid activity_id", http.StatusBadRequest)
+        return false
+    }
+    activity, err := h.service.GetActivityByID(r.Context(), int32(id))
+    if err != nil {
+        if errors.Is(err, pgx.ErrNoRows) {
+            http.Error(w, "activity not found", http.StatusNotFound)
+            return false
+        }
+        log.Println(err)
+        http.Error(w, "failed to get activity", http.StatusInternalServerError)
+        return false
+    }
+
+    if activity.WheelchairAccessible && !slices.Contains(f.Skills, volunteers.SkillWheelchairHandling) {
+        f.Skills = append(f.Skills, volunteers.SkillWheelchairHandling)
+    }
+    if activity.SignLanguageAvailable && !slices.Contains(f.Skills, volunteers.SkillSignLanguage) {
+        f.Skills = append(f.Skills, volunteers.SkillSignLanguage)
+    }
+    if f.Available == nil {
+        // activities running past midnight are not matched against availability
+        f.Available, _ = SlotOf(activity.StartTime.Time, activity.EndTime.Time)
+        f.CertifiedOn = activity.StartTime.Time
+    }
+    return true
+}
+
+func encodeCursor(c Cursor) string {
+    b, _ := stdjson.Marshal(c)
+    return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func decodeCursor(s string) (Cursor, error) {
+    var c Cursor
+    b, err := base64.RawURLEncoding.DecodeString(s)
+    if err != nil {
+        return c, err
+    }
+    if err := stdjson.Unmarshal(b, &c); err != nil {
+        return c, err
+    }
+    if c.ID == 0 {
+        return c, errors.New("cursor without id")
+    }
+    return c, nil
+}
+
+func (h *Handler) toListedUser(r *http.Request, row repo.SearchUsersRow) ListedUser {
+    u := ListedUser{
+        User: h.toUser(r, repo.User{
+            ID:             row.ID,
+            Name:           row.Name,
+            Email:          row.Email,
+            Role:           row.Role,
+            Status:         row.Status,
+            CreatedAt:      row.CreatedAt,
+            PhoneEncrypted: row.PhoneEncrypted,
+        }),
+        MembershipType: row.MembershipType.String,
+        Skills:         row.Skills,
+    }
+    // the boolean columns are only valid when a profile row was joined
+    if row.Wheelchair.Valid {
+        u.Needs = &AccessibilityNeeds{
+            Wheelchair:          row.Wheelchair.Bool,
+            SignLanguage:        row.SignLanguage.Bool,
+            NeedsSeatedActivity: row.NeedsSeatedActivity.Bool,
+            SensitiveToLight:    row.SensitiveToLight.Bool,
+            SensitiveToNoise:    row.SensitiveToNoise.Bool,
+        }
+    }
+    return u
+}
+
+// convert DB user to response user; the phone number is only decrypted for staff and the user themselves
+func (h *Handler) toUser(r *http.Request, u repo.User) User {
+    user := User{
+        ID:        u.ID,
+        Name:      u.Name,
+        Email:     u.Email,
+        Role:      u.Role,
+        Status:    u.Status,
+        Language:  u.Language,
+        CreatedAt: u.CreatedAt.Time,
+    }
+
+    if claims, ok := auth.FromContext(r.Context()); ok && (claims.Role == "staff" || claims.ID == u.ID) {
+        phone, err := h.service.RevealPhone(u)
+        if err != nil {
+            log.Println(err)
+        }
+        user.Phone = phone
     }
+    return user
 }
\ No newline at end of file
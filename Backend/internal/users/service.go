@@ -2,25 +2,49 @@ package users
 
 import (
 	"context"
+	"errors"
+	"strings"
+	"time"
 	repo "hack4good-backend/db/sqlc"
+	"hack4good-backend/internal/phone"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+var (
+	ErrNotPending = errors.New("registration is not pending")
+	ErrHasHistory = errors.New("user has booking or activity history; deactivate or anonymise the account instead")
 )
 
 type Service interface {
 	GetUserByID(ctx context.Context, userID int32) (repo.User, error)
 	GetUserByEmail(ctx context.Context, email string) (repo.User, error)
-	ListUsersByRole(ctx context.Context, role string) ([]repo.User, error)
+	ListUsers(ctx context.Context, f UserFilter) ([]repo.SearchUsersRow, int64, error)
+	GetActivityByID(ctx context.Context, id int32) (repo.Activity, error)
 	GetUserByPhone (ctx context.Context, phone string) (repo.User, error)
+	PhoneIndex(phone string) (string, error)
 	DeleteUserByID (ctx context.Context, id int32) (error)
 	CreateUser (ctx context.Context, param CreateUserParams) (repo.User, error)
+	RevealPhone(u repo.User) (string, error)
+	RegisterUser(ctx context.Context, param CreateUserParams) (repo.User, error)
+	ListUsersByStatus(ctx context.Context, status string) ([]repo.User, error)
+	ReviewRegistration(ctx context.Context, id int32, approve bool, reason string, reviewerID int32) (repo.User, error)
+	GetParticipantProfile(ctx context.Context, userID int32) (repo.ParticipantProfile, error)
+	UpdateUser(ctx context.Context, id int32, req UpdateUserRequest) (repo.User, string, error)
+	ConfirmEmailChange(ctx context.Context, token string) (repo.User, string, error)
 }
 
 type svc struct {
 	repo *repo.Queries
+	phones *phone.Protector
 }
 
-func NewService(repo *repo.Queries) Service {
+func NewService(repo *repo.Queries, phones *phone.Protector) Service {
 	return &svc{
 		repo: repo,
+		phones: phones,
 	}
 }
 
@@ -32,18 +56,40 @@ func (s *svc) GetUserByEmail(ctx context.Context, email string) (repo.User, erro
 	return s.repo.GetUserByEmail(ctx, email)
 }
 
+// look up by blind index of the normalised number
 func (s *svc) GetUserByPhone (ctx context.Context, phone string) (repo.User, error) {
-	return s.repo.GetUserByPhone(ctx, phone)
+	index, err := s.phones.Index(phone)
+	if err != nil {
+		return repo.User{}, err
+	}
+	return s.repo.GetUserByPhone(ctx, pgtype.Text{String: index, Valid: true})
+}
+
+// blind index of the normalised number, the same for every way of writing it
+func (s *svc) PhoneIndex(phone string) (string, error) {
+	return s.phones.Index(phone)
 }
 
+// bookings and activities keep their user, so only accounts without history can be deleted
 func (s *svc) DeleteUserByID (ctx context.Context, id int32) (error) {
-	return s.repo.DeleteUserByID(ctx, id)
+	err := s.repo.DeleteUserByID(ctx, id)
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23503" { // foreign_key_violation
+		return ErrHasHistory
+	}
+	return err
 }
 
 func (s *svc) CreateUser (ctx context.Context, param CreateUserParams) (repo.User, error) {
+	encrypted, index, err := s.protectPhone(param.Phone)
+	if err != nil {
+		return repo.User{}, err
+	}
+
 	user, err := s.repo.CreateUser(ctx, repo.CreateUserParams{
 		Name: param.Name,
-		Phone: param.Phone,
+		PhoneEncrypted: encrypted,
+		PhoneIndex: index,
 		Email: param.Email,
 		Role: param.Role,
 	})
@@ -54,6 +100,163 @@ func (s *svc) CreateUser (ctx context.Context, param CreateUserParams) (repo.Use
 	return user, nil
 }
 
-func (s *svc) ListUsersByRole(ctx context.Context, role string) ([]repo.User, error) {
-	return s.repo.ListUsersByRole(ctx, role)
-}
\ No newline at end of file
+// self-registration; the account is pending until staff approve it
+func (s *svc) RegisterUser(ctx context.Context, param CreateUserParams) (repo.User, error) {
+	encrypted, index, err := s.protectPhone(param.Phone)
+	if err != nil {
+		return repo.User{}, err
+	}
+
+	return s.repo.RegisterUser(ctx, repo.RegisterUserParams{
+		Name:           param.Name,
+		PhoneEncrypted: encrypted,
+		PhoneIndex:     index,
+		Email:          param.Email,
+		Role:           param.Role,
+	})
+}
+
+// encrypted number and blind index; staff may have no phone
+func (s *svc) protectPhone(raw string) ([]byte, pgtype.Text, error) {
+	if raw == "" {
+		return nil, pgtype.Text{}, nil
+	}
+	_, enc, idx, err := s.phones.Protect(raw)
+	if err != nil {
+		return nil, pgtype.Text{}, err
+	}
+	return enc, pgtype.Text{String: idx, Valid: true}, nil
+}
+
+func (s *svc) ListUsersByStatus(ctx context.Context, status string) ([]repo.User, error) {
+	return s.repo.ListUsersByStatus(ctx, status)
+}
+
+// approve or reject a pending registration (by staff)
+func (s *svc) ReviewRegistration(ctx context.Context, id int32, approve bool, reason string, reviewerID int32) (repo.User, error) {
+	status := StatusActive
+	if !approve {
+		status = StatusRejected
+	}
+
+	user, err := s.repo.ReviewUserRegistration(ctx, repo.ReviewUserRegistrationParams{
+		ID:              id,
+		Status:          status,
+		StatusReason:    pgtype.Text{String: reason, Valid: reason != ""},
+		StatusChangedBy: pgtype.Int4{Int32: reviewerID, Valid: true},
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return repo.User{}, ErrNotPending
+	}
+	return user, err
+}
+
+// one page of users matching the filter, plus the total number of matches
+func (s *svc) ListUsers(ctx context.Context, f UserFilter) ([]repo.SearchUsersRow, int64, error) {
+	count := repo.CountSearchUsersParams{
+		Search:              optText(likeEscape(f.Search)),
+		Role:                optText(f.Role),
+		Status:              optText(f.Status),
+		MembershipType:      optText(f.MembershipType),
+		Wheelchair:          optBool(f.Wheelchair),
+		SignLanguage:        optBool(f.SignLanguage),
+		NeedsSeatedActivity: optBool(f.NeedsSeatedActivity),
+		SensitiveToLight:    optBool(f.SensitiveToLight),
+		SensitiveToNoise:    optBool(f.SensitiveToNoise),
+		CreatedFrom:         optTime(f.CreatedFrom),
+		CreatedTo:           optTime(f.CreatedTo),
+		Skills:              f.Skills,
+		Venue:               optText(f.Venue),
+		Certification:       optText(f.Certification),
+		CertifiedOn:         pgtype.Date{Time: dateOf(f.CertifiedOn), Valid: true},
+	}
+	if f.Available != nil {
+		count.AvailableWeekday = pgtype.Int2{Int16: int16(f.Available.Weekday), Valid: true}
+		count.AvailableFrom = pgtype.Time{Microseconds: f.Available.From.Microseconds(), Valid: true}
+		count.AvailableTo = pgtype.Time{Microseconds: f.Available.To.Microseconds(), Valid: true}
+	}
+	total, err := s.repo.CountSearchUsers(ctx, count)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	params := repo.SearchUsersParams{
+		Search:              count.Search,
+		Role:                count.Role,
+		Status:              count.Status,
+		MembershipType:      count.MembershipType,
+		Wheelchair:          count.Wheelchair,
+		SignLanguage:        count.SignLanguage,
+		NeedsSeatedActivity: count.NeedsSeatedActivity,
+		SensitiveToLight:    count.SensitiveToLight,
+		SensitiveToNoise:    count.SensitiveToNoise,
+		CreatedFrom:         count.CreatedFrom,
+		CreatedTo:           count.CreatedTo,
+		Skills:              count.Skills,
+		Venue:               count.Venue,
+		Certification:       count.Certification,
+		CertifiedOn:         count.CertifiedOn,
+		AvailableWeekday:    count.AvailableWeekday,
+		AvailableFrom:       count.AvailableFrom,
+		AvailableTo:         count.AvailableTo,
+		Sort:                f.Sort,
+		PageLimit:           f.Limit,
+	}
+	if f.After != nil {
+		params.AfterID = pgtype.Int4{Int32: f.After.ID, Valid: true}
+		params.AfterName = pgtype.Text{String: f.After.Name, Valid: true}
+		params.AfterCreatedAt = pgtype.Timestamp{Time: f.After.CreatedAt, Valid: true}
+	}
+	rows, err := s.repo.SearchUsers(ctx, params)
+	if err != nil {
+		return nil, 0, err
+	}
+	return rows, total, nil
+}
+
+// calendar date of t (today if zero) as a DATE value
+func dateOf(t time.Time) time.Time {
+	if t.IsZero() {
+		t = time.Now()
+	}
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+func (s *svc) GetActivityByID(ctx context.Context, id int32) (repo.Activity, error) {
+	return s.repo.GetActivityByID(ctx, id)
+}
+
+// search terms are matched literally, not as LIKE patterns
+func likeEscape(term string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(term)
+}
+
+func optText(v string) pgtype.Text {
+	return pgtype.Text{String: v, Valid: v != ""}
+}
+
+func optBool(v *bool) pgtype.Bool {
+	if v == nil {
+		return pgtype.Bool{}
+	}
+	return pgtype.Bool{Bool: *v, Valid: true}
+}
+
+func optTime(v *time.Time) pgtype.Timestamp {
+	if v == nil {
+		return pgtype.Timestamp{}
+	}
+	return pgtype.Timestamp{Time: *v, Valid: true}
+}
+
+// decrypt the stored phone number, empty if the user has none
+func (s *svc) RevealPhone(u repo.User) (string, error) {
+	if len(u.PhoneEncrypted) == 0 {
+		return "", nil
+	}
+	return s.phones.Reveal(u.PhoneEncrypted)
+}
+
+func (s *svc) GetParticipantProfile(ctx context.Context, userID int32) (repo.ParticipantProfile, error) {
+	return s.repo.GetParticipantProfile(ctx, userID)
+}
@@ -1,23 +1,151 @@
 package users
 
+import (
+	"hack4good-backend/internal/profiles"
+	"time"
+)
+
+// users.status
+const (
+	StatusPending     = "pending" // self-registered, waiting for staff
+	StatusActive      = "active"
+	StatusRejected    = "rejected"
+	StatusDeactivated = "deactivated" // cannot log in, history kept
+	StatusAnonymised  = "anonymised"  // personal data scrubbed, bookings kept
+)
+
+// user as returned by the API; phone is only filled in for staff and the user themselves
 type User struct {
-	ID int32
-	Name string
-	Email string
-	Phone string
-	Role string
+	ID        int32     `json:"id"`
+	Name      string    `json:"name"`
+	Email     string    `json:"email"`
+	Phone     string    `json:"phone,omitempty"`
+	Role      string    `json:"role"`
+	Status    string    `json:"status"`
+	Language  string    `json:"language,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// signed-in user, with the participant profile for participants
+type Me struct {
+	User
+	Profile *profiles.Profile `json:"profile,omitempty"`
+}
+
+type Config struct {
+	AppURL string // frontend base URL used in links sent to users
+}
+
+// languages a user can choose (users.language): English, Chinese, Malay, Tamil
+var languages = map[string]bool{"en": true, "zh": true, "ms": true, "ta": true}
+
+// PATCH /user/me and /dashboard/users/{id}; only the fields sent are changed
+type UpdateUserRequest struct {
+	Name     *string `json:"name"`
+	Email    *string `json:"email"` // applied once the link sent to the new address is used
+	Phone    *string `json:"phone"` // "" removes it (staff only)
+	Language *string `json:"language"`
+}
+
+type UpdatedUser struct {
+	User
+	PendingEmail string `json:"pending_email,omitempty"` // waiting for confirmation
+}
+
+type ConfirmEmailRequest struct {
+	Token string `json:"token"`
 }
 
 type CreateUserParams struct {
 	Name string `json:"name"`
-	Phone string `json:"phonehash"`
+	Phone string `json:"phone"` // raw number, normalised and encrypted by the service
 	Email string `json:"email"`
 	Role string `json:"role"`
 }
 
 type CreateUserRequest struct {
 	Name string `json:"name"`
-	Phone string `json:"phonehash"`
+	Phone string `json:"phone"`
 	Email string `json:"email"`
 	Role string `json:"role"`
-}
\ No newline at end of file
+}
+// sort orders accepted by the user lists; a leading "-" means descending
+const (
+	SortName          = "name"
+	SortNameDesc      = "-name"
+	SortCreatedAt     = "created_at"
+	SortCreatedAtDesc = "-created_at"
+)
+
+// filters for the staff user lists; empty / nil fields are not applied
+type UserFilter struct {
+	Search              string
+	Role                string
+	Status              string
+	MembershipType      string
+	Wheelchair          *bool
+	SignLanguage        *bool
+	NeedsSeatedActivity *bool
+	SensitiveToLight    *bool
+	SensitiveToNoise    *bool
+	CreatedFrom         *time.Time
+	CreatedTo           *time.Time // exclusive
+	Skills              []string   // volunteers with all of these
+	Venue               string     // among the volunteer's preferred venues
+	Certification       string     // by name, not expired on CertifiedOn
+	CertifiedOn         time.Time  // zero means today
+	Available           *Slot      // covered by one of the volunteer's weekly windows
+	Sort                string
+	After               *Cursor
+	Limit               int32
+}
+
+// time slot within one day, in local time
+type Slot struct {
+	Weekday time.Weekday
+	From    time.Duration // since midnight
+	To      time.Duration
+}
+
+// slot between two wall-clock times, false if they are not on the same day
+func SlotOf(start, end time.Time) (*Slot, bool) {
+	if start.YearDay() != end.YearDay() || start.Year() != end.Year() || !end.After(start) {
+		return nil, false
+	}
+	sinceMidnight := func(t time.Time) time.Duration {
+		return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute + time.Duration(t.Second())*time.Second
+	}
+	return &Slot{Weekday: start.Weekday(), From: sinceMidnight(start), To: sinceMidnight(end)}, true
+}
+
+// position in a sorted list, handed to clients as an opaque token
+type Cursor struct {
+	ID        int32     `json:"id"`
+	Name      string    `json:"name,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// accessibility flags from the participant profile
+type AccessibilityNeeds struct {
+	Wheelchair          bool `json:"wheelchair"`
+	SignLanguage        bool `json:"sign_language"`
+	NeedsSeatedActivity bool `json:"needs_seated_activity"`
+	SensitiveToLight    bool `json:"sensitive_to_light"`
+	SensitiveToNoise    bool `json:"sensitive_to_noise"`
+}
+
+// user list entry; never carries credential columns.
+// membership and needs are only set for users with a participant profile,
+// skills for volunteers with a volunteer profile
+type ListedUser struct {
+	User
+	MembershipType string              `json:"membership_type,omitempty"`
+	Needs          *AccessibilityNeeds `json:"accessibility_needs,omitempty"`
+	Skills         []string            `json:"skills,omitempty"` // volunteers
+}
+
+type UserPage struct {
+	Users      []ListedUser `json:"users"`
+	Total      int64        `json:"total"`
+	NextCursor string       `json:"next_cursor,omitempty"`
+}
@@ -2,11 +2,22 @@ package main
 
 import (
 	repo "hack4good-backend/db/sqlc"
+	"hack4good-backend/internal/accounts"
 	"hack4good-backend/internal/activities"
 	"hack4good-backend/internal/auth"
 	"hack4good-backend/internal/auth/authhttp"
+	"hack4good-backend/internal/auth/oidc"
+	"hack4good-backend/internal/carelinks"
 	"hack4good-backend/internal/env"
+	"hack4good-backend/internal/invitations"
+	"hack4good-backend/internal/medical"
+	"hack4good-backend/internal/notify"
+	"hack4good-backend/internal/phone"
+	"hack4good-backend/internal/profiles"
+	"hack4good-backend/internal/secure"
+	"hack4good-backend/internal/userimport"
 	"hack4good-backend/internal/users"
+	"hack4good-backend/internal/volunteers"
 	"hack4good-backend/internal/activities"
 	"hack4good-backend/internal/bookings"
 	
@@ -35,11 +46,6 @@ func (app *application) mount() http.Handler {
 
 	r.Use(middleware.Timeout(60 * time.Second))
 
-	// secret key
-	var secretKey = env.GetString("secretKey", "01234567890123456789012345678901") // 32 chars
-	if len(secretKey) < 32 {
-		log.Fatal("secretKey must be at least 32 characters long")
-	}
 
 	// health check
 	r.Get("/health", func(w http.ResponseWriter, r *http.Request) {
@@ -47,52 +53,346 @@ func (app *application) mount() http.Handler {
 	})
 
 	// create token maker
-	tokenMaker := auth.NewJWTMaker(secretKey)
+	var tokenMaker *auth.JWTMaker
+	switch alg := env.GetString("JWT_ALGORITHM", "HS256"); alg {
+	case "HS256":
+		// secret key
+		secretKey := env.GetString("secretKey", "")
+		if len(secretKey) < 32 {
+			log.Fatal("secretKey must be set and at least 32 characters long")
+		}
+		tokenMaker = auth.NewJWTMaker(secretKey)
+	default:
+		// keys stay valid for verification for as long as the longest token (refresh, 24h) lives
+		keys, err := auth.NewKeyStore(
+			env.GetString("JWT_KEYS_DIR", "keys"),
+			alg,
+			env.GetDuration("JWT_ROTATE_EVERY", 30*24*time.Hour),
+			env.GetDuration("JWT_KEY_RETAIN_FOR", 25*time.Hour),
+		)
+		if err != nil {
+			log.Fatal(err)
+		}
+		keys.StartRotation(time.Hour)
+		tokenMaker = auth.NewAsymmetricJWTMaker(keys)
+	}
 
 	// CORS
 	c := cors.New(cors.Options{
 		AllowedOrigins:   []string{"http://localhost:3000"},
-		AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-		AllowedHeaders:   []string{"Authorization", "Content-Type"},
+		AllowedMethods:   []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
+		AllowedHeaders:   []string{"Authorization", "Content-Type", "X-API-Key", auth.CSRFHeader, auth.AuthModeHeader},
 		AllowCredentials: true,
 	})
 
+	// phone encryption (separate keys for ciphertext and lookup index)
+	phoneKey, err := secure.KeyFromEnv("PHONE_ENCRYPTION_KEY")
+	if err != nil {
+		log.Fatal(err)
+	}
+	phoneIndexKey, err := secure.KeyFromEnv("PHONE_INDEX_KEY")
+	if err != nil {
+		log.Fatal(err)
+	}
+	phones, err := phone.NewProtector(phoneKey, phoneIndexKey, env.GetString("PHONE_DEFAULT_COUNTRY_CODE", "65"))
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// medical details get their own key, so a leaked phone key does not expose them
+	medicalKey, err := secure.KeyFromEnv("MEDICAL_ENCRYPTION_KEY")
+	if err != nil {
+		log.Fatal(err)
+	}
+	medicalCipher, err := secure.NewCipher(medicalKey)
+	if err != nil {
+		log.Fatal(err)
+	}
+
 	// For users
-	userService := users.NewService(repo.New(app.db))
-	userHandler := users.NewHandler(userService)
+	userService := users.NewService(repo.New(app.db), phones)
 	ActivityService := activities.NewService(repo.New(app.db))
 	ActivityHandler := activities.NewHandler(ActivityService)
-	BookingService := bookings.NewService(repo.New(app.db))
+	BookingService := bookings.NewService(app.db, bookings.QuotasFromEnv())
 	BookingHandler := bookings.NewHandler(BookingService)
+	profileHandler := profiles.NewHandler(profiles.NewService(repo.New(app.db)))
+	careLinkHandler := carelinks.NewHandler(carelinks.NewService(app.db))
+	importHandler := userimport.NewHandler(userimport.NewService(app.db, phones))
+	volunteerHandler := volunteers.NewHandler(volunteers.NewService(app.db), env.GetString("ORG_NAME", "Hack4Good"))
+	medicalService := medical.NewService(app.db, medicalCipher, env.GetString("PHONE_DEFAULT_COUNTRY_CODE", "65"))
+	medicalHandler := medical.NewHandler(medicalService)
+	accountHandler := accounts.NewHandler(accounts.NewService(app.db, phones, medicalService))
+
+	// For auth (TOTP secrets for staff 2FA are encrypted with their own key)
+	totpKey, err := secure.KeyFromEnv("TOTP_ENCRYPTION_KEY")
+	if err != nil {
+		log.Fatal(err)
+	}
+	totpSecrets, err := secure.NewCipher(totpKey)
+	if err != nil {
+		log.Fatal(err)
+	}
+	authService := authhttp.NewService(app.db, auth.NewTOTP(env.GetString("TOTP_ISSUER", "Hack4Good")), totpSecrets)
+	tokenMaker.CheckSessions(authService) // logout and revoked sessions take effect at once
+	notifier := notify.FromEnv()
+	appURL := env.GetString("APP_BASE_URL", "http://localhost:3000")
+	passwordPolicy := auth.PasswordPolicyFromEnv()
+	var sso *oidc.Provider // staff single sign-on, only when OIDC_ISSUER_URL is set
+	if ssoConfig, ok := oidc.ConfigFromEnv(); ok {
+		sso = oidc.NewProvider(ssoConfig, nil)
+	}
+	authHandler := authhttp.NewHandler(authService, userService, tokenMaker, notifier, authhttp.Config{
+		AppURL:         appURL,
+		PasswordPolicy: passwordPolicy,
+		Cookies:        auth.CookieConfigFromEnv(),
+		SSO:            sso,
+	})
+	userHandler := users.NewHandler(userService, authHandler, notifier, users.Config{AppURL: appURL}) // staff get a password setup link
+
+	// For invitations (links are signed with their own key)
+	invitationKey, err := secure.KeyFromEnv("INVITATION_SIGNING_KEY")
+	if err != nil {
+		log.Fatal(err)
+	}
+	invitationTTL := env.GetDuration("INVITATION_TTL", 7*24*time.Hour)
+	invitationService := invitations.NewService(app.db, phones, invitationKey, invitationTTL)
+	invitationHandler := invitations.NewHandler(invitationService, notifier, invitations.Config{
+		AppURL:         appURL,
+		TTL:            invitationTTL,
+		PasswordPolicy: passwordPolicy,
+	})
 
-	// For staff
+	// role -> permission mapping, API keys carry their own scopes
+	permissions, err := auth.PermissionsFromEnv()
+	if err != nil {
+		log.Fatal(err)
+	}
+	guard := auth.NewGuard(tokenMaker, permissions, authService)
+
+	// For staff (user management)
+	r.Group(func(r chi.Router) {
+		r.Use(guard.RequirePermission(auth.PermUsersManage))
+		r.Use(auth.RejectAPIKeys)
+		r.Post("/dashboard/createusers", userHandler.CreateUser)      // Create user(Register)
+		r.Post("/dashboard/users/import", importHandler.Import) // Bulk import from CSV (?dry_run=true to validate only)
+		r.Patch("/dashboard/users/{id}", userHandler.UpdateUser) // Edit name, email, phone, language (email confirmed by the user)
+		r.Delete("/dashboard/users/{id}", userHandler.DeleteUserByID) // Delete user (only without booking history)
+		r.Post("/dashboard/users/{id}/deactivate", accountHandler.Deactivate) // Block login, keep history
+		r.Post("/dashboard/users/{id}/reactivate", accountHandler.Reactivate) // Undo deactivation
+		r.Post("/dashboard/users/{id}/anonymise", accountHandler.Anonymise) // Scrub personal data, keep bookings
+		r.Get("/dashboard/users/{id}/export", accountHandler.ExportUser) // Personal data export (?format=json|zip)
+
+		r.Delete("/dashboard/users/{id}/sessions", authHandler.ForceLogoutUser) // Force logout user from all devices
+		r.Get("/dashboard/lockouts", authHandler.ListLockouts) // List locked accounts and IPs
+		r.Post("/dashboard/lockouts/unlock", authHandler.Unlock) // Unlock account or IP
+		r.Post("/dashboard/users/{id}/password", authHandler.SetTemporaryPassword) // Set temporary staff password
+		r.Delete("/dashboard/users/{id}/2fa", authHandler.ResetUserTOTP) // Reset another staff member's 2FA
+
+		r.Post("/dashboard/invitations", invitationHandler.CreateInvitation) // Invite user
+		r.Get("/dashboard/invitations", invitationHandler.ListInvitations) // List invitations (?status=pending|expired|accepted|revoked)
+		r.Post("/dashboard/invitations/{id}/resend", invitationHandler.ResendInvitation) // Resend invitation with a fresh link
+		r.Delete("/dashboard/invitations/{id}", invitationHandler.RevokeInvitation) // Revoke invitation
+
+		r.Get("/dashboard/registrations", authHandler.ListRegistrations) // Self-registrations waiting for approval
+		r.Post("/dashboard/registrations/{id}/approve", authHandler.ApproveRegistration) // Approve registration
+		r.Post("/dashboard/registrations/{id}/reject", authHandler.RejectRegistration) // Reject registration with reason
+
+		r.Get("/dashboard/care-links", careLinkHandler.ListLinks) // List caregiver links (?status=pending|active)
+		r.Post("/dashboard/care-links", careLinkHandler.CreateLink) // Link caregiver to participant
+		r.Post("/dashboard/care-links/{participantID}/{caregiverID}/approve", careLinkHandler.ApproveLink) // Approve pending link request
+		r.Delete("/dashboard/care-links/{participantID}/{caregiverID}", careLinkHandler.RevokeLink) // Revoke link
+	})
+
+	r.Group(func(r chi.Router) {
+		r.Use(guard.RequirePermission(auth.PermUsersRead))
+		r.Get("/dashboard/users", userHandler.ListUsers) // Search users (?q=&role=&sort=&limit=&cursor=...)
+		r.Get("/dashboard/participants", userHandler.ListUsersByRole("participant")) //List Participants (paginated, same filters)
+		r.Get("/dashboard/volunteers", userHandler.ListUsersByRole("volunteer")) //List Volunteers (paginated, same filters, plus skill/venue/certification/availability or activity_id)
+	})
+
+	// For staff (act on behalf of a user)
 	r.Group(func(r chi.Router) {
-        r.Use(auth.RequireRole(tokenMaker, "staff"))
-        r.Post("/dashboard/createusers", userHandler.CreateUser)      // Create user(Register)
-        r.Delete("/dashboard/users/{id}", userHandler.DeleteUserByID) // Delete user
+		r.Use(guard.RequirePermission(auth.PermUsersImpersonate))
+		r.Use(auth.RejectAPIKeys)
+		r.Post("/dashboard/users/{id}/impersonate", authHandler.StartImpersonation) // Token to act on behalf of user
+		r.Get("/dashboard/impersonations", authHandler.ListImpersonationLogs) // Audit trail of impersonated writes
+	})
+
+	// For staff (API keys for kiosks and integrations)
+	r.Group(func(r chi.Router) {
+		r.Use(guard.RequirePermission(auth.PermAPIKeysManage))
+		r.Use(auth.RejectAPIKeys)
+		r.Post("/dashboard/api-keys", authHandler.CreateAPIKey) // Create API key (key is shown once)
+		r.Get("/dashboard/api-keys", authHandler.ListAPIKeys) // List API keys
+		r.Delete("/dashboard/api-keys/{id}", authHandler.RevokeAPIKey) // Revoke API key
+	})
 
+	// For staff (activities)
+	r.Group(func(r chi.Router) {
+		r.Use(guard.RequirePermission(auth.PermActivitiesWrite))
 		r.Get("/dashboard/activities", ActivityHandler.ListActivities) //List activities
 		r.Post("/dashboard/activities", ActivityHandler.CreateActivity) // Create activity
 		r.Delete("/dashboard/activities/{id}", ActivityHandler.DeleteActivity) // Delete activity
 		r.Patch("/dashboard/activities/{id}", ActivityHandler.UpdateActivity) // Update activity
+		r.Get("/dashboard/activities/{id}/volunteer-lead", medicalHandler.GetLead) // Volunteer lead
+		r.Put("/dashboard/activities/{id}/volunteer-lead", medicalHandler.SetLead) // Assign volunteer lead ({"user_id"})
+		r.Delete("/dashboard/activities/{id}/volunteer-lead", medicalHandler.RemoveLead) // Remove volunteer lead
+	})
+
+	// For bookings (own, linked participants' or any, checked per booking)
+	r.Group(func(r chi.Router) {
+		r.With(guard.RequirePermission(auth.PermBookingsReadOwn, auth.PermBookingsReadDependents, auth.PermBookingsReadAny)).
+			Get("/user/bookings", BookingHandler.ListBookings) //List users bookings
+		r.With(guard.RequirePermission(auth.PermBookingsReadOwn, auth.PermBookingsReadDependents, auth.PermBookingsReadAny)).
+			Get("/user/bookings/waitlist", BookingHandler.ListWaitlist) //Waitlisted bookings (over the weekly quota)
+
+		r.Group(func(r chi.Router) {
+			r.Use(guard.RequirePermission(auth.PermBookingsWriteOwn, auth.PermBookingsWriteDependents, auth.PermBookingsWriteAny))
+			r.Use(auth.RejectAPIKeys)
+			r.Use(authHandler.AuditImpersonation)
+			r.Post("/user/bookings", BookingHandler.CreateBooking) //Create booking
+			r.Delete("/user/bookings/{id}", BookingHandler.DeleteBooking) //Delete booking
+			r.Patch("/user/bookings/{id}", BookingHandler.UpdateBooking) //Update booking 
+			r.Delete("/user/bookings/waitlist/{id}", BookingHandler.LeaveWaitlist) //Leave the waitlist
+		})
+
+		r.With(guard.RequirePermission(auth.PermBookingsWriteAny), auth.RejectAPIKeys, authHandler.AuditImpersonation).
+			Post("/dashboard/bookings/waitlist/{id}/confirm", BookingHandler.ConfirmWaitlistEntry) //Book a waitlist entry now (over the quota)
+	})
+
+	// For participant profiles (own, linked participants' or any, checked per participant)
+	r.Group(func(r chi.Router) {
+		r.With(guard.RequirePermission(auth.PermProfilesReadOwn, auth.PermProfilesReadDependents, auth.PermProfilesReadAny)).
+			Get("/user/profile", profileHandler.GetProfile) // Own profile
+		r.With(guard.RequirePermission(auth.PermProfilesReadOwn, auth.PermProfilesReadDependents, auth.PermProfilesReadAny)).
+			Get("/user/participants/{id}/profile", profileHandler.GetProfile) // Participant's profile
 
-		r.Get("/dashboard/participants", userHandler.ListUsersByRole("participant")) //List Participants (all)
-		r.Get("/dashboard/volunteers", userHandler.ListUsersByRole("volunteer")) //List Volunteers (all) 
-    })
+		r.Group(func(r chi.Router) {
+			r.Use(guard.RequirePermission(auth.PermProfilesWriteOwn, auth.PermProfilesWriteDependents, auth.PermProfilesWriteAny))
+			r.Use(authHandler.AuditImpersonation)
+			r.Post("/user/profile", profileHandler.CreateProfile) // Create own profile
+			r.Patch("/user/profile", profileHandler.UpdateProfile) // Update own profile
+			r.Post("/user/participants/{id}/profile", profileHandler.CreateProfile) // Create participant's profile
+			r.Patch("/user/participants/{id}/profile", profileHandler.UpdateProfile) // Update participant's profile
+		})
+	})
+
+	// For emergency contacts and medical notes (written by the participant, their caregivers or staff, read by staff only); every read is logged
+	r.Group(func(r chi.Router) {
+		r.With(guard.RequirePermission(auth.PermMedicalReadAny), auth.RejectAPIKeys).
+			Get("/dashboard/participants/{id}/medical", medicalHandler.Get) // Participant's medical details
+		r.With(guard.RequirePermission(auth.PermMedicalReadAny), auth.RejectAPIKeys).
+			Get("/dashboard/participants/{id}/medical/access-log", medicalHandler.AccessLog) // Who read them, newest first
+
+		r.Group(func(r chi.Router) {
+			r.Use(guard.RequirePermission(auth.PermMedicalWriteOwn, auth.PermMedicalWriteDependents, auth.PermMedicalWriteAny))
+			r.Use(auth.RejectAPIKeys)
+			r.Use(authHandler.AuditImpersonation)
+			r.Put("/user/profile/medical", medicalHandler.Update) // Replace own emergency contacts / notes
+			r.Put("/user/participants/{id}/profile/medical", medicalHandler.Update) // Replace participant's
+			r.Put("/dashboard/participants/{id}/medical", medicalHandler.Update) // Replace participant's
+		})
+	})
+
+	// For volunteer leads (participants' medical details on the day of their activity)
+	r.With(auth.RequireRole(tokenMaker, "volunteer"), auth.RejectAPIKeys).
+		Get("/user/activities/{id}/medical", medicalHandler.ForActivity) // Booked participants' details (lead only, on the day)
+
+	// For volunteer profiles (own, or any for staff)
+	r.Group(func(r chi.Router) {
+		r.With(guard.RequirePermission(auth.PermVolunteersReadOwn, auth.PermVolunteersReadAny)).
+			Get("/user/volunteer-profile", volunteerHandler.GetProfile) // Own skills, certifications, availability
+		r.With(guard.RequirePermission(auth.PermVolunteersReadOwn, auth.PermVolunteersReadAny)).
+			Get("/dashboard/volunteers/{id}/profile", volunteerHandler.GetProfile) // Volunteer's profile
+		r.With(guard.RequirePermission(auth.PermVolunteersReadOwn, auth.PermVolunteersReadAny)).
+			Get("/user/volunteer-hours", volunteerHandler.GetHours) // Own hours ledger, monthly and yearly totals
+		r.With(guard.RequirePermission(auth.PermVolunteersReadOwn, auth.PermVolunteersReadAny)).
+			Get("/user/volunteer-hours/certificate", volunteerHandler.HoursCertificate) // Own hours certificate (PDF)
+		r.With(guard.RequirePermission(auth.PermVolunteersReadOwn, auth.PermVolunteersReadAny)).
+			Get("/dashboard/volunteers/{id}/hours", volunteerHandler.GetHours) // Volunteer's hours ledger
+		r.With(guard.RequirePermission(auth.PermVolunteersReadOwn, auth.PermVolunteersReadAny)).
+			Get("/dashboard/volunteers/{id}/hours/certificate", volunteerHandler.HoursCertificate) // Volunteer's hours certificate (PDF)
+		r.With(guard.RequirePermission(auth.PermVolunteersReadAny)).
+			Get("/dashboard/volunteer-hours", volunteerHandler.ListHours) // Hours per volunteer (?year= or ?from=&to=)
+		r.With(guard.RequirePermission(auth.PermVolunteersWriteAny), authHandler.AuditImpersonation).
+			Post("/dashboard/volunteers/{id}/hours/adjustments", volunteerHandler.AddHoursAdjustment) // Correct a volunteer's hours (signed minutes)
 
-	// For auth
-	authService := authhttp.NewService(repo.New(app.db))
-	authHandler := authhttp.NewHandler(authService, userService, tokenMaker)
+		r.Group(func(r chi.Router) {
+			r.Use(guard.RequirePermission(auth.PermVolunteersWriteOwn, auth.PermVolunteersWriteAny))
+			r.Use(authHandler.AuditImpersonation)
+			r.Put("/user/volunteer-profile", volunteerHandler.UpdateProfile) // Replace own skills, venues, availability
+			r.Post("/user/volunteer-profile/certifications", volunteerHandler.AddCertification) // Add certification
+			r.Delete("/user/volunteer-profile/certifications/{certID}", volunteerHandler.DeleteCertification) // Remove certification
+			r.Put("/dashboard/volunteers/{id}/profile", volunteerHandler.UpdateProfile) // Update volunteer's profile
+			r.Post("/dashboard/volunteers/{id}/profile/certifications", volunteerHandler.AddCertification) // Add volunteer's certification
+			r.Delete("/dashboard/volunteers/{id}/profile/certifications/{certID}", volunteerHandler.DeleteCertification) // Remove volunteer's certification
+		})
+	})
+
+	// For participants (their caregivers)
+	r.Group(func(r chi.Router) {
+		r.Use(auth.RequireRole(tokenMaker, "participant"))
+		r.Use(authHandler.AuditImpersonation)
+		r.Post("/user/link-code", careLinkHandler.IssueLinkCode) // Code to give a caregiver
+		r.Get("/user/caregivers", careLinkHandler.ListCaregivers) // My caregivers and requests
+		r.Post("/user/caregivers/{id}/confirm", careLinkHandler.ConfirmCaregiver) // Accept caregiver request
+		r.Delete("/user/caregivers/{id}", careLinkHandler.RemoveCaregiver) // Remove caregiver / decline request
+	})
+
+	// For caregivers (their dependents)
+	r.Group(func(r chi.Router) {
+		r.Use(auth.RequireRole(tokenMaker, "caregiver"))
+		r.Use(authHandler.AuditImpersonation)
+		r.Post("/user/dependents", careLinkHandler.RequestLink) // Link with a code, or request by participant email
+		r.Get("/user/dependents", careLinkHandler.ListDependents) // My dependents and pending requests
+		r.Delete("/user/dependents/{id}", careLinkHandler.RemoveDependent) // Remove dependent / withdraw request
+	})
+
+	// For signed-in staff (own 2FA)
+	r.Group(func(r chi.Router) {
+		r.Use(auth.RequireRole(tokenMaker, "staff"))
+		r.Get("/user/2fa", authHandler.TOTPStatus) // 2FA status
+		r.Post("/user/2fa/enroll", authHandler.EnrollTOTP) // Start 2FA enrolment (QR provisioning URI)
+		r.Post("/user/2fa/confirm", authHandler.ConfirmTOTP) // Confirm 2FA, returns recovery codes
+		r.Post("/user/2fa/recovery-codes", authHandler.RegenerateRecoveryCodes) // New recovery codes
+	})
+
+	r.With(auth.RequireRole(tokenMaker)).Get("/user/me", userHandler.GetMe) // Signed-in user (with participant profile)
+	r.With(auth.RequireRole(tokenMaker), auth.RejectImpersonation).
+		Patch("/user/me", userHandler.UpdateMe) // Update my name, email, phone, language (new email needs confirming; not while acting on behalf)
+
+	// For signed-in users (any role)
+	r.Group(func(r chi.Router) {
+		r.Use(auth.RequireRole(tokenMaker))
+		r.Use(auth.RejectImpersonation)
+		r.Post("/api/logout", authHandler.HandleLogout) // Logout current session
+		r.Post("/user/password", authHandler.ChangePassword) // Change own password (staff)
+
+		r.Get("/user/sessions", authHandler.ListSessions) // List my active sessions
+		r.Delete("/user/sessions", authHandler.HandleLogoutAll) // Logout of all devices
+		r.Delete("/user/sessions/{id}", authHandler.RevokeSession) // Revoke one session
+
+		r.Get("/user/me/export", accountHandler.ExportMe) // Download my personal data (?format=json|zip)
+	})
 
 	// For public (participants & volunteers dashboard) 
 	r.Group(func(r chi.Router) {
-		r.Post("/api/login", authHandler.HandleLogin) //Login
+		r.Post("/api/register", authHandler.HandleRegister) //Self-registration (participant, caregiver, volunteer), pending approval
+		r.Post("/api/login", authHandler.HandleLogin) //Login (staff)
+		r.Post("/api/login/2fa", authHandler.VerifyMFA) //Second login step for staff with 2FA
+		r.Get("/api/sso/login", authHandler.StartSSO) //Redirect to the identity provider (staff)
+		r.Get("/api/sso/callback", authHandler.SSOCallback) //Identity provider redirects back here
+		r.Post("/api/login/code", authHandler.RequestLoginCode) //Send one-time login code
+		r.Post("/api/login/code/verify", authHandler.VerifyLoginCode) //Login with one-time code
+		r.Post("/api/refresh", authHandler.RenewAccessToken) //Renew access token (rotates refresh token)
+		r.Post("/api/password/forgot", authHandler.ForgotPassword) //Send password reset link (staff)
+		r.Post("/api/password/reset", authHandler.ResetPassword) //Set password with setup/reset link
+		r.Post("/api/email/confirm", userHandler.ConfirmEmail) //Confirm a new email address with the emailed link
+		r.Post("/api/invitations/preview", invitationHandler.PreviewInvitation) //Invitation details for the invitee
+		r.Post("/api/invitations/accept", invitationHandler.AcceptInvitation) //Accept invitation and create account
+		r.Get("/.well-known/jwks.json", authHandler.HandleJWKS) //Public signing keys
 
 		r.Get("/dashboard/user/activities", ActivityHandler.ListActivities) //List activities
-		r.Get("/user/bookings", BookingHandler.ListBookings) //List users bookings
-		r.Post("/user/bookings", BookingHandler.CreateBooking) //Create booking
-		r.Delete("/user/bookings/{id}", BookingHandler.DeleteBooking) //Delete booking
-		r.Patch("/user/bookings/{id}", BookingHandler.UpdateBooking) //Update booking 
 	
 	}) 
 
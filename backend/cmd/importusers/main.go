@@ -0,0 +1,86 @@
+// Bulk import of participants, caregivers and volunteers from a CSV file,
+// the same as POST /dashboard/users/import.
+//
+// Columns (header required, any order): name, email, phone, role and optionally
+// the participant profile columns age, membership_type, wheelchair,
+// sign_language, needs_seated_activity, sensitive_to_light, sensitive_to_noise,
+// other_need, plus caregiver_email to link a participant to caregivers.
+// Nothing is written unless every row is valid.
+//
+//	go run ./cmd/importusers -csv users.csv [-dry-run]
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"os"
+
+	"hack4good-backend/internal/env"
+	"hack4good-backend/internal/phone"
+	"hack4good-backend/internal/secure"
+	"hack4good-backend/internal/userimport"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/joho/godotenv"
+)
+
+func main() {
+	csvPath := flag.String("csv", "", "CSV file with one user per row")
+	dryRun := flag.Bool("dry-run", false, "validate every row without writing")
+	flag.Parse()
+
+	if *csvPath == "" {
+		log.Fatal("-csv is required")
+	}
+
+	_ = godotenv.Load()
+	ctx := context.Background()
+
+	phoneKey, err := secure.KeyFromEnv("PHONE_ENCRYPTION_KEY")
+	if err != nil {
+		log.Fatal(err)
+	}
+	phoneIndexKey, err := secure.KeyFromEnv("PHONE_INDEX_KEY")
+	if err != nil {
+		log.Fatal(err)
+	}
+	phones, err := phone.NewProtector(phoneKey, phoneIndexKey, env.GetString("PHONE_DEFAULT_COUNTRY_CODE", "65"))
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	f, err := os.Open(*csvPath)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer f.Close()
+
+	pool, err := pgxpool.New(ctx, env.GetString("GOOSE_DBSTRING", ""))
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer pool.Close()
+
+	report, err := userimport.NewService(pool, phones).Import(ctx, f, userimport.Options{DryRun: *dryRun})
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	for _, e := range report.Errors {
+		if e.Column != "" {
+			log.Printf("row %d, %s: %s", e.Row, e.Column, e.Message)
+		} else {
+			log.Printf("row %d: %s", e.Row, e.Message)
+		}
+	}
+	if len(report.Errors) > 0 {
+		log.Fatalf("%d errors in %d rows, nothing imported", len(report.Errors), report.Rows)
+	}
+
+	if report.Applied {
+		log.Printf("imported %d users and %d caregiver links", len(report.Users), report.Links)
+	} else {
+		log.Printf("%d users and %d caregiver links are valid (dry run, nothing written)", len(report.Users), report.Links)
+	}
+}
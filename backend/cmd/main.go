@@ -3,6 +3,7 @@ package main
 import (
 	"context"
 	"hack4good-backend/internal/env"
+	"hack4good-backend/internal/secure"
 	"log/slog"
 	"os"
 
@@ -10,6 +11,15 @@ import (
 	"github.com/joho/godotenv"
 )
 
+// encryption and signing keys the API needs, each 32 bytes in base64
+var requiredKeys = []string{
+	"PHONE_ENCRYPTION_KEY",
+	"PHONE_INDEX_KEY",
+	"MEDICAL_ENCRYPTION_KEY",
+	"TOTP_ENCRYPTION_KEY",
+	"INVITATION_SIGNING_KEY",
+}
+
 func main() {
 	// load .env
 	_ = godotenv.Load()
@@ -27,6 +37,12 @@ func main() {
 	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
 	slog.SetDefault(logger)
 
+	// keys are never committed, so refuse to start until every one is set
+	if err := secure.CheckKeysFromEnv(requiredKeys...); err != nil {
+		slog.Error("Missing or invalid keys, see README", "error", err)
+		os.Exit(1)
+	}
+
 	// Database
 	pool, err := pgxpool.New(ctx, cfg.db.dsn)
 	if err != nil {
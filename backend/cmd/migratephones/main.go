@@ -0,0 +1,129 @@
+// One-off migration of legacy bcrypt phone hashes to encrypted phones.
+//
+// bcrypt cannot be reversed, so the real numbers come from a CSV export of
+// staff records with the columns email,phone. Each number is checked against
+// the stored hash before it is encrypted; rows without a matching number are
+// reported and left untouched.
+//
+//	go run ./cmd/migratephones -csv phones.csv [-dry-run]
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"flag"
+	"io"
+	"log"
+	"os"
+	"strings"
+
+	repo "hack4good-backend/db/sqlc"
+	"hack4good-backend/internal/auth"
+	"hack4good-backend/internal/env"
+	"hack4good-backend/internal/phone"
+	"hack4good-backend/internal/secure"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/joho/godotenv"
+)
+
+func main() {
+	csvPath := flag.String("csv", "", "CSV file with email,phone rows")
+	dryRun := flag.Bool("dry-run", false, "report what would change without writing")
+	flag.Parse()
+
+	if *csvPath == "" {
+		log.Fatal("-csv is required")
+	}
+
+	_ = godotenv.Load()
+	ctx := context.Background()
+
+	phoneKey, err := secure.KeyFromEnv("PHONE_ENCRYPTION_KEY")
+	if err != nil {
+		log.Fatal(err)
+	}
+	phoneIndexKey, err := secure.KeyFromEnv("PHONE_INDEX_KEY")
+	if err != nil {
+		log.Fatal(err)
+	}
+	phones, err := phone.NewProtector(phoneKey, phoneIndexKey, env.GetString("PHONE_DEFAULT_COUNTRY_CODE", "65"))
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	known, err := readPhones(*csvPath)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	pool, err := pgxpool.New(ctx, env.GetString("GOOSE_DBSTRING", ""))
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer pool.Close()
+	queries := repo.New(pool)
+
+	rows, err := queries.ListUsersWithLegacyPhone(ctx)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	var migrated, skipped int
+	for _, row := range rows {
+		hash, _ := row.Phone.(string)
+		raw, ok := known[strings.ToLower(row.Email)]
+		if !ok || !auth.CheckPhone(hash, []byte(raw)) {
+			log.Printf("user %d (%s): no matching phone in CSV, skipped", row.ID, row.Email)
+			skipped++
+			continue
+		}
+
+		_, encrypted, index, err := phones.Protect(raw)
+		if err != nil {
+			log.Printf("user %d (%s): %v, skipped", row.ID, row.Email, err)
+			skipped++
+			continue
+		}
+
+		if !*dryRun {
+			if err := queries.SetUserPhone(ctx, repo.SetUserPhoneParams{
+				ID:             row.ID,
+				PhoneEncrypted: encrypted,
+				PhoneIndex:     pgtype.Text{String: index, Valid: true},
+			}); err != nil {
+				log.Fatalf("user %d (%s): %v", row.ID, row.Email, err)
+			}
+		}
+		migrated++
+	}
+
+	log.Printf("migrated %d, skipped %d of %d legacy phones (dry run: %t)", migrated, skipped, len(rows), *dryRun)
+}
+
+// map of lower-cased email to raw phone
+func readPhones(path string) (map[string]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	r := csv.NewReader(f)
+	phones := make(map[string]string)
+	for {
+		record, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if len(record) < 2 || strings.EqualFold(record[0], "email") {
+			continue
+		}
+		phones[strings.ToLower(strings.TrimSpace(record[0]))] = strings.TrimSpace(record[1])
+	}
+	return phones, nil
+}
@@ -0,0 +1,23 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE sessions
+    ADD COLUMN family_id TEXT,
+    ADD COLUMN replaced_by TEXT,
+    ADD COLUMN rotated_at TIMESTAMP;
+
+UPDATE sessions SET family_id = id WHERE family_id IS NULL;
+
+ALTER TABLE sessions ALTER COLUMN family_id SET NOT NULL;
+
+CREATE INDEX IF NOT EXISTS sessions_family_id_idx ON sessions (family_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS sessions_family_id_idx;
+
+ALTER TABLE sessions
+    DROP COLUMN IF EXISTS rotated_at,
+    DROP COLUMN IF EXISTS replaced_by,
+    DROP COLUMN IF EXISTS family_id;
+-- +goose StatementEnd
@@ -0,0 +1,22 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE users
+    ADD COLUMN phone_encrypted BYTEA,
+    ADD COLUMN phone_index TEXT UNIQUE;
+
+-- legacy bcrypt phones are cleared by cmd/migratephones, so either column satisfies the rule
+ALTER TABLE users DROP CONSTRAINT IF EXISTS nonstaff_phone_required;
+ALTER TABLE users ADD CONSTRAINT nonstaff_phone_required
+    CHECK (role = 'staff' OR phone IS NOT NULL OR phone_index IS NOT NULL);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE users DROP CONSTRAINT IF EXISTS nonstaff_phone_required;
+ALTER TABLE users ADD CONSTRAINT nonstaff_phone_required
+    CHECK (role = 'staff' OR phone IS NOT NULL);
+
+ALTER TABLE users
+    DROP COLUMN IF EXISTS phone_index,
+    DROP COLUMN IF EXISTS phone_encrypted;
+-- +goose StatementEnd
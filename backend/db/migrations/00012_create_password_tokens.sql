@@ -0,0 +1,31 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE users
+    ADD COLUMN password_must_change BOOLEAN NOT NULL DEFAULT FALSE,
+    ADD COLUMN password_changed_at TIMESTAMP;
+
+-- staff accounts start without a password and set one through a setup link
+ALTER TABLE users DROP CONSTRAINT IF EXISTS staff_password_required;
+
+CREATE TABLE IF NOT EXISTS password_tokens (
+    id SERIAL PRIMARY KEY,
+    user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    token_hash TEXT NOT NULL UNIQUE,
+    purpose TEXT NOT NULL CHECK (purpose IN ('setup', 'reset', 'change')),
+    expires_at TIMESTAMP NOT NULL,
+    used_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS password_tokens;
+
+ALTER TABLE users ADD CONSTRAINT staff_password_required
+    CHECK (role != 'staff' OR password IS NOT NULL) NOT VALID;
+
+ALTER TABLE users
+    DROP COLUMN IF EXISTS password_changed_at,
+    DROP COLUMN IF EXISTS password_must_change;
+-- +goose StatementEnd
@@ -0,0 +1,25 @@
+-- +goose Up
+-- +goose StatementBegin
+-- staff member who made the booking while acting on behalf of the user
+ALTER TABLE bookings
+    ADD COLUMN created_by_staff_id INT REFERENCES users(id) ON DELETE SET NULL;
+
+CREATE TABLE IF NOT EXISTS impersonation_logs (
+    id SERIAL PRIMARY KEY,
+    staff_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    method TEXT NOT NULL,
+    path TEXT NOT NULL,
+    status INT NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS impersonation_logs_user_id_idx ON impersonation_logs (user_id, created_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS impersonation_logs;
+
+ALTER TABLE bookings DROP COLUMN IF EXISTS created_by_staff_id;
+-- +goose StatementEnd
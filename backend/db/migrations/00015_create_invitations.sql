@@ -0,0 +1,31 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS invitations (
+    id SERIAL PRIMARY KEY,
+    name TEXT NOT NULL,
+    email TEXT NOT NULL CHECK (email LIKE '%_@_%.__%'),
+    phone_encrypted BYTEA,
+    role TEXT NOT NULL CHECK (role IN ('participant', 'caregiver', 'volunteer', 'staff')),
+    channel TEXT NOT NULL CHECK (channel IN ('email', 'sms')),
+    token_hash TEXT NOT NULL, -- hash of the nonce in the current signed token, replaced on resend
+    invited_by INT REFERENCES users(id) ON DELETE SET NULL,
+    expires_at TIMESTAMP NOT NULL,
+    sent_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    accepted_at TIMESTAMP,
+    accepted_user_id INT REFERENCES users(id) ON DELETE SET NULL,
+    revoked_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+
+    CONSTRAINT sms_invitation_phone_required
+        CHECK (channel != 'sms' OR phone_encrypted IS NOT NULL)
+);
+
+-- one open invitation per email
+CREATE UNIQUE INDEX IF NOT EXISTS invitations_open_email_idx ON invitations (LOWER(email))
+    WHERE accepted_at IS NULL AND revoked_at IS NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS invitations;
+-- +goose StatementEnd
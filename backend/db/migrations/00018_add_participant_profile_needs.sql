@@ -0,0 +1,17 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE participant_profiles
+    ADD COLUMN IF NOT EXISTS needs_seated_activity BOOLEAN NOT NULL DEFAULT FALSE,
+    ADD COLUMN IF NOT EXISTS sensitive_to_light BOOLEAN NOT NULL DEFAULT FALSE,
+    ADD COLUMN IF NOT EXISTS sensitive_to_noise BOOLEAN NOT NULL DEFAULT FALSE,
+    ADD COLUMN IF NOT EXISTS updated_at TIMESTAMP NOT NULL DEFAULT NOW();
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE participant_profiles
+    DROP COLUMN IF EXISTS needs_seated_activity,
+    DROP COLUMN IF EXISTS sensitive_to_light,
+    DROP COLUMN IF EXISTS sensitive_to_noise,
+    DROP COLUMN IF EXISTS updated_at;
+-- +goose StatementEnd
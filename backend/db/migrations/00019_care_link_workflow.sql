@@ -0,0 +1,29 @@
+-- +goose Up
+-- +goose StatementBegin
+-- existing links stay active, new requests start pending
+ALTER TABLE care_relationships
+    ADD COLUMN IF NOT EXISTS status TEXT NOT NULL DEFAULT 'active' CHECK (status IN ('pending', 'active')),
+    ADD COLUMN IF NOT EXISTS requested_by INT REFERENCES users(id) ON DELETE SET NULL,
+    ADD COLUMN IF NOT EXISTS approved_by INT REFERENCES users(id) ON DELETE SET NULL,
+    ADD COLUMN IF NOT EXISTS approved_at TIMESTAMP;
+ALTER TABLE care_relationships ALTER COLUMN status SET DEFAULT 'pending';
+
+-- one-time codes a participant shares with a caregiver
+CREATE TABLE IF NOT EXISTS care_link_codes (
+    participant_id INT PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    code_hash TEXT NOT NULL UNIQUE,
+    expires_at TIMESTAMP NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS care_link_codes;
+DELETE FROM care_relationships WHERE status = 'pending';
+ALTER TABLE care_relationships
+    DROP COLUMN IF EXISTS status,
+    DROP COLUMN IF EXISTS requested_by,
+    DROP COLUMN IF EXISTS approved_by,
+    DROP COLUMN IF EXISTS approved_at;
+-- +goose StatementEnd
@@ -0,0 +1,37 @@
+-- +goose Up
+-- +goose StatementBegin
+-- deactivated accounts cannot log in; anonymised accounts have had their personal data scrubbed
+ALTER TABLE users DROP CONSTRAINT IF EXISTS users_status_check;
+ALTER TABLE users ADD CONSTRAINT users_status_check
+    CHECK (status IN ('pending', 'active', 'rejected', 'deactivated', 'anonymised'));
+
+ALTER TABLE users DROP CONSTRAINT IF EXISTS nonstaff_phone_required;
+ALTER TABLE users ADD CONSTRAINT nonstaff_phone_required
+    CHECK (role = 'staff' OR status = 'anonymised' OR phone IS NOT NULL OR phone_index IS NOT NULL);
+
+-- deleting a user no longer wipes their booking and attendance history
+ALTER TABLE bookings DROP CONSTRAINT IF EXISTS bookings_user_id_fkey;
+ALTER TABLE bookings ADD CONSTRAINT bookings_user_id_fkey
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE RESTRICT;
+ALTER TABLE bookings DROP CONSTRAINT IF EXISTS bookings_booked_for_user_id_fkey;
+ALTER TABLE bookings ADD CONSTRAINT bookings_booked_for_user_id_fkey
+    FOREIGN KEY (booked_for_user_id) REFERENCES users(id) ON DELETE RESTRICT;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE bookings DROP CONSTRAINT IF EXISTS bookings_booked_for_user_id_fkey;
+ALTER TABLE bookings ADD CONSTRAINT bookings_booked_for_user_id_fkey
+    FOREIGN KEY (booked_for_user_id) REFERENCES users(id) ON DELETE CASCADE;
+ALTER TABLE bookings DROP CONSTRAINT IF EXISTS bookings_user_id_fkey;
+ALTER TABLE bookings ADD CONSTRAINT bookings_user_id_fkey
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE;
+
+ALTER TABLE users DROP CONSTRAINT IF EXISTS nonstaff_phone_required;
+ALTER TABLE users ADD CONSTRAINT nonstaff_phone_required
+    CHECK (role = 'staff' OR phone IS NOT NULL OR phone_index IS NOT NULL) NOT VALID;
+
+ALTER TABLE users DROP CONSTRAINT IF EXISTS users_status_check;
+ALTER TABLE users ADD CONSTRAINT users_status_check
+    CHECK (status IN ('pending', 'active', 'rejected')) NOT VALID;
+-- +goose StatementEnd
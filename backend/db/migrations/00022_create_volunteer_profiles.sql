@@ -0,0 +1,42 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS volunteer_profiles (
+    user_id INT PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    skills TEXT[] NOT NULL DEFAULT '{}'
+        CHECK (skills <@ ARRAY['first_aid', 'sign_language', 'driving', 'wheelchair_handling']),
+    preferred_venues TEXT[] NOT NULL DEFAULT '{}',
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+CREATE INDEX IF NOT EXISTS volunteer_profiles_skills_idx ON volunteer_profiles USING GIN (skills);
+
+CREATE TABLE IF NOT EXISTS volunteer_certifications (
+    id SERIAL PRIMARY KEY,
+    user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name TEXT NOT NULL,
+    issuer TEXT,
+    issued_on DATE,
+    expires_on DATE, -- NULL means it does not expire
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    CHECK (issued_on IS NULL OR expires_on IS NULL OR expires_on >= issued_on)
+);
+CREATE INDEX IF NOT EXISTS volunteer_certifications_user_id_idx ON volunteer_certifications (user_id);
+
+-- weekly windows in local time; weekday 0 is Sunday
+CREATE TABLE IF NOT EXISTS volunteer_availability (
+    id SERIAL PRIMARY KEY,
+    user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    weekday SMALLINT NOT NULL CHECK (weekday BETWEEN 0 AND 6),
+    start_time TIME NOT NULL,
+    end_time TIME NOT NULL,
+    CHECK (end_time > start_time)
+);
+CREATE INDEX IF NOT EXISTS volunteer_availability_user_id_idx ON volunteer_availability (user_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS volunteer_availability;
+DROP TABLE IF EXISTS volunteer_certifications;
+DROP TABLE IF EXISTS volunteer_profiles;
+-- +goose StatementEnd
@@ -0,0 +1,20 @@
+-- +goose Up
+-- +goose StatementBegin
+-- hours are computed from attended volunteer bookings; staff corrections are
+-- added here as signed entries and never edited, so the ledger stays auditable
+CREATE TABLE IF NOT EXISTS volunteer_hour_adjustments (
+    id SERIAL PRIMARY KEY,
+    user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    minutes INT NOT NULL CHECK (minutes <> 0),
+    worked_on DATE NOT NULL,
+    reason TEXT NOT NULL CHECK (reason <> ''),
+    created_by INT REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+CREATE INDEX IF NOT EXISTS volunteer_hour_adjustments_user_id_idx ON volunteer_hour_adjustments (user_id, worked_on);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS volunteer_hour_adjustments;
+-- +goose StatementEnd
@@ -0,0 +1,41 @@
+-- +goose Up
+-- +goose StatementBegin
+-- both columns are JSON encrypted by the application with MEDICAL_ENCRYPTION_KEY,
+-- so database access alone does not reveal them
+CREATE TABLE IF NOT EXISTS participant_medical (
+    user_id INT PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    emergency_contacts_encrypted BYTEA NOT NULL,
+    medical_notes_encrypted BYTEA NOT NULL,
+    updated_by INT REFERENCES users(id) ON DELETE SET NULL,
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+-- the volunteer who leads an activity sees its participants' medical details on the day
+CREATE TABLE IF NOT EXISTS activity_volunteer_leads (
+    activity_id INT PRIMARY KEY REFERENCES activities(id) ON DELETE CASCADE,
+    user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    assigned_by INT REFERENCES users(id) ON DELETE SET NULL,
+    assigned_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+CREATE INDEX IF NOT EXISTS activity_volunteer_leads_user_id_idx ON activity_volunteer_leads (user_id);
+
+-- one row per read of participant_medical
+CREATE TABLE IF NOT EXISTS medical_access_logs (
+    id SERIAL PRIMARY KEY,
+    participant_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    viewer_id INT REFERENCES users(id) ON DELETE SET NULL,
+    impersonator_id INT REFERENCES users(id) ON DELETE SET NULL,
+    api_key_id INT REFERENCES api_keys(id) ON DELETE SET NULL,
+    activity_id INT REFERENCES activities(id) ON DELETE SET NULL,
+    basis TEXT NOT NULL CHECK (basis IN ('own', 'dependent', 'any', 'volunteer_lead')),
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+CREATE INDEX IF NOT EXISTS medical_access_logs_participant_id_idx ON medical_access_logs (participant_id, created_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS medical_access_logs;
+DROP TABLE IF EXISTS activity_volunteer_leads;
+DROP TABLE IF EXISTS participant_medical;
+-- +goose StatementEnd
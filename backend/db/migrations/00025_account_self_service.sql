@@ -0,0 +1,25 @@
+-- +goose Up
+-- +goose StatementBegin
+-- English, Chinese, Malay, Tamil
+ALTER TABLE users
+    ADD COLUMN language TEXT NOT NULL DEFAULT 'en' CHECK (language IN ('en', 'zh', 'ms', 'ta'));
+
+-- a new email only replaces the old one once the link sent to it is used
+CREATE TABLE IF NOT EXISTS email_change_tokens (
+    id SERIAL PRIMARY KEY,
+    user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    new_email TEXT NOT NULL,
+    token_hash TEXT NOT NULL UNIQUE,
+    expires_at TIMESTAMP NOT NULL,
+    used_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+CREATE INDEX IF NOT EXISTS email_change_tokens_user_id_idx ON email_change_tokens (user_id, created_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS email_change_tokens;
+
+ALTER TABLE users DROP COLUMN IF EXISTS language;
+-- +goose StatementEnd
@@ -0,0 +1,26 @@
+-- +goose Up
+-- +goose StatementBegin
+-- staff member who let a participant booking go over the weekly quota of their membership
+ALTER TABLE bookings
+    ADD COLUMN quota_override_by INT REFERENCES users(id) ON DELETE SET NULL;
+
+-- participant bookings asked for beyond the quota; the oldest one in a week is
+-- turned into a booking when one of the participant's bookings that week goes away
+CREATE TABLE IF NOT EXISTS booking_waitlist (
+    id SERIAL PRIMARY KEY,
+    activity_id INT NOT NULL REFERENCES activities(id) ON DELETE CASCADE,
+    participant_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    requested_by INT NOT NULL REFERENCES users(id) ON DELETE CASCADE, -- participant or caregiver
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+
+    UNIQUE (activity_id, participant_id)
+);
+CREATE INDEX IF NOT EXISTS booking_waitlist_participant_id_idx ON booking_waitlist (participant_id, created_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS booking_waitlist;
+
+ALTER TABLE bookings DROP COLUMN IF EXISTS quota_override_by;
+-- +goose StatementEnd
@@ -26,6 +26,26 @@ type Activity struct {
 	CreatedAt             pgtype.Timestamp `json:"created_at"`
 }
 
+type ActivityVolunteerLead struct {
+	ActivityID int32            `json:"activity_id"`
+	UserID     int32            `json:"user_id"`
+	AssignedBy pgtype.Int4      `json:"assigned_by"`
+	AssignedAt pgtype.Timestamp `json:"assigned_at"`
+}
+
+type ApiKey struct {
+	ID         int32            `json:"id"`
+	Name       string           `json:"name"`
+	Prefix     string           `json:"prefix"`
+	KeyHash    string           `json:"key_hash"`
+	Scopes     []string         `json:"scopes"`
+	CreatedBy  pgtype.Int4      `json:"created_by"`
+	ExpiresAt  pgtype.Timestamp `json:"expires_at"`
+	LastUsedAt pgtype.Timestamp `json:"last_used_at"`
+	RevokedAt  pgtype.Timestamp `json:"revoked_at"`
+	CreatedAt  pgtype.Timestamp `json:"created_at"`
+}
+
 type Booking struct {
 	ID               int32            `json:"id"`
 	ActivityID       int32            `json:"activity_id"`
@@ -36,24 +56,160 @@ type Booking struct {
 	AttendanceStatus pgtype.Text      `json:"attendance_status"`
 	CreatedAt        pgtype.Timestamp `json:"created_at"`
 	CancelledAt      pgtype.Timestamp `json:"cancelled_at"`
+	CreatedByStaffID pgtype.Int4      `json:"created_by_staff_id"`
+	QuotaOverrideBy  pgtype.Int4      `json:"quota_override_by"`
+}
+
+type BookingWaitlist struct {
+	ID            int32            `json:"id"`
+	ActivityID    int32            `json:"activity_id"`
+	ParticipantID int32            `json:"participant_id"`
+	RequestedBy   int32            `json:"requested_by"`
+	CreatedAt     pgtype.Timestamp `json:"created_at"`
+}
+
+type CareLinkCode struct {
+	ParticipantID int32            `json:"participant_id"`
+	CodeHash      string           `json:"code_hash"`
+	ExpiresAt     pgtype.Timestamp `json:"expires_at"`
+	CreatedAt     pgtype.Timestamp `json:"created_at"`
 }
 
 type CareRelationship struct {
 	ParticipantID int32            `json:"participant_id"`
 	CaregiverID   int32            `json:"caregiver_id"`
 	CreatedAt     pgtype.Timestamp `json:"created_at"`
+	Status        string           `json:"status"`
+	RequestedBy   pgtype.Int4      `json:"requested_by"`
+	ApprovedBy    pgtype.Int4      `json:"approved_by"`
+	ApprovedAt    pgtype.Timestamp `json:"approved_at"`
 }
 
-type ParticipantProfile struct {
-	UserID         int32            `json:"user_id"`
-	Age            pgtype.Int4      `json:"age"`
-	MembershipType pgtype.Text      `json:"membership_type"`
-	Wheelchair     bool             `json:"wheelchair"`
-	SignLanguage   bool             `json:"sign_language"`
-	OtherNeed      pgtype.Text      `json:"other_need"`
+type EmailChangeToken struct {
+	ID        int32            `json:"id"`
+	UserID    int32            `json:"user_id"`
+	NewEmail  string           `json:"new_email"`
+	TokenHash string           `json:"token_hash"`
+	ExpiresAt pgtype.Timestamp `json:"expires_at"`
+	UsedAt    pgtype.Timestamp `json:"used_at"`
+	CreatedAt pgtype.Timestamp `json:"created_at"`
+}
+
+type ImpersonationLog struct {
+	ID        int32            `json:"id"`
+	StaffID   int32            `json:"staff_id"`
+	UserID    int32            `json:"user_id"`
+	Method    string           `json:"method"`
+	Path      string           `json:"path"`
+	Status    int32            `json:"status"`
+	CreatedAt pgtype.Timestamp `json:"created_at"`
+}
+
+type Invitation struct {
+	ID             int32            `json:"id"`
+	Name           string           `json:"name"`
+	Email          string           `json:"email"`
+	PhoneEncrypted []byte           `json:"phone_encrypted"`
+	Role           string           `json:"role"`
+	Channel        string           `json:"channel"`
+	TokenHash      string           `json:"token_hash"`
+	InvitedBy      pgtype.Int4      `json:"invited_by"`
+	ExpiresAt      pgtype.Timestamp `json:"expires_at"`
+	SentAt         pgtype.Timestamp `json:"sent_at"`
+	AcceptedAt     pgtype.Timestamp `json:"accepted_at"`
+	AcceptedUserID pgtype.Int4      `json:"accepted_user_id"`
+	RevokedAt      pgtype.Timestamp `json:"revoked_at"`
+	CreatedAt      pgtype.Timestamp `json:"created_at"`
+}
+
+type LoginCode struct {
+	ID        int32            `json:"id"`
+	UserID    int32            `json:"user_id"`
+	CodeHash  string           `json:"code_hash"`
+	Channel   string           `json:"channel"`
+	Attempts  int32            `json:"attempts"`
+	ExpiresAt pgtype.Timestamp `json:"expires_at"`
+	UsedAt    pgtype.Timestamp `json:"used_at"`
+	CreatedAt pgtype.Timestamp `json:"created_at"`
+}
+
+type LoginThrottle struct {
+	Scope          string           `json:"scope"`
+	Subject        string           `json:"subject"`
+	FailedAttempts int32            `json:"failed_attempts"`
+	LockedUntil    pgtype.Timestamp `json:"locked_until"`
+	LastFailedAt   pgtype.Timestamp `json:"last_failed_at"`
+}
+
+type MedicalAccessLog struct {
+	ID             int32            `json:"id"`
+	ParticipantID  int32            `json:"participant_id"`
+	ViewerID       pgtype.Int4      `json:"viewer_id"`
+	ImpersonatorID pgtype.Int4      `json:"impersonator_id"`
+	ApiKeyID       pgtype.Int4      `json:"api_key_id"`
+	ActivityID     pgtype.Int4      `json:"activity_id"`
+	Basis          string           `json:"basis"`
 	CreatedAt      pgtype.Timestamp `json:"created_at"`
 }
 
+type MfaChallenge struct {
+	ID        int32            `json:"id"`
+	UserID    int32            `json:"user_id"`
+	TokenHash string           `json:"token_hash"`
+	Attempts  int32            `json:"attempts"`
+	ExpiresAt pgtype.Timestamp `json:"expires_at"`
+	UsedAt    pgtype.Timestamp `json:"used_at"`
+	CreatedAt pgtype.Timestamp `json:"created_at"`
+}
+
+type OidcLoginState struct {
+	StateHash    string           `json:"state_hash"`
+	Nonce        string           `json:"nonce"`
+	CodeVerifier string           `json:"code_verifier"`
+	ExpiresAt    pgtype.Timestamp `json:"expires_at"`
+	CreatedAt    pgtype.Timestamp `json:"created_at"`
+}
+
+type ParticipantMedical struct {
+	UserID                     int32            `json:"user_id"`
+	EmergencyContactsEncrypted []byte           `json:"emergency_contacts_encrypted"`
+	MedicalNotesEncrypted      []byte           `json:"medical_notes_encrypted"`
+	UpdatedBy                  pgtype.Int4      `json:"updated_by"`
+	UpdatedAt                  pgtype.Timestamp `json:"updated_at"`
+}
+
+type ParticipantProfile struct {
+	UserID              int32            `json:"user_id"`
+	Age                 pgtype.Int4      `json:"age"`
+	MembershipType      pgtype.Text      `json:"membership_type"`
+	Wheelchair          bool             `json:"wheelchair"`
+	SignLanguage        bool             `json:"sign_language"`
+	OtherNeed           pgtype.Text      `json:"other_need"`
+	CreatedAt           pgtype.Timestamp `json:"created_at"`
+	NeedsSeatedActivity bool             `json:"needs_seated_activity"`
+	SensitiveToLight    bool             `json:"sensitive_to_light"`
+	SensitiveToNoise    bool             `json:"sensitive_to_noise"`
+	UpdatedAt           pgtype.Timestamp `json:"updated_at"`
+}
+
+type PasswordToken struct {
+	ID        int32            `json:"id"`
+	UserID    int32            `json:"user_id"`
+	TokenHash string           `json:"token_hash"`
+	Purpose   string           `json:"purpose"`
+	ExpiresAt pgtype.Timestamp `json:"expires_at"`
+	UsedAt    pgtype.Timestamp `json:"used_at"`
+	CreatedAt pgtype.Timestamp `json:"created_at"`
+}
+
+type RecoveryCode struct {
+	ID        int32            `json:"id"`
+	UserID    int32            `json:"user_id"`
+	CodeHash  string           `json:"code_hash"`
+	UsedAt    pgtype.Timestamp `json:"used_at"`
+	CreatedAt pgtype.Timestamp `json:"created_at"`
+}
+
 type Session struct {
 	ID           string           `json:"id"`
 	UserID       int32            `json:"user_id"`
@@ -61,14 +217,73 @@ type Session struct {
 	IsRevoked    bool             `json:"is_revoked"`
 	ExpiresAt    pgtype.Timestamp `json:"expires_at"`
 	CreatedAt    pgtype.Timestamp `json:"created_at"`
+	FamilyID     string           `json:"family_id"`
+	ReplacedBy   pgtype.Text      `json:"replaced_by"`
+	RotatedAt    pgtype.Timestamp `json:"rotated_at"`
+	UserAgent    pgtype.Text      `json:"user_agent"`
+	IpAddress    pgtype.Text      `json:"ip_address"`
+	LastUsedAt   pgtype.Timestamp `json:"last_used_at"`
 }
 
 type User struct {
+	ID                 int32            `json:"id"`
+	Name               string           `json:"name"`
+	Phone              interface{}      `json:"phone"`
+	Email              string           `json:"email"`
+	Password           interface{}      `json:"password"`
+	Role               string           `json:"role"`
+	CreatedAt          pgtype.Timestamp `json:"created_at"`
+	PhoneEncrypted     []byte           `json:"phone_encrypted"`
+	PhoneIndex         pgtype.Text      `json:"phone_index"`
+	PasswordMustChange bool             `json:"password_must_change"`
+	PasswordChangedAt  pgtype.Timestamp `json:"password_changed_at"`
+	Status             string           `json:"status"`
+	StatusReason       pgtype.Text      `json:"status_reason"`
+	StatusChangedAt    pgtype.Timestamp `json:"status_changed_at"`
+	StatusChangedBy    pgtype.Int4      `json:"status_changed_by"`
+	Language           string           `json:"language"`
+}
+
+type UserTotp struct {
+	UserID          int32            `json:"user_id"`
+	SecretEncrypted []byte           `json:"secret_encrypted"`
+	ConfirmedAt     pgtype.Timestamp `json:"confirmed_at"`
+	LastUsedStep    int64            `json:"last_used_step"`
+	CreatedAt       pgtype.Timestamp `json:"created_at"`
+}
+
+type VolunteerAvailability struct {
+	ID        int32       `json:"id"`
+	UserID    int32       `json:"user_id"`
+	Weekday   int16       `json:"weekday"`
+	StartTime pgtype.Time `json:"start_time"`
+	EndTime   pgtype.Time `json:"end_time"`
+}
+
+type VolunteerCertification struct {
 	ID        int32            `json:"id"`
+	UserID    int32            `json:"user_id"`
 	Name      string           `json:"name"`
-	Phone     interface{}      `json:"phone"`
-	Email     string           `json:"email"`
-	Password  interface{}      `json:"password"`
-	Role      string           `json:"role"`
+	Issuer    pgtype.Text      `json:"issuer"`
+	IssuedOn  pgtype.Date      `json:"issued_on"`
+	ExpiresOn pgtype.Date      `json:"expires_on"`
 	CreatedAt pgtype.Timestamp `json:"created_at"`
 }
+
+type VolunteerHourAdjustment struct {
+	ID        int32            `json:"id"`
+	UserID    int32            `json:"user_id"`
+	Minutes   int32            `json:"minutes"`
+	WorkedOn  pgtype.Date      `json:"worked_on"`
+	Reason    string           `json:"reason"`
+	CreatedBy pgtype.Int4      `json:"created_by"`
+	CreatedAt pgtype.Timestamp `json:"created_at"`
+}
+
+type VolunteerProfile struct {
+	UserID          int32            `json:"user_id"`
+	Skills          []string         `json:"skills"`
+	PreferredVenues []string         `json:"preferred_venues"`
+	CreatedAt       pgtype.Timestamp `json:"created_at"`
+	UpdatedAt       pgtype.Timestamp `json:"updated_at"`
+}
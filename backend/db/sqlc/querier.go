@@ -6,35 +6,152 @@ package repo
 
 import (
 	"context"
+
+	"github.com/jackc/pgx/v5/pgtype"
 )
 
 type Querier interface {
+	AcceptInvitation(ctx context.Context, arg AcceptInvitationParams) (int64, error)
+	ActivateCareRelationship(ctx context.Context, arg ActivateCareRelationshipParams) (CareRelationship, error)
+	AnonymiseUser(ctx context.Context, arg AnonymiseUserParams) (User, error)
+	AreImpersonationUsersActive(ctx context.Context, arg AreImpersonationUsersActiveParams) (bool, error)
+	ClearLoginThrottle(ctx context.Context, arg ClearLoginThrottleParams) (int64, error)
+	ConfirmUserTOTP(ctx context.Context, arg ConfirmUserTOTPParams) (int64, error)
+	ConsumeCareLinkCode(ctx context.Context, codeHash string) (int32, error)
+	ConsumeEmailChangeToken(ctx context.Context, tokenHash string) (EmailChangeToken, error)
+	ConsumeLoginCode(ctx context.Context, id int32) (int64, error)
+	ConsumeMFAChallenge(ctx context.Context, id int32) (int64, error)
+	ConsumeOIDCLoginState(ctx context.Context, stateHash string) (OidcLoginState, error)
+	ConsumePasswordToken(ctx context.Context, tokenHash string) (PasswordToken, error)
 	CountBookingsByActivityID(ctx context.Context, activityID int32) (int64, error)
+	CountParticipantBookingsInWeek(ctx context.Context, arg CountParticipantBookingsInWeekParams) (int64, error)
+	CountRecentEmailChangeTokens(ctx context.Context, arg CountRecentEmailChangeTokensParams) (int64, error)
+	CountRecentLoginCodes(ctx context.Context, arg CountRecentLoginCodesParams) (int64, error)
+	CountRecentPasswordTokens(ctx context.Context, arg CountRecentPasswordTokensParams) (int64, error)
+	CountSearchUsers(ctx context.Context, arg CountSearchUsersParams) (int64, error)
+	CountUnusedRecoveryCodes(ctx context.Context, userID int32) (int64, error)
 	CreateActivity(ctx context.Context, arg CreateActivityParams) (Activity, error)
+	CreateAPIKey(ctx context.Context, arg CreateAPIKeyParams) (ApiKey, error)
 	CreateBooking(ctx context.Context, arg CreateBookingParams) (Booking, error)
+	CreateBookingWaitlistEntry(ctx context.Context, arg CreateBookingWaitlistEntryParams) (BookingWaitlist, error)
+	CreateCareRelationship(ctx context.Context, arg CreateCareRelationshipParams) (CareRelationship, error)
+	CreateEmailChangeToken(ctx context.Context, arg CreateEmailChangeTokenParams) (EmailChangeToken, error)
+	CreateImpersonationLog(ctx context.Context, arg CreateImpersonationLogParams) error
+	CreateInvitation(ctx context.Context, arg CreateInvitationParams) (Invitation, error)
+	CreateLoginCode(ctx context.Context, arg CreateLoginCodeParams) (LoginCode, error)
+	CreateMedicalAccessLog(ctx context.Context, arg CreateMedicalAccessLogParams) error
+	CreateMFAChallenge(ctx context.Context, arg CreateMFAChallengeParams) (MfaChallenge, error)
+	CreateOIDCLoginState(ctx context.Context, arg CreateOIDCLoginStateParams) error
+	CreateParticipantProfile(ctx context.Context, arg CreateParticipantProfileParams) (ParticipantProfile, error)
+	CreatePasswordToken(ctx context.Context, arg CreatePasswordTokenParams) (PasswordToken, error)
+	CreateRecoveryCode(ctx context.Context, arg CreateRecoveryCodeParams) error
 	CreateSession(ctx context.Context, arg CreateSessionParams) (Session, error)
 	CreateUser(ctx context.Context, arg CreateUserParams) (User, error)
+	CreateVolunteerAvailability(ctx context.Context, arg CreateVolunteerAvailabilityParams) error
+	CreateVolunteerCertification(ctx context.Context, arg CreateVolunteerCertificationParams) (VolunteerCertification, error)
+	CreateVolunteerHourAdjustment(ctx context.Context, arg CreateVolunteerHourAdjustmentParams) (VolunteerHourAdjustment, error)
+	DeactivateUser(ctx context.Context, arg DeactivateUserParams) (User, error)
 	DeleteActivityByID(ctx context.Context, id int32) error
+	DeleteActivityVolunteerLead(ctx context.Context, activityID int32) (int64, error)
 	DeleteBookingByID(ctx context.Context, id int32) error
+	DeleteBookingWaitlistEntry(ctx context.Context, id int32) error
+	DeleteCareRelationship(ctx context.Context, arg DeleteCareRelationshipParams) (int64, error)
+	DeleteExpiredOIDCLoginStates(ctx context.Context) error
+	DeleteMFAChallenges(ctx context.Context, userID int32) error
+	DeleteRecoveryCodes(ctx context.Context, userID int32) error
 	DeleteSessionsByUserID(ctx context.Context, userID int32) error
 	DeleteUserByID(ctx context.Context, id int32) error
+	DeleteUserPersonalData(ctx context.Context, userID int32) error
+	DeleteUserTOTP(ctx context.Context, userID int32) (int64, error)
+	DeleteVolunteerAvailability(ctx context.Context, userID int32) error
+	DeleteVolunteerCertification(ctx context.Context, arg DeleteVolunteerCertificationParams) (int64, error)
+	GetActiveLoginCode(ctx context.Context, userID int32) (LoginCode, error)
+	GetActiveMFAChallenge(ctx context.Context, tokenHash string) (MfaChallenge, error)
 	GetActivityByID(ctx context.Context, id int32) (Activity, error)
+	GetActivityVolunteerLead(ctx context.Context, activityID int32) (ActivityVolunteerLead, error)
 	GetAllUsers(ctx context.Context) ([]User, error)
+	GetAPIKeyByPrefix(ctx context.Context, prefix string) (ApiKey, error)
 	GetBookingByID(ctx context.Context, id int32) (Booking, error)
+	GetBookingWaitlistEntry(ctx context.Context, id int32) (BookingWaitlist, error)
+	GetCareRelationship(ctx context.Context, arg GetCareRelationshipParams) (CareRelationship, error)
+	GetInvitationByID(ctx context.Context, id int32) (Invitation, error)
+	GetLoginThrottle(ctx context.Context, arg GetLoginThrottleParams) (LoginThrottle, error)
+	GetParticipantMedical(ctx context.Context, userID int32) (ParticipantMedical, error)
+	GetParticipantProfile(ctx context.Context, userID int32) (ParticipantProfile, error)
 	GetSession(ctx context.Context, id string) (Session, error)
 	GetUserByEmail(ctx context.Context, email string) (User, error)
 	GetUserByID(ctx context.Context, id int32) (User, error)
 	GetUserByNameAndPhone(ctx context.Context, arg GetUserByNameAndPhoneParams) (GetUserByNameAndPhoneRow, error)
-	GetUserByPhone(ctx context.Context, phone interface{}) (User, error)
+	GetUserByPhone(ctx context.Context, phoneIndex pgtype.Text) (User, error)
+	GetUserTOTP(ctx context.Context, userID int32) (UserTotp, error)
+	GetValidPasswordToken(ctx context.Context, tokenHash string) (PasswordToken, error)
+	GetVolunteerProfile(ctx context.Context, userID int32) (VolunteerProfile, error)
+	IncrementLoginCodeAttempts(ctx context.Context, id int32) (int32, error)
+	IncrementMFAChallengeAttempts(ctx context.Context, id int32) (int32, error)
+	InvalidateEmailChangeTokens(ctx context.Context, userID int32) error
+	InvalidateLoginCodes(ctx context.Context, userID int32) error
+	InvalidatePasswordLinks(ctx context.Context, userID int32) error
+	InvalidatePasswordTokens(ctx context.Context, userID int32) error
+	IsActivityLeadOn(ctx context.Context, arg IsActivityLeadOnParams) (bool, error)
+	IsCaregiverOf(ctx context.Context, arg IsCaregiverOfParams) (bool, error)
+	IsSessionActive(ctx context.Context, arg IsSessionActiveParams) (bool, error)
+	ListActiveSessionsByUserID(ctx context.Context, userID int32) ([]ListActiveSessionsByUserIDRow, error)
 	ListActivities(ctx context.Context) ([]Activity, error)
 	ListActivitiesWithCounts(ctx context.Context) ([]ListActivitiesWithCountsRow, error)
+	ListActivityParticipantMedical(ctx context.Context, activityID int32) ([]ListActivityParticipantMedicalRow, error)
+	ListAPIKeys(ctx context.Context) ([]ApiKey, error)
+	ListBookingHistoryByUserID(ctx context.Context, userID int32) ([]ListBookingHistoryByUserIDRow, error)
 	ListBookings(ctx context.Context) ([]Booking, error)
 	ListBookingsByActivityID(ctx context.Context, activityID int32) ([]Booking, error)
+	ListBookingsByUserID(ctx context.Context, userID int32) ([]Booking, error)
+	ListBookingsForCaregiver(ctx context.Context, userID int32) ([]Booking, error)
+	ListBookingWaitlist(ctx context.Context, userID pgtype.Int4) ([]ListBookingWaitlistRow, error)
+	ListCareRelationships(ctx context.Context) ([]CareRelationship, error)
+	ListCareRelationshipsByCaregiver(ctx context.Context, caregiverID int32) ([]CareRelationship, error)
+	ListCareRelationshipsByParticipant(ctx context.Context, participantID int32) ([]CareRelationship, error)
+	ListImpersonationLogs(ctx context.Context, limit int32) ([]ImpersonationLog, error)
+	ListInvitations(ctx context.Context) ([]Invitation, error)
+	ListLockedLoginThrottles(ctx context.Context) ([]LoginThrottle, error)
+	ListMedicalAccessLogs(ctx context.Context, participantID int32) ([]ListMedicalAccessLogsRow, error)
+	ListParticipantWaitlistInWeek(ctx context.Context, arg ListParticipantWaitlistInWeekParams) ([]BookingWaitlist, error)
+	ListSessionsByUserID(ctx context.Context, userID int32) ([]ListSessionsByUserIDRow, error)
 	ListUsersByRole(ctx context.Context, role string) ([]User, error)
+	ListUsersByStatus(ctx context.Context, status string) ([]User, error)
+	ListUsersWithLegacyPhone(ctx context.Context) ([]ListUsersWithLegacyPhoneRow, error)
+	ListVolunteerAvailability(ctx context.Context, userID int32) ([]VolunteerAvailability, error)
+	ListVolunteerCertifications(ctx context.Context, userID int32) ([]VolunteerCertification, error)
+	ListVolunteerHourEntries(ctx context.Context, arg ListVolunteerHourEntriesParams) ([]ListVolunteerHourEntriesRow, error)
+	ListVolunteerHourTotals(ctx context.Context, arg ListVolunteerHourTotalsParams) ([]ListVolunteerHourTotalsRow, error)
+	LockUser(ctx context.Context, id int32) error
+	ReactivateUser(ctx context.Context, arg ReactivateUserParams) (User, error)
+	RecordLoginFailure(ctx context.Context, arg RecordLoginFailureParams) (LoginThrottle, error)
+	RegisterUser(ctx context.Context, arg RegisterUserParams) (User, error)
+	RenewInvitationToken(ctx context.Context, arg RenewInvitationTokenParams) (Invitation, error)
+	ReviewUserRegistration(ctx context.Context, arg ReviewUserRegistrationParams) (User, error)
+	RevokeAPIKey(ctx context.Context, id int32) (int64, error)
+	RevokeInvitation(ctx context.Context, id int32) (int64, error)
 	RevokeSession(ctx context.Context, id string) error
+	RevokeSessionFamily(ctx context.Context, familyID string) error
+	RevokeSessionFamilyForUser(ctx context.Context, arg RevokeSessionFamilyForUserParams) (int64, error)
+	RotateSession(ctx context.Context, arg RotateSessionParams) (Session, error)
+	SearchUsers(ctx context.Context, arg SearchUsersParams) ([]SearchUsersRow, error)
+	SetActivityVolunteerLead(ctx context.Context, arg SetActivityVolunteerLeadParams) (ActivityVolunteerLead, error)
+	SetLoginLockout(ctx context.Context, arg SetLoginLockoutParams) error
+	SetUserEmail(ctx context.Context, arg SetUserEmailParams) (User, error)
+	SetUserPassword(ctx context.Context, arg SetUserPasswordParams) error
+	SetUserPhone(ctx context.Context, arg SetUserPhoneParams) error
+	TouchAPIKey(ctx context.Context, id int32) error
 	UpdateActivity(ctx context.Context, arg UpdateActivityParams) (Activity, error)
 	UpdateActivityByID(ctx context.Context, arg UpdateActivityByIDParams) (Activity, error)
 	UpdateBooking(ctx context.Context, arg UpdateBookingParams) (Booking, error)
+	UpdateParticipantProfile(ctx context.Context, arg UpdateParticipantProfileParams) (ParticipantProfile, error)
+	UpdateUserDetails(ctx context.Context, arg UpdateUserDetailsParams) (User, error)
+	UpsertCareLinkCode(ctx context.Context, arg UpsertCareLinkCodeParams) error
+	UpsertParticipantMedical(ctx context.Context, arg UpsertParticipantMedicalParams) (ParticipantMedical, error)
+	UpsertUserTOTP(ctx context.Context, arg UpsertUserTOTPParams) (UserTotp, error)
+	UpsertVolunteerProfile(ctx context.Context, arg UpsertVolunteerProfileParams) (VolunteerProfile, error)
+	UseRecoveryCode(ctx context.Context, arg UseRecoveryCodeParams) (int64, error)
+	UseTOTPStep(ctx context.Context, arg UseTOTPStepParams) (int64, error)
 }
 
 var _ Querier = (*Queries)(nil)
@@ -9,13 +9,19 @@ INSERT INTO sessions (
     user_id,
     refresh_token,
     is_revoked,
-    expires_at
+    expires_at,
+    family_id,
+    user_agent,
+    ip_address
 ) VALUES (
     $1,
     $2,
     $3,
     $4,
-    $5
+    $5,
+    $6,
+    $7,
+    $8
 )
 RETURNING *;
 
@@ -25,6 +31,59 @@ SELECT * FROM sessions WHERE id = $1;
 -- name: RevokeSession :exec
 UPDATE sessions SET is_revoked = TRUE WHERE id = $1;
 
+-- name: RotateSession :one
+UPDATE sessions
+SET
+  is_revoked = TRUE,
+  replaced_by = $2,
+  rotated_at = NOW()
+WHERE id = $1
+  AND is_revoked = FALSE
+  AND rotated_at IS NULL
+RETURNING *;
+
+-- name: RevokeSessionFamily :exec
+UPDATE sessions SET is_revoked = TRUE WHERE family_id = $1;
+
+-- name: RevokeSessionFamilyForUser :execrows
+UPDATE sessions SET is_revoked = TRUE
+WHERE family_id = $1 AND user_id = $2 AND is_revoked = FALSE;
+
+-- name: IsSessionActive :one
+-- the family still has an unrevoked, unexpired session (its latest refresh token)
+-- and the account is still active
+SELECT EXISTS (
+  SELECT 1 FROM sessions s
+  JOIN users u ON u.id = s.user_id
+  WHERE s.family_id = $1
+    AND s.user_id = $2
+    AND s.is_revoked = FALSE
+    AND s.expires_at > NOW()
+    AND u.status = 'active'
+);
+
+-- name: AreImpersonationUsersActive :one
+-- staff acting on behalf of a user need both accounts to be active
+SELECT COUNT(*) = 2
+FROM users
+WHERE id IN (sqlc.arg('user_id')::int, sqlc.arg('impersonator_id')::int)
+  AND status = 'active';
+
+-- name: ListActiveSessionsByUserID :many
+SELECT
+  s.family_id,
+  s.user_agent,
+  s.ip_address,
+  s.expires_at,
+  s.last_used_at,
+  f.created_at AS signed_in_at
+FROM sessions s
+JOIN sessions f ON f.id = s.family_id
+WHERE s.user_id = $1
+  AND s.is_revoked = FALSE
+  AND s.expires_at > NOW()
+ORDER BY s.last_used_at DESC;
+
 -- name: DeleteSessionsByUserID :exec
 DELETE FROM sessions
 WHERE user_id = $1;
@@ -32,11 +91,12 @@ WHERE user_id = $1;
 -- name: CreateUser :one
 INSERT INTO users (
     name,
-    phone,
+    phone_encrypted,
+    phone_index,
     email,
     role
 ) VALUES (
-    $1, $2, $3, $4
+    $1, $2, $3, $4, $5
 )
 RETURNING *;
 
@@ -46,7 +106,7 @@ WHERE id = $1;
 
 -- name: GetUserByPhone :one
 SELECT * FROM users
-WHERE phone = $1;
+WHERE phone_index = $1;
 
 -- name: GetUserByEmail :one
 SELECT * FROM users
@@ -122,11 +182,11 @@ WHERE
 
 -- name: CreateBooking :one
 INSERT INTO bookings (
-   id, activity_id, user_id, booked_for_user_id,
+   activity_id, user_id, booked_for_user_id,
    role, is_paid, attendance_status, created_at,
-   cancelled_at
+   cancelled_at, created_by_staff_id, quota_override_by
 ) VALUES (
-  gen_random_uuid(), $1, $2, $3, $4, $5, $6, NOW(), $7
+  $1, $2, $3, $4, $5, $6, NOW(), $7, $8, $9
 )
 RETURNING *;
 
@@ -181,7 +241,8 @@ SET
   role = $4,
   is_paid = $5,
   attendance_status = $6,
-  cancelled_at = $7
+  cancelled_at = $7,
+  quota_override_by = $9
 WHERE id = $8
 RETURNING *;
 -- name: ListActivitiesWithCounts :many
@@ -200,3 +261,829 @@ FROM activities a
 LEFT JOIN bookings b
   ON b.activity_id = a.id
   AND b.role = 'participant';   -- filter only participants
+
+-- name: GetLoginThrottle :one
+SELECT * FROM login_throttles
+WHERE scope = $1 AND subject = $2;
+
+-- name: RecordLoginFailure :one
+INSERT INTO login_throttles (scope, subject, failed_attempts, last_failed_at)
+VALUES ($1, $2, 1, NOW())
+ON CONFLICT (scope, subject) DO UPDATE
+SET
+  failed_attempts = CASE
+    WHEN login_throttles.last_failed_at < NOW() - INTERVAL '24 hours' THEN 1
+    ELSE login_throttles.failed_attempts + 1
+  END,
+  last_failed_at = NOW()
+RETURNING *;
+
+-- name: SetLoginLockout :exec
+UPDATE login_throttles
+SET locked_until = $3
+WHERE scope = $1 AND subject = $2;
+
+-- name: ClearLoginThrottle :execrows
+DELETE FROM login_throttles
+WHERE scope = $1 AND subject = $2;
+
+-- name: ListLockedLoginThrottles :many
+SELECT * FROM login_throttles
+WHERE locked_until > NOW()
+ORDER BY locked_until DESC;
+
+-- name: CreateLoginCode :one
+INSERT INTO login_codes (
+  user_id, code_hash, channel, expires_at
+) VALUES (
+  $1, $2, $3, $4
+)
+RETURNING *;
+
+-- name: CountRecentLoginCodes :one
+SELECT COUNT(*)::bigint
+FROM login_codes
+WHERE user_id = $1 AND created_at > $2;
+
+-- name: GetActiveLoginCode :one
+SELECT * FROM login_codes
+WHERE user_id = $1
+  AND used_at IS NULL
+  AND expires_at > NOW()
+ORDER BY created_at DESC
+LIMIT 1;
+
+-- name: IncrementLoginCodeAttempts :one
+UPDATE login_codes
+SET attempts = attempts + 1
+WHERE id = $1
+RETURNING attempts;
+
+-- name: ConsumeLoginCode :execrows
+UPDATE login_codes
+SET used_at = NOW()
+WHERE id = $1 AND used_at IS NULL;
+
+-- name: InvalidateLoginCodes :exec
+UPDATE login_codes
+SET used_at = NOW()
+WHERE user_id = $1 AND used_at IS NULL;
+
+-- name: ListUsersWithLegacyPhone :many
+SELECT id, email, phone FROM users
+WHERE phone IS NOT NULL AND phone_index IS NULL;
+
+-- name: SetUserPhone :exec
+UPDATE users
+SET
+  phone_encrypted = $2,
+  phone_index = $3,
+  phone = NULL
+WHERE id = $1;
+
+-- name: SetUserPassword :exec
+UPDATE users
+SET
+  password = $2,
+  password_must_change = $3,
+  password_changed_at = NOW()
+WHERE id = $1;
+
+-- name: CreatePasswordToken :one
+INSERT INTO password_tokens (
+  user_id, token_hash, purpose, expires_at
+) VALUES (
+  $1, $2, $3, $4
+)
+RETURNING *;
+
+-- name: CountRecentPasswordTokens :one
+SELECT COUNT(*)::bigint
+FROM password_tokens
+WHERE user_id = $1 AND purpose <> 'change' AND created_at > $2;
+
+-- name: GetValidPasswordToken :one
+SELECT * FROM password_tokens
+WHERE token_hash = $1
+  AND used_at IS NULL
+  AND expires_at > NOW();
+
+-- name: ConsumePasswordToken :one
+UPDATE password_tokens
+SET used_at = NOW()
+WHERE token_hash = $1
+  AND used_at IS NULL
+  AND expires_at > NOW()
+RETURNING *;
+
+-- name: InvalidatePasswordLinks :exec
+UPDATE password_tokens
+SET used_at = NOW()
+WHERE user_id = $1 AND used_at IS NULL AND purpose <> 'change';
+
+-- name: InvalidatePasswordTokens :exec
+UPDATE password_tokens
+SET used_at = NOW()
+WHERE user_id = $1 AND used_at IS NULL;
+
+-- name: IsCaregiverOf :one
+SELECT EXISTS (
+  SELECT 1 FROM care_relationships
+  WHERE caregiver_id = $1 AND participant_id = $2 AND status = 'active'
+);
+
+-- name: ListBookingsByUserID :many
+SELECT * FROM bookings
+WHERE user_id = $1 OR booked_for_user_id = $1
+ORDER BY created_at DESC;
+
+-- name: ListBookingsForCaregiver :many
+SELECT * FROM bookings
+WHERE user_id = $1
+   OR booked_for_user_id = $1
+   OR booked_for_user_id IN (
+     SELECT participant_id FROM care_relationships WHERE caregiver_id = $1 AND status = 'active'
+   )
+ORDER BY created_at DESC;
+
+-- name: UpsertUserTOTP :one
+INSERT INTO user_totp (
+  user_id, secret_encrypted
+) VALUES (
+  $1, $2
+)
+ON CONFLICT (user_id) DO UPDATE
+SET secret_encrypted = EXCLUDED.secret_encrypted,
+    confirmed_at = NULL,
+    last_used_step = 0,
+    created_at = NOW()
+WHERE user_totp.confirmed_at IS NULL
+RETURNING *;
+
+-- name: GetUserTOTP :one
+SELECT * FROM user_totp
+WHERE user_id = $1;
+
+-- name: ConfirmUserTOTP :execrows
+UPDATE user_totp
+SET confirmed_at = NOW(), last_used_step = $2
+WHERE user_id = $1 AND confirmed_at IS NULL;
+
+-- name: UseTOTPStep :execrows
+UPDATE user_totp
+SET last_used_step = $2
+WHERE user_id = $1 AND last_used_step < $2;
+
+-- name: DeleteUserTOTP :execrows
+DELETE FROM user_totp
+WHERE user_id = $1;
+
+-- name: CreateRecoveryCode :exec
+INSERT INTO recovery_codes (
+  user_id, code_hash
+) VALUES (
+  $1, $2
+);
+
+-- name: DeleteRecoveryCodes :exec
+DELETE FROM recovery_codes
+WHERE user_id = $1;
+
+-- name: UseRecoveryCode :execrows
+UPDATE recovery_codes
+SET used_at = NOW()
+WHERE user_id = $1 AND code_hash = $2 AND used_at IS NULL;
+
+-- name: CountUnusedRecoveryCodes :one
+SELECT COUNT(*)::bigint
+FROM recovery_codes
+WHERE user_id = $1 AND used_at IS NULL;
+
+-- name: CreateMFAChallenge :one
+INSERT INTO mfa_challenges (
+  user_id, token_hash, expires_at
+) VALUES (
+  $1, $2, $3
+)
+RETURNING *;
+
+-- name: GetActiveMFAChallenge :one
+SELECT * FROM mfa_challenges
+WHERE token_hash = $1
+  AND used_at IS NULL
+  AND expires_at > NOW();
+
+-- name: IncrementMFAChallengeAttempts :one
+UPDATE mfa_challenges
+SET attempts = attempts + 1
+WHERE id = $1
+RETURNING attempts;
+
+-- name: ConsumeMFAChallenge :execrows
+UPDATE mfa_challenges
+SET used_at = NOW()
+WHERE id = $1 AND used_at IS NULL;
+
+-- name: DeleteMFAChallenges :exec
+DELETE FROM mfa_challenges
+WHERE user_id = $1;
+
+-- name: CreateImpersonationLog :exec
+INSERT INTO impersonation_logs (
+  staff_id, user_id, method, path, status
+) VALUES (
+  $1, $2, $3, $4, $5
+);
+
+-- name: ListImpersonationLogs :many
+SELECT * FROM impersonation_logs
+ORDER BY created_at DESC
+LIMIT $1;
+
+-- name: CreateInvitation :one
+INSERT INTO invitations (
+  name, email, phone_encrypted, role, channel, token_hash, invited_by, expires_at
+) VALUES (
+  $1, $2, $3, $4, $5, $6, $7, $8
+)
+RETURNING *;
+
+-- name: GetInvitationByID :one
+SELECT * FROM invitations
+WHERE id = $1;
+
+-- name: ListInvitations :many
+SELECT * FROM invitations
+ORDER BY created_at DESC;
+
+-- name: RenewInvitationToken :one
+UPDATE invitations
+SET token_hash = $2, expires_at = $3, sent_at = NOW()
+WHERE id = $1 AND accepted_at IS NULL AND revoked_at IS NULL
+RETURNING *;
+
+-- name: RevokeInvitation :execrows
+UPDATE invitations
+SET revoked_at = NOW()
+WHERE id = $1 AND accepted_at IS NULL AND revoked_at IS NULL;
+
+-- name: AcceptInvitation :execrows
+UPDATE invitations
+SET accepted_at = NOW(), accepted_user_id = $2
+WHERE id = $1
+  AND accepted_at IS NULL
+  AND revoked_at IS NULL
+  AND expires_at > NOW();
+
+-- name: CreateAPIKey :one
+INSERT INTO api_keys (
+  name, prefix, key_hash, scopes, created_by, expires_at
+) VALUES (
+  $1, $2, $3, $4, $5, $6
+)
+RETURNING *;
+
+-- name: GetAPIKeyByPrefix :one
+SELECT * FROM api_keys
+WHERE prefix = $1;
+
+-- name: ListAPIKeys :many
+SELECT * FROM api_keys
+ORDER BY created_at DESC;
+
+-- name: RevokeAPIKey :execrows
+UPDATE api_keys
+SET revoked_at = NOW()
+WHERE id = $1 AND revoked_at IS NULL;
+
+-- name: TouchAPIKey :exec
+UPDATE api_keys
+SET last_used_at = NOW()
+WHERE id = $1
+  AND (last_used_at IS NULL OR last_used_at < NOW() - INTERVAL '1 minute');
+
+-- name: CreateOIDCLoginState :exec
+INSERT INTO oidc_login_states (
+  state_hash, nonce, code_verifier, expires_at
+) VALUES (
+  $1, $2, $3, $4
+);
+
+-- name: ConsumeOIDCLoginState :one
+DELETE FROM oidc_login_states
+WHERE state_hash = $1 AND expires_at > NOW()
+RETURNING *;
+
+-- name: DeleteExpiredOIDCLoginStates :exec
+DELETE FROM oidc_login_states
+WHERE expires_at <= NOW();
+
+-- name: GetParticipantProfile :one
+SELECT * FROM participant_profiles
+WHERE user_id = $1;
+
+-- name: CreateParticipantProfile :one
+INSERT INTO participant_profiles (
+  user_id, age, membership_type, wheelchair, sign_language, other_need,
+  needs_seated_activity, sensitive_to_light, sensitive_to_noise
+) VALUES (
+  $1, $2, $3, $4, $5, $6, $7, $8, $9
+)
+RETURNING *;
+
+-- name: UpdateParticipantProfile :one
+UPDATE participant_profiles
+SET age = $2,
+    membership_type = $3,
+    wheelchair = $4,
+    sign_language = $5,
+    other_need = $6,
+    needs_seated_activity = $7,
+    sensitive_to_light = $8,
+    sensitive_to_noise = $9,
+    updated_at = NOW()
+WHERE user_id = $1
+RETURNING *;
+
+-- name: UpsertCareLinkCode :exec
+INSERT INTO care_link_codes (
+  participant_id, code_hash, expires_at
+) VALUES (
+  $1, $2, $3
+)
+ON CONFLICT (participant_id) DO UPDATE
+SET code_hash = EXCLUDED.code_hash,
+    expires_at = EXCLUDED.expires_at,
+    created_at = NOW();
+
+-- name: ConsumeCareLinkCode :one
+DELETE FROM care_link_codes
+WHERE code_hash = $1 AND expires_at > NOW()
+RETURNING participant_id;
+
+-- name: CreateCareRelationship :one
+INSERT INTO care_relationships (
+  participant_id, caregiver_id, status, requested_by, approved_by, approved_at
+) VALUES (
+  $1, $2, $3, $4, $5, $6
+)
+ON CONFLICT (participant_id, caregiver_id) DO NOTHING
+RETURNING *;
+
+-- name: ActivateCareRelationship :one
+UPDATE care_relationships
+SET status = 'active', approved_by = $3, approved_at = NOW()
+WHERE participant_id = $1 AND caregiver_id = $2 AND status = 'pending'
+RETURNING *;
+
+-- name: DeleteCareRelationship :execrows
+DELETE FROM care_relationships
+WHERE participant_id = $1 AND caregiver_id = $2;
+
+-- name: GetCareRelationship :one
+SELECT * FROM care_relationships
+WHERE participant_id = $1 AND caregiver_id = $2;
+
+-- name: ListCareRelationships :many
+SELECT * FROM care_relationships
+ORDER BY created_at DESC;
+
+-- name: ListCareRelationshipsByCaregiver :many
+SELECT * FROM care_relationships
+WHERE caregiver_id = $1
+ORDER BY created_at DESC;
+
+-- name: ListCareRelationshipsByParticipant :many
+SELECT * FROM care_relationships
+WHERE participant_id = $1
+ORDER BY created_at DESC;
+
+-- name: RegisterUser :one
+INSERT INTO users (
+    name,
+    phone_encrypted,
+    phone_index,
+    email,
+    role,
+    status
+) VALUES (
+    $1, $2, $3, $4, $5, 'pending'
+)
+RETURNING *;
+
+-- name: ListUsersByStatus :many
+SELECT * FROM users
+WHERE status = $1
+ORDER BY created_at;
+
+-- name: ReviewUserRegistration :one
+UPDATE users
+SET status = $2,
+    status_reason = $3,
+    status_changed_by = $4,
+    status_changed_at = NOW()
+WHERE id = $1 AND status = 'pending'
+RETURNING *;
+
+-- name: DeactivateUser :one
+UPDATE users
+SET status = 'deactivated',
+    status_reason = $2,
+    status_changed_by = $3,
+    status_changed_at = NOW()
+WHERE id = $1 AND status = 'active'
+RETURNING *;
+
+-- name: ReactivateUser :one
+UPDATE users
+SET status = 'active',
+    status_reason = NULL,
+    status_changed_by = $2,
+    status_changed_at = NOW()
+WHERE id = $1 AND status = 'deactivated'
+RETURNING *;
+
+-- name: AnonymiseUser :one
+-- keeps the row (and so the booking history) but nothing that identifies the person
+UPDATE users
+SET name = 'Anonymised user',
+    email = 'anonymised-' || id || '@example.invalid',
+    phone = NULL,
+    phone_encrypted = NULL,
+    phone_index = NULL,
+    password = NULL,
+    password_must_change = FALSE,
+    status = 'anonymised',
+    status_reason = NULL,
+    status_changed_by = $2,
+    status_changed_at = NOW()
+WHERE id = $1 AND status <> 'anonymised'
+RETURNING *;
+
+-- name: DeleteUserPersonalData :exec
+-- everything about the user except their bookings
+WITH profile AS (DELETE FROM participant_profiles WHERE user_id = $1),
+     care AS (DELETE FROM care_relationships WHERE participant_id = $1 OR caregiver_id = $1),
+     link_codes AS (DELETE FROM care_link_codes WHERE participant_id = $1),
+     login AS (DELETE FROM login_codes WHERE user_id = $1),
+     passwords AS (DELETE FROM password_tokens WHERE user_id = $1),
+     totp AS (DELETE FROM user_totp WHERE user_id = $1),
+     recovery AS (DELETE FROM recovery_codes WHERE user_id = $1),
+     mfa AS (DELETE FROM mfa_challenges WHERE user_id = $1),
+     invites AS (DELETE FROM invitations WHERE accepted_user_id = $1),
+     volunteer AS (DELETE FROM volunteer_profiles WHERE user_id = $1),
+     certifications AS (DELETE FROM volunteer_certifications WHERE user_id = $1),
+     availability AS (DELETE FROM volunteer_availability WHERE user_id = $1),
+     medical AS (DELETE FROM participant_medical WHERE user_id = $1),
+     leads AS (DELETE FROM activity_volunteer_leads WHERE user_id = $1),
+     email_changes AS (DELETE FROM email_change_tokens WHERE user_id = $1),
+     waitlist AS (DELETE FROM booking_waitlist WHERE participant_id = $1 OR requested_by = $1)
+DELETE FROM sessions WHERE user_id = $1;
+
+-- name: ListSessionsByUserID :many
+SELECT created_at, last_used_at, expires_at, is_revoked, user_agent, ip_address
+FROM sessions
+WHERE user_id = $1
+ORDER BY created_at DESC;
+
+-- name: ListBookingHistoryByUserID :many
+SELECT b.id, b.activity_id, a.title AS activity_title, a.venue, a.start_time, a.end_time,
+       b.user_id, b.booked_for_user_id, b.role, b.is_paid, b.attendance_status, b.created_at, b.cancelled_at
+FROM bookings b
+JOIN activities a ON a.id = b.activity_id
+WHERE b.user_id = $1 OR b.booked_for_user_id = $1
+ORDER BY a.start_time DESC;
+
+-- name: SearchUsers :many
+-- credential columns are deliberately not selected
+SELECT u.id, u.name, u.email, u.role, u.status, u.created_at, u.phone_encrypted,
+       p.membership_type, p.wheelchair, p.sign_language, p.needs_seated_activity,
+       p.sensitive_to_light, p.sensitive_to_noise, v.skills
+FROM users u
+LEFT JOIN participant_profiles p ON p.user_id = u.id
+LEFT JOIN volunteer_profiles v ON v.user_id = u.id
+WHERE (sqlc.narg('search')::text IS NULL
+       OR u.name ILIKE '%' || sqlc.narg('search') || '%'
+       OR u.email ILIKE '%' || sqlc.narg('search') || '%')
+  AND (sqlc.narg('role')::text IS NULL OR u.role = sqlc.narg('role'))
+  AND (sqlc.narg('status')::text IS NULL OR u.status = sqlc.narg('status'))
+  AND (sqlc.narg('membership_type')::text IS NULL OR p.membership_type = sqlc.narg('membership_type'))
+  AND (sqlc.narg('wheelchair')::bool IS NULL OR COALESCE(p.wheelchair, FALSE) = sqlc.narg('wheelchair'))
+  AND (sqlc.narg('sign_language')::bool IS NULL OR COALESCE(p.sign_language, FALSE) = sqlc.narg('sign_language'))
+  AND (sqlc.narg('needs_seated_activity')::bool IS NULL OR COALESCE(p.needs_seated_activity, FALSE) = sqlc.narg('needs_seated_activity'))
+  AND (sqlc.narg('sensitive_to_light')::bool IS NULL OR COALESCE(p.sensitive_to_light, FALSE) = sqlc.narg('sensitive_to_light'))
+  AND (sqlc.narg('sensitive_to_noise')::bool IS NULL OR COALESCE(p.sensitive_to_noise, FALSE) = sqlc.narg('sensitive_to_noise'))
+  AND (sqlc.narg('created_from')::timestamp IS NULL OR u.created_at >= sqlc.narg('created_from'))
+  AND (sqlc.narg('created_to')::timestamp IS NULL OR u.created_at < sqlc.narg('created_to'))
+  -- volunteers: all of the skills, a preferred venue, a certification valid on a date, a weekly window
+  AND (sqlc.narg('skills')::text[] IS NULL OR v.skills @> sqlc.narg('skills'))
+  AND (sqlc.narg('venue')::text IS NULL OR sqlc.narg('venue') = ANY(v.preferred_venues))
+  AND (sqlc.narg('certification')::text IS NULL OR EXISTS (
+    SELECT 1 FROM volunteer_certifications c
+    WHERE c.user_id = u.id
+      AND LOWER(c.name) = LOWER(sqlc.narg('certification'))
+      AND (c.expires_on IS NULL OR c.expires_on >= sqlc.arg('certified_on')::date)))
+  AND (sqlc.narg('available_weekday')::smallint IS NULL OR EXISTS (
+    SELECT 1 FROM volunteer_availability a
+    WHERE a.user_id = u.id
+      AND a.weekday = sqlc.narg('available_weekday')
+      AND a.start_time <= sqlc.arg('available_from')::time
+      AND a.end_time >= sqlc.arg('available_to')::time))
+  -- keyset pagination: rows after the cursor in the chosen order
+  AND (sqlc.narg('after_id')::int IS NULL OR CASE sqlc.arg('sort')::text
+    WHEN 'name' THEN (u.name, u.id) > (sqlc.narg('after_name')::text, sqlc.narg('after_id')::int)
+    WHEN '-name' THEN (u.name, u.id) < (sqlc.narg('after_name')::text, sqlc.narg('after_id')::int)
+    WHEN 'created_at' THEN (u.created_at, u.id) > (sqlc.narg('after_created_at')::timestamp, sqlc.narg('after_id')::int)
+    ELSE (u.created_at, u.id) < (sqlc.narg('after_created_at')::timestamp, sqlc.narg('after_id')::int)
+  END)
+ORDER BY
+  CASE WHEN sqlc.arg('sort')::text = 'name' THEN u.name END ASC,
+  CASE WHEN sqlc.arg('sort')::text = '-name' THEN u.name END DESC,
+  CASE WHEN sqlc.arg('sort')::text = 'created_at' THEN u.created_at END ASC,
+  CASE WHEN sqlc.arg('sort')::text = '-created_at' THEN u.created_at END DESC,
+  CASE WHEN sqlc.arg('sort')::text IN ('name', 'created_at') THEN u.id END ASC,
+  u.id DESC
+LIMIT sqlc.arg('page_limit');
+
+-- name: CountSearchUsers :one
+SELECT COUNT(*) FROM users u
+LEFT JOIN participant_profiles p ON p.user_id = u.id
+LEFT JOIN volunteer_profiles v ON v.user_id = u.id
+WHERE (sqlc.narg('search')::text IS NULL
+       OR u.name ILIKE '%' || sqlc.narg('search') || '%'
+       OR u.email ILIKE '%' || sqlc.narg('search') || '%')
+  AND (sqlc.narg('role')::text IS NULL OR u.role = sqlc.narg('role'))
+  AND (sqlc.narg('status')::text IS NULL OR u.status = sqlc.narg('status'))
+  AND (sqlc.narg('membership_type')::text IS NULL OR p.membership_type = sqlc.narg('membership_type'))
+  AND (sqlc.narg('wheelchair')::bool IS NULL OR COALESCE(p.wheelchair, FALSE) = sqlc.narg('wheelchair'))
+  AND (sqlc.narg('sign_language')::bool IS NULL OR COALESCE(p.sign_language, FALSE) = sqlc.narg('sign_language'))
+  AND (sqlc.narg('needs_seated_activity')::bool IS NULL OR COALESCE(p.needs_seated_activity, FALSE) = sqlc.narg('needs_seated_activity'))
+  AND (sqlc.narg('sensitive_to_light')::bool IS NULL OR COALESCE(p.sensitive_to_light, FALSE) = sqlc.narg('sensitive_to_light'))
+  AND (sqlc.narg('sensitive_to_noise')::bool IS NULL OR COALESCE(p.sensitive_to_noise, FALSE) = sqlc.narg('sensitive_to_noise'))
+  AND (sqlc.narg('created_from')::timestamp IS NULL OR u.created_at >= sqlc.narg('created_from'))
+  AND (sqlc.narg('created_to')::timestamp IS NULL OR u.created_at < sqlc.narg('created_to'))
+  -- volunteers: all of the skills, a preferred venue, a certification valid on a date, a weekly window
+  AND (sqlc.narg('skills')::text[] IS NULL OR v.skills @> sqlc.narg('skills'))
+  AND (sqlc.narg('venue')::text IS NULL OR sqlc.narg('venue') = ANY(v.preferred_venues))
+  AND (sqlc.narg('certification')::text IS NULL OR EXISTS (
+    SELECT 1 FROM volunteer_certifications c
+    WHERE c.user_id = u.id
+      AND LOWER(c.name) = LOWER(sqlc.narg('certification'))
+      AND (c.expires_on IS NULL OR c.expires_on >= sqlc.arg('certified_on')::date)))
+  AND (sqlc.narg('available_weekday')::smallint IS NULL OR EXISTS (
+    SELECT 1 FROM volunteer_availability a
+    WHERE a.user_id = u.id
+      AND a.weekday = sqlc.narg('available_weekday')
+      AND a.start_time <= sqlc.arg('available_from')::time
+      AND a.end_time >= sqlc.arg('available_to')::time));
+
+-- name: GetVolunteerProfile :one
+SELECT * FROM volunteer_profiles
+WHERE user_id = $1;
+
+-- name: UpsertVolunteerProfile :one
+INSERT INTO volunteer_profiles (user_id, skills, preferred_venues)
+VALUES ($1, $2, $3)
+ON CONFLICT (user_id) DO UPDATE
+SET skills = EXCLUDED.skills,
+    preferred_venues = EXCLUDED.preferred_venues,
+    updated_at = NOW()
+RETURNING *;
+
+-- name: ListVolunteerAvailability :many
+SELECT * FROM volunteer_availability
+WHERE user_id = $1
+ORDER BY weekday, start_time;
+
+-- name: DeleteVolunteerAvailability :exec
+DELETE FROM volunteer_availability
+WHERE user_id = $1;
+
+-- name: CreateVolunteerAvailability :exec
+INSERT INTO volunteer_availability (user_id, weekday, start_time, end_time)
+VALUES ($1, $2, $3, $4);
+
+-- name: ListVolunteerCertifications :many
+SELECT * FROM volunteer_certifications
+WHERE user_id = $1
+ORDER BY expires_on NULLS LAST, name;
+
+-- name: CreateVolunteerCertification :one
+INSERT INTO volunteer_certifications (user_id, name, issuer, issued_on, expires_on)
+VALUES ($1, $2, $3, $4, $5)
+RETURNING *;
+
+-- name: DeleteVolunteerCertification :execrows
+DELETE FROM volunteer_certifications
+WHERE id = $1 AND user_id = $2;
+
+-- name: CreateVolunteerHourAdjustment :one
+INSERT INTO volunteer_hour_adjustments (user_id, minutes, worked_on, reason, created_by)
+VALUES ($1, $2, $3, $4, $5)
+RETURNING *;
+
+-- name: ListVolunteerHourEntries :many
+-- attended volunteer shifts plus staff adjustments, dated by the day worked
+SELECT 'activity'::text AS kind, b.id, a.start_time::date AS worked_on, a.title AS description,
+       GREATEST(EXTRACT(EPOCH FROM a.end_time - a.start_time) / 60, 0)::int AS minutes,
+       NULL::int AS created_by
+FROM bookings b
+JOIN activities a ON a.id = b.activity_id
+WHERE b.user_id = sqlc.arg(user_id)
+  AND b.role = 'volunteer'
+  AND b.attendance_status = 'PRESENT'
+  AND b.cancelled_at IS NULL
+  AND a.start_time >= sqlc.arg(from_date)::date
+  AND a.start_time < sqlc.arg(until_date)::date
+UNION ALL
+SELECT 'adjustment'::text, h.id, h.worked_on, h.reason, h.minutes, h.created_by
+FROM volunteer_hour_adjustments h
+WHERE h.user_id = sqlc.arg(user_id)
+  AND h.worked_on >= sqlc.arg(from_date)::date
+  AND h.worked_on < sqlc.arg(until_date)::date
+ORDER BY worked_on, kind, id;
+
+-- name: ListVolunteerHourTotals :many
+SELECT u.id AS user_id, u.name, SUM(e.minutes)::bigint AS minutes,
+       COUNT(*) FILTER (WHERE e.kind = 'activity') AS activities
+FROM (
+    SELECT 'activity'::text AS kind, b.user_id,
+           GREATEST(EXTRACT(EPOCH FROM a.end_time - a.start_time) / 60, 0)::int AS minutes
+    FROM bookings b
+    JOIN activities a ON a.id = b.activity_id
+    WHERE b.role = 'volunteer'
+      AND b.attendance_status = 'PRESENT'
+      AND b.cancelled_at IS NULL
+      AND a.start_time >= sqlc.arg(from_date)::date
+      AND a.start_time < sqlc.arg(until_date)::date
+    UNION ALL
+    SELECT 'adjustment'::text, h.user_id, h.minutes
+    FROM volunteer_hour_adjustments h
+    WHERE h.worked_on >= sqlc.arg(from_date)::date
+      AND h.worked_on < sqlc.arg(until_date)::date
+) e
+JOIN users u ON u.id = e.user_id
+GROUP BY u.id, u.name
+ORDER BY minutes DESC, u.name;
+
+-- name: GetParticipantMedical :one
+SELECT * FROM participant_medical
+WHERE user_id = $1;
+
+-- name: UpsertParticipantMedical :one
+INSERT INTO participant_medical (user_id, emergency_contacts_encrypted, medical_notes_encrypted, updated_by)
+VALUES ($1, $2, $3, $4)
+ON CONFLICT (user_id) DO UPDATE
+SET emergency_contacts_encrypted = EXCLUDED.emergency_contacts_encrypted,
+    medical_notes_encrypted = EXCLUDED.medical_notes_encrypted,
+    updated_by = EXCLUDED.updated_by,
+    updated_at = NOW()
+RETURNING *;
+
+-- name: CreateMedicalAccessLog :exec
+INSERT INTO medical_access_logs (participant_id, viewer_id, impersonator_id, api_key_id, activity_id, basis)
+VALUES ($1, $2, $3, $4, $5, $6);
+
+-- name: ListMedicalAccessLogs :many
+SELECT l.id, l.viewer_id, v.name AS viewer_name, l.impersonator_id, l.api_key_id, l.activity_id, l.basis, l.created_at
+FROM medical_access_logs l
+LEFT JOIN users v ON v.id = l.viewer_id
+WHERE l.participant_id = $1
+ORDER BY l.created_at DESC, l.id DESC;
+
+-- name: GetActivityVolunteerLead :one
+SELECT * FROM activity_volunteer_leads
+WHERE activity_id = $1;
+
+-- name: SetActivityVolunteerLead :one
+INSERT INTO activity_volunteer_leads (activity_id, user_id, assigned_by)
+VALUES ($1, $2, $3)
+ON CONFLICT (activity_id) DO UPDATE
+SET user_id = EXCLUDED.user_id,
+    assigned_by = EXCLUDED.assigned_by,
+    assigned_at = NOW()
+RETURNING *;
+
+-- name: DeleteActivityVolunteerLead :execrows
+DELETE FROM activity_volunteer_leads
+WHERE activity_id = $1;
+
+-- name: IsActivityLeadOn :one
+SELECT EXISTS (
+    SELECT 1 FROM activity_volunteer_leads l
+    JOIN activities a ON a.id = l.activity_id
+    WHERE l.activity_id = $1
+      AND l.user_id = $2
+      AND a.start_time::date = sqlc.arg(on_date)::date
+);
+
+-- name: ListActivityParticipantMedical :many
+-- participants with a live booking on the activity who have medical details
+SELECT u.id, u.name, m.emergency_contacts_encrypted, m.medical_notes_encrypted, m.updated_by, m.updated_at
+FROM users u
+JOIN participant_medical m ON m.user_id = u.id
+WHERE u.id IN (
+    SELECT COALESCE(b.booked_for_user_id, b.user_id)
+    FROM bookings b
+    WHERE b.activity_id = $1
+      AND b.role = 'participant'
+      AND b.cancelled_at IS NULL
+)
+ORDER BY u.name, u.id;
+
+-- name: UpdateUserDetails :one
+-- null leaves a field as it is; a new phone also clears the legacy bcrypt one
+UPDATE users
+SET name = COALESCE(sqlc.narg(name), name),
+    language = COALESCE(sqlc.narg(language), language),
+    phone_encrypted = CASE WHEN sqlc.arg(set_phone)::bool THEN sqlc.narg(phone_encrypted)::bytea ELSE phone_encrypted END,
+    phone_index = CASE WHEN sqlc.arg(set_phone)::bool THEN sqlc.narg(phone_index)::text ELSE phone_index END,
+    phone = CASE WHEN sqlc.arg(set_phone)::bool THEN NULL ELSE phone END
+WHERE id = sqlc.arg(id)
+RETURNING *;
+
+-- name: CreateEmailChangeToken :one
+INSERT INTO email_change_tokens (user_id, new_email, token_hash, expires_at)
+VALUES ($1, $2, $3, $4)
+RETURNING *;
+
+-- name: CountRecentEmailChangeTokens :one
+SELECT COUNT(*)::bigint
+FROM email_change_tokens
+WHERE user_id = $1 AND created_at > $2;
+
+-- name: InvalidateEmailChangeTokens :exec
+UPDATE email_change_tokens
+SET used_at = NOW()
+WHERE user_id = $1 AND used_at IS NULL;
+
+-- name: ConsumeEmailChangeToken :one
+UPDATE email_change_tokens
+SET used_at = NOW()
+WHERE token_hash = $1
+  AND used_at IS NULL
+  AND expires_at > NOW()
+RETURNING *;
+
+-- name: SetUserEmail :one
+UPDATE users
+SET email = $2
+WHERE id = $1 AND status <> 'anonymised'
+RETURNING *;
+
+-- name: LockUser :exec
+-- held until the transaction ends, so quota checks for one participant run one at a time
+SELECT id FROM users WHERE id = $1 FOR UPDATE;
+
+-- name: CountParticipantBookingsInWeek :one
+-- active participant bookings in the ISO week (Monday to Sunday) containing week_of
+SELECT COUNT(*)::bigint
+FROM bookings b
+JOIN activities a ON a.id = b.activity_id
+WHERE COALESCE(b.booked_for_user_id, b.user_id) = sqlc.arg('participant_id')::int
+  AND b.role = 'participant'
+  AND b.cancelled_at IS NULL
+  AND a.status <> 'CANCELLED'
+  AND date_trunc('week', a.start_time) = date_trunc('week', sqlc.arg('week_of')::timestamp)
+  AND b.id <> sqlc.arg('exclude_id')::int;
+
+-- name: CreateBookingWaitlistEntry :one
+INSERT INTO booking_waitlist (activity_id, participant_id, requested_by)
+VALUES ($1, $2, $3)
+RETURNING *;
+
+-- name: GetBookingWaitlistEntry :one
+SELECT * FROM booking_waitlist
+WHERE id = $1;
+
+-- name: DeleteBookingWaitlistEntry :exec
+DELETE FROM booking_waitlist
+WHERE id = $1;
+
+-- name: ListParticipantWaitlistInWeek :many
+-- entries that can still become bookings, oldest first
+SELECT w.*
+FROM booking_waitlist w
+JOIN activities a ON a.id = w.activity_id
+WHERE w.participant_id = sqlc.arg('participant_id')
+  AND a.status = 'OPEN'
+  AND a.start_time > NOW()
+  AND date_trunc('week', a.start_time) = date_trunc('week', sqlc.arg('week_of')::timestamp)
+ORDER BY w.created_at;
+
+-- name: ListBookingWaitlist :many
+-- entries for activities that have not started; a null user_id lists everyone,
+-- otherwise their own, those they asked for and those of their linked participants
+SELECT w.id, w.activity_id, a.title AS activity_title, a.start_time,
+       w.participant_id, u.name AS participant_name, w.requested_by, w.created_at
+FROM booking_waitlist w
+JOIN activities a ON a.id = w.activity_id
+JOIN users u ON u.id = w.participant_id
+WHERE a.start_time > NOW()
+  AND (sqlc.narg('user_id')::int IS NULL
+       OR w.participant_id = sqlc.narg('user_id')
+       OR w.requested_by = sqlc.narg('user_id')
+       OR w.participant_id IN (
+         SELECT participant_id FROM care_relationships WHERE caregiver_id = sqlc.narg('user_id') AND status = 'active'
+       ))
+ORDER BY a.start_time, w.created_at;
@@ -11,6 +11,278 @@ import (
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
+const acceptInvitation = `-- name: AcceptInvitation :execrows
+UPDATE invitations
+SET accepted_at = NOW(), accepted_user_id = $2
+WHERE id = $1
+  AND accepted_at IS NULL
+  AND revoked_at IS NULL
+  AND expires_at > NOW()
+`
+
+type AcceptInvitationParams struct {
+	ID             int32       `json:"id"`
+	AcceptedUserID pgtype.Int4 `json:"accepted_user_id"`
+}
+
+func (q *Queries) AcceptInvitation(ctx context.Context, arg AcceptInvitationParams) (int64, error) {
+	result, err := q.db.Exec(ctx, acceptInvitation, arg.ID, arg.AcceptedUserID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const activateCareRelationship = `-- name: ActivateCareRelationship :one
+UPDATE care_relationships
+SET status = 'active', approved_by = $3, approved_at = NOW()
+WHERE participant_id = $1 AND caregiver_id = $2 AND status = 'pending'
+RETURNING participant_id, caregiver_id, created_at, status, requested_by, approved_by, approved_at
+`
+
+type ActivateCareRelationshipParams struct {
+	ParticipantID int32       `json:"participant_id"`
+	CaregiverID   int32       `json:"caregiver_id"`
+	ApprovedBy    pgtype.Int4 `json:"approved_by"`
+}
+
+func (q *Queries) ActivateCareRelationship(ctx context.Context, arg ActivateCareRelationshipParams) (CareRelationship, error) {
+	row := q.db.QueryRow(ctx, activateCareRelationship,
+		arg.ParticipantID,
+		arg.CaregiverID,
+		arg.ApprovedBy,
+	)
+	var i CareRelationship
+	err := row.Scan(
+		&i.ParticipantID,
+		&i.CaregiverID,
+		&i.CreatedAt,
+		&i.Status,
+		&i.RequestedBy,
+		&i.ApprovedBy,
+		&i.ApprovedAt,
+	)
+	return i, err
+}
+
+const anonymiseUser = `-- name: AnonymiseUser :one
+-- keeps the row (and so the booking history) but nothing that identifies the person
+UPDATE users
+SET name = 'Anonymised user',
+    email = 'anonymised-' || id || '@example.invalid',
+    phone = NULL,
+    phone_encrypted = NULL,
+    phone_index = NULL,
+    password = NULL,
+    password_must_change = FALSE,
+    status = 'anonymised',
+    status_reason = NULL,
+    status_changed_by = $2,
+    status_changed_at = NOW()
+WHERE id = $1 AND status <> 'anonymised'
+RETURNING id, name, phone, email, password, role, created_at, phone_encrypted, phone_index, password_must_change, password_changed_at, status, status_reason, status_changed_at, status_changed_by, language
+`
+
+type AnonymiseUserParams struct {
+	ID              int32       `json:"id"`
+	StatusChangedBy pgtype.Int4 `json:"status_changed_by"`
+}
+
+func (q *Queries) AnonymiseUser(ctx context.Context, arg AnonymiseUserParams) (User, error) {
+	row := q.db.QueryRow(ctx, anonymiseUser,
+		arg.ID,
+		arg.StatusChangedBy,
+	)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Phone,
+		&i.Email,
+		&i.Password,
+		&i.Role,
+		&i.CreatedAt,
+		&i.PhoneEncrypted,
+		&i.PhoneIndex,
+		&i.PasswordMustChange,
+		&i.PasswordChangedAt,
+		&i.Status,
+		&i.StatusReason,
+		&i.StatusChangedAt,
+		&i.StatusChangedBy,
+		&i.Language,
+	)
+	return i, err
+}
+
+const areImpersonationUsersActive = `-- name: AreImpersonationUsersActive :one
+-- staff acting on behalf of a user need both accounts to be active
+SELECT COUNT(*) = 2
+FROM users
+WHERE id IN ($1::int, $2::int)
+  AND status = 'active'
+`
+
+type AreImpersonationUsersActiveParams struct {
+	UserID         int32 `json:"user_id"`
+	ImpersonatorID int32 `json:"impersonator_id"`
+}
+
+func (q *Queries) AreImpersonationUsersActive(ctx context.Context, arg AreImpersonationUsersActiveParams) (bool, error) {
+	row := q.db.QueryRow(ctx, areImpersonationUsersActive,
+		arg.UserID,
+		arg.ImpersonatorID,
+	)
+	var column_1 bool
+	err := row.Scan(&column_1)
+	return column_1, err
+}
+
+const clearLoginThrottle = `-- name: ClearLoginThrottle :execrows
+DELETE FROM login_throttles
+WHERE scope = $1 AND subject = $2
+`
+
+type ClearLoginThrottleParams struct {
+	Scope   string `json:"scope"`
+	Subject string `json:"subject"`
+}
+
+func (q *Queries) ClearLoginThrottle(ctx context.Context, arg ClearLoginThrottleParams) (int64, error) {
+	result, err := q.db.Exec(ctx, clearLoginThrottle, arg.Scope, arg.Subject)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const confirmUserTOTP = `-- name: ConfirmUserTOTP :execrows
+UPDATE user_totp
+SET confirmed_at = NOW(), last_used_step = $2
+WHERE user_id = $1 AND confirmed_at IS NULL
+`
+
+type ConfirmUserTOTPParams struct {
+	UserID       int32 `json:"user_id"`
+	LastUsedStep int64 `json:"last_used_step"`
+}
+
+func (q *Queries) ConfirmUserTOTP(ctx context.Context, arg ConfirmUserTOTPParams) (int64, error) {
+	result, err := q.db.Exec(ctx, confirmUserTOTP, arg.UserID, arg.LastUsedStep)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const consumeCareLinkCode = `-- name: ConsumeCareLinkCode :one
+DELETE FROM care_link_codes
+WHERE code_hash = $1 AND expires_at > NOW()
+RETURNING participant_id
+`
+
+func (q *Queries) ConsumeCareLinkCode(ctx context.Context, codeHash string) (int32, error) {
+	row := q.db.QueryRow(ctx, consumeCareLinkCode, codeHash)
+	var participant_id int32
+	err := row.Scan(&participant_id)
+	return participant_id, err
+}
+
+const consumeEmailChangeToken = `-- name: ConsumeEmailChangeToken :one
+UPDATE email_change_tokens
+SET used_at = NOW()
+WHERE token_hash = $1
+  AND used_at IS NULL
+  AND expires_at > NOW()
+RETURNING id, user_id, new_email, token_hash, expires_at, used_at, created_at
+`
+
+func (q *Queries) ConsumeEmailChangeToken(ctx context.Context, tokenHash string) (EmailChangeToken, error) {
+	row := q.db.QueryRow(ctx, consumeEmailChangeToken, tokenHash)
+	var i EmailChangeToken
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.NewEmail,
+		&i.TokenHash,
+		&i.ExpiresAt,
+		&i.UsedAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const consumeLoginCode = `-- name: ConsumeLoginCode :execrows
+UPDATE login_codes
+SET used_at = NOW()
+WHERE id = $1 AND used_at IS NULL
+`
+
+func (q *Queries) ConsumeLoginCode(ctx context.Context, id int32) (int64, error) {
+	result, err := q.db.Exec(ctx, consumeLoginCode, id)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const consumeMFAChallenge = `-- name: ConsumeMFAChallenge :execrows
+UPDATE mfa_challenges
+SET used_at = NOW()
+WHERE id = $1 AND used_at IS NULL
+`
+
+func (q *Queries) ConsumeMFAChallenge(ctx context.Context, id int32) (int64, error) {
+	result, err := q.db.Exec(ctx, consumeMFAChallenge, id)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const consumeOIDCLoginState = `-- name: ConsumeOIDCLoginState :one
+DELETE FROM oidc_login_states
+WHERE state_hash = $1 AND expires_at > NOW()
+RETURNING state_hash, nonce, code_verifier, expires_at, created_at
+`
+
+func (q *Queries) ConsumeOIDCLoginState(ctx context.Context, stateHash string) (OidcLoginState, error) {
+	row := q.db.QueryRow(ctx, consumeOIDCLoginState, stateHash)
+	var i OidcLoginState
+	err := row.Scan(
+		&i.StateHash,
+		&i.Nonce,
+		&i.CodeVerifier,
+		&i.ExpiresAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const consumePasswordToken = `-- name: ConsumePasswordToken :one
+UPDATE password_tokens
+SET used_at = NOW()
+WHERE token_hash = $1
+  AND used_at IS NULL
+  AND expires_at > NOW()
+RETURNING id, user_id, token_hash, purpose, expires_at, used_at, created_at
+`
+
+func (q *Queries) ConsumePasswordToken(ctx context.Context, tokenHash string) (PasswordToken, error) {
+	row := q.db.QueryRow(ctx, consumePasswordToken, tokenHash)
+	var i PasswordToken
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.TokenHash,
+		&i.Purpose,
+		&i.ExpiresAt,
+		&i.UsedAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
 const countBookingsByActivityID = `-- name: CountBookingsByActivityID :one
 SELECT 
   COUNT(*)::bigint
@@ -27,6 +299,186 @@ func (q *Queries) CountBookingsByActivityID(ctx context.Context, activityID int3
 	return column_1, err
 }
 
+const countParticipantBookingsInWeek = `-- name: CountParticipantBookingsInWeek :one
+-- active participant bookings in the ISO week (Monday to Sunday) containing week_of
+SELECT COUNT(*)::bigint
+FROM bookings b
+JOIN activities a ON a.id = b.activity_id
+WHERE COALESCE(b.booked_for_user_id, b.user_id) = $1::int
+  AND b.role = 'participant'
+  AND b.cancelled_at IS NULL
+  AND a.status <> 'CANCELLED'
+  AND date_trunc('week', a.start_time) = date_trunc('week', $2::timestamp)
+  AND b.id <> $3::int
+`
+
+type CountParticipantBookingsInWeekParams struct {
+	ParticipantID int32            `json:"participant_id"`
+	WeekOf        pgtype.Timestamp `json:"week_of"`
+	ExcludeID     int32            `json:"exclude_id"`
+}
+
+func (q *Queries) CountParticipantBookingsInWeek(ctx context.Context, arg CountParticipantBookingsInWeekParams) (int64, error) {
+	row := q.db.QueryRow(ctx, countParticipantBookingsInWeek,
+		arg.ParticipantID,
+		arg.WeekOf,
+		arg.ExcludeID,
+	)
+	var column_1 int64
+	err := row.Scan(&column_1)
+	return column_1, err
+}
+
+const countRecentEmailChangeTokens = `-- name: CountRecentEmailChangeTokens :one
+SELECT COUNT(*)::bigint
+FROM email_change_tokens
+WHERE user_id = $1 AND created_at > $2
+`
+
+type CountRecentEmailChangeTokensParams struct {
+	UserID    int32            `json:"user_id"`
+	CreatedAt pgtype.Timestamp `json:"created_at"`
+}
+
+func (q *Queries) CountRecentEmailChangeTokens(ctx context.Context, arg CountRecentEmailChangeTokensParams) (int64, error) {
+	row := q.db.QueryRow(ctx, countRecentEmailChangeTokens,
+		arg.UserID,
+		arg.CreatedAt,
+	)
+	var column_1 int64
+	err := row.Scan(&column_1)
+	return column_1, err
+}
+
+const countRecentLoginCodes = `-- name: CountRecentLoginCodes :one
+SELECT COUNT(*)::bigint
+FROM login_codes
+WHERE user_id = $1 AND created_at > $2
+`
+
+type CountRecentLoginCodesParams struct {
+	UserID    int32            `json:"user_id"`
+	CreatedAt pgtype.Timestamp `json:"created_at"`
+}
+
+func (q *Queries) CountRecentLoginCodes(ctx context.Context, arg CountRecentLoginCodesParams) (int64, error) {
+	row := q.db.QueryRow(ctx, countRecentLoginCodes, arg.UserID, arg.CreatedAt)
+	var column_1 int64
+	err := row.Scan(&column_1)
+	return column_1, err
+}
+
+const countRecentPasswordTokens = `-- name: CountRecentPasswordTokens :one
+SELECT COUNT(*)::bigint
+FROM password_tokens
+WHERE user_id = $1 AND purpose <> 'change' AND created_at > $2
+`
+
+type CountRecentPasswordTokensParams struct {
+	UserID    int32            `json:"user_id"`
+	CreatedAt pgtype.Timestamp `json:"created_at"`
+}
+
+func (q *Queries) CountRecentPasswordTokens(ctx context.Context, arg CountRecentPasswordTokensParams) (int64, error) {
+	row := q.db.QueryRow(ctx, countRecentPasswordTokens, arg.UserID, arg.CreatedAt)
+	var column_1 int64
+	err := row.Scan(&column_1)
+	return column_1, err
+}
+
+const countSearchUsers = `-- name: CountSearchUsers :one
+SELECT COUNT(*) FROM users u
+LEFT JOIN participant_profiles p ON p.user_id = u.id
+LEFT JOIN volunteer_profiles v ON v.user_id = u.id
+WHERE ($1::text IS NULL
+       OR u.name ILIKE '%' || $1 || '%'
+       OR u.email ILIKE '%' || $1 || '%')
+  AND ($2::text IS NULL OR u.role = $2)
+  AND ($3::text IS NULL OR u.status = $3)
+  AND ($4::text IS NULL OR p.membership_type = $4)
+  AND ($5::bool IS NULL OR COALESCE(p.wheelchair, FALSE) = $5)
+  AND ($6::bool IS NULL OR COALESCE(p.sign_language, FALSE) = $6)
+  AND ($7::bool IS NULL OR COALESCE(p.needs_seated_activity, FALSE) = $7)
+  AND ($8::bool IS NULL OR COALESCE(p.sensitive_to_light, FALSE) = $8)
+  AND ($9::bool IS NULL OR COALESCE(p.sensitive_to_noise, FALSE) = $9)
+  AND ($10::timestamp IS NULL OR u.created_at >= $10)
+  AND ($11::timestamp IS NULL OR u.created_at < $11)
+  -- volunteers: all of the skills, a preferred venue, a certification valid on a date, a weekly window
+  AND ($12::text[] IS NULL OR v.skills @> $12)
+  AND ($13::text IS NULL OR $13 = ANY(v.preferred_venues))
+  AND ($14::text IS NULL OR EXISTS (
+    SELECT 1 FROM volunteer_certifications c
+    WHERE c.user_id = u.id
+      AND LOWER(c.name) = LOWER($14)
+      AND (c.expires_on IS NULL OR c.expires_on >= $15::date)))
+  AND ($16::smallint IS NULL OR EXISTS (
+    SELECT 1 FROM volunteer_availability a
+    WHERE a.user_id = u.id
+      AND a.weekday = $16
+      AND a.start_time <= $17::time
+      AND a.end_time >= $18::time))
+`
+
+type CountSearchUsersParams struct {
+	Search              pgtype.Text      `json:"search"`
+	Role                pgtype.Text      `json:"role"`
+	Status              pgtype.Text      `json:"status"`
+	MembershipType      pgtype.Text      `json:"membership_type"`
+	Wheelchair          pgtype.Bool      `json:"wheelchair"`
+	SignLanguage        pgtype.Bool      `json:"sign_language"`
+	NeedsSeatedActivity pgtype.Bool      `json:"needs_seated_activity"`
+	SensitiveToLight    pgtype.Bool      `json:"sensitive_to_light"`
+	SensitiveToNoise    pgtype.Bool      `json:"sensitive_to_noise"`
+	CreatedFrom         pgtype.Timestamp `json:"created_from"`
+	CreatedTo           pgtype.Timestamp `json:"created_to"`
+	Skills              []string         `json:"skills"`
+	Venue               pgtype.Text      `json:"venue"`
+	Certification       pgtype.Text      `json:"certification"`
+	CertifiedOn         pgtype.Date      `json:"certified_on"`
+	AvailableWeekday    pgtype.Int2      `json:"available_weekday"`
+	AvailableFrom       pgtype.Time      `json:"available_from"`
+	AvailableTo         pgtype.Time      `json:"available_to"`
+}
+
+func (q *Queries) CountSearchUsers(ctx context.Context, arg CountSearchUsersParams) (int64, error) {
+	row := q.db.QueryRow(ctx, countSearchUsers,
+		arg.Search,
+		arg.Role,
+		arg.Status,
+		arg.MembershipType,
+		arg.Wheelchair,
+		arg.SignLanguage,
+		arg.NeedsSeatedActivity,
+		arg.SensitiveToLight,
+		arg.SensitiveToNoise,
+		arg.CreatedFrom,
+		arg.CreatedTo,
+		arg.Skills,
+		arg.Venue,
+		arg.Certification,
+		arg.CertifiedOn,
+		arg.AvailableWeekday,
+		arg.AvailableFrom,
+		arg.AvailableTo,
+	)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const countUnusedRecoveryCodes = `-- name: CountUnusedRecoveryCodes :one
+SELECT COUNT(*)::bigint
+FROM recovery_codes
+WHERE user_id = $1 AND used_at IS NULL
+`
+
+func (q *Queries) CountUnusedRecoveryCodes(ctx context.Context, userID int32) (int64, error) {
+	row := q.db.QueryRow(ctx, countUnusedRecoveryCodes, userID)
+	var column_1 int64
+	err := row.Scan(&column_1)
+	return column_1, err
+}
+
 const createActivity = `-- name: CreateActivity :one
 INSERT INTO activities (
   id, title, description, venue, start_time, end_time,
@@ -97,15 +549,58 @@ func (q *Queries) CreateActivity(ctx context.Context, arg CreateActivityParams)
 	return i, err
 }
 
+const createAPIKey = `-- name: CreateAPIKey :one
+INSERT INTO api_keys (
+  name, prefix, key_hash, scopes, created_by, expires_at
+) VALUES (
+  $1, $2, $3, $4, $5, $6
+)
+RETURNING id, name, prefix, key_hash, scopes, created_by, expires_at, last_used_at, revoked_at, created_at
+`
+
+type CreateAPIKeyParams struct {
+	Name      string           `json:"name"`
+	Prefix    string           `json:"prefix"`
+	KeyHash   string           `json:"key_hash"`
+	Scopes    []string         `json:"scopes"`
+	CreatedBy pgtype.Int4      `json:"created_by"`
+	ExpiresAt pgtype.Timestamp `json:"expires_at"`
+}
+
+func (q *Queries) CreateAPIKey(ctx context.Context, arg CreateAPIKeyParams) (ApiKey, error) {
+	row := q.db.QueryRow(ctx, createAPIKey,
+		arg.Name,
+		arg.Prefix,
+		arg.KeyHash,
+		arg.Scopes,
+		arg.CreatedBy,
+		arg.ExpiresAt,
+	)
+	var i ApiKey
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Prefix,
+		&i.KeyHash,
+		&i.Scopes,
+		&i.CreatedBy,
+		&i.ExpiresAt,
+		&i.LastUsedAt,
+		&i.RevokedAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
 const createBooking = `-- name: CreateBooking :one
 INSERT INTO bookings (
-   id, activity_id, user_id, booked_for_user_id,
+   activity_id, user_id, booked_for_user_id,
    role, is_paid, attendance_status, created_at,
-   cancelled_at
+   cancelled_at, created_by_staff_id, quota_override_by
 ) VALUES (
-  gen_random_uuid(), $1, $2, $3, $4, $5, $6, NOW(), $7
+  $1, $2, $3, $4, $5, $6, NOW(), $7, $8, $9
 )
-RETURNING id, activity_id, user_id, booked_for_user_id, role, is_paid, attendance_status, created_at, cancelled_at
+RETURNING id, activity_id, user_id, booked_for_user_id, role, is_paid, attendance_status, created_at, cancelled_at, created_by_staff_id, quota_override_by
 `
 
 type CreateBookingParams struct {
@@ -116,6 +611,8 @@ type CreateBookingParams struct {
 	IsPaid           bool             `json:"is_paid"`
 	AttendanceStatus pgtype.Text      `json:"attendance_status"`
 	CancelledAt      pgtype.Timestamp `json:"cancelled_at"`
+	CreatedByStaffID pgtype.Int4      `json:"created_by_staff_id"`
+	QuotaOverrideBy  pgtype.Int4      `json:"quota_override_by"`
 }
 
 func (q *Queries) CreateBooking(ctx context.Context, arg CreateBookingParams) (Booking, error) {
@@ -127,6 +624,8 @@ func (q *Queries) CreateBooking(ctx context.Context, arg CreateBookingParams) (B
 		arg.IsPaid,
 		arg.AttendanceStatus,
 		arg.CancelledAt,
+		arg.CreatedByStaffID,
+		arg.QuotaOverrideBy,
 	)
 	var i Booking
 	err := row.Scan(
@@ -139,188 +638,2697 @@ func (q *Queries) CreateBooking(ctx context.Context, arg CreateBookingParams) (B
 		&i.AttendanceStatus,
 		&i.CreatedAt,
 		&i.CancelledAt,
+		&i.CreatedByStaffID,
+		&i.QuotaOverrideBy,
 	)
 	return i, err
 }
 
-const createSession = `-- name: CreateSession :one
-INSERT INTO sessions (
-    id,
-    user_id,
-    refresh_token,
-    is_revoked,
-    expires_at
-) VALUES (
-    $1,
-    $2,
-    $3,
-    $4,
-    $5
-)
-RETURNING id, user_id, refresh_token, is_revoked, expires_at, created_at
+const createBookingWaitlistEntry = `-- name: CreateBookingWaitlistEntry :one
+INSERT INTO booking_waitlist (activity_id, participant_id, requested_by)
+VALUES ($1, $2, $3)
+RETURNING id, activity_id, participant_id, requested_by, created_at
 `
 
-type CreateSessionParams struct {
-	ID           string           `json:"id"`
-	UserID       int32            `json:"user_id"`
-	RefreshToken string           `json:"refresh_token"`
-	IsRevoked    bool             `json:"is_revoked"`
-	ExpiresAt    pgtype.Timestamp `json:"expires_at"`
+type CreateBookingWaitlistEntryParams struct {
+	ActivityID    int32 `json:"activity_id"`
+	ParticipantID int32 `json:"participant_id"`
+	RequestedBy   int32 `json:"requested_by"`
 }
 
-func (q *Queries) CreateSession(ctx context.Context, arg CreateSessionParams) (Session, error) {
-	row := q.db.QueryRow(ctx, createSession,
-		arg.ID,
-		arg.UserID,
-		arg.RefreshToken,
-		arg.IsRevoked,
-		arg.ExpiresAt,
+func (q *Queries) CreateBookingWaitlistEntry(ctx context.Context, arg CreateBookingWaitlistEntryParams) (BookingWaitlist, error) {
+	row := q.db.QueryRow(ctx, createBookingWaitlistEntry,
+		arg.ActivityID,
+		arg.ParticipantID,
+		arg.RequestedBy,
 	)
-	var i Session
+	var i BookingWaitlist
 	err := row.Scan(
 		&i.ID,
-		&i.UserID,
-		&i.RefreshToken,
-		&i.IsRevoked,
-		&i.ExpiresAt,
+		&i.ActivityID,
+		&i.ParticipantID,
+		&i.RequestedBy,
 		&i.CreatedAt,
 	)
 	return i, err
 }
 
-const createUser = `-- name: CreateUser :one
-INSERT INTO users (
-    name,
-    phone,
-    email,
-    role
+const createCareRelationship = `-- name: CreateCareRelationship :one
+INSERT INTO care_relationships (
+  participant_id, caregiver_id, status, requested_by, approved_by, approved_at
 ) VALUES (
-    $1, $2, $3, $4
+  $1, $2, $3, $4, $5, $6
 )
-RETURNING id, name, phone, email, password, role, created_at
+ON CONFLICT (participant_id, caregiver_id) DO NOTHING
+RETURNING participant_id, caregiver_id, created_at, status, requested_by, approved_by, approved_at
 `
 
-type CreateUserParams struct {
-	Name  string      `json:"name"`
-	Phone interface{} `json:"phone"`
-	Email string      `json:"email"`
-	Role  string      `json:"role"`
+type CreateCareRelationshipParams struct {
+	ParticipantID int32            `json:"participant_id"`
+	CaregiverID   int32            `json:"caregiver_id"`
+	Status        string           `json:"status"`
+	RequestedBy   pgtype.Int4      `json:"requested_by"`
+	ApprovedBy    pgtype.Int4      `json:"approved_by"`
+	ApprovedAt    pgtype.Timestamp `json:"approved_at"`
 }
 
-func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (User, error) {
-	row := q.db.QueryRow(ctx, createUser,
-		arg.Name,
-		arg.Phone,
-		arg.Email,
-		arg.Role,
+func (q *Queries) CreateCareRelationship(ctx context.Context, arg CreateCareRelationshipParams) (CareRelationship, error) {
+	row := q.db.QueryRow(ctx, createCareRelationship,
+		arg.ParticipantID,
+		arg.CaregiverID,
+		arg.Status,
+		arg.RequestedBy,
+		arg.ApprovedBy,
+		arg.ApprovedAt,
 	)
-	var i User
+	var i CareRelationship
 	err := row.Scan(
-		&i.ID,
-		&i.Name,
-		&i.Phone,
-		&i.Email,
-		&i.Password,
-		&i.Role,
+		&i.ParticipantID,
+		&i.CaregiverID,
 		&i.CreatedAt,
+		&i.Status,
+		&i.RequestedBy,
+		&i.ApprovedBy,
+		&i.ApprovedAt,
 	)
 	return i, err
 }
 
-const deleteActivityByID = `-- name: DeleteActivityByID :exec
-DELETE FROM activities
-WHERE id = $1
+const createEmailChangeToken = `-- name: CreateEmailChangeToken :one
+INSERT INTO email_change_tokens (user_id, new_email, token_hash, expires_at)
+VALUES ($1, $2, $3, $4)
+RETURNING id, user_id, new_email, token_hash, expires_at, used_at, created_at
 `
 
-func (q *Queries) DeleteActivityByID(ctx context.Context, id int32) error {
-	_, err := q.db.Exec(ctx, deleteActivityByID, id)
-	return err
+type CreateEmailChangeTokenParams struct {
+	UserID    int32            `json:"user_id"`
+	NewEmail  string           `json:"new_email"`
+	TokenHash string           `json:"token_hash"`
+	ExpiresAt pgtype.Timestamp `json:"expires_at"`
 }
 
-const deleteBookingByID = `-- name: DeleteBookingByID :exec
-DELETE FROM bookings
-WHERE id = $1
+func (q *Queries) CreateEmailChangeToken(ctx context.Context, arg CreateEmailChangeTokenParams) (EmailChangeToken, error) {
+	row := q.db.QueryRow(ctx, createEmailChangeToken,
+		arg.UserID,
+		arg.NewEmail,
+		arg.TokenHash,
+		arg.ExpiresAt,
+	)
+	var i EmailChangeToken
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.NewEmail,
+		&i.TokenHash,
+		&i.ExpiresAt,
+		&i.UsedAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const createImpersonationLog = `-- name: CreateImpersonationLog :exec
+INSERT INTO impersonation_logs (
+  staff_id, user_id, method, path, status
+) VALUES (
+  $1, $2, $3, $4, $5
+)
 `
 
-func (q *Queries) DeleteBookingByID(ctx context.Context, id int32) error {
-	_, err := q.db.Exec(ctx, deleteBookingByID, id)
+type CreateImpersonationLogParams struct {
+	StaffID int32  `json:"staff_id"`
+	UserID  int32  `json:"user_id"`
+	Method  string `json:"method"`
+	Path    string `json:"path"`
+	Status  int32  `json:"status"`
+}
+
+func (q *Queries) CreateImpersonationLog(ctx context.Context, arg CreateImpersonationLogParams) error {
+	_, err := q.db.Exec(ctx, createImpersonationLog,
+		arg.StaffID,
+		arg.UserID,
+		arg.Method,
+		arg.Path,
+		arg.Status,
+	)
 	return err
 }
 
-const deleteSessionsByUserID = `-- name: DeleteSessionsByUserID :exec
-DELETE FROM sessions
-WHERE user_id = $1
+const createInvitation = `-- name: CreateInvitation :one
+INSERT INTO invitations (
+  name, email, phone_encrypted, role, channel, token_hash, invited_by, expires_at
+) VALUES (
+  $1, $2, $3, $4, $5, $6, $7, $8
+)
+RETURNING id, name, email, phone_encrypted, role, channel, token_hash, invited_by, expires_at, sent_at, accepted_at, accepted_user_id, revoked_at, created_at
 `
 
-func (q *Queries) DeleteSessionsByUserID(ctx context.Context, userID int32) error {
-	_, err := q.db.Exec(ctx, deleteSessionsByUserID, userID)
+type CreateInvitationParams struct {
+	Name           string           `json:"name"`
+	Email          string           `json:"email"`
+	PhoneEncrypted []byte           `json:"phone_encrypted"`
+	Role           string           `json:"role"`
+	Channel        string           `json:"channel"`
+	TokenHash      string           `json:"token_hash"`
+	InvitedBy      pgtype.Int4      `json:"invited_by"`
+	ExpiresAt      pgtype.Timestamp `json:"expires_at"`
+}
+
+func (q *Queries) CreateInvitation(ctx context.Context, arg CreateInvitationParams) (Invitation, error) {
+	row := q.db.QueryRow(ctx, createInvitation,
+		arg.Name,
+		arg.Email,
+		arg.PhoneEncrypted,
+		arg.Role,
+		arg.Channel,
+		arg.TokenHash,
+		arg.InvitedBy,
+		arg.ExpiresAt,
+	)
+	var i Invitation
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Email,
+		&i.PhoneEncrypted,
+		&i.Role,
+		&i.Channel,
+		&i.TokenHash,
+		&i.InvitedBy,
+		&i.ExpiresAt,
+		&i.SentAt,
+		&i.AcceptedAt,
+		&i.AcceptedUserID,
+		&i.RevokedAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const createLoginCode = `-- name: CreateLoginCode :one
+INSERT INTO login_codes (
+  user_id, code_hash, channel, expires_at
+) VALUES (
+  $1, $2, $3, $4
+)
+RETURNING id, user_id, code_hash, channel, attempts, expires_at, used_at, created_at
+`
+
+type CreateLoginCodeParams struct {
+	UserID    int32            `json:"user_id"`
+	CodeHash  string           `json:"code_hash"`
+	Channel   string           `json:"channel"`
+	ExpiresAt pgtype.Timestamp `json:"expires_at"`
+}
+
+func (q *Queries) CreateLoginCode(ctx context.Context, arg CreateLoginCodeParams) (LoginCode, error) {
+	row := q.db.QueryRow(ctx, createLoginCode,
+		arg.UserID,
+		arg.CodeHash,
+		arg.Channel,
+		arg.ExpiresAt,
+	)
+	var i LoginCode
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.CodeHash,
+		&i.Channel,
+		&i.Attempts,
+		&i.ExpiresAt,
+		&i.UsedAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const createMedicalAccessLog = `-- name: CreateMedicalAccessLog :exec
+INSERT INTO medical_access_logs (participant_id, viewer_id, impersonator_id, api_key_id, activity_id, basis)
+VALUES ($1, $2, $3, $4, $5, $6)
+`
+
+type CreateMedicalAccessLogParams struct {
+	ParticipantID  int32       `json:"participant_id"`
+	ViewerID       pgtype.Int4 `json:"viewer_id"`
+	ImpersonatorID pgtype.Int4 `json:"impersonator_id"`
+	ApiKeyID       pgtype.Int4 `json:"api_key_id"`
+	ActivityID     pgtype.Int4 `json:"activity_id"`
+	Basis          string      `json:"basis"`
+}
+
+func (q *Queries) CreateMedicalAccessLog(ctx context.Context, arg CreateMedicalAccessLogParams) error {
+	_, err := q.db.Exec(ctx, createMedicalAccessLog,
+		arg.ParticipantID,
+		arg.ViewerID,
+		arg.ImpersonatorID,
+		arg.ApiKeyID,
+		arg.ActivityID,
+		arg.Basis,
+	)
 	return err
 }
 
-const deleteUserByID = `-- name: DeleteUserByID :exec
-DELETE FROM users
-WHERE id = $1
+const createMFAChallenge = `-- name: CreateMFAChallenge :one
+INSERT INTO mfa_challenges (
+  user_id, token_hash, expires_at
+) VALUES (
+  $1, $2, $3
+)
+RETURNING id, user_id, token_hash, attempts, expires_at, used_at, created_at
 `
 
-func (q *Queries) DeleteUserByID(ctx context.Context, id int32) error {
-	_, err := q.db.Exec(ctx, deleteUserByID, id)
+type CreateMFAChallengeParams struct {
+	UserID    int32            `json:"user_id"`
+	TokenHash string           `json:"token_hash"`
+	ExpiresAt pgtype.Timestamp `json:"expires_at"`
+}
+
+func (q *Queries) CreateMFAChallenge(ctx context.Context, arg CreateMFAChallengeParams) (MfaChallenge, error) {
+	row := q.db.QueryRow(ctx, createMFAChallenge, arg.UserID, arg.TokenHash, arg.ExpiresAt)
+	var i MfaChallenge
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.TokenHash,
+		&i.Attempts,
+		&i.ExpiresAt,
+		&i.UsedAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const createOIDCLoginState = `-- name: CreateOIDCLoginState :exec
+INSERT INTO oidc_login_states (
+  state_hash, nonce, code_verifier, expires_at
+) VALUES (
+  $1, $2, $3, $4
+)
+`
+
+type CreateOIDCLoginStateParams struct {
+	StateHash    string           `json:"state_hash"`
+	Nonce        string           `json:"nonce"`
+	CodeVerifier string           `json:"code_verifier"`
+	ExpiresAt    pgtype.Timestamp `json:"expires_at"`
+}
+
+func (q *Queries) CreateOIDCLoginState(ctx context.Context, arg CreateOIDCLoginStateParams) error {
+	_, err := q.db.Exec(ctx, createOIDCLoginState,
+		arg.StateHash,
+		arg.Nonce,
+		arg.CodeVerifier,
+		arg.ExpiresAt,
+	)
 	return err
 }
 
-const getActivityByID = `-- name: GetActivityByID :one
-SELECT
-  id, title, description, venue, start_time, end_time, signup_deadline, participant_capacity, volunteer_capacity, wheelchair_accessible, sign_language_available, requires_payment, status, created_by, created_at
-FROM
-  activities
-WHERE
-  id = $1
+const createParticipantProfile = `-- name: CreateParticipantProfile :one
+INSERT INTO participant_profiles (
+  user_id, age, membership_type, wheelchair, sign_language, other_need,
+  needs_seated_activity, sensitive_to_light, sensitive_to_noise
+) VALUES (
+  $1, $2, $3, $4, $5, $6, $7, $8, $9
+)
+RETURNING user_id, age, membership_type, wheelchair, sign_language, other_need, created_at, needs_seated_activity, sensitive_to_light, sensitive_to_noise, updated_at
 `
 
-func (q *Queries) GetActivityByID(ctx context.Context, id int32) (Activity, error) {
-	row := q.db.QueryRow(ctx, getActivityByID, id)
-	var i Activity
+type CreateParticipantProfileParams struct {
+	UserID              int32       `json:"user_id"`
+	Age                 pgtype.Int4 `json:"age"`
+	MembershipType      pgtype.Text `json:"membership_type"`
+	Wheelchair          bool        `json:"wheelchair"`
+	SignLanguage        bool        `json:"sign_language"`
+	OtherNeed           pgtype.Text `json:"other_need"`
+	NeedsSeatedActivity bool        `json:"needs_seated_activity"`
+	SensitiveToLight    bool        `json:"sensitive_to_light"`
+	SensitiveToNoise    bool        `json:"sensitive_to_noise"`
+}
+
+func (q *Queries) CreateParticipantProfile(ctx context.Context, arg CreateParticipantProfileParams) (ParticipantProfile, error) {
+	row := q.db.QueryRow(ctx, createParticipantProfile,
+		arg.UserID,
+		arg.Age,
+		arg.MembershipType,
+		arg.Wheelchair,
+		arg.SignLanguage,
+		arg.OtherNeed,
+		arg.NeedsSeatedActivity,
+		arg.SensitiveToLight,
+		arg.SensitiveToNoise,
+	)
+	var i ParticipantProfile
+	err := row.Scan(
+		&i.UserID,
+		&i.Age,
+		&i.MembershipType,
+		&i.Wheelchair,
+		&i.SignLanguage,
+		&i.OtherNeed,
+		&i.CreatedAt,
+		&i.NeedsSeatedActivity,
+		&i.SensitiveToLight,
+		&i.SensitiveToNoise,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const createPasswordToken = `-- name: CreatePasswordToken :one
+INSERT INTO password_tokens (
+  user_id, token_hash, purpose, expires_at
+) VALUES (
+  $1, $2, $3, $4
+)
+RETURNING id, user_id, token_hash, purpose, expires_at, used_at, created_at
+`
+
+type CreatePasswordTokenParams struct {
+	UserID    int32            `json:"user_id"`
+	TokenHash string           `json:"token_hash"`
+	Purpose   string           `json:"purpose"`
+	ExpiresAt pgtype.Timestamp `json:"expires_at"`
+}
+
+func (q *Queries) CreatePasswordToken(ctx context.Context, arg CreatePasswordTokenParams) (PasswordToken, error) {
+	row := q.db.QueryRow(ctx, createPasswordToken,
+		arg.UserID,
+		arg.TokenHash,
+		arg.Purpose,
+		arg.ExpiresAt,
+	)
+	var i PasswordToken
 	err := row.Scan(
 		&i.ID,
-		&i.Title,
-		&i.Description,
-		&i.Venue,
-		&i.StartTime,
-		&i.EndTime,
-		&i.SignupDeadline,
-		&i.ParticipantCapacity,
-		&i.VolunteerCapacity,
-		&i.WheelchairAccessible,
-		&i.SignLanguageAvailable,
-		&i.RequiresPayment,
+		&i.UserID,
+		&i.TokenHash,
+		&i.Purpose,
+		&i.ExpiresAt,
+		&i.UsedAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const createRecoveryCode = `-- name: CreateRecoveryCode :exec
+INSERT INTO recovery_codes (
+  user_id, code_hash
+) VALUES (
+  $1, $2
+)
+`
+
+type CreateRecoveryCodeParams struct {
+	UserID   int32  `json:"user_id"`
+	CodeHash string `json:"code_hash"`
+}
+
+func (q *Queries) CreateRecoveryCode(ctx context.Context, arg CreateRecoveryCodeParams) error {
+	_, err := q.db.Exec(ctx, createRecoveryCode, arg.UserID, arg.CodeHash)
+	return err
+}
+
+const createSession = `-- name: CreateSession :one
+INSERT INTO sessions (
+    id,
+    user_id,
+    refresh_token,
+    is_revoked,
+    expires_at,
+    family_id,
+    user_agent,
+    ip_address
+) VALUES (
+    $1,
+    $2,
+    $3,
+    $4,
+    $5,
+    $6,
+    $7,
+    $8
+)
+RETURNING id, user_id, refresh_token, is_revoked, expires_at, created_at, family_id, replaced_by, rotated_at, user_agent, ip_address, last_used_at
+`
+
+type CreateSessionParams struct {
+	ID           string           `json:"id"`
+	UserID       int32            `json:"user_id"`
+	RefreshToken string           `json:"refresh_token"`
+	IsRevoked    bool             `json:"is_revoked"`
+	ExpiresAt    pgtype.Timestamp `json:"expires_at"`
+	FamilyID     string           `json:"family_id"`
+	UserAgent    pgtype.Text      `json:"user_agent"`
+	IpAddress    pgtype.Text      `json:"ip_address"`
+}
+
+func (q *Queries) CreateSession(ctx context.Context, arg CreateSessionParams) (Session, error) {
+	row := q.db.QueryRow(ctx, createSession,
+		arg.ID,
+		arg.UserID,
+		arg.RefreshToken,
+		arg.IsRevoked,
+		arg.ExpiresAt,
+		arg.FamilyID,
+		arg.UserAgent,
+		arg.IpAddress,
+	)
+	var i Session
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.RefreshToken,
+		&i.IsRevoked,
+		&i.ExpiresAt,
+		&i.CreatedAt,
+		&i.FamilyID,
+		&i.ReplacedBy,
+		&i.RotatedAt,
+		&i.UserAgent,
+		&i.IpAddress,
+		&i.LastUsedAt,
+	)
+	return i, err
+}
+
+const createUser = `-- name: CreateUser :one
+INSERT INTO users (
+    name,
+    phone_encrypted,
+    phone_index,
+    email,
+    role
+) VALUES (
+    $1, $2, $3, $4, $5
+)
+RETURNING id, name, phone, email, password, role, created_at, phone_encrypted, phone_index, password_must_change, password_changed_at, status, status_reason, status_changed_at, status_changed_by, language
+`
+
+type CreateUserParams struct {
+	Name           string      `json:"name"`
+	PhoneEncrypted []byte      `json:"phone_encrypted"`
+	PhoneIndex     pgtype.Text `json:"phone_index"`
+	Email          string      `json:"email"`
+	Role           string      `json:"role"`
+}
+
+func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (User, error) {
+	row := q.db.QueryRow(ctx, createUser,
+		arg.Name,
+		arg.PhoneEncrypted,
+		arg.PhoneIndex,
+		arg.Email,
+		arg.Role,
+	)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Phone,
+		&i.Email,
+		&i.Password,
+		&i.Role,
+		&i.CreatedAt,
+		&i.PhoneEncrypted,
+		&i.PhoneIndex,
+		&i.PasswordMustChange,
+		&i.PasswordChangedAt,
 		&i.Status,
-		&i.CreatedBy,
+		&i.StatusReason,
+		&i.StatusChangedAt,
+		&i.StatusChangedBy,
+		&i.Language,
+	)
+	return i, err
+}
+
+const createVolunteerAvailability = `-- name: CreateVolunteerAvailability :exec
+INSERT INTO volunteer_availability (user_id, weekday, start_time, end_time)
+VALUES ($1, $2, $3, $4)
+`
+
+type CreateVolunteerAvailabilityParams struct {
+	UserID    int32       `json:"user_id"`
+	Weekday   int16       `json:"weekday"`
+	StartTime pgtype.Time `json:"start_time"`
+	EndTime   pgtype.Time `json:"end_time"`
+}
+
+func (q *Queries) CreateVolunteerAvailability(ctx context.Context, arg CreateVolunteerAvailabilityParams) error {
+	_, err := q.db.Exec(ctx, createVolunteerAvailability,
+		arg.UserID,
+		arg.Weekday,
+		arg.StartTime,
+		arg.EndTime,
+	)
+	return err
+}
+
+const createVolunteerCertification = `-- name: CreateVolunteerCertification :one
+INSERT INTO volunteer_certifications (user_id, name, issuer, issued_on, expires_on)
+VALUES ($1, $2, $3, $4, $5)
+RETURNING id, user_id, name, issuer, issued_on, expires_on, created_at
+`
+
+type CreateVolunteerCertificationParams struct {
+	UserID    int32       `json:"user_id"`
+	Name      string      `json:"name"`
+	Issuer    pgtype.Text `json:"issuer"`
+	IssuedOn  pgtype.Date `json:"issued_on"`
+	ExpiresOn pgtype.Date `json:"expires_on"`
+}
+
+func (q *Queries) CreateVolunteerCertification(ctx context.Context, arg CreateVolunteerCertificationParams) (VolunteerCertification, error) {
+	row := q.db.QueryRow(ctx, createVolunteerCertification,
+		arg.UserID,
+		arg.Name,
+		arg.Issuer,
+		arg.IssuedOn,
+		arg.ExpiresOn,
+	)
+	var i VolunteerCertification
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Name,
+		&i.Issuer,
+		&i.IssuedOn,
+		&i.ExpiresOn,
 		&i.CreatedAt,
 	)
 	return i, err
 }
 
-const getAllUsers = `-- name: GetAllUsers :many
-SELECT id, name, phone, email, password, role, created_at FROM users
-ORDER BY created_at DESC
+const createVolunteerHourAdjustment = `-- name: CreateVolunteerHourAdjustment :one
+INSERT INTO volunteer_hour_adjustments (user_id, minutes, worked_on, reason, created_by)
+VALUES ($1, $2, $3, $4, $5)
+RETURNING id, user_id, minutes, worked_on, reason, created_by, created_at
+`
+
+type CreateVolunteerHourAdjustmentParams struct {
+	UserID    int32       `json:"user_id"`
+	Minutes   int32       `json:"minutes"`
+	WorkedOn  pgtype.Date `json:"worked_on"`
+	Reason    string      `json:"reason"`
+	CreatedBy pgtype.Int4 `json:"created_by"`
+}
+
+func (q *Queries) CreateVolunteerHourAdjustment(ctx context.Context, arg CreateVolunteerHourAdjustmentParams) (VolunteerHourAdjustment, error) {
+	row := q.db.QueryRow(ctx, createVolunteerHourAdjustment,
+		arg.UserID,
+		arg.Minutes,
+		arg.WorkedOn,
+		arg.Reason,
+		arg.CreatedBy,
+	)
+	var i VolunteerHourAdjustment
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Minutes,
+		&i.WorkedOn,
+		&i.Reason,
+		&i.CreatedBy,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const deactivateUser = `-- name: DeactivateUser :one
+UPDATE users
+SET status = 'deactivated',
+    status_reason = $2,
+    status_changed_by = $3,
+    status_changed_at = NOW()
+WHERE id = $1 AND status = 'active'
+RETURNING id, name, phone, email, password, role, created_at, phone_encrypted, phone_index, password_must_change, password_changed_at, status, status_reason, status_changed_at, status_changed_by, language
+`
+
+type DeactivateUserParams struct {
+	ID              int32       `json:"id"`
+	StatusReason    pgtype.Text `json:"status_reason"`
+	StatusChangedBy pgtype.Int4 `json:"status_changed_by"`
+}
+
+func (q *Queries) DeactivateUser(ctx context.Context, arg DeactivateUserParams) (User, error) {
+	row := q.db.QueryRow(ctx, deactivateUser,
+		arg.ID,
+		arg.StatusReason,
+		arg.StatusChangedBy,
+	)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Phone,
+		&i.Email,
+		&i.Password,
+		&i.Role,
+		&i.CreatedAt,
+		&i.PhoneEncrypted,
+		&i.PhoneIndex,
+		&i.PasswordMustChange,
+		&i.PasswordChangedAt,
+		&i.Status,
+		&i.StatusReason,
+		&i.StatusChangedAt,
+		&i.StatusChangedBy,
+		&i.Language,
+	)
+	return i, err
+}
+
+const deleteActivityByID = `-- name: DeleteActivityByID :exec
+DELETE FROM activities
+WHERE id = $1
+`
+
+func (q *Queries) DeleteActivityByID(ctx context.Context, id int32) error {
+	_, err := q.db.Exec(ctx, deleteActivityByID, id)
+	return err
+}
+
+const deleteActivityVolunteerLead = `-- name: DeleteActivityVolunteerLead :execrows
+DELETE FROM activity_volunteer_leads
+WHERE activity_id = $1
+`
+
+func (q *Queries) DeleteActivityVolunteerLead(ctx context.Context, activityID int32) (int64, error) {
+	result, err := q.db.Exec(ctx, deleteActivityVolunteerLead, activityID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const deleteBookingByID = `-- name: DeleteBookingByID :exec
+DELETE FROM bookings
+WHERE id = $1
+`
+
+func (q *Queries) DeleteBookingByID(ctx context.Context, id int32) error {
+	_, err := q.db.Exec(ctx, deleteBookingByID, id)
+	return err
+}
+
+const deleteBookingWaitlistEntry = `-- name: DeleteBookingWaitlistEntry :exec
+DELETE FROM booking_waitlist
+WHERE id = $1
+`
+
+func (q *Queries) DeleteBookingWaitlistEntry(ctx context.Context, id int32) error {
+	_, err := q.db.Exec(ctx, deleteBookingWaitlistEntry, id)
+	return err
+}
+
+const deleteCareRelationship = `-- name: DeleteCareRelationship :execrows
+DELETE FROM care_relationships
+WHERE participant_id = $1 AND caregiver_id = $2
+`
+
+type DeleteCareRelationshipParams struct {
+	ParticipantID int32 `json:"participant_id"`
+	CaregiverID   int32 `json:"caregiver_id"`
+}
+
+func (q *Queries) DeleteCareRelationship(ctx context.Context, arg DeleteCareRelationshipParams) (int64, error) {
+	result, err := q.db.Exec(ctx, deleteCareRelationship,
+		arg.ParticipantID,
+		arg.CaregiverID,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const deleteExpiredOIDCLoginStates = `-- name: DeleteExpiredOIDCLoginStates :exec
+DELETE FROM oidc_login_states
+WHERE expires_at <= NOW()
+`
+
+func (q *Queries) DeleteExpiredOIDCLoginStates(ctx context.Context) error {
+	_, err := q.db.Exec(ctx, deleteExpiredOIDCLoginStates)
+	return err
+}
+
+const deleteMFAChallenges = `-- name: DeleteMFAChallenges :exec
+DELETE FROM mfa_challenges
+WHERE user_id = $1
+`
+
+func (q *Queries) DeleteMFAChallenges(ctx context.Context, userID int32) error {
+	_, err := q.db.Exec(ctx, deleteMFAChallenges, userID)
+	return err
+}
+
+const deleteRecoveryCodes = `-- name: DeleteRecoveryCodes :exec
+DELETE FROM recovery_codes
+WHERE user_id = $1
+`
+
+func (q *Queries) DeleteRecoveryCodes(ctx context.Context, userID int32) error {
+	_, err := q.db.Exec(ctx, deleteRecoveryCodes, userID)
+	return err
+}
+
+const deleteSessionsByUserID = `-- name: DeleteSessionsByUserID :exec
+DELETE FROM sessions
+WHERE user_id = $1
+`
+
+func (q *Queries) DeleteSessionsByUserID(ctx context.Context, userID int32) error {
+	_, err := q.db.Exec(ctx, deleteSessionsByUserID, userID)
+	return err
+}
+
+const deleteUserByID = `-- name: DeleteUserByID :exec
+DELETE FROM users
+WHERE id = $1
+`
+
+func (q *Queries) DeleteUserByID(ctx context.Context, id int32) error {
+	_, err := q.db.Exec(ctx, deleteUserByID, id)
+	return err
+}
+
+const deleteUserPersonalData = `-- name: DeleteUserPersonalData :exec
+-- everything about the user except their bookings
+WITH profile AS (DELETE FROM participant_profiles WHERE user_id = $1),
+     care AS (DELETE FROM care_relationships WHERE participant_id = $1 OR caregiver_id = $1),
+     link_codes AS (DELETE FROM care_link_codes WHERE participant_id = $1),
+     login AS (DELETE FROM login_codes WHERE user_id = $1),
+     passwords AS (DELETE FROM password_tokens WHERE user_id = $1),
+     totp AS (DELETE FROM user_totp WHERE user_id = $1),
+     recovery AS (DELETE FROM recovery_codes WHERE user_id = $1),
+     mfa AS (DELETE FROM mfa_challenges WHERE user_id = $1),
+     invites AS (DELETE FROM invitations WHERE accepted_user_id = $1),
+     volunteer AS (DELETE FROM volunteer_profiles WHERE user_id = $1),
+     certifications AS (DELETE FROM volunteer_certifications WHERE user_id = $1),
+     availability AS (DELETE FROM volunteer_availability WHERE user_id = $1),
+     medical AS (DELETE FROM participant_medical WHERE user_id = $1),
+     leads AS (DELETE FROM activity_volunteer_leads WHERE user_id = $1),
+     email_changes AS (DELETE FROM email_change_tokens WHERE user_id = $1),
+     waitlist AS (DELETE FROM booking_waitlist WHERE participant_id = $1 OR requested_by = $1)
+DELETE FROM sessions WHERE user_id = $1
+`
+
+func (q *Queries) DeleteUserPersonalData(ctx context.Context, userID int32) error {
+	_, err := q.db.Exec(ctx, deleteUserPersonalData, userID)
+	return err
+}
+
+const deleteUserTOTP = `-- name: DeleteUserTOTP :execrows
+DELETE FROM user_totp
+WHERE user_id = $1
+`
+
+func (q *Queries) DeleteUserTOTP(ctx context.Context, userID int32) (int64, error) {
+	result, err := q.db.Exec(ctx, deleteUserTOTP, userID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const deleteVolunteerAvailability = `-- name: DeleteVolunteerAvailability :exec
+DELETE FROM volunteer_availability
+WHERE user_id = $1
+`
+
+func (q *Queries) DeleteVolunteerAvailability(ctx context.Context, userID int32) error {
+	_, err := q.db.Exec(ctx, deleteVolunteerAvailability, userID)
+	return err
+}
+
+const deleteVolunteerCertification = `-- name: DeleteVolunteerCertification :execrows
+DELETE FROM volunteer_certifications
+WHERE id = $1 AND user_id = $2
+`
+
+type DeleteVolunteerCertificationParams struct {
+	ID     int32 `json:"id"`
+	UserID int32 `json:"user_id"`
+}
+
+func (q *Queries) DeleteVolunteerCertification(ctx context.Context, arg DeleteVolunteerCertificationParams) (int64, error) {
+	result, err := q.db.Exec(ctx, deleteVolunteerCertification,
+		arg.ID,
+		arg.UserID,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const getActiveLoginCode = `-- name: GetActiveLoginCode :one
+SELECT id, user_id, code_hash, channel, attempts, expires_at, used_at, created_at FROM login_codes
+WHERE user_id = $1
+  AND used_at IS NULL
+  AND expires_at > NOW()
+ORDER BY created_at DESC
+LIMIT 1
+`
+
+func (q *Queries) GetActiveLoginCode(ctx context.Context, userID int32) (LoginCode, error) {
+	row := q.db.QueryRow(ctx, getActiveLoginCode, userID)
+	var i LoginCode
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.CodeHash,
+		&i.Channel,
+		&i.Attempts,
+		&i.ExpiresAt,
+		&i.UsedAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getActiveMFAChallenge = `-- name: GetActiveMFAChallenge :one
+SELECT id, user_id, token_hash, attempts, expires_at, used_at, created_at FROM mfa_challenges
+WHERE token_hash = $1
+  AND used_at IS NULL
+  AND expires_at > NOW()
+`
+
+func (q *Queries) GetActiveMFAChallenge(ctx context.Context, tokenHash string) (MfaChallenge, error) {
+	row := q.db.QueryRow(ctx, getActiveMFAChallenge, tokenHash)
+	var i MfaChallenge
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.TokenHash,
+		&i.Attempts,
+		&i.ExpiresAt,
+		&i.UsedAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getActivityByID = `-- name: GetActivityByID :one
+SELECT
+  id, title, description, venue, start_time, end_time, signup_deadline, participant_capacity, volunteer_capacity, wheelchair_accessible, sign_language_available, requires_payment, status, created_by, created_at
+FROM
+  activities
+WHERE
+  id = $1
+`
+
+func (q *Queries) GetActivityByID(ctx context.Context, id int32) (Activity, error) {
+	row := q.db.QueryRow(ctx, getActivityByID, id)
+	var i Activity
+	err := row.Scan(
+		&i.ID,
+		&i.Title,
+		&i.Description,
+		&i.Venue,
+		&i.StartTime,
+		&i.EndTime,
+		&i.SignupDeadline,
+		&i.ParticipantCapacity,
+		&i.VolunteerCapacity,
+		&i.WheelchairAccessible,
+		&i.SignLanguageAvailable,
+		&i.RequiresPayment,
+		&i.Status,
+		&i.CreatedBy,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getActivityVolunteerLead = `-- name: GetActivityVolunteerLead :one
+SELECT activity_id, user_id, assigned_by, assigned_at FROM activity_volunteer_leads
+WHERE activity_id = $1
+`
+
+func (q *Queries) GetActivityVolunteerLead(ctx context.Context, activityID int32) (ActivityVolunteerLead, error) {
+	row := q.db.QueryRow(ctx, getActivityVolunteerLead, activityID)
+	var i ActivityVolunteerLead
+	err := row.Scan(
+		&i.ActivityID,
+		&i.UserID,
+		&i.AssignedBy,
+		&i.AssignedAt,
+	)
+	return i, err
+}
+
+const getAllUsers = `-- name: GetAllUsers :many
+SELECT id, name, phone, email, password, role, created_at, phone_encrypted, phone_index, password_must_change, password_changed_at, status, status_reason, status_changed_at, status_changed_by, language FROM users
+ORDER BY created_at DESC
+`
+
+func (q *Queries) GetAllUsers(ctx context.Context) ([]User, error) {
+	rows, err := q.db.Query(ctx, getAllUsers)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []User
+	for rows.Next() {
+		var i User
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.Phone,
+			&i.Email,
+			&i.Password,
+			&i.Role,
+			&i.CreatedAt,
+			&i.PhoneEncrypted,
+			&i.PhoneIndex,
+			&i.PasswordMustChange,
+			&i.PasswordChangedAt,
+			&i.Status,
+			&i.StatusReason,
+			&i.StatusChangedAt,
+			&i.StatusChangedBy,
+			&i.Language,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getAPIKeyByPrefix = `-- name: GetAPIKeyByPrefix :one
+SELECT id, name, prefix, key_hash, scopes, created_by, expires_at, last_used_at, revoked_at, created_at FROM api_keys
+WHERE prefix = $1
+`
+
+func (q *Queries) GetAPIKeyByPrefix(ctx context.Context, prefix string) (ApiKey, error) {
+	row := q.db.QueryRow(ctx, getAPIKeyByPrefix, prefix)
+	var i ApiKey
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Prefix,
+		&i.KeyHash,
+		&i.Scopes,
+		&i.CreatedBy,
+		&i.ExpiresAt,
+		&i.LastUsedAt,
+		&i.RevokedAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getBookingByID = `-- name: GetBookingByID :one
+SELECT
+  id, activity_id, user_id, booked_for_user_id, role, is_paid, attendance_status, created_at, cancelled_at, created_by_staff_id, quota_override_by
+FROM
+  bookings
+WHERE
+  id = $1
+`
+
+func (q *Queries) GetBookingByID(ctx context.Context, id int32) (Booking, error) {
+	row := q.db.QueryRow(ctx, getBookingByID, id)
+	var i Booking
+	err := row.Scan(
+		&i.ID,
+		&i.ActivityID,
+		&i.UserID,
+		&i.BookedForUserID,
+		&i.Role,
+		&i.IsPaid,
+		&i.AttendanceStatus,
+		&i.CreatedAt,
+		&i.CancelledAt,
+		&i.CreatedByStaffID,
+		&i.QuotaOverrideBy,
+	)
+	return i, err
+}
+
+const getBookingWaitlistEntry = `-- name: GetBookingWaitlistEntry :one
+SELECT id, activity_id, participant_id, requested_by, created_at FROM booking_waitlist
+WHERE id = $1
+`
+
+func (q *Queries) GetBookingWaitlistEntry(ctx context.Context, id int32) (BookingWaitlist, error) {
+	row := q.db.QueryRow(ctx, getBookingWaitlistEntry, id)
+	var i BookingWaitlist
+	err := row.Scan(
+		&i.ID,
+		&i.ActivityID,
+		&i.ParticipantID,
+		&i.RequestedBy,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getCareRelationship = `-- name: GetCareRelationship :one
+SELECT participant_id, caregiver_id, created_at, status, requested_by, approved_by, approved_at FROM care_relationships
+WHERE participant_id = $1 AND caregiver_id = $2
+`
+
+type GetCareRelationshipParams struct {
+	ParticipantID int32 `json:"participant_id"`
+	CaregiverID   int32 `json:"caregiver_id"`
+}
+
+func (q *Queries) GetCareRelationship(ctx context.Context, arg GetCareRelationshipParams) (CareRelationship, error) {
+	row := q.db.QueryRow(ctx, getCareRelationship,
+		arg.ParticipantID,
+		arg.CaregiverID,
+	)
+	var i CareRelationship
+	err := row.Scan(
+		&i.ParticipantID,
+		&i.CaregiverID,
+		&i.CreatedAt,
+		&i.Status,
+		&i.RequestedBy,
+		&i.ApprovedBy,
+		&i.ApprovedAt,
+	)
+	return i, err
+}
+
+const getInvitationByID = `-- name: GetInvitationByID :one
+SELECT id, name, email, phone_encrypted, role, channel, token_hash, invited_by, expires_at, sent_at, accepted_at, accepted_user_id, revoked_at, created_at FROM invitations
+WHERE id = $1
+`
+
+func (q *Queries) GetInvitationByID(ctx context.Context, id int32) (Invitation, error) {
+	row := q.db.QueryRow(ctx, getInvitationByID, id)
+	var i Invitation
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Email,
+		&i.PhoneEncrypted,
+		&i.Role,
+		&i.Channel,
+		&i.TokenHash,
+		&i.InvitedBy,
+		&i.ExpiresAt,
+		&i.SentAt,
+		&i.AcceptedAt,
+		&i.AcceptedUserID,
+		&i.RevokedAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getLoginThrottle = `-- name: GetLoginThrottle :one
+SELECT scope, subject, failed_attempts, locked_until, last_failed_at FROM login_throttles
+WHERE scope = $1 AND subject = $2
+`
+
+type GetLoginThrottleParams struct {
+	Scope   string `json:"scope"`
+	Subject string `json:"subject"`
+}
+
+func (q *Queries) GetLoginThrottle(ctx context.Context, arg GetLoginThrottleParams) (LoginThrottle, error) {
+	row := q.db.QueryRow(ctx, getLoginThrottle, arg.Scope, arg.Subject)
+	var i LoginThrottle
+	err := row.Scan(
+		&i.Scope,
+		&i.Subject,
+		&i.FailedAttempts,
+		&i.LockedUntil,
+		&i.LastFailedAt,
+	)
+	return i, err
+}
+
+const getParticipantMedical = `-- name: GetParticipantMedical :one
+SELECT user_id, emergency_contacts_encrypted, medical_notes_encrypted, updated_by, updated_at FROM participant_medical
+WHERE user_id = $1
+`
+
+func (q *Queries) GetParticipantMedical(ctx context.Context, userID int32) (ParticipantMedical, error) {
+	row := q.db.QueryRow(ctx, getParticipantMedical, userID)
+	var i ParticipantMedical
+	err := row.Scan(
+		&i.UserID,
+		&i.EmergencyContactsEncrypted,
+		&i.MedicalNotesEncrypted,
+		&i.UpdatedBy,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const getParticipantProfile = `-- name: GetParticipantProfile :one
+SELECT user_id, age, membership_type, wheelchair, sign_language, other_need, created_at, needs_seated_activity, sensitive_to_light, sensitive_to_noise, updated_at FROM participant_profiles
+WHERE user_id = $1
+`
+
+func (q *Queries) GetParticipantProfile(ctx context.Context, userID int32) (ParticipantProfile, error) {
+	row := q.db.QueryRow(ctx, getParticipantProfile, userID)
+	var i ParticipantProfile
+	err := row.Scan(
+		&i.UserID,
+		&i.Age,
+		&i.MembershipType,
+		&i.Wheelchair,
+		&i.SignLanguage,
+		&i.OtherNeed,
+		&i.CreatedAt,
+		&i.NeedsSeatedActivity,
+		&i.SensitiveToLight,
+		&i.SensitiveToNoise,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const getSession = `-- name: GetSession :one
+SELECT id, user_id, refresh_token, is_revoked, expires_at, created_at, family_id, replaced_by, rotated_at, user_agent, ip_address, last_used_at FROM sessions WHERE id = $1
+`
+
+func (q *Queries) GetSession(ctx context.Context, id string) (Session, error) {
+	row := q.db.QueryRow(ctx, getSession, id)
+	var i Session
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.RefreshToken,
+		&i.IsRevoked,
+		&i.ExpiresAt,
+		&i.CreatedAt,
+		&i.FamilyID,
+		&i.ReplacedBy,
+		&i.RotatedAt,
+		&i.UserAgent,
+		&i.IpAddress,
+		&i.LastUsedAt,
+	)
+	return i, err
+}
+
+const getUserByEmail = `-- name: GetUserByEmail :one
+SELECT id, name, phone, email, password, role, created_at, phone_encrypted, phone_index, password_must_change, password_changed_at, status, status_reason, status_changed_at, status_changed_by, language FROM users
+WHERE email = $1
+`
+
+func (q *Queries) GetUserByEmail(ctx context.Context, email string) (User, error) {
+	row := q.db.QueryRow(ctx, getUserByEmail, email)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Phone,
+		&i.Email,
+		&i.Password,
+		&i.Role,
+		&i.CreatedAt,
+		&i.PhoneEncrypted,
+		&i.PhoneIndex,
+		&i.PasswordMustChange,
+		&i.PasswordChangedAt,
+		&i.Status,
+		&i.StatusReason,
+		&i.StatusChangedAt,
+		&i.StatusChangedBy,
+		&i.Language,
+	)
+	return i, err
+}
+
+const getUserByID = `-- name: GetUserByID :one
+SELECT id, name, phone, email, password, role, created_at, phone_encrypted, phone_index, password_must_change, password_changed_at, status, status_reason, status_changed_at, status_changed_by, language FROM users
+WHERE id = $1
+`
+
+func (q *Queries) GetUserByID(ctx context.Context, id int32) (User, error) {
+	row := q.db.QueryRow(ctx, getUserByID, id)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Phone,
+		&i.Email,
+		&i.Password,
+		&i.Role,
+		&i.CreatedAt,
+		&i.PhoneEncrypted,
+		&i.PhoneIndex,
+		&i.PasswordMustChange,
+		&i.PasswordChangedAt,
+		&i.Status,
+		&i.StatusReason,
+		&i.StatusChangedAt,
+		&i.StatusChangedBy,
+		&i.Language,
+	)
+	return i, err
+}
+
+const getUserByNameAndPhone = `-- name: GetUserByNameAndPhone :one
+SELECT id, name, phone, email, role
+FROM users
+WHERE LOWER(name) = LOWER($1) AND phone = $2
+`
+
+type GetUserByNameAndPhoneParams struct {
+	Lower string      `json:"lower"`
+	Phone interface{} `json:"phone"`
+}
+
+type GetUserByNameAndPhoneRow struct {
+	ID    int32       `json:"id"`
+	Name  string      `json:"name"`
+	Phone interface{} `json:"phone"`
+	Email string      `json:"email"`
+	Role  string      `json:"role"`
+}
+
+func (q *Queries) GetUserByNameAndPhone(ctx context.Context, arg GetUserByNameAndPhoneParams) (GetUserByNameAndPhoneRow, error) {
+	row := q.db.QueryRow(ctx, getUserByNameAndPhone, arg.Lower, arg.Phone)
+	var i GetUserByNameAndPhoneRow
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Phone,
+		&i.Email,
+		&i.Role,
+	)
+	return i, err
+}
+
+const getUserByPhone = `-- name: GetUserByPhone :one
+SELECT id, name, phone, email, password, role, created_at, phone_encrypted, phone_index, password_must_change, password_changed_at, status, status_reason, status_changed_at, status_changed_by, language FROM users
+WHERE phone_index = $1
+`
+
+func (q *Queries) GetUserByPhone(ctx context.Context, phoneIndex pgtype.Text) (User, error) {
+	row := q.db.QueryRow(ctx, getUserByPhone, phoneIndex)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Phone,
+		&i.Email,
+		&i.Password,
+		&i.Role,
+		&i.CreatedAt,
+		&i.PhoneEncrypted,
+		&i.PhoneIndex,
+		&i.PasswordMustChange,
+		&i.PasswordChangedAt,
+		&i.Status,
+		&i.StatusReason,
+		&i.StatusChangedAt,
+		&i.StatusChangedBy,
+		&i.Language,
+	)
+	return i, err
+}
+
+const getUserTOTP = `-- name: GetUserTOTP :one
+SELECT user_id, secret_encrypted, confirmed_at, last_used_step, created_at FROM user_totp
+WHERE user_id = $1
+`
+
+func (q *Queries) GetUserTOTP(ctx context.Context, userID int32) (UserTotp, error) {
+	row := q.db.QueryRow(ctx, getUserTOTP, userID)
+	var i UserTotp
+	err := row.Scan(
+		&i.UserID,
+		&i.SecretEncrypted,
+		&i.ConfirmedAt,
+		&i.LastUsedStep,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getValidPasswordToken = `-- name: GetValidPasswordToken :one
+SELECT id, user_id, token_hash, purpose, expires_at, used_at, created_at FROM password_tokens
+WHERE token_hash = $1
+  AND used_at IS NULL
+  AND expires_at > NOW()
+`
+
+func (q *Queries) GetValidPasswordToken(ctx context.Context, tokenHash string) (PasswordToken, error) {
+	row := q.db.QueryRow(ctx, getValidPasswordToken, tokenHash)
+	var i PasswordToken
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.TokenHash,
+		&i.Purpose,
+		&i.ExpiresAt,
+		&i.UsedAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getVolunteerProfile = `-- name: GetVolunteerProfile :one
+SELECT user_id, skills, preferred_venues, created_at, updated_at FROM volunteer_profiles
+WHERE user_id = $1
+`
+
+func (q *Queries) GetVolunteerProfile(ctx context.Context, userID int32) (VolunteerProfile, error) {
+	row := q.db.QueryRow(ctx, getVolunteerProfile, userID)
+	var i VolunteerProfile
+	err := row.Scan(
+		&i.UserID,
+		&i.Skills,
+		&i.PreferredVenues,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const incrementLoginCodeAttempts = `-- name: IncrementLoginCodeAttempts :one
+UPDATE login_codes
+SET attempts = attempts + 1
+WHERE id = $1
+RETURNING attempts
+`
+
+func (q *Queries) IncrementLoginCodeAttempts(ctx context.Context, id int32) (int32, error) {
+	row := q.db.QueryRow(ctx, incrementLoginCodeAttempts, id)
+	var attempts int32
+	err := row.Scan(&attempts)
+	return attempts, err
+}
+
+const incrementMFAChallengeAttempts = `-- name: IncrementMFAChallengeAttempts :one
+UPDATE mfa_challenges
+SET attempts = attempts + 1
+WHERE id = $1
+RETURNING attempts
+`
+
+func (q *Queries) IncrementMFAChallengeAttempts(ctx context.Context, id int32) (int32, error) {
+	row := q.db.QueryRow(ctx, incrementMFAChallengeAttempts, id)
+	var attempts int32
+	err := row.Scan(&attempts)
+	return attempts, err
+}
+
+const invalidateEmailChangeTokens = `-- name: InvalidateEmailChangeTokens :exec
+UPDATE email_change_tokens
+SET used_at = NOW()
+WHERE user_id = $1 AND used_at IS NULL
+`
+
+func (q *Queries) InvalidateEmailChangeTokens(ctx context.Context, userID int32) error {
+	_, err := q.db.Exec(ctx, invalidateEmailChangeTokens, userID)
+	return err
+}
+
+const invalidateLoginCodes = `-- name: InvalidateLoginCodes :exec
+UPDATE login_codes
+SET used_at = NOW()
+WHERE user_id = $1 AND used_at IS NULL
+`
+
+func (q *Queries) InvalidateLoginCodes(ctx context.Context, userID int32) error {
+	_, err := q.db.Exec(ctx, invalidateLoginCodes, userID)
+	return err
+}
+
+const invalidatePasswordLinks = `-- name: InvalidatePasswordLinks :exec
+UPDATE password_tokens
+SET used_at = NOW()
+WHERE user_id = $1 AND used_at IS NULL AND purpose <> 'change'
+`
+
+func (q *Queries) InvalidatePasswordLinks(ctx context.Context, userID int32) error {
+	_, err := q.db.Exec(ctx, invalidatePasswordLinks, userID)
+	return err
+}
+
+const invalidatePasswordTokens = `-- name: InvalidatePasswordTokens :exec
+UPDATE password_tokens
+SET used_at = NOW()
+WHERE user_id = $1 AND used_at IS NULL
+`
+
+func (q *Queries) InvalidatePasswordTokens(ctx context.Context, userID int32) error {
+	_, err := q.db.Exec(ctx, invalidatePasswordTokens, userID)
+	return err
+}
+
+const isActivityLeadOn = `-- name: IsActivityLeadOn :one
+SELECT EXISTS (
+    SELECT 1 FROM activity_volunteer_leads l
+    JOIN activities a ON a.id = l.activity_id
+    WHERE l.activity_id = $1
+      AND l.user_id = $2
+      AND a.start_time::date = $3::date
+)
+`
+
+type IsActivityLeadOnParams struct {
+	ActivityID int32       `json:"activity_id"`
+	UserID     int32       `json:"user_id"`
+	OnDate     pgtype.Date `json:"on_date"`
+}
+
+func (q *Queries) IsActivityLeadOn(ctx context.Context, arg IsActivityLeadOnParams) (bool, error) {
+	row := q.db.QueryRow(ctx, isActivityLeadOn,
+		arg.ActivityID,
+		arg.UserID,
+		arg.OnDate,
+	)
+	var exists bool
+	err := row.Scan(&exists)
+	return exists, err
+}
+
+const isCaregiverOf = `-- name: IsCaregiverOf :one
+SELECT EXISTS (
+  SELECT 1 FROM care_relationships
+  WHERE caregiver_id = $1 AND participant_id = $2 AND status = 'active'
+)
+`
+
+type IsCaregiverOfParams struct {
+	CaregiverID   int32 `json:"caregiver_id"`
+	ParticipantID int32 `json:"participant_id"`
+}
+
+func (q *Queries) IsCaregiverOf(ctx context.Context, arg IsCaregiverOfParams) (bool, error) {
+	row := q.db.QueryRow(ctx, isCaregiverOf, arg.CaregiverID, arg.ParticipantID)
+	var exists bool
+	err := row.Scan(&exists)
+	return exists, err
+}
+
+const isSessionActive = `-- name: IsSessionActive :one
+-- the family still has an unrevoked, unexpired session (its latest refresh token)
+-- and the account is still active
+SELECT EXISTS (
+  SELECT 1 FROM sessions s
+  JOIN users u ON u.id = s.user_id
+  WHERE s.family_id = $1
+    AND s.user_id = $2
+    AND s.is_revoked = FALSE
+    AND s.expires_at > NOW()
+    AND u.status = 'active'
+)
+`
+
+type IsSessionActiveParams struct {
+	FamilyID string `json:"family_id"`
+	UserID   int32  `json:"user_id"`
+}
+
+func (q *Queries) IsSessionActive(ctx context.Context, arg IsSessionActiveParams) (bool, error) {
+	row := q.db.QueryRow(ctx, isSessionActive,
+		arg.FamilyID,
+		arg.UserID,
+	)
+	var exists bool
+	err := row.Scan(&exists)
+	return exists, err
+}
+
+const listActiveSessionsByUserID = `-- name: ListActiveSessionsByUserID :many
+SELECT
+  s.family_id,
+  s.user_agent,
+  s.ip_address,
+  s.expires_at,
+  s.last_used_at,
+  f.created_at AS signed_in_at
+FROM sessions s
+JOIN sessions f ON f.id = s.family_id
+WHERE s.user_id = $1
+  AND s.is_revoked = FALSE
+  AND s.expires_at > NOW()
+ORDER BY s.last_used_at DESC
+`
+
+type ListActiveSessionsByUserIDRow struct {
+	FamilyID   string           `json:"family_id"`
+	UserAgent  pgtype.Text      `json:"user_agent"`
+	IpAddress  pgtype.Text      `json:"ip_address"`
+	ExpiresAt  pgtype.Timestamp `json:"expires_at"`
+	LastUsedAt pgtype.Timestamp `json:"last_used_at"`
+	SignedInAt pgtype.Timestamp `json:"signed_in_at"`
+}
+
+func (q *Queries) ListActiveSessionsByUserID(ctx context.Context, userID int32) ([]ListActiveSessionsByUserIDRow, error) {
+	rows, err := q.db.Query(ctx, listActiveSessionsByUserID, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListActiveSessionsByUserIDRow
+	for rows.Next() {
+		var i ListActiveSessionsByUserIDRow
+		if err := rows.Scan(
+			&i.FamilyID,
+			&i.UserAgent,
+			&i.IpAddress,
+			&i.ExpiresAt,
+			&i.LastUsedAt,
+			&i.SignedInAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listActivities = `-- name: ListActivities :many
+SELECT
+  id, title, description, venue, start_time, end_time, signup_deadline, participant_capacity, volunteer_capacity, wheelchair_accessible, sign_language_available, requires_payment, status, created_by, created_at 
+FROM
+  activities
+`
+
+func (q *Queries) ListActivities(ctx context.Context) ([]Activity, error) {
+	rows, err := q.db.Query(ctx, listActivities)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Activity
+	for rows.Next() {
+		var i Activity
+		if err := rows.Scan(
+			&i.ID,
+			&i.Title,
+			&i.Description,
+			&i.Venue,
+			&i.StartTime,
+			&i.EndTime,
+			&i.SignupDeadline,
+			&i.ParticipantCapacity,
+			&i.VolunteerCapacity,
+			&i.WheelchairAccessible,
+			&i.SignLanguageAvailable,
+			&i.RequiresPayment,
+			&i.Status,
+			&i.CreatedBy,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listActivitiesWithCounts = `-- name: ListActivitiesWithCounts :many
+SELECT
+  a.id,
+  a.title,
+  a.description,
+  a.venue,
+  a.start_time,
+  a.end_time,
+  a.signup_deadline,
+  a.participant_capacity,
+  a.volunteer_capacity,
+  COUNT(b.id) AS registered_participants_count
+FROM activities a
+LEFT JOIN bookings b
+  ON b.activity_id = a.id
+  AND b.role = 'participant'
+`
+
+type ListActivitiesWithCountsRow struct {
+	ID                          int32            `json:"id"`
+	Title                       string           `json:"title"`
+	Description                 interface{}      `json:"description"`
+	Venue                       string           `json:"venue"`
+	StartTime                   pgtype.Timestamp `json:"start_time"`
+	EndTime                     pgtype.Timestamp `json:"end_time"`
+	SignupDeadline              pgtype.Timestamp `json:"signup_deadline"`
+	ParticipantCapacity         int32            `json:"participant_capacity"`
+	VolunteerCapacity           int32            `json:"volunteer_capacity"`
+	RegisteredParticipantsCount int64            `json:"registered_participants_count"`
+}
+
+func (q *Queries) ListActivitiesWithCounts(ctx context.Context) ([]ListActivitiesWithCountsRow, error) {
+	rows, err := q.db.Query(ctx, listActivitiesWithCounts)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListActivitiesWithCountsRow
+	for rows.Next() {
+		var i ListActivitiesWithCountsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Title,
+			&i.Description,
+			&i.Venue,
+			&i.StartTime,
+			&i.EndTime,
+			&i.SignupDeadline,
+			&i.ParticipantCapacity,
+			&i.VolunteerCapacity,
+			&i.RegisteredParticipantsCount,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listActivityParticipantMedical = `-- name: ListActivityParticipantMedical :many
+-- participants with a live booking on the activity who have medical details
+SELECT u.id, u.name, m.emergency_contacts_encrypted, m.medical_notes_encrypted, m.updated_by, m.updated_at
+FROM users u
+JOIN participant_medical m ON m.user_id = u.id
+WHERE u.id IN (
+    SELECT COALESCE(b.booked_for_user_id, b.user_id)
+    FROM bookings b
+    WHERE b.activity_id = $1
+      AND b.role = 'participant'
+      AND b.cancelled_at IS NULL
+)
+ORDER BY u.name, u.id
+`
+
+type ListActivityParticipantMedicalRow struct {
+	ID                         int32            `json:"id"`
+	Name                       string           `json:"name"`
+	EmergencyContactsEncrypted []byte           `json:"emergency_contacts_encrypted"`
+	MedicalNotesEncrypted      []byte           `json:"medical_notes_encrypted"`
+	UpdatedBy                  pgtype.Int4      `json:"updated_by"`
+	UpdatedAt                  pgtype.Timestamp `json:"updated_at"`
+}
+
+func (q *Queries) ListActivityParticipantMedical(ctx context.Context, activityID int32) ([]ListActivityParticipantMedicalRow, error) {
+	rows, err := q.db.Query(ctx, listActivityParticipantMedical, activityID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListActivityParticipantMedicalRow
+	for rows.Next() {
+		var i ListActivityParticipantMedicalRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.EmergencyContactsEncrypted,
+			&i.MedicalNotesEncrypted,
+			&i.UpdatedBy,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listAPIKeys = `-- name: ListAPIKeys :many
+SELECT id, name, prefix, key_hash, scopes, created_by, expires_at, last_used_at, revoked_at, created_at FROM api_keys
+ORDER BY created_at DESC
+`
+
+func (q *Queries) ListAPIKeys(ctx context.Context) ([]ApiKey, error) {
+	rows, err := q.db.Query(ctx, listAPIKeys)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ApiKey
+	for rows.Next() {
+		var i ApiKey
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.Prefix,
+			&i.KeyHash,
+			&i.Scopes,
+			&i.CreatedBy,
+			&i.ExpiresAt,
+			&i.LastUsedAt,
+			&i.RevokedAt,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listBookingHistoryByUserID = `-- name: ListBookingHistoryByUserID :many
+SELECT b.id, b.activity_id, a.title AS activity_title, a.venue, a.start_time, a.end_time,
+       b.user_id, b.booked_for_user_id, b.role, b.is_paid, b.attendance_status, b.created_at, b.cancelled_at
+FROM bookings b
+JOIN activities a ON a.id = b.activity_id
+WHERE b.user_id = $1 OR b.booked_for_user_id = $1
+ORDER BY a.start_time DESC
+`
+
+type ListBookingHistoryByUserIDRow struct {
+	ID               int32            `json:"id"`
+	ActivityID       int32            `json:"activity_id"`
+	ActivityTitle    string           `json:"activity_title"`
+	Venue            string           `json:"venue"`
+	StartTime        pgtype.Timestamp `json:"start_time"`
+	EndTime          pgtype.Timestamp `json:"end_time"`
+	UserID           int32            `json:"user_id"`
+	BookedForUserID  pgtype.Int4      `json:"booked_for_user_id"`
+	Role             string           `json:"role"`
+	IsPaid           bool             `json:"is_paid"`
+	AttendanceStatus pgtype.Text      `json:"attendance_status"`
+	CreatedAt        pgtype.Timestamp `json:"created_at"`
+	CancelledAt      pgtype.Timestamp `json:"cancelled_at"`
+}
+
+func (q *Queries) ListBookingHistoryByUserID(ctx context.Context, userID int32) ([]ListBookingHistoryByUserIDRow, error) {
+	rows, err := q.db.Query(ctx, listBookingHistoryByUserID, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListBookingHistoryByUserIDRow
+	for rows.Next() {
+		var i ListBookingHistoryByUserIDRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.ActivityID,
+			&i.ActivityTitle,
+			&i.Venue,
+			&i.StartTime,
+			&i.EndTime,
+			&i.UserID,
+			&i.BookedForUserID,
+			&i.Role,
+			&i.IsPaid,
+			&i.AttendanceStatus,
+			&i.CreatedAt,
+			&i.CancelledAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listBookings = `-- name: ListBookings :many
+SELECT
+  id, activity_id, user_id, booked_for_user_id, role, is_paid, attendance_status, created_at, cancelled_at, created_by_staff_id, quota_override_by 
+FROM
+  bookings
+`
+
+func (q *Queries) ListBookings(ctx context.Context) ([]Booking, error) {
+	rows, err := q.db.Query(ctx, listBookings)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Booking
+	for rows.Next() {
+		var i Booking
+		if err := rows.Scan(
+			&i.ID,
+			&i.ActivityID,
+			&i.UserID,
+			&i.BookedForUserID,
+			&i.Role,
+			&i.IsPaid,
+			&i.AttendanceStatus,
+			&i.CreatedAt,
+			&i.CancelledAt,
+			&i.CreatedByStaffID,
+			&i.QuotaOverrideBy,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listBookingsByActivityID = `-- name: ListBookingsByActivityID :many
+SELECT
+  id, activity_id, user_id, booked_for_user_id, role, is_paid, attendance_status, created_at, cancelled_at, created_by_staff_id, quota_override_by
+FROM
+  bookings
+WHERE
+  activity_id = $1
+`
+
+func (q *Queries) ListBookingsByActivityID(ctx context.Context, activityID int32) ([]Booking, error) {
+	rows, err := q.db.Query(ctx, listBookingsByActivityID, activityID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Booking
+	for rows.Next() {
+		var i Booking
+		if err := rows.Scan(
+			&i.ID,
+			&i.ActivityID,
+			&i.UserID,
+			&i.BookedForUserID,
+			&i.Role,
+			&i.IsPaid,
+			&i.AttendanceStatus,
+			&i.CreatedAt,
+			&i.CancelledAt,
+			&i.CreatedByStaffID,
+			&i.QuotaOverrideBy,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listBookingsByUserID = `-- name: ListBookingsByUserID :many
+SELECT id, activity_id, user_id, booked_for_user_id, role, is_paid, attendance_status, created_at, cancelled_at, created_by_staff_id, quota_override_by FROM bookings
+WHERE user_id = $1 OR booked_for_user_id = $1
+ORDER BY created_at DESC
+`
+
+func (q *Queries) ListBookingsByUserID(ctx context.Context, userID int32) ([]Booking, error) {
+	rows, err := q.db.Query(ctx, listBookingsByUserID, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Booking
+	for rows.Next() {
+		var i Booking
+		if err := rows.Scan(
+			&i.ID,
+			&i.ActivityID,
+			&i.UserID,
+			&i.BookedForUserID,
+			&i.Role,
+			&i.IsPaid,
+			&i.AttendanceStatus,
+			&i.CreatedAt,
+			&i.CancelledAt,
+			&i.CreatedByStaffID,
+			&i.QuotaOverrideBy,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listBookingsForCaregiver = `-- name: ListBookingsForCaregiver :many
+SELECT id, activity_id, user_id, booked_for_user_id, role, is_paid, attendance_status, created_at, cancelled_at, created_by_staff_id, quota_override_by FROM bookings
+WHERE user_id = $1
+   OR booked_for_user_id = $1
+   OR booked_for_user_id IN (
+     SELECT participant_id FROM care_relationships WHERE caregiver_id = $1 AND status = 'active'
+   )
+ORDER BY created_at DESC
+`
+
+func (q *Queries) ListBookingsForCaregiver(ctx context.Context, userID int32) ([]Booking, error) {
+	rows, err := q.db.Query(ctx, listBookingsForCaregiver, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Booking
+	for rows.Next() {
+		var i Booking
+		if err := rows.Scan(
+			&i.ID,
+			&i.ActivityID,
+			&i.UserID,
+			&i.BookedForUserID,
+			&i.Role,
+			&i.IsPaid,
+			&i.AttendanceStatus,
+			&i.CreatedAt,
+			&i.CancelledAt,
+			&i.CreatedByStaffID,
+			&i.QuotaOverrideBy,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listBookingWaitlist = `-- name: ListBookingWaitlist :many
+-- entries for activities that have not started; a null user_id lists everyone,
+-- otherwise their own, those they asked for and those of their linked participants
+SELECT w.id, w.activity_id, a.title AS activity_title, a.start_time,
+       w.participant_id, u.name AS participant_name, w.requested_by, w.created_at
+FROM booking_waitlist w
+JOIN activities a ON a.id = w.activity_id
+JOIN users u ON u.id = w.participant_id
+WHERE a.start_time > NOW()
+  AND ($1::int IS NULL
+       OR w.participant_id = $1
+       OR w.requested_by = $1
+       OR w.participant_id IN (
+         SELECT participant_id FROM care_relationships WHERE caregiver_id = $1 AND status = 'active'
+       ))
+ORDER BY a.start_time, w.created_at
+`
+
+type ListBookingWaitlistRow struct {
+	ID              int32            `json:"id"`
+	ActivityID      int32            `json:"activity_id"`
+	ActivityTitle   string           `json:"activity_title"`
+	StartTime       pgtype.Timestamp `json:"start_time"`
+	ParticipantID   int32            `json:"participant_id"`
+	ParticipantName string           `json:"participant_name"`
+	RequestedBy     int32            `json:"requested_by"`
+	CreatedAt       pgtype.Timestamp `json:"created_at"`
+}
+
+func (q *Queries) ListBookingWaitlist(ctx context.Context, userID pgtype.Int4) ([]ListBookingWaitlistRow, error) {
+	rows, err := q.db.Query(ctx, listBookingWaitlist, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListBookingWaitlistRow
+	for rows.Next() {
+		var i ListBookingWaitlistRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.ActivityID,
+			&i.ActivityTitle,
+			&i.StartTime,
+			&i.ParticipantID,
+			&i.ParticipantName,
+			&i.RequestedBy,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listCareRelationships = `-- name: ListCareRelationships :many
+SELECT participant_id, caregiver_id, created_at, status, requested_by, approved_by, approved_at FROM care_relationships
+ORDER BY created_at DESC
+`
+
+func (q *Queries) ListCareRelationships(ctx context.Context) ([]CareRelationship, error) {
+	rows, err := q.db.Query(ctx, listCareRelationships)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []CareRelationship
+	for rows.Next() {
+		var i CareRelationship
+		if err := rows.Scan(
+			&i.ParticipantID,
+			&i.CaregiverID,
+			&i.CreatedAt,
+			&i.Status,
+			&i.RequestedBy,
+			&i.ApprovedBy,
+			&i.ApprovedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listCareRelationshipsByCaregiver = `-- name: ListCareRelationshipsByCaregiver :many
+SELECT participant_id, caregiver_id, created_at, status, requested_by, approved_by, approved_at FROM care_relationships
+WHERE caregiver_id = $1
+ORDER BY created_at DESC
+`
+
+func (q *Queries) ListCareRelationshipsByCaregiver(ctx context.Context, caregiverID int32) ([]CareRelationship, error) {
+	rows, err := q.db.Query(ctx, listCareRelationshipsByCaregiver, caregiverID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []CareRelationship
+	for rows.Next() {
+		var i CareRelationship
+		if err := rows.Scan(
+			&i.ParticipantID,
+			&i.CaregiverID,
+			&i.CreatedAt,
+			&i.Status,
+			&i.RequestedBy,
+			&i.ApprovedBy,
+			&i.ApprovedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listCareRelationshipsByParticipant = `-- name: ListCareRelationshipsByParticipant :many
+SELECT participant_id, caregiver_id, created_at, status, requested_by, approved_by, approved_at FROM care_relationships
+WHERE participant_id = $1
+ORDER BY created_at DESC
+`
+
+func (q *Queries) ListCareRelationshipsByParticipant(ctx context.Context, participantID int32) ([]CareRelationship, error) {
+	rows, err := q.db.Query(ctx, listCareRelationshipsByParticipant, participantID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []CareRelationship
+	for rows.Next() {
+		var i CareRelationship
+		if err := rows.Scan(
+			&i.ParticipantID,
+			&i.CaregiverID,
+			&i.CreatedAt,
+			&i.Status,
+			&i.RequestedBy,
+			&i.ApprovedBy,
+			&i.ApprovedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listImpersonationLogs = `-- name: ListImpersonationLogs :many
+SELECT id, staff_id, user_id, method, path, status, created_at FROM impersonation_logs
+ORDER BY created_at DESC
+LIMIT $1
+`
+
+func (q *Queries) ListImpersonationLogs(ctx context.Context, limit int32) ([]ImpersonationLog, error) {
+	rows, err := q.db.Query(ctx, listImpersonationLogs, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ImpersonationLog
+	for rows.Next() {
+		var i ImpersonationLog
+		if err := rows.Scan(
+			&i.ID,
+			&i.StaffID,
+			&i.UserID,
+			&i.Method,
+			&i.Path,
+			&i.Status,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listInvitations = `-- name: ListInvitations :many
+SELECT id, name, email, phone_encrypted, role, channel, token_hash, invited_by, expires_at, sent_at, accepted_at, accepted_user_id, revoked_at, created_at FROM invitations
+ORDER BY created_at DESC
+`
+
+func (q *Queries) ListInvitations(ctx context.Context) ([]Invitation, error) {
+	rows, err := q.db.Query(ctx, listInvitations)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Invitation
+	for rows.Next() {
+		var i Invitation
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.Email,
+			&i.PhoneEncrypted,
+			&i.Role,
+			&i.Channel,
+			&i.TokenHash,
+			&i.InvitedBy,
+			&i.ExpiresAt,
+			&i.SentAt,
+			&i.AcceptedAt,
+			&i.AcceptedUserID,
+			&i.RevokedAt,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listLockedLoginThrottles = `-- name: ListLockedLoginThrottles :many
+SELECT scope, subject, failed_attempts, locked_until, last_failed_at FROM login_throttles
+WHERE locked_until > NOW()
+ORDER BY locked_until DESC
+`
+
+func (q *Queries) ListLockedLoginThrottles(ctx context.Context) ([]LoginThrottle, error) {
+	rows, err := q.db.Query(ctx, listLockedLoginThrottles)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []LoginThrottle
+	for rows.Next() {
+		var i LoginThrottle
+		if err := rows.Scan(
+			&i.Scope,
+			&i.Subject,
+			&i.FailedAttempts,
+			&i.LockedUntil,
+			&i.LastFailedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listMedicalAccessLogs = `-- name: ListMedicalAccessLogs :many
+SELECT l.id, l.viewer_id, v.name AS viewer_name, l.impersonator_id, l.api_key_id, l.activity_id, l.basis, l.created_at
+FROM medical_access_logs l
+LEFT JOIN users v ON v.id = l.viewer_id
+WHERE l.participant_id = $1
+ORDER BY l.created_at DESC, l.id DESC
+`
+
+type ListMedicalAccessLogsRow struct {
+	ID             int32            `json:"id"`
+	ViewerID       pgtype.Int4      `json:"viewer_id"`
+	ViewerName     pgtype.Text      `json:"viewer_name"`
+	ImpersonatorID pgtype.Int4      `json:"impersonator_id"`
+	ApiKeyID       pgtype.Int4      `json:"api_key_id"`
+	ActivityID     pgtype.Int4      `json:"activity_id"`
+	Basis          string           `json:"basis"`
+	CreatedAt      pgtype.Timestamp `json:"created_at"`
+}
+
+func (q *Queries) ListMedicalAccessLogs(ctx context.Context, participantID int32) ([]ListMedicalAccessLogsRow, error) {
+	rows, err := q.db.Query(ctx, listMedicalAccessLogs, participantID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListMedicalAccessLogsRow
+	for rows.Next() {
+		var i ListMedicalAccessLogsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.ViewerID,
+			&i.ViewerName,
+			&i.ImpersonatorID,
+			&i.ApiKeyID,
+			&i.ActivityID,
+			&i.Basis,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listParticipantWaitlistInWeek = `-- name: ListParticipantWaitlistInWeek :many
+-- entries that can still become bookings, oldest first
+SELECT w.id, w.activity_id, w.participant_id, w.requested_by, w.created_at
+FROM booking_waitlist w
+JOIN activities a ON a.id = w.activity_id
+WHERE w.participant_id = $1
+  AND a.status = 'OPEN'
+  AND a.start_time > NOW()
+  AND date_trunc('week', a.start_time) = date_trunc('week', $2::timestamp)
+ORDER BY w.created_at
+`
+
+type ListParticipantWaitlistInWeekParams struct {
+	ParticipantID int32            `json:"participant_id"`
+	WeekOf        pgtype.Timestamp `json:"week_of"`
+}
+
+func (q *Queries) ListParticipantWaitlistInWeek(ctx context.Context, arg ListParticipantWaitlistInWeekParams) ([]BookingWaitlist, error) {
+	rows, err := q.db.Query(ctx, listParticipantWaitlistInWeek,
+		arg.ParticipantID,
+		arg.WeekOf,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []BookingWaitlist
+	for rows.Next() {
+		var i BookingWaitlist
+		if err := rows.Scan(
+			&i.ID,
+			&i.ActivityID,
+			&i.ParticipantID,
+			&i.RequestedBy,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listSessionsByUserID = `-- name: ListSessionsByUserID :many
+SELECT created_at, last_used_at, expires_at, is_revoked, user_agent, ip_address
+FROM sessions
+WHERE user_id = $1
+ORDER BY created_at DESC
+`
+
+type ListSessionsByUserIDRow struct {
+	CreatedAt  pgtype.Timestamp `json:"created_at"`
+	LastUsedAt pgtype.Timestamp `json:"last_used_at"`
+	ExpiresAt  pgtype.Timestamp `json:"expires_at"`
+	IsRevoked  bool             `json:"is_revoked"`
+	UserAgent  pgtype.Text      `json:"user_agent"`
+	IpAddress  pgtype.Text      `json:"ip_address"`
+}
+
+func (q *Queries) ListSessionsByUserID(ctx context.Context, userID int32) ([]ListSessionsByUserIDRow, error) {
+	rows, err := q.db.Query(ctx, listSessionsByUserID, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListSessionsByUserIDRow
+	for rows.Next() {
+		var i ListSessionsByUserIDRow
+		if err := rows.Scan(
+			&i.CreatedAt,
+			&i.LastUsedAt,
+			&i.ExpiresAt,
+			&i.IsRevoked,
+			&i.UserAgent,
+			&i.IpAddress,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listUsersByRole = `-- name: ListUsersByRole :many
+SELECT
+  id, name, phone, email, password, role, created_at, phone_encrypted, phone_index, password_must_change, password_changed_at, status, status_reason, status_changed_at, status_changed_by, language
+FROM
+  users
+WHERE
+  role = $1
+`
+
+func (q *Queries) ListUsersByRole(ctx context.Context, role string) ([]User, error) {
+	rows, err := q.db.Query(ctx, listUsersByRole, role)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []User
+	for rows.Next() {
+		var i User
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.Phone,
+			&i.Email,
+			&i.Password,
+			&i.Role,
+			&i.CreatedAt,
+			&i.PhoneEncrypted,
+			&i.PhoneIndex,
+			&i.PasswordMustChange,
+			&i.PasswordChangedAt,
+			&i.Status,
+			&i.StatusReason,
+			&i.StatusChangedAt,
+			&i.StatusChangedBy,
+			&i.Language,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listUsersByStatus = `-- name: ListUsersByStatus :many
+SELECT id, name, phone, email, password, role, created_at, phone_encrypted, phone_index, password_must_change, password_changed_at, status, status_reason, status_changed_at, status_changed_by, language FROM users
+WHERE status = $1
+ORDER BY created_at
+`
+
+func (q *Queries) ListUsersByStatus(ctx context.Context, status string) ([]User, error) {
+	rows, err := q.db.Query(ctx, listUsersByStatus, status)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []User
+	for rows.Next() {
+		var i User
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.Phone,
+			&i.Email,
+			&i.Password,
+			&i.Role,
+			&i.CreatedAt,
+			&i.PhoneEncrypted,
+			&i.PhoneIndex,
+			&i.PasswordMustChange,
+			&i.PasswordChangedAt,
+			&i.Status,
+			&i.StatusReason,
+			&i.StatusChangedAt,
+			&i.StatusChangedBy,
+			&i.Language,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listUsersWithLegacyPhone = `-- name: ListUsersWithLegacyPhone :many
+SELECT id, email, phone FROM users
+WHERE phone IS NOT NULL AND phone_index IS NULL
+`
+
+type ListUsersWithLegacyPhoneRow struct {
+	ID    int32       `json:"id"`
+	Email string      `json:"email"`
+	Phone interface{} `json:"phone"`
+}
+
+func (q *Queries) ListUsersWithLegacyPhone(ctx context.Context) ([]ListUsersWithLegacyPhoneRow, error) {
+	rows, err := q.db.Query(ctx, listUsersWithLegacyPhone)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListUsersWithLegacyPhoneRow
+	for rows.Next() {
+		var i ListUsersWithLegacyPhoneRow
+		if err := rows.Scan(&i.ID, &i.Email, &i.Phone); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listVolunteerAvailability = `-- name: ListVolunteerAvailability :many
+SELECT id, user_id, weekday, start_time, end_time FROM volunteer_availability
+WHERE user_id = $1
+ORDER BY weekday, start_time
+`
+
+func (q *Queries) ListVolunteerAvailability(ctx context.Context, userID int32) ([]VolunteerAvailability, error) {
+	rows, err := q.db.Query(ctx, listVolunteerAvailability, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []VolunteerAvailability
+	for rows.Next() {
+		var i VolunteerAvailability
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.Weekday,
+			&i.StartTime,
+			&i.EndTime,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listVolunteerCertifications = `-- name: ListVolunteerCertifications :many
+SELECT id, user_id, name, issuer, issued_on, expires_on, created_at FROM volunteer_certifications
+WHERE user_id = $1
+ORDER BY expires_on NULLS LAST, name
+`
+
+func (q *Queries) ListVolunteerCertifications(ctx context.Context, userID int32) ([]VolunteerCertification, error) {
+	rows, err := q.db.Query(ctx, listVolunteerCertifications, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []VolunteerCertification
+	for rows.Next() {
+		var i VolunteerCertification
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.Name,
+			&i.Issuer,
+			&i.IssuedOn,
+			&i.ExpiresOn,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listVolunteerHourEntries = `-- name: ListVolunteerHourEntries :many
+-- attended volunteer shifts plus staff adjustments, dated by the day worked
+SELECT 'activity'::text AS kind, b.id, a.start_time::date AS worked_on, a.title AS description,
+       GREATEST(EXTRACT(EPOCH FROM a.end_time - a.start_time) / 60, 0)::int AS minutes,
+       NULL::int AS created_by
+FROM bookings b
+JOIN activities a ON a.id = b.activity_id
+WHERE b.user_id = $1
+  AND b.role = 'volunteer'
+  AND b.attendance_status = 'PRESENT'
+  AND b.cancelled_at IS NULL
+  AND a.start_time >= $2::date
+  AND a.start_time < $3::date
+UNION ALL
+SELECT 'adjustment'::text, h.id, h.worked_on, h.reason, h.minutes, h.created_by
+FROM volunteer_hour_adjustments h
+WHERE h.user_id = $1
+  AND h.worked_on >= $2::date
+  AND h.worked_on < $3::date
+ORDER BY worked_on, kind, id
+`
+
+type ListVolunteerHourEntriesParams struct {
+	UserID    int32       `json:"user_id"`
+	FromDate  pgtype.Date `json:"from_date"`
+	UntilDate pgtype.Date `json:"until_date"`
+}
+
+type ListVolunteerHourEntriesRow struct {
+	Kind        string      `json:"kind"`
+	ID          int32       `json:"id"`
+	WorkedOn    pgtype.Date `json:"worked_on"`
+	Description string      `json:"description"`
+	Minutes     int32       `json:"minutes"`
+	CreatedBy   pgtype.Int4 `json:"created_by"`
+}
+
+func (q *Queries) ListVolunteerHourEntries(ctx context.Context, arg ListVolunteerHourEntriesParams) ([]ListVolunteerHourEntriesRow, error) {
+	rows, err := q.db.Query(ctx, listVolunteerHourEntries,
+		arg.UserID,
+		arg.FromDate,
+		arg.UntilDate,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListVolunteerHourEntriesRow
+	for rows.Next() {
+		var i ListVolunteerHourEntriesRow
+		if err := rows.Scan(
+			&i.Kind,
+			&i.ID,
+			&i.WorkedOn,
+			&i.Description,
+			&i.Minutes,
+			&i.CreatedBy,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listVolunteerHourTotals = `-- name: ListVolunteerHourTotals :many
+SELECT u.id AS user_id, u.name, SUM(e.minutes)::bigint AS minutes,
+       COUNT(*) FILTER (WHERE e.kind = 'activity') AS activities
+FROM (
+    SELECT 'activity'::text AS kind, b.user_id,
+           GREATEST(EXTRACT(EPOCH FROM a.end_time - a.start_time) / 60, 0)::int AS minutes
+    FROM bookings b
+    JOIN activities a ON a.id = b.activity_id
+    WHERE b.role = 'volunteer'
+      AND b.attendance_status = 'PRESENT'
+      AND b.cancelled_at IS NULL
+      AND a.start_time >= $1::date
+      AND a.start_time < $2::date
+    UNION ALL
+    SELECT 'adjustment'::text, h.user_id, h.minutes
+    FROM volunteer_hour_adjustments h
+    WHERE h.worked_on >= $1::date
+      AND h.worked_on < $2::date
+) e
+JOIN users u ON u.id = e.user_id
+GROUP BY u.id, u.name
+ORDER BY minutes DESC, u.name
 `
 
-func (q *Queries) GetAllUsers(ctx context.Context) ([]User, error) {
-	rows, err := q.db.Query(ctx, getAllUsers)
+type ListVolunteerHourTotalsParams struct {
+	FromDate  pgtype.Date `json:"from_date"`
+	UntilDate pgtype.Date `json:"until_date"`
+}
+
+type ListVolunteerHourTotalsRow struct {
+	UserID     int32  `json:"user_id"`
+	Name       string `json:"name"`
+	Minutes    int64  `json:"minutes"`
+	Activities int64  `json:"activities"`
+}
+
+func (q *Queries) ListVolunteerHourTotals(ctx context.Context, arg ListVolunteerHourTotalsParams) ([]ListVolunteerHourTotalsRow, error) {
+	rows, err := q.db.Query(ctx, listVolunteerHourTotals,
+		arg.FromDate,
+		arg.UntilDate,
+	)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []User
+	var items []ListVolunteerHourTotalsRow
 	for rows.Next() {
-		var i User
+		var i ListVolunteerHourTotalsRow
 		if err := rows.Scan(
-			&i.ID,
+			&i.UserID,
 			&i.Name,
-			&i.Phone,
-			&i.Email,
-			&i.Password,
-			&i.Role,
-			&i.CreatedAt,
+			&i.Minutes,
+			&i.Activities,
 		); err != nil {
 			return nil, err
 		}
@@ -332,57 +3340,36 @@ func (q *Queries) GetAllUsers(ctx context.Context) ([]User, error) {
 	return items, nil
 }
 
-const getBookingByID = `-- name: GetBookingByID :one
-SELECT
-  id, activity_id, user_id, booked_for_user_id, role, is_paid, attendance_status, created_at, cancelled_at
-FROM
-  bookings
-WHERE
-  id = $1
+const lockUser = `-- name: LockUser :exec
+-- held until the transaction ends, so quota checks for one participant run one at a time
+SELECT id FROM users WHERE id = $1 FOR UPDATE
 `
 
-func (q *Queries) GetBookingByID(ctx context.Context, id int32) (Booking, error) {
-	row := q.db.QueryRow(ctx, getBookingByID, id)
-	var i Booking
-	err := row.Scan(
-		&i.ID,
-		&i.ActivityID,
-		&i.UserID,
-		&i.BookedForUserID,
-		&i.Role,
-		&i.IsPaid,
-		&i.AttendanceStatus,
-		&i.CreatedAt,
-		&i.CancelledAt,
-	)
-	return i, err
+func (q *Queries) LockUser(ctx context.Context, id int32) error {
+	_, err := q.db.Exec(ctx, lockUser, id)
+	return err
 }
 
-const getSession = `-- name: GetSession :one
-SELECT id, user_id, refresh_token, is_revoked, expires_at, created_at FROM sessions WHERE id = $1
+const reactivateUser = `-- name: ReactivateUser :one
+UPDATE users
+SET status = 'active',
+    status_reason = NULL,
+    status_changed_by = $2,
+    status_changed_at = NOW()
+WHERE id = $1 AND status = 'deactivated'
+RETURNING id, name, phone, email, password, role, created_at, phone_encrypted, phone_index, password_must_change, password_changed_at, status, status_reason, status_changed_at, status_changed_by, language
 `
 
-func (q *Queries) GetSession(ctx context.Context, id string) (Session, error) {
-	row := q.db.QueryRow(ctx, getSession, id)
-	var i Session
-	err := row.Scan(
-		&i.ID,
-		&i.UserID,
-		&i.RefreshToken,
-		&i.IsRevoked,
-		&i.ExpiresAt,
-		&i.CreatedAt,
-	)
-	return i, err
+type ReactivateUserParams struct {
+	ID              int32       `json:"id"`
+	StatusChangedBy pgtype.Int4 `json:"status_changed_by"`
 }
 
-const getUserByEmail = `-- name: GetUserByEmail :one
-SELECT id, name, phone, email, password, role, created_at FROM users
-WHERE email = $1
-`
-
-func (q *Queries) GetUserByEmail(ctx context.Context, email string) (User, error) {
-	row := q.db.QueryRow(ctx, getUserByEmail, email)
+func (q *Queries) ReactivateUser(ctx context.Context, arg ReactivateUserParams) (User, error) {
+	row := q.db.QueryRow(ctx, reactivateUser,
+		arg.ID,
+		arg.StatusChangedBy,
+	)
 	var i User
 	err := row.Scan(
 		&i.ID,
@@ -392,17 +3379,80 @@ func (q *Queries) GetUserByEmail(ctx context.Context, email string) (User, error
 		&i.Password,
 		&i.Role,
 		&i.CreatedAt,
+		&i.PhoneEncrypted,
+		&i.PhoneIndex,
+		&i.PasswordMustChange,
+		&i.PasswordChangedAt,
+		&i.Status,
+		&i.StatusReason,
+		&i.StatusChangedAt,
+		&i.StatusChangedBy,
+		&i.Language,
 	)
 	return i, err
 }
 
-const getUserByID = `-- name: GetUserByID :one
-SELECT id, name, phone, email, password, role, created_at FROM users
-WHERE id = $1
+const recordLoginFailure = `-- name: RecordLoginFailure :one
+INSERT INTO login_throttles (scope, subject, failed_attempts, last_failed_at)
+VALUES ($1, $2, 1, NOW())
+ON CONFLICT (scope, subject) DO UPDATE
+SET
+  failed_attempts = CASE
+    WHEN login_throttles.last_failed_at < NOW() - INTERVAL '24 hours' THEN 1
+    ELSE login_throttles.failed_attempts + 1
+  END,
+  last_failed_at = NOW()
+RETURNING scope, subject, failed_attempts, locked_until, last_failed_at
 `
 
-func (q *Queries) GetUserByID(ctx context.Context, id int32) (User, error) {
-	row := q.db.QueryRow(ctx, getUserByID, id)
+type RecordLoginFailureParams struct {
+	Scope   string `json:"scope"`
+	Subject string `json:"subject"`
+}
+
+func (q *Queries) RecordLoginFailure(ctx context.Context, arg RecordLoginFailureParams) (LoginThrottle, error) {
+	row := q.db.QueryRow(ctx, recordLoginFailure, arg.Scope, arg.Subject)
+	var i LoginThrottle
+	err := row.Scan(
+		&i.Scope,
+		&i.Subject,
+		&i.FailedAttempts,
+		&i.LockedUntil,
+		&i.LastFailedAt,
+	)
+	return i, err
+}
+
+const registerUser = `-- name: RegisterUser :one
+INSERT INTO users (
+    name,
+    phone_encrypted,
+    phone_index,
+    email,
+    role,
+    status
+) VALUES (
+    $1, $2, $3, $4, $5, 'pending'
+)
+RETURNING id, name, phone, email, password, role, created_at, phone_encrypted, phone_index, password_must_change, password_changed_at, status, status_reason, status_changed_at, status_changed_by, language
+`
+
+type RegisterUserParams struct {
+	Name           string      `json:"name"`
+	PhoneEncrypted []byte      `json:"phone_encrypted"`
+	PhoneIndex     pgtype.Text `json:"phone_index"`
+	Email          string      `json:"email"`
+	Role           string      `json:"role"`
+}
+
+func (q *Queries) RegisterUser(ctx context.Context, arg RegisterUserParams) (User, error) {
+	row := q.db.QueryRow(ctx, registerUser,
+		arg.Name,
+		arg.PhoneEncrypted,
+		arg.PhoneIndex,
+		arg.Email,
+		arg.Role,
+	)
 	var i User
 	err := row.Scan(
 		&i.ID,
@@ -412,49 +3462,78 @@ func (q *Queries) GetUserByID(ctx context.Context, id int32) (User, error) {
 		&i.Password,
 		&i.Role,
 		&i.CreatedAt,
+		&i.PhoneEncrypted,
+		&i.PhoneIndex,
+		&i.PasswordMustChange,
+		&i.PasswordChangedAt,
+		&i.Status,
+		&i.StatusReason,
+		&i.StatusChangedAt,
+		&i.StatusChangedBy,
+		&i.Language,
 	)
 	return i, err
 }
 
-const getUserByNameAndPhone = `-- name: GetUserByNameAndPhone :one
-SELECT id, name, phone, email, role
-FROM users
-WHERE LOWER(name) = LOWER($1) AND phone = $2
+const renewInvitationToken = `-- name: RenewInvitationToken :one
+UPDATE invitations
+SET token_hash = $2, expires_at = $3, sent_at = NOW()
+WHERE id = $1 AND accepted_at IS NULL AND revoked_at IS NULL
+RETURNING id, name, email, phone_encrypted, role, channel, token_hash, invited_by, expires_at, sent_at, accepted_at, accepted_user_id, revoked_at, created_at
 `
 
-type GetUserByNameAndPhoneParams struct {
-	Lower string      `json:"lower"`
-	Phone interface{} `json:"phone"`
-}
-
-type GetUserByNameAndPhoneRow struct {
-	ID    int32       `json:"id"`
-	Name  string      `json:"name"`
-	Phone interface{} `json:"phone"`
-	Email string      `json:"email"`
-	Role  string      `json:"role"`
+type RenewInvitationTokenParams struct {
+	ID        int32            `json:"id"`
+	TokenHash string           `json:"token_hash"`
+	ExpiresAt pgtype.Timestamp `json:"expires_at"`
 }
 
-func (q *Queries) GetUserByNameAndPhone(ctx context.Context, arg GetUserByNameAndPhoneParams) (GetUserByNameAndPhoneRow, error) {
-	row := q.db.QueryRow(ctx, getUserByNameAndPhone, arg.Lower, arg.Phone)
-	var i GetUserByNameAndPhoneRow
+func (q *Queries) RenewInvitationToken(ctx context.Context, arg RenewInvitationTokenParams) (Invitation, error) {
+	row := q.db.QueryRow(ctx, renewInvitationToken, arg.ID, arg.TokenHash, arg.ExpiresAt)
+	var i Invitation
 	err := row.Scan(
 		&i.ID,
 		&i.Name,
-		&i.Phone,
 		&i.Email,
+		&i.PhoneEncrypted,
 		&i.Role,
+		&i.Channel,
+		&i.TokenHash,
+		&i.InvitedBy,
+		&i.ExpiresAt,
+		&i.SentAt,
+		&i.AcceptedAt,
+		&i.AcceptedUserID,
+		&i.RevokedAt,
+		&i.CreatedAt,
 	)
 	return i, err
 }
 
-const getUserByPhone = `-- name: GetUserByPhone :one
-SELECT id, name, phone, email, password, role, created_at FROM users
-WHERE phone = $1
+const reviewUserRegistration = `-- name: ReviewUserRegistration :one
+UPDATE users
+SET status = $2,
+    status_reason = $3,
+    status_changed_by = $4,
+    status_changed_at = NOW()
+WHERE id = $1 AND status = 'pending'
+RETURNING id, name, phone, email, password, role, created_at, phone_encrypted, phone_index, password_must_change, password_changed_at, status, status_reason, status_changed_at, status_changed_by, language
 `
 
-func (q *Queries) GetUserByPhone(ctx context.Context, phone interface{}) (User, error) {
-	row := q.db.QueryRow(ctx, getUserByPhone, phone)
+type ReviewUserRegistrationParams struct {
+	ID              int32       `json:"id"`
+	Status          string      `json:"status"`
+	StatusReason    pgtype.Text `json:"status_reason"`
+	StatusChangedBy pgtype.Int4 `json:"status_changed_by"`
+}
+
+func (q *Queries) ReviewUserRegistration(ctx context.Context, arg ReviewUserRegistrationParams) (User, error) {
+	row := q.db.QueryRow(ctx, reviewUserRegistration,
+		arg.ID,
+		arg.Status,
+		arg.StatusReason,
+		arg.StatusChangedBy,
+	)
 	var i User
 	err := row.Scan(
 		&i.ID,
@@ -464,217 +3543,263 @@ func (q *Queries) GetUserByPhone(ctx context.Context, phone interface{}) (User,
 		&i.Password,
 		&i.Role,
 		&i.CreatedAt,
+		&i.PhoneEncrypted,
+		&i.PhoneIndex,
+		&i.PasswordMustChange,
+		&i.PasswordChangedAt,
+		&i.Status,
+		&i.StatusReason,
+		&i.StatusChangedAt,
+		&i.StatusChangedBy,
+		&i.Language,
 	)
 	return i, err
 }
 
-const listActivities = `-- name: ListActivities :many
-SELECT
-  id, title, description, venue, start_time, end_time, signup_deadline, participant_capacity, volunteer_capacity, wheelchair_accessible, sign_language_available, requires_payment, status, created_by, created_at 
-FROM
-  activities
-`
-
-func (q *Queries) ListActivities(ctx context.Context) ([]Activity, error) {
-	rows, err := q.db.Query(ctx, listActivities)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-	var items []Activity
-	for rows.Next() {
-		var i Activity
-		if err := rows.Scan(
-			&i.ID,
-			&i.Title,
-			&i.Description,
-			&i.Venue,
-			&i.StartTime,
-			&i.EndTime,
-			&i.SignupDeadline,
-			&i.ParticipantCapacity,
-			&i.VolunteerCapacity,
-			&i.WheelchairAccessible,
-			&i.SignLanguageAvailable,
-			&i.RequiresPayment,
-			&i.Status,
-			&i.CreatedBy,
-			&i.CreatedAt,
-		); err != nil {
-			return nil, err
-		}
-		items = append(items, i)
-	}
-	if err := rows.Err(); err != nil {
-		return nil, err
-	}
-	return items, nil
-}
-
-const listActivitiesWithCounts = `-- name: ListActivitiesWithCounts :many
-SELECT
-  a.id,
-  a.title,
-  a.description,
-  a.venue,
-  a.start_time,
-  a.end_time,
-  a.signup_deadline,
-  a.participant_capacity,
-  a.volunteer_capacity,
-  COUNT(b.id) AS registered_participants_count
-FROM activities a
-LEFT JOIN bookings b
-  ON b.activity_id = a.id
-  AND b.role = 'participant'
-`
-
-type ListActivitiesWithCountsRow struct {
-	ID                          int32            `json:"id"`
-	Title                       string           `json:"title"`
-	Description                 interface{}      `json:"description"`
-	Venue                       string           `json:"venue"`
-	StartTime                   pgtype.Timestamp `json:"start_time"`
-	EndTime                     pgtype.Timestamp `json:"end_time"`
-	SignupDeadline              pgtype.Timestamp `json:"signup_deadline"`
-	ParticipantCapacity         int32            `json:"participant_capacity"`
-	VolunteerCapacity           int32            `json:"volunteer_capacity"`
-	RegisteredParticipantsCount int64            `json:"registered_participants_count"`
-}
-
-func (q *Queries) ListActivitiesWithCounts(ctx context.Context) ([]ListActivitiesWithCountsRow, error) {
-	rows, err := q.db.Query(ctx, listActivitiesWithCounts)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-	var items []ListActivitiesWithCountsRow
-	for rows.Next() {
-		var i ListActivitiesWithCountsRow
-		if err := rows.Scan(
-			&i.ID,
-			&i.Title,
-			&i.Description,
-			&i.Venue,
-			&i.StartTime,
-			&i.EndTime,
-			&i.SignupDeadline,
-			&i.ParticipantCapacity,
-			&i.VolunteerCapacity,
-			&i.RegisteredParticipantsCount,
-		); err != nil {
-			return nil, err
-		}
-		items = append(items, i)
-	}
-	if err := rows.Err(); err != nil {
-		return nil, err
-	}
-	return items, nil
-}
-
-const listBookings = `-- name: ListBookings :many
-SELECT
-  id, activity_id, user_id, booked_for_user_id, role, is_paid, attendance_status, created_at, cancelled_at 
-FROM
-  bookings
+const revokeAPIKey = `-- name: RevokeAPIKey :execrows
+UPDATE api_keys
+SET revoked_at = NOW()
+WHERE id = $1 AND revoked_at IS NULL
 `
 
-func (q *Queries) ListBookings(ctx context.Context) ([]Booking, error) {
-	rows, err := q.db.Query(ctx, listBookings)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-	var items []Booking
-	for rows.Next() {
-		var i Booking
-		if err := rows.Scan(
-			&i.ID,
-			&i.ActivityID,
-			&i.UserID,
-			&i.BookedForUserID,
-			&i.Role,
-			&i.IsPaid,
-			&i.AttendanceStatus,
-			&i.CreatedAt,
-			&i.CancelledAt,
-		); err != nil {
-			return nil, err
-		}
-		items = append(items, i)
-	}
-	if err := rows.Err(); err != nil {
-		return nil, err
+func (q *Queries) RevokeAPIKey(ctx context.Context, id int32) (int64, error) {
+	result, err := q.db.Exec(ctx, revokeAPIKey, id)
+	if err != nil {
+		return 0, err
 	}
-	return items, nil
+	return result.RowsAffected(), nil
 }
 
-const listBookingsByActivityID = `-- name: ListBookingsByActivityID :many
-SELECT
-  id, activity_id, user_id, booked_for_user_id, role, is_paid, attendance_status, created_at, cancelled_at
-FROM
-  bookings
-WHERE
-  activity_id = $1
+const revokeInvitation = `-- name: RevokeInvitation :execrows
+UPDATE invitations
+SET revoked_at = NOW()
+WHERE id = $1 AND accepted_at IS NULL AND revoked_at IS NULL
 `
 
-func (q *Queries) ListBookingsByActivityID(ctx context.Context, activityID int32) ([]Booking, error) {
-	rows, err := q.db.Query(ctx, listBookingsByActivityID, activityID)
+func (q *Queries) RevokeInvitation(ctx context.Context, id int32) (int64, error) {
+	result, err := q.db.Exec(ctx, revokeInvitation, id)
 	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-	var items []Booking
-	for rows.Next() {
-		var i Booking
-		if err := rows.Scan(
-			&i.ID,
-			&i.ActivityID,
-			&i.UserID,
-			&i.BookedForUserID,
-			&i.Role,
-			&i.IsPaid,
-			&i.AttendanceStatus,
-			&i.CreatedAt,
-			&i.CancelledAt,
-		); err != nil {
-			return nil, err
-		}
-		items = append(items, i)
+		return 0, err
 	}
-	if err := rows.Err(); err != nil {
-		return nil, err
+	return result.RowsAffected(), nil
+}
+
+const revokeSession = `-- name: RevokeSession :exec
+UPDATE sessions SET is_revoked = TRUE WHERE id = $1
+`
+
+func (q *Queries) RevokeSession(ctx context.Context, id string) error {
+	_, err := q.db.Exec(ctx, revokeSession, id)
+	return err
+}
+
+const revokeSessionFamily = `-- name: RevokeSessionFamily :exec
+UPDATE sessions SET is_revoked = TRUE WHERE family_id = $1
+`
+
+func (q *Queries) RevokeSessionFamily(ctx context.Context, familyID string) error {
+	_, err := q.db.Exec(ctx, revokeSessionFamily, familyID)
+	return err
+}
+
+const revokeSessionFamilyForUser = `-- name: RevokeSessionFamilyForUser :execrows
+UPDATE sessions SET is_revoked = TRUE
+WHERE family_id = $1 AND user_id = $2 AND is_revoked = FALSE
+`
+
+type RevokeSessionFamilyForUserParams struct {
+	FamilyID string `json:"family_id"`
+	UserID   int32  `json:"user_id"`
+}
+
+func (q *Queries) RevokeSessionFamilyForUser(ctx context.Context, arg RevokeSessionFamilyForUserParams) (int64, error) {
+	result, err := q.db.Exec(ctx, revokeSessionFamilyForUser, arg.FamilyID, arg.UserID)
+	if err != nil {
+		return 0, err
 	}
-	return items, nil
+	return result.RowsAffected(), nil
 }
 
-const listUsersByRole = `-- name: ListUsersByRole :many
-SELECT
-  id, name, phone, email, password, role, created_at
-FROM
-  users
-WHERE
-  role = $1
+const rotateSession = `-- name: RotateSession :one
+UPDATE sessions
+SET
+  is_revoked = TRUE,
+  replaced_by = $2,
+  rotated_at = NOW()
+WHERE id = $1
+  AND is_revoked = FALSE
+  AND rotated_at IS NULL
+RETURNING id, user_id, refresh_token, is_revoked, expires_at, created_at, family_id, replaced_by, rotated_at, user_agent, ip_address, last_used_at
 `
 
-func (q *Queries) ListUsersByRole(ctx context.Context, role string) ([]User, error) {
-	rows, err := q.db.Query(ctx, listUsersByRole, role)
+type RotateSessionParams struct {
+	ID         string      `json:"id"`
+	ReplacedBy pgtype.Text `json:"replaced_by"`
+}
+
+func (q *Queries) RotateSession(ctx context.Context, arg RotateSessionParams) (Session, error) {
+	row := q.db.QueryRow(ctx, rotateSession, arg.ID, arg.ReplacedBy)
+	var i Session
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.RefreshToken,
+		&i.IsRevoked,
+		&i.ExpiresAt,
+		&i.CreatedAt,
+		&i.FamilyID,
+		&i.ReplacedBy,
+		&i.RotatedAt,
+		&i.UserAgent,
+		&i.IpAddress,
+		&i.LastUsedAt,
+	)
+	return i, err
+}
+
+const searchUsers = `-- name: SearchUsers :many
+-- credential columns are deliberately not selected
+SELECT u.id, u.name, u.email, u.role, u.status, u.created_at, u.phone_encrypted,
+       p.membership_type, p.wheelchair, p.sign_language, p.needs_seated_activity,
+       p.sensitive_to_light, p.sensitive_to_noise, v.skills
+FROM users u
+LEFT JOIN participant_profiles p ON p.user_id = u.id
+LEFT JOIN volunteer_profiles v ON v.user_id = u.id
+WHERE ($1::text IS NULL
+       OR u.name ILIKE '%' || $1 || '%'
+       OR u.email ILIKE '%' || $1 || '%')
+  AND ($2::text IS NULL OR u.role = $2)
+  AND ($3::text IS NULL OR u.status = $3)
+  AND ($4::text IS NULL OR p.membership_type = $4)
+  AND ($5::bool IS NULL OR COALESCE(p.wheelchair, FALSE) = $5)
+  AND ($6::bool IS NULL OR COALESCE(p.sign_language, FALSE) = $6)
+  AND ($7::bool IS NULL OR COALESCE(p.needs_seated_activity, FALSE) = $7)
+  AND ($8::bool IS NULL OR COALESCE(p.sensitive_to_light, FALSE) = $8)
+  AND ($9::bool IS NULL OR COALESCE(p.sensitive_to_noise, FALSE) = $9)
+  AND ($10::timestamp IS NULL OR u.created_at >= $10)
+  AND ($11::timestamp IS NULL OR u.created_at < $11)
+  -- volunteers: all of the skills, a preferred venue, a certification valid on a date, a weekly window
+  AND ($12::text[] IS NULL OR v.skills @> $12)
+  AND ($13::text IS NULL OR $13 = ANY(v.preferred_venues))
+  AND ($14::text IS NULL OR EXISTS (
+    SELECT 1 FROM volunteer_certifications c
+    WHERE c.user_id = u.id
+      AND LOWER(c.name) = LOWER($14)
+      AND (c.expires_on IS NULL OR c.expires_on >= $15::date)))
+  AND ($16::smallint IS NULL OR EXISTS (
+    SELECT 1 FROM volunteer_availability a
+    WHERE a.user_id = u.id
+      AND a.weekday = $16
+      AND a.start_time <= $17::time
+      AND a.end_time >= $18::time))
+  -- keyset pagination: rows after the cursor in the chosen order
+  AND ($19::int IS NULL OR CASE $20::text
+    WHEN 'name' THEN (u.name, u.id) > ($21::text, $19::int)
+    WHEN '-name' THEN (u.name, u.id) < ($21::text, $19::int)
+    WHEN 'created_at' THEN (u.created_at, u.id) > ($22::timestamp, $19::int)
+    ELSE (u.created_at, u.id) < ($22::timestamp, $19::int)
+  END)
+ORDER BY
+  CASE WHEN $20::text = 'name' THEN u.name END ASC,
+  CASE WHEN $20::text = '-name' THEN u.name END DESC,
+  CASE WHEN $20::text = 'created_at' THEN u.created_at END ASC,
+  CASE WHEN $20::text = '-created_at' THEN u.created_at END DESC,
+  CASE WHEN $20::text IN ('name', 'created_at') THEN u.id END ASC,
+  u.id DESC
+LIMIT $23
+`
+
+type SearchUsersParams struct {
+	Search              pgtype.Text      `json:"search"`
+	Role                pgtype.Text      `json:"role"`
+	Status              pgtype.Text      `json:"status"`
+	MembershipType      pgtype.Text      `json:"membership_type"`
+	Wheelchair          pgtype.Bool      `json:"wheelchair"`
+	SignLanguage        pgtype.Bool      `json:"sign_language"`
+	NeedsSeatedActivity pgtype.Bool      `json:"needs_seated_activity"`
+	SensitiveToLight    pgtype.Bool      `json:"sensitive_to_light"`
+	SensitiveToNoise    pgtype.Bool      `json:"sensitive_to_noise"`
+	CreatedFrom         pgtype.Timestamp `json:"created_from"`
+	CreatedTo           pgtype.Timestamp `json:"created_to"`
+	Skills              []string         `json:"skills"`
+	Venue               pgtype.Text      `json:"venue"`
+	Certification       pgtype.Text      `json:"certification"`
+	CertifiedOn         pgtype.Date      `json:"certified_on"`
+	AvailableWeekday    pgtype.Int2      `json:"available_weekday"`
+	AvailableFrom       pgtype.Time      `json:"available_from"`
+	AvailableTo         pgtype.Time      `json:"available_to"`
+	AfterID             pgtype.Int4      `json:"after_id"`
+	Sort                string           `json:"sort"`
+	AfterName           pgtype.Text      `json:"after_name"`
+	AfterCreatedAt      pgtype.Timestamp `json:"after_created_at"`
+	PageLimit           int32            `json:"page_limit"`
+}
+
+type SearchUsersRow struct {
+	ID                  int32            `json:"id"`
+	Name                string           `json:"name"`
+	Email               string           `json:"email"`
+	Role                string           `json:"role"`
+	Status              string           `json:"status"`
+	CreatedAt           pgtype.Timestamp `json:"created_at"`
+	PhoneEncrypted      []byte           `json:"phone_encrypted"`
+	MembershipType      pgtype.Text      `json:"membership_type"`
+	Wheelchair          pgtype.Bool      `json:"wheelchair"`
+	SignLanguage        pgtype.Bool      `json:"sign_language"`
+	NeedsSeatedActivity pgtype.Bool      `json:"needs_seated_activity"`
+	SensitiveToLight    pgtype.Bool      `json:"sensitive_to_light"`
+	SensitiveToNoise    pgtype.Bool      `json:"sensitive_to_noise"`
+	Skills              []string         `json:"skills"`
+}
+
+func (q *Queries) SearchUsers(ctx context.Context, arg SearchUsersParams) ([]SearchUsersRow, error) {
+	rows, err := q.db.Query(ctx, searchUsers,
+		arg.Search,
+		arg.Role,
+		arg.Status,
+		arg.MembershipType,
+		arg.Wheelchair,
+		arg.SignLanguage,
+		arg.NeedsSeatedActivity,
+		arg.SensitiveToLight,
+		arg.SensitiveToNoise,
+		arg.CreatedFrom,
+		arg.CreatedTo,
+		arg.Skills,
+		arg.Venue,
+		arg.Certification,
+		arg.CertifiedOn,
+		arg.AvailableWeekday,
+		arg.AvailableFrom,
+		arg.AvailableTo,
+		arg.AfterID,
+		arg.Sort,
+		arg.AfterName,
+		arg.AfterCreatedAt,
+		arg.PageLimit,
+	)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []User
+	var items []SearchUsersRow
 	for rows.Next() {
-		var i User
+		var i SearchUsersRow
 		if err := rows.Scan(
 			&i.ID,
 			&i.Name,
-			&i.Phone,
 			&i.Email,
-			&i.Password,
 			&i.Role,
+			&i.Status,
 			&i.CreatedAt,
+			&i.PhoneEncrypted,
+			&i.MembershipType,
+			&i.Wheelchair,
+			&i.SignLanguage,
+			&i.NeedsSeatedActivity,
+			&i.SensitiveToLight,
+			&i.SensitiveToNoise,
+			&i.Skills,
 		); err != nil {
 			return nil, err
 		}
@@ -686,12 +3811,143 @@ func (q *Queries) ListUsersByRole(ctx context.Context, role string) ([]User, err
 	return items, nil
 }
 
-const revokeSession = `-- name: RevokeSession :exec
-UPDATE sessions SET is_revoked = TRUE WHERE id = $1
+const setActivityVolunteerLead = `-- name: SetActivityVolunteerLead :one
+INSERT INTO activity_volunteer_leads (activity_id, user_id, assigned_by)
+VALUES ($1, $2, $3)
+ON CONFLICT (activity_id) DO UPDATE
+SET user_id = EXCLUDED.user_id,
+    assigned_by = EXCLUDED.assigned_by,
+    assigned_at = NOW()
+RETURNING activity_id, user_id, assigned_by, assigned_at
 `
 
-func (q *Queries) RevokeSession(ctx context.Context, id string) error {
-	_, err := q.db.Exec(ctx, revokeSession, id)
+type SetActivityVolunteerLeadParams struct {
+	ActivityID int32       `json:"activity_id"`
+	UserID     int32       `json:"user_id"`
+	AssignedBy pgtype.Int4 `json:"assigned_by"`
+}
+
+func (q *Queries) SetActivityVolunteerLead(ctx context.Context, arg SetActivityVolunteerLeadParams) (ActivityVolunteerLead, error) {
+	row := q.db.QueryRow(ctx, setActivityVolunteerLead,
+		arg.ActivityID,
+		arg.UserID,
+		arg.AssignedBy,
+	)
+	var i ActivityVolunteerLead
+	err := row.Scan(
+		&i.ActivityID,
+		&i.UserID,
+		&i.AssignedBy,
+		&i.AssignedAt,
+	)
+	return i, err
+}
+
+const setLoginLockout = `-- name: SetLoginLockout :exec
+UPDATE login_throttles
+SET locked_until = $3
+WHERE scope = $1 AND subject = $2
+`
+
+type SetLoginLockoutParams struct {
+	Scope       string           `json:"scope"`
+	Subject     string           `json:"subject"`
+	LockedUntil pgtype.Timestamp `json:"locked_until"`
+}
+
+func (q *Queries) SetLoginLockout(ctx context.Context, arg SetLoginLockoutParams) error {
+	_, err := q.db.Exec(ctx, setLoginLockout, arg.Scope, arg.Subject, arg.LockedUntil)
+	return err
+}
+
+const setUserEmail = `-- name: SetUserEmail :one
+UPDATE users
+SET email = $2
+WHERE id = $1 AND status <> 'anonymised'
+RETURNING id, name, phone, email, password, role, created_at, phone_encrypted, phone_index, password_must_change, password_changed_at, status, status_reason, status_changed_at, status_changed_by, language
+`
+
+type SetUserEmailParams struct {
+	ID    int32  `json:"id"`
+	Email string `json:"email"`
+}
+
+func (q *Queries) SetUserEmail(ctx context.Context, arg SetUserEmailParams) (User, error) {
+	row := q.db.QueryRow(ctx, setUserEmail,
+		arg.ID,
+		arg.Email,
+	)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Phone,
+		&i.Email,
+		&i.Password,
+		&i.Role,
+		&i.CreatedAt,
+		&i.PhoneEncrypted,
+		&i.PhoneIndex,
+		&i.PasswordMustChange,
+		&i.PasswordChangedAt,
+		&i.Status,
+		&i.StatusReason,
+		&i.StatusChangedAt,
+		&i.StatusChangedBy,
+		&i.Language,
+	)
+	return i, err
+}
+
+const setUserPassword = `-- name: SetUserPassword :exec
+UPDATE users
+SET
+  password = $2,
+  password_must_change = $3,
+  password_changed_at = NOW()
+WHERE id = $1
+`
+
+type SetUserPasswordParams struct {
+	ID                 int32       `json:"id"`
+	Password           interface{} `json:"password"`
+	PasswordMustChange bool        `json:"password_must_change"`
+}
+
+func (q *Queries) SetUserPassword(ctx context.Context, arg SetUserPasswordParams) error {
+	_, err := q.db.Exec(ctx, setUserPassword, arg.ID, arg.Password, arg.PasswordMustChange)
+	return err
+}
+
+const setUserPhone = `-- name: SetUserPhone :exec
+UPDATE users
+SET
+  phone_encrypted = $2,
+  phone_index = $3,
+  phone = NULL
+WHERE id = $1
+`
+
+type SetUserPhoneParams struct {
+	ID             int32       `json:"id"`
+	PhoneEncrypted []byte      `json:"phone_encrypted"`
+	PhoneIndex     pgtype.Text `json:"phone_index"`
+}
+
+func (q *Queries) SetUserPhone(ctx context.Context, arg SetUserPhoneParams) error {
+	_, err := q.db.Exec(ctx, setUserPhone, arg.ID, arg.PhoneEncrypted, arg.PhoneIndex)
+	return err
+}
+
+const touchAPIKey = `-- name: TouchAPIKey :exec
+UPDATE api_keys
+SET last_used_at = NOW()
+WHERE id = $1
+  AND (last_used_at IS NULL OR last_used_at < NOW() - INTERVAL '1 minute')
+`
+
+func (q *Queries) TouchAPIKey(ctx context.Context, id int32) error {
+	_, err := q.db.Exec(ctx, touchAPIKey, id)
 	return err
 }
 
@@ -824,9 +4080,10 @@ SET
   role = $4,
   is_paid = $5,
   attendance_status = $6,
-  cancelled_at = $7
+  cancelled_at = $7,
+  quota_override_by = $9
 WHERE id = $8
-RETURNING id, activity_id, user_id, booked_for_user_id, role, is_paid, attendance_status, created_at, cancelled_at
+RETURNING id, activity_id, user_id, booked_for_user_id, role, is_paid, attendance_status, created_at, cancelled_at, created_by_staff_id, quota_override_by
 `
 
 type UpdateBookingParams struct {
@@ -838,6 +4095,7 @@ type UpdateBookingParams struct {
 	AttendanceStatus pgtype.Text      `json:"attendance_status"`
 	CancelledAt      pgtype.Timestamp `json:"cancelled_at"`
 	ID               int32            `json:"id"`
+	QuotaOverrideBy  pgtype.Int4      `json:"quota_override_by"`
 }
 
 func (q *Queries) UpdateBooking(ctx context.Context, arg UpdateBookingParams) (Booking, error) {
@@ -850,6 +4108,7 @@ func (q *Queries) UpdateBooking(ctx context.Context, arg UpdateBookingParams) (B
 		arg.AttendanceStatus,
 		arg.CancelledAt,
 		arg.ID,
+		arg.QuotaOverrideBy,
 	)
 	var i Booking
 	err := row.Scan(
@@ -862,6 +4121,283 @@ func (q *Queries) UpdateBooking(ctx context.Context, arg UpdateBookingParams) (B
 		&i.AttendanceStatus,
 		&i.CreatedAt,
 		&i.CancelledAt,
+		&i.CreatedByStaffID,
+		&i.QuotaOverrideBy,
+	)
+	return i, err
+}
+
+const updateParticipantProfile = `-- name: UpdateParticipantProfile :one
+UPDATE participant_profiles
+SET age = $2,
+    membership_type = $3,
+    wheelchair = $4,
+    sign_language = $5,
+    other_need = $6,
+    needs_seated_activity = $7,
+    sensitive_to_light = $8,
+    sensitive_to_noise = $9,
+    updated_at = NOW()
+WHERE user_id = $1
+RETURNING user_id, age, membership_type, wheelchair, sign_language, other_need, created_at, needs_seated_activity, sensitive_to_light, sensitive_to_noise, updated_at
+`
+
+type UpdateParticipantProfileParams struct {
+	UserID              int32       `json:"user_id"`
+	Age                 pgtype.Int4 `json:"age"`
+	MembershipType      pgtype.Text `json:"membership_type"`
+	Wheelchair          bool        `json:"wheelchair"`
+	SignLanguage        bool        `json:"sign_language"`
+	OtherNeed           pgtype.Text `json:"other_need"`
+	NeedsSeatedActivity bool        `json:"needs_seated_activity"`
+	SensitiveToLight    bool        `json:"sensitive_to_light"`
+	SensitiveToNoise    bool        `json:"sensitive_to_noise"`
+}
+
+func (q *Queries) UpdateParticipantProfile(ctx context.Context, arg UpdateParticipantProfileParams) (ParticipantProfile, error) {
+	row := q.db.QueryRow(ctx, updateParticipantProfile,
+		arg.UserID,
+		arg.Age,
+		arg.MembershipType,
+		arg.Wheelchair,
+		arg.SignLanguage,
+		arg.OtherNeed,
+		arg.NeedsSeatedActivity,
+		arg.SensitiveToLight,
+		arg.SensitiveToNoise,
+	)
+	var i ParticipantProfile
+	err := row.Scan(
+		&i.UserID,
+		&i.Age,
+		&i.MembershipType,
+		&i.Wheelchair,
+		&i.SignLanguage,
+		&i.OtherNeed,
+		&i.CreatedAt,
+		&i.NeedsSeatedActivity,
+		&i.SensitiveToLight,
+		&i.SensitiveToNoise,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const updateUserDetails = `-- name: UpdateUserDetails :one
+-- null leaves a field as it is; a new phone also clears the legacy bcrypt one
+UPDATE users
+SET name = COALESCE($1, name),
+    language = COALESCE($2, language),
+    phone_encrypted = CASE WHEN $3::bool THEN $4::bytea ELSE phone_encrypted END,
+    phone_index = CASE WHEN $3::bool THEN $5::text ELSE phone_index END,
+    phone = CASE WHEN $3::bool THEN NULL ELSE phone END
+WHERE id = $6
+RETURNING id, name, phone, email, password, role, created_at, phone_encrypted, phone_index, password_must_change, password_changed_at, status, status_reason, status_changed_at, status_changed_by, language
+`
+
+type UpdateUserDetailsParams struct {
+	Name           pgtype.Text `json:"name"`
+	Language       pgtype.Text `json:"language"`
+	SetPhone       bool        `json:"set_phone"`
+	PhoneEncrypted []byte      `json:"phone_encrypted"`
+	PhoneIndex     pgtype.Text `json:"phone_index"`
+	ID             int32       `json:"id"`
+}
+
+func (q *Queries) UpdateUserDetails(ctx context.Context, arg UpdateUserDetailsParams) (User, error) {
+	row := q.db.QueryRow(ctx, updateUserDetails,
+		arg.Name,
+		arg.Language,
+		arg.SetPhone,
+		arg.PhoneEncrypted,
+		arg.PhoneIndex,
+		arg.ID,
+	)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Phone,
+		&i.Email,
+		&i.Password,
+		&i.Role,
+		&i.CreatedAt,
+		&i.PhoneEncrypted,
+		&i.PhoneIndex,
+		&i.PasswordMustChange,
+		&i.PasswordChangedAt,
+		&i.Status,
+		&i.StatusReason,
+		&i.StatusChangedAt,
+		&i.StatusChangedBy,
+		&i.Language,
+	)
+	return i, err
+}
+
+const upsertCareLinkCode = `-- name: UpsertCareLinkCode :exec
+INSERT INTO care_link_codes (
+  participant_id, code_hash, expires_at
+) VALUES (
+  $1, $2, $3
+)
+ON CONFLICT (participant_id) DO UPDATE
+SET code_hash = EXCLUDED.code_hash,
+    expires_at = EXCLUDED.expires_at,
+    created_at = NOW()
+`
+
+type UpsertCareLinkCodeParams struct {
+	ParticipantID int32            `json:"participant_id"`
+	CodeHash      string           `json:"code_hash"`
+	ExpiresAt     pgtype.Timestamp `json:"expires_at"`
+}
+
+func (q *Queries) UpsertCareLinkCode(ctx context.Context, arg UpsertCareLinkCodeParams) error {
+	_, err := q.db.Exec(ctx, upsertCareLinkCode,
+		arg.ParticipantID,
+		arg.CodeHash,
+		arg.ExpiresAt,
+	)
+	return err
+}
+
+const upsertParticipantMedical = `-- name: UpsertParticipantMedical :one
+INSERT INTO participant_medical (user_id, emergency_contacts_encrypted, medical_notes_encrypted, updated_by)
+VALUES ($1, $2, $3, $4)
+ON CONFLICT (user_id) DO UPDATE
+SET emergency_contacts_encrypted = EXCLUDED.emergency_contacts_encrypted,
+    medical_notes_encrypted = EXCLUDED.medical_notes_encrypted,
+    updated_by = EXCLUDED.updated_by,
+    updated_at = NOW()
+RETURNING user_id, emergency_contacts_encrypted, medical_notes_encrypted, updated_by, updated_at
+`
+
+type UpsertParticipantMedicalParams struct {
+	UserID                     int32       `json:"user_id"`
+	EmergencyContactsEncrypted []byte      `json:"emergency_contacts_encrypted"`
+	MedicalNotesEncrypted      []byte      `json:"medical_notes_encrypted"`
+	UpdatedBy                  pgtype.Int4 `json:"updated_by"`
+}
+
+func (q *Queries) UpsertParticipantMedical(ctx context.Context, arg UpsertParticipantMedicalParams) (ParticipantMedical, error) {
+	row := q.db.QueryRow(ctx, upsertParticipantMedical,
+		arg.UserID,
+		arg.EmergencyContactsEncrypted,
+		arg.MedicalNotesEncrypted,
+		arg.UpdatedBy,
+	)
+	var i ParticipantMedical
+	err := row.Scan(
+		&i.UserID,
+		&i.EmergencyContactsEncrypted,
+		&i.MedicalNotesEncrypted,
+		&i.UpdatedBy,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const upsertUserTOTP = `-- name: UpsertUserTOTP :one
+INSERT INTO user_totp (
+  user_id, secret_encrypted
+) VALUES (
+  $1, $2
+)
+ON CONFLICT (user_id) DO UPDATE
+SET secret_encrypted = EXCLUDED.secret_encrypted,
+    confirmed_at = NULL,
+    last_used_step = 0,
+    created_at = NOW()
+WHERE user_totp.confirmed_at IS NULL
+RETURNING user_id, secret_encrypted, confirmed_at, last_used_step, created_at
+`
+
+type UpsertUserTOTPParams struct {
+	UserID          int32  `json:"user_id"`
+	SecretEncrypted []byte `json:"secret_encrypted"`
+}
+
+func (q *Queries) UpsertUserTOTP(ctx context.Context, arg UpsertUserTOTPParams) (UserTotp, error) {
+	row := q.db.QueryRow(ctx, upsertUserTOTP, arg.UserID, arg.SecretEncrypted)
+	var i UserTotp
+	err := row.Scan(
+		&i.UserID,
+		&i.SecretEncrypted,
+		&i.ConfirmedAt,
+		&i.LastUsedStep,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const upsertVolunteerProfile = `-- name: UpsertVolunteerProfile :one
+INSERT INTO volunteer_profiles (user_id, skills, preferred_venues)
+VALUES ($1, $2, $3)
+ON CONFLICT (user_id) DO UPDATE
+SET skills = EXCLUDED.skills,
+    preferred_venues = EXCLUDED.preferred_venues,
+    updated_at = NOW()
+RETURNING user_id, skills, preferred_venues, created_at, updated_at
+`
+
+type UpsertVolunteerProfileParams struct {
+	UserID          int32    `json:"user_id"`
+	Skills          []string `json:"skills"`
+	PreferredVenues []string `json:"preferred_venues"`
+}
+
+func (q *Queries) UpsertVolunteerProfile(ctx context.Context, arg UpsertVolunteerProfileParams) (VolunteerProfile, error) {
+	row := q.db.QueryRow(ctx, upsertVolunteerProfile,
+		arg.UserID,
+		arg.Skills,
+		arg.PreferredVenues,
+	)
+	var i VolunteerProfile
+	err := row.Scan(
+		&i.UserID,
+		&i.Skills,
+		&i.PreferredVenues,
+		&i.CreatedAt,
+		&i.UpdatedAt,
 	)
 	return i, err
 }
+
+const useRecoveryCode = `-- name: UseRecoveryCode :execrows
+UPDATE recovery_codes
+SET used_at = NOW()
+WHERE user_id = $1 AND code_hash = $2 AND used_at IS NULL
+`
+
+type UseRecoveryCodeParams struct {
+	UserID   int32  `json:"user_id"`
+	CodeHash string `json:"code_hash"`
+}
+
+func (q *Queries) UseRecoveryCode(ctx context.Context, arg UseRecoveryCodeParams) (int64, error) {
+	result, err := q.db.Exec(ctx, useRecoveryCode, arg.UserID, arg.CodeHash)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const useTOTPStep = `-- name: UseTOTPStep :execrows
+UPDATE user_totp
+SET last_used_step = $2
+WHERE user_id = $1 AND last_used_step < $2
+`
+
+type UseTOTPStepParams struct {
+	UserID       int32 `json:"user_id"`
+	LastUsedStep int64 `json:"last_used_step"`
+}
+
+func (q *Queries) UseTOTPStep(ctx context.Context, arg UseTOTPStepParams) (int64, error) {
+	result, err := q.db.Exec(ctx, useTOTPStep, arg.UserID, arg.LastUsedStep)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
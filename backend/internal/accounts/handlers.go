@@ -0,0 +1,238 @@
+package accounts
+
+import (
+	"archive/zip"
+	stdjson "encoding/json"
+	"errors"
+	"fmt"
+	repo "hack4good-backend/db/sqlc"
+	"hack4good-backend/internal/auth"
+	"hack4good-backend/internal/json"
+	"hack4good-backend/internal/medical"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+)
+
+type Handler struct {
+	service Service
+}
+
+func NewHandler(service Service) *Handler {
+	return &Handler{
+		service: service,
+	}
+}
+
+func userIDParam(w http.ResponseWriter, r *http.Request) (int32, bool) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "invalid user id", http.StatusBadRequest)
+		return 0, false
+	}
+	return int32(id), true
+}
+
+func writeError(w http.ResponseWriter, err error, msg string) {
+	switch {
+	case errors.Is(err, ErrUserNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, ErrNotActive), errors.Is(err, ErrNotDeactivated), errors.Is(err, ErrAlreadyAnonymised):
+		http.Error(w, err.Error(), http.StatusConflict)
+	case errors.Is(err, ErrOwnAccount):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	default:
+		log.Println(err)
+		http.Error(w, msg, http.StatusInternalServerError)
+	}
+}
+
+func toAccount(u repo.User) Account {
+	a := Account{
+		ID:              u.ID,
+		Name:            u.Name,
+		Email:           u.Email,
+		Role:            u.Role,
+		Status:          u.Status,
+		StatusChangedAt: timePtr(u.StatusChangedAt),
+	}
+	if u.StatusReason.Valid {
+		a.StatusReason = &u.StatusReason.String
+	}
+	return a
+}
+
+// Deactivate user (by staff): blocks login, keeps history
+func (h *Handler) Deactivate(w http.ResponseWriter, r *http.Request) {
+	claims, ok := auth.FromContext(r.Context())
+	if !ok {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	id, ok := userIDParam(w, r)
+	if !ok {
+		return
+	}
+
+	var req DeactivateRequest
+	if r.ContentLength != 0 {
+		if err := json.Read(r, &req); err != nil {
+			http.Error(w, "invalid request body", http.StatusBadRequest)
+			return
+		}
+	}
+
+	user, err := h.service.Deactivate(r.Context(), id, claims.ID, req.Reason)
+	if err != nil {
+		writeError(w, err, "failed to deactivate user")
+		return
+	}
+
+	json.Write(w, http.StatusOK, toAccount(user))
+}
+
+// Reactivate a deactivated user (by staff)
+func (h *Handler) Reactivate(w http.ResponseWriter, r *http.Request) {
+	claims, ok := auth.FromContext(r.Context())
+	if !ok {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	id, ok := userIDParam(w, r)
+	if !ok {
+		return
+	}
+
+	user, err := h.service.Reactivate(r.Context(), id, claims.ID)
+	if err != nil {
+		writeError(w, err, "failed to reactivate user")
+		return
+	}
+
+	json.Write(w, http.StatusOK, toAccount(user))
+}
+
+// Anonymise user (by staff): irreversible, bookings are kept for statistics
+func (h *Handler) Anonymise(w http.ResponseWriter, r *http.Request) {
+	claims, ok := auth.FromContext(r.Context())
+	if !ok {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	id, ok := userIDParam(w, r)
+	if !ok {
+		return
+	}
+
+	user, err := h.service.Anonymise(r.Context(), id, claims.ID)
+	if err != nil {
+		writeError(w, err, "failed to anonymise user")
+		return
+	}
+
+	json.Write(w, http.StatusOK, toAccount(user))
+}
+
+// Export a user's personal data (by staff), ?format=json|zip
+func (h *Handler) ExportUser(w http.ResponseWriter, r *http.Request) {
+	id, ok := userIDParam(w, r)
+	if !ok {
+		return
+	}
+	h.export(w, r, id, medical.BasisAny)
+}
+
+// Export the signed-in user's personal data, ?format=json|zip
+func (h *Handler) ExportMe(w http.ResponseWriter, r *http.Request) {
+	claims, ok := auth.FromContext(r.Context())
+	if !ok {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	h.export(w, r, claims.ID, medical.BasisOwn)
+}
+
+// basis is why the caller may read the medical details in the export
+func (h *Handler) export(w http.ResponseWriter, r *http.Request, userID int32, basis string) {
+	claims, ok := auth.FromContext(r.Context())
+	if !ok {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	format := r.URL.Query().Get("format")
+	if format != "" && format != "json" && format != "zip" {
+		http.Error(w, "format must be json or zip", http.StatusBadRequest)
+		return
+	}
+
+	export, err := h.service.Export(r.Context(), userID, medical.Access{
+		ViewerID:       claims.ID,
+		ImpersonatorID: claims.ImpersonatorID,
+		APIKeyID:       claims.APIKeyID,
+		Basis:          basis,
+	})
+	if err != nil {
+		writeError(w, err, "failed to export user data")
+		return
+	}
+
+	name := fmt.Sprintf("user-%d-export", userID)
+	if format != "zip" {
+		w.Header().Set("Content-Disposition", `attachment; filename="`+name+`.json"`)
+		json.Write(w, http.StatusOK, export)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", `attachment; filename="`+name+`.zip"`)
+	if err := writeZip(w, export); err != nil {
+		// headers are already sent, so all we can do is log
+		log.Println(err)
+	}
+}
+
+// one JSON file per section
+func writeZip(w http.ResponseWriter, export Export) error {
+	type file struct {
+		name string
+		data any
+	}
+	files := []file{{"account.json", export.Account}}
+	if export.Profile != nil {
+		files = append(files, file{"participant_profile.json", export.Profile})
+	}
+	if export.Volunteer != nil {
+		files = append(files, file{"volunteer_profile.json", export.Volunteer})
+	}
+	files = append(files,
+		file{"care_links.json", export.CareLinks},
+		file{"bookings.json", export.Bookings},
+		file{"sessions.json", export.Sessions},
+	)
+	if export.Medical != nil {
+		files = append(files, file{"medical.json", export.Medical})
+	}
+	if export.MedicalAccessLog != nil {
+		files = append(files, file{"medical_access_log.json", export.MedicalAccessLog})
+	}
+
+	zw := zip.NewWriter(w)
+	for _, f := range files {
+		fw, err := zw.CreateHeader(&zip.FileHeader{
+			Name:     f.name,
+			Method:   zip.Deflate,
+			Modified: export.ExportedAt,
+		})
+		if err != nil {
+			return err
+		}
+		enc := stdjson.NewEncoder(fw)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(f.data); err != nil {
+			return err
+		}
+	}
+	return zw.Close()
+}
@@ -0,0 +1,276 @@
+package accounts
+
+import (
+	"context"
+	"errors"
+	repo "hack4good-backend/db/sqlc"
+	"hack4good-backend/internal/medical"
+	"hack4good-backend/internal/phone"
+	"hack4good-backend/internal/profiles"
+	"hack4good-backend/internal/users"
+	"hack4good-backend/internal/volunteers"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+var (
+	ErrUserNotFound      = errors.New("user not found")
+	ErrNotActive         = errors.New("only active accounts can be deactivated")
+	ErrNotDeactivated    = errors.New("account is not deactivated")
+	ErrAlreadyAnonymised = errors.New("account is already anonymised")
+	ErrOwnAccount        = errors.New("staff cannot deactivate or anonymise their own account")
+)
+
+type Service interface {
+	Deactivate(ctx context.Context, userID, staffID int32, reason string) (repo.User, error)
+	Reactivate(ctx context.Context, userID, staffID int32) (repo.User, error)
+	Anonymise(ctx context.Context, userID, staffID int32) (repo.User, error)
+	Export(ctx context.Context, userID int32, access medical.Access) (Export, error)
+}
+
+type svc struct {
+	db         *pgxpool.Pool // status changes and their clean-up happen together
+	repo       *repo.Queries
+	phones     *phone.Protector
+	volunteers volunteers.Service
+	medical    medical.Service
+}
+
+func NewService(db *pgxpool.Pool, phones *phone.Protector, medical medical.Service) Service {
+	return &svc{
+		db:         db,
+		repo:       repo.New(db),
+		phones:     phones,
+		volunteers: volunteers.NewService(db),
+		medical:    medical,
+	}
+}
+
+// block logins and end every session; bookings and attendance stay as they are
+func (s *svc) Deactivate(ctx context.Context, userID, staffID int32, reason string) (repo.User, error) {
+	if userID == staffID {
+		return repo.User{}, ErrOwnAccount
+	}
+
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return repo.User{}, err
+	}
+	defer tx.Rollback(ctx)
+	q := s.repo.WithTx(tx)
+
+	user, err := q.DeactivateUser(ctx, repo.DeactivateUserParams{
+		ID:              userID,
+		StatusReason:    pgtype.Text{String: reason, Valid: reason != ""},
+		StatusChangedBy: pgtype.Int4{Int32: staffID, Valid: true},
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return repo.User{}, s.statusError(ctx, userID, ErrNotActive)
+	}
+	if err != nil {
+		return repo.User{}, err
+	}
+
+	if err := q.DeleteSessionsByUserID(ctx, userID); err != nil {
+		return repo.User{}, err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return repo.User{}, err
+	}
+	return user, nil
+}
+
+func (s *svc) Reactivate(ctx context.Context, userID, staffID int32) (repo.User, error) {
+	user, err := s.repo.ReactivateUser(ctx, repo.ReactivateUserParams{
+		ID:              userID,
+		StatusChangedBy: pgtype.Int4{Int32: staffID, Valid: true},
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return repo.User{}, s.statusError(ctx, userID, ErrNotDeactivated)
+	}
+	return user, err
+}
+
+// scrub name, contact details, credentials, profile and links; the user row and
+// its bookings stay (under a placeholder name) so statistics still add up
+func (s *svc) Anonymise(ctx context.Context, userID, staffID int32) (repo.User, error) {
+	if userID == staffID {
+		return repo.User{}, ErrOwnAccount
+	}
+
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return repo.User{}, err
+	}
+	defer tx.Rollback(ctx)
+	q := s.repo.WithTx(tx)
+
+	user, err := q.AnonymiseUser(ctx, repo.AnonymiseUserParams{
+		ID:              userID,
+		StatusChangedBy: pgtype.Int4{Int32: staffID, Valid: true},
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return repo.User{}, s.statusError(ctx, userID, ErrAlreadyAnonymised)
+	}
+	if err != nil {
+		return repo.User{}, err
+	}
+
+	if err := q.DeleteUserPersonalData(ctx, userID); err != nil {
+		return repo.User{}, err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return repo.User{}, err
+	}
+	return user, nil
+}
+
+// a status update matched no row: either the user is missing or in the wrong state
+func (s *svc) statusError(ctx context.Context, userID int32, wrongState error) error {
+	if _, err := s.repo.GetUserByID(ctx, userID); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrUserNotFound
+		}
+		return err
+	}
+	return wrongState
+}
+
+// access is who is exporting, for the medical access log
+func (s *svc) Export(ctx context.Context, userID int32, access medical.Access) (Export, error) {
+	user, err := s.repo.GetUserByID(ctx, userID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return Export{}, ErrUserNotFound
+	}
+	if err != nil {
+		return Export{}, err
+	}
+
+	out := Export{
+		ExportedAt: time.Now().UTC(),
+		Account: ExportAccount{
+			ID:                user.ID,
+			Name:              user.Name,
+			Email:             user.Email,
+			Role:              user.Role,
+			Status:            user.Status,
+			CreatedAt:         user.CreatedAt.Time,
+			PasswordChangedAt: timePtr(user.PasswordChangedAt),
+		},
+		CareLinks: []ExportCareLink{},
+		Bookings:  []ExportBooking{},
+		Sessions:  []ExportSession{},
+	}
+	if user.Status != users.StatusAnonymised && len(user.PhoneEncrypted) > 0 {
+		if out.Account.Phone, err = s.phones.Reveal(user.PhoneEncrypted); err != nil {
+			return Export{}, err
+		}
+	}
+
+	profile, err := s.repo.GetParticipantProfile(ctx, userID)
+	switch {
+	case err == nil:
+		p := profiles.ToProfile(profile)
+		out.Profile = &p
+	case !errors.Is(err, pgx.ErrNoRows):
+		return Export{}, err
+	}
+
+	if user.Role == "volunteer" {
+		v, err := s.volunteers.GetProfile(ctx, userID)
+		if err != nil {
+			return Export{}, err
+		}
+		out.Volunteer = &v
+	}
+
+	if user.Role == "participant" {
+		record, err := s.medical.Get(ctx, userID, access)
+		switch {
+		case err == nil:
+			out.Medical = &record
+		case !errors.Is(err, medical.ErrRecordNotFound):
+			return Export{}, err
+		}
+		// read after Get so the export itself is listed
+		if out.MedicalAccessLog, err = s.medical.AccessLogs(ctx, userID); err != nil {
+			return Export{}, err
+		}
+	}
+
+	links, err := s.careLinks(ctx, user)
+	if err != nil {
+		return Export{}, err
+	}
+	for _, l := range links {
+		out.CareLinks = append(out.CareLinks, ExportCareLink{
+			ParticipantID: l.ParticipantID,
+			CaregiverID:   l.CaregiverID,
+			Status:        l.Status,
+			CreatedAt:     l.CreatedAt.Time,
+			ApprovedAt:    timePtr(l.ApprovedAt),
+		})
+	}
+
+	bookings, err := s.repo.ListBookingHistoryByUserID(ctx, userID)
+	if err != nil {
+		return Export{}, err
+	}
+	for _, b := range bookings {
+		booking := ExportBooking{
+			ID:               b.ID,
+			ActivityID:       b.ActivityID,
+			ActivityTitle:    b.ActivityTitle,
+			Venue:            b.Venue,
+			StartTime:        b.StartTime.Time,
+			EndTime:          b.EndTime.Time,
+			BookedBy:         b.UserID,
+			Role:             b.Role,
+			IsPaid:           b.IsPaid,
+			AttendanceStatus: b.AttendanceStatus.String,
+			CreatedAt:        b.CreatedAt.Time,
+			CancelledAt:      timePtr(b.CancelledAt),
+		}
+		if b.BookedForUserID.Valid {
+			booking.BookedFor = &b.BookedForUserID.Int32
+		}
+		out.Bookings = append(out.Bookings, booking)
+	}
+
+	sessions, err := s.repo.ListSessionsByUserID(ctx, userID)
+	if err != nil {
+		return Export{}, err
+	}
+	for _, se := range sessions {
+		out.Sessions = append(out.Sessions, ExportSession{
+			SignedInAt: se.CreatedAt.Time,
+			LastUsedAt: se.LastUsedAt.Time,
+			ExpiresAt:  se.ExpiresAt.Time,
+			Revoked:    se.IsRevoked,
+			UserAgent:  se.UserAgent.String,
+			IPAddress:  se.IpAddress.String,
+		})
+	}
+
+	return out, nil
+}
+
+func (s *svc) careLinks(ctx context.Context, user repo.User) ([]repo.CareRelationship, error) {
+	switch user.Role {
+	case "participant":
+		return s.repo.ListCareRelationshipsByParticipant(ctx, user.ID)
+	case "caregiver":
+		return s.repo.ListCareRelationshipsByCaregiver(ctx, user.ID)
+	}
+	return nil, nil
+}
+
+func timePtr(t pgtype.Timestamp) *time.Time {
+	if !t.Valid {
+		return nil
+	}
+	return &t.Time
+}
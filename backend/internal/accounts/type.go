@@ -0,0 +1,82 @@
+package accounts
+
+import (
+	"hack4good-backend/internal/medical"
+	"hack4good-backend/internal/profiles"
+	"hack4good-backend/internal/volunteers"
+	"time"
+)
+
+// account after a status change
+type Account struct {
+	ID              int32      `json:"id"`
+	Name            string     `json:"name"`
+	Email           string     `json:"email"`
+	Role            string     `json:"role"`
+	Status          string     `json:"status"`
+	StatusReason    *string    `json:"status_reason,omitempty"`
+	StatusChangedAt *time.Time `json:"status_changed_at,omitempty"`
+}
+
+type DeactivateRequest struct {
+	Reason string `json:"reason"`
+}
+
+// everything we hold about a user, for subject access requests
+type Export struct {
+	ExportedAt time.Time           `json:"exported_at"`
+	Account    ExportAccount       `json:"account"`
+	Profile    *profiles.Profile   `json:"participant_profile,omitempty"`
+	Volunteer  *volunteers.Profile `json:"volunteer_profile,omitempty"`
+	CareLinks  []ExportCareLink    `json:"care_links"`
+	Bookings   []ExportBooking     `json:"bookings"`
+	Sessions   []ExportSession     `json:"sessions"`
+
+	// participants only; exporting counts as a read and is logged
+	Medical          *medical.Record     `json:"medical,omitempty"`
+	MedicalAccessLog []medical.AccessLog `json:"medical_access_log,omitempty"`
+}
+
+type ExportAccount struct {
+	ID                int32      `json:"id"`
+	Name              string     `json:"name"`
+	Email             string     `json:"email"`
+	Phone             string     `json:"phone,omitempty"`
+	Role              string     `json:"role"`
+	Status            string     `json:"status"`
+	CreatedAt         time.Time  `json:"created_at"`
+	PasswordChangedAt *time.Time `json:"password_changed_at,omitempty"`
+}
+
+type ExportCareLink struct {
+	ParticipantID int32      `json:"participant_id"`
+	CaregiverID   int32      `json:"caregiver_id"`
+	Status        string     `json:"status"`
+	CreatedAt     time.Time  `json:"created_at"`
+	ApprovedAt    *time.Time `json:"approved_at,omitempty"`
+}
+
+type ExportBooking struct {
+	ID               int32      `json:"id"`
+	ActivityID       int32      `json:"activity_id"`
+	ActivityTitle    string     `json:"activity_title"`
+	Venue            string     `json:"venue"`
+	StartTime        time.Time  `json:"start_time"`
+	EndTime          time.Time  `json:"end_time"`
+	BookedBy         int32      `json:"booked_by"`
+	BookedFor        *int32     `json:"booked_for,omitempty"`
+	Role             string     `json:"role"`
+	IsPaid           bool       `json:"is_paid"`
+	AttendanceStatus string     `json:"attendance_status"`
+	CreatedAt        time.Time  `json:"created_at"`
+	CancelledAt      *time.Time `json:"cancelled_at,omitempty"`
+}
+
+type ExportSession struct {
+	SignedInAt time.Time `json:"signed_in_at"`
+	LastUsedAt time.Time `json:"last_used_at"`
+	ExpiresAt  time.Time `json:"expires_at"`
+	Revoked    bool      `json:"revoked"`
+	UserAgent  string    `json:"user_agent,omitempty"`
+	IPAddress  string    `json:"ip_address,omitempty"`
+}
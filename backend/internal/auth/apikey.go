@@ -0,0 +1,78 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// API keys look like h4g_<prefix>_<secret>; the prefix identifies the key, only a hash is stored
+const (
+	apiKeyMarker    = "h4g_"
+	apiKeyPrefixLen = 8
+
+	// role in the claims of requests made with an API key
+	APIKeyRole = "api_key"
+)
+
+// a verified API key
+type APIKey struct {
+	ID     int32
+	Name   string
+	Scopes []Permission
+}
+
+// claims handlers see for a request made with the key (no user ID)
+func (k *APIKey) Claims() *UserClaims {
+	return &UserClaims{
+		Name:     k.Name,
+		Role:     APIKeyRole,
+		APIKeyID: k.ID,
+	}
+}
+
+// checks an API key presented with a request
+type APIKeyVerifier interface {
+	VerifyAPIKey(ctx context.Context, key string) (*APIKey, error)
+}
+
+// generate a new API key, its lookup prefix and the hash to store
+func NewAPIKey() (key, prefix, hash string, err error) {
+	b := make([]byte, apiKeyPrefixLen/2)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", "", fmt.Errorf("failed to generate API key prefix: %w", err)
+	}
+	prefix = hex.EncodeToString(b)
+
+	secret, _, err := NewOpaqueToken()
+	if err != nil {
+		return "", "", "", err
+	}
+
+	key = apiKeyMarker + prefix + "_" + secret
+	return key, prefix, HashToken(key), nil
+}
+
+// prefix of a key in the h4g_<prefix>_<secret> format
+func APIKeyPrefix(key string) (string, bool) {
+	rest, ok := strings.CutPrefix(key, apiKeyMarker)
+	if !ok || len(rest) <= apiKeyPrefixLen+1 || rest[apiKeyPrefixLen] != '_' {
+		return "", false
+	}
+	return rest[:apiKeyPrefixLen], true
+}
+
+// API key from X-API-Key, or a bearer token in the API key format
+func apiKeyFromRequest(r *http.Request) (string, bool) {
+	if key := r.Header.Get("X-API-Key"); key != "" {
+		return key, true
+	}
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if ok && strings.HasPrefix(token, apiKeyMarker) {
+		return token, true
+	}
+	return "", false
+}
@@ -0,0 +1,229 @@
+package authhttp
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	repo "hack4good-backend/db/sqlc"
+	"hack4good-backend/internal/auth"
+	"hack4good-backend/internal/json"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+var (
+	ErrInvalidAPIKey  = errors.New("invalid or expired API key")
+	ErrAPIKeyNotFound = errors.New("API key not found")
+)
+
+// look up a presented key by its prefix and check it is the stored one, still active
+func (s *svc) VerifyAPIKey(ctx context.Context, key string) (*auth.APIKey, error) {
+	prefix, ok := auth.APIKeyPrefix(key)
+	if !ok {
+		return nil, ErrInvalidAPIKey
+	}
+
+	stored, err := s.repo.GetAPIKeyByPrefix(ctx, prefix)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrInvalidAPIKey
+		}
+		return nil, fmt.Errorf("failed to get API key: %w", err)
+	}
+	if subtle.ConstantTimeCompare([]byte(stored.KeyHash), []byte(auth.HashToken(key))) != 1 {
+		return nil, ErrInvalidAPIKey
+	}
+	if stored.RevokedAt.Valid || (stored.ExpiresAt.Valid && time.Now().After(stored.ExpiresAt.Time)) {
+		return nil, ErrInvalidAPIKey
+	}
+
+	// at most once a minute per key
+	if err := s.repo.TouchAPIKey(ctx, stored.ID); err != nil {
+		log.Println(err)
+	}
+
+	scopes := make([]auth.Permission, 0, len(stored.Scopes))
+	for _, scope := range stored.Scopes {
+		// skip permissions that no longer exist or can no longer be granted to keys
+		if p, ok := auth.ParsePermission(scope); ok && auth.GrantableToAPIKey(p) {
+			scopes = append(scopes, p)
+		}
+	}
+	return &auth.APIKey{ID: stored.ID, Name: stored.Name, Scopes: scopes}, nil
+}
+
+// create a key, returns the key itself (only available here) and the stored record
+func (s *svc) CreateAPIKey(ctx context.Context, name string, scopes []auth.Permission, createdBy int32, expiresAt *time.Time) (string, repo.ApiKey, error) {
+	key, prefix, hash, err := auth.NewAPIKey()
+	if err != nil {
+		return "", repo.ApiKey{}, fmt.Errorf("failed to generate API key: %w", err)
+	}
+
+	stored := make([]string, len(scopes))
+	for i, p := range scopes {
+		stored[i] = string(p)
+	}
+	params := repo.CreateAPIKeyParams{
+		Name:      name,
+		Prefix:    prefix,
+		KeyHash:   hash,
+		Scopes:    stored,
+		CreatedBy: pgtype.Int4{Int32: createdBy, Valid: createdBy != 0},
+	}
+	if expiresAt != nil {
+		params.ExpiresAt = pgtype.Timestamp{Time: *expiresAt, Valid: true}
+	}
+
+	apiKey, err := s.repo.CreateAPIKey(ctx, params)
+	if err != nil {
+		return "", repo.ApiKey{}, fmt.Errorf("failed to create API key: %w", err)
+	}
+	return key, apiKey, nil
+}
+
+func (s *svc) ListAPIKeys(ctx context.Context) ([]repo.ApiKey, error) {
+	keys, err := s.repo.ListAPIKeys(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list API keys: %w", err)
+	}
+	return keys, nil
+}
+
+func (s *svc) RevokeAPIKey(ctx context.Context, id int32) error {
+	n, err := s.repo.RevokeAPIKey(ctx, id)
+	if err != nil {
+		return fmt.Errorf("failed to revoke API key: %w", err)
+	}
+	if n == 0 {
+		return ErrAPIKeyNotFound
+	}
+	return nil
+}
+
+func toAPIKeyResponse(k repo.ApiKey) APIKeyResponse {
+	resp := APIKeyResponse{
+		ID:        k.ID,
+		Name:      k.Name,
+		Prefix:    k.Prefix,
+		Scopes:    k.Scopes,
+		CreatedAt: k.CreatedAt.Time,
+	}
+	if k.CreatedBy.Valid {
+		resp.CreatedBy = &k.CreatedBy.Int32
+	}
+	if k.ExpiresAt.Valid {
+		resp.ExpiresAt = &k.ExpiresAt.Time
+	}
+	if k.LastUsedAt.Valid {
+		resp.LastUsedAt = &k.LastUsedAt.Time
+	}
+	if k.RevokedAt.Valid {
+		resp.RevokedAt = &k.RevokedAt.Time
+	}
+	return resp
+}
+
+// create an API key for a kiosk or integration (by staff)
+// scopes are limited to read and reporting permissions the caller has themselves
+func (h *handler) CreateAPIKey(w http.ResponseWriter, r *http.Request) {
+	claims, ok := auth.FromContext(r.Context())
+	if !ok {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	// a key made by a key would outlive revoking it
+	if claims.IsAPIKey() {
+		http.Error(w, "API keys cannot create API keys", http.StatusForbidden)
+		return
+	}
+
+	var req CreateAPIKeyPayload
+	if err := json.Read(r, &req); err != nil {
+		http.Error(w, "invalid request", http.StatusBadRequest)
+		return
+	}
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" || len(req.Scopes) == 0 {
+		http.Error(w, "name and scopes are required", http.StatusBadRequest)
+		return
+	}
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(time.Now()) {
+		http.Error(w, "expires_at must be in the future", http.StatusBadRequest)
+		return
+	}
+
+	scopes := make([]auth.Permission, 0, len(req.Scopes))
+	for _, s := range req.Scopes {
+		p, ok := auth.ParsePermission(s)
+		if !ok {
+			http.Error(w, fmt.Sprintf("unknown scope %q", s), http.StatusBadRequest)
+			return
+		}
+		if !auth.GrantableToAPIKey(p) {
+			http.Error(w, fmt.Sprintf("scope %q cannot be granted to an API key", s), http.StatusBadRequest)
+			return
+		}
+		if !auth.Can(r.Context(), p) {
+			http.Error(w, fmt.Sprintf("cannot grant scope %q", s), http.StatusForbidden)
+			return
+		}
+		scopes = append(scopes, p)
+	}
+
+	key, apiKey, err := h.service.CreateAPIKey(r.Context(), req.Name, scopes, claims.ID, req.ExpiresAt)
+	if err != nil {
+		log.Println(err)
+		http.Error(w, "failed to create API key", http.StatusInternalServerError)
+		return
+	}
+
+	json.Write(w, http.StatusCreated, CreatedAPIKeyResponse{
+		APIKeyResponse: toAPIKeyResponse(apiKey),
+		Key:            key,
+	})
+}
+
+// list API keys, including revoked and expired ones (by staff)
+func (h *handler) ListAPIKeys(w http.ResponseWriter, r *http.Request) {
+	keys, err := h.service.ListAPIKeys(r.Context())
+	if err != nil {
+		log.Println(err)
+		http.Error(w, "failed to list API keys", http.StatusInternalServerError)
+		return
+	}
+
+	resp := make([]APIKeyResponse, len(keys))
+	for i, k := range keys {
+		resp[i] = toAPIKeyResponse(k)
+	}
+	json.Write(w, http.StatusOK, resp)
+}
+
+// revoke an API key, it stops working immediately (by staff)
+func (h *handler) RevokeAPIKey(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "invalid API key id", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.service.RevokeAPIKey(r.Context(), int32(id)); err != nil {
+		if errors.Is(err, ErrAPIKeyNotFound) {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		log.Println(err)
+		http.Error(w, "failed to revoke API key", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
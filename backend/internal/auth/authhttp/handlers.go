@@ -1,13 +1,22 @@
 package authhttp
 
 import (
+	"errors"
+	"fmt"
+	repo "hack4good-backend/db/sqlc"
 	"hack4good-backend/internal/auth"
 	"hack4good-backend/internal/json"
+	"hack4good-backend/internal/notify"
+	"hack4good-backend/internal/phone"
 	"hack4good-backend/internal/users"
 	"log"
 	"net/http"
+	"strconv"
+	"strings"
 	"time"
 
+	"github.com/go-chi/chi/v5"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
@@ -16,17 +25,22 @@ type handler struct {
 	service Service
 	userService users.Service
 	tokenMaker *auth.JWTMaker
+	notifier *notify.Notifier
+	config Config
 }
 
-func NewHandler(service Service, userService users.Service, tokenMaker *auth.JWTMaker) *handler {
+func NewHandler(service Service, userService users.Service, tokenMaker *auth.JWTMaker, notifier *notify.Notifier, config Config) *handler {
 	return &handler{
 		service:     service,
 		userService: userService,
 		tokenMaker:  tokenMaker,
+		notifier:    notifier,
+		config:      config,
 	}
 }
 
-// handle registration of a new user
+// public self-registration for participants, caregivers and volunteers;
+// the account stays pending (cannot log in) until staff approve it
 func (h *handler) HandleRegister(w http.ResponseWriter, r *http.Request) {
 	var payload RegisterUserPayload
 	if err := json.Read(r, &payload); err != nil {
@@ -34,12 +48,18 @@ func (h *handler) HandleRegister(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// validate role
-	validRoles := map[string]bool{"participant": true, "caregiver": true, "volunteer": true, "staff": true}
+	// validate role (staff accounts are only created by staff)
+	validRoles := map[string]bool{"participant": true, "caregiver": true, "volunteer": true}
 	if !validRoles[payload.Role] {
 		http.Error(w, "invalid role", http.StatusBadRequest)
 		return
 	}
+	payload.Name = strings.TrimSpace(payload.Name)
+	payload.Email = strings.TrimSpace(payload.Email)
+	if payload.Name == "" || payload.Email == "" || payload.Phone == "" {
+		http.Error(w, "name, email and phone are required", http.StatusBadRequest)
+		return
+	}
 
 	// check if user exists (by phone or email)
 	if _, err := h.userService.GetUserByPhone(r.Context(), payload.Phone); err == nil {
@@ -51,165 +71,604 @@ func (h *handler) HandleRegister(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// hash phone
-	hashedPhone, err := auth.HashPhone(payload.Phone)
-	if err != nil {
-		log.Println(err)
-		http.Error(w, "failed to hash phone", http.StatusInternalServerError)
-		return
-	}
-
-	// create user in DB
+	// create user in DB (phone is encrypted by the user service)
 	newUser := users.CreateUserParams{
 		Name:      payload.Name,
-		Phone: hashedPhone,
+		Phone: payload.Phone,
 		Email:     payload.Email,
 		Role:      payload.Role,
 	}
 
-	userID, err := h.userService.CreateUser(r.Context(), newUser)
+	user, err := h.userService.RegisterUser(r.Context(), newUser)
 	if err != nil {
+		if errors.Is(err, phone.ErrInvalidPhone) {
+			http.Error(w, "invalid phone number", http.StatusBadRequest)
+			return
+		}
 		log.Println(err)
 		http.Error(w, "failed to create user", http.StatusInternalServerError)
 		return
 	}
 
-	json.Write(w, http.StatusOK, map[string]interface{}{"user_id": userID})
+	if err := h.notifier.Email.Send(r.Context(), notify.Message{
+		To:      user.Email,
+		Subject: "We received your registration",
+		Body:    fmt.Sprintf("Hi %s,\n\nThanks for registering with Hack4Good. Our staff will review your registration and let you know once you can log in.", user.Name),
+	}); err != nil {
+		log.Println(err)
+	}
+
+	json.Write(w, http.StatusAccepted, map[string]interface{}{"user_id": user.ID, "status": user.Status})
 }
 
-// handle login using email and phone 
+// handle login using email and password (staff)
 func (h *handler) HandleLogin(w http.ResponseWriter, r *http.Request) {
 	var payload LoginUserPayload
 	if err := json.Read(r, &payload); err != nil {
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
-	log.Printf("LOGIN PAYLOAD: %+v\n", payload)
-
 	if payload.Email == "" {
 		http.Error(w, "email required", http.StatusBadRequest)
 		return
 	}
 
+	// refuse early while the account or IP is locked out
+	ip := clientIP(r)
+	if wait, err := h.service.CheckLoginAllowed(r.Context(), payload.Email, ip); err != nil {
+		if errors.Is(err, ErrLoginLocked) {
+			w.Header().Set("Retry-After", strconv.Itoa(int(wait.Seconds())+1))
+			http.Error(w, "too many login attempts, try again later", http.StatusTooManyRequests)
+			return
+		}
+		log.Println(err)
+		http.Error(w, "failed to log in", http.StatusInternalServerError)
+		return
+	}
+
 	// get user info from DB
 	user, err := h.userService.GetUserByEmail(r.Context(), payload.Email)
 	if err != nil {
-		http.Error(w, "user not found", http.StatusBadRequest)
+		// compare anyway so unknown emails take as long as wrong credentials
+		auth.CheckPassword(dummyHash, []byte(payload.Password))
+		h.loginFailed(w, r, payload.Email, ip)
 		return
 	}
 
-	// role-based credential check 
-	switch user.Role {
-	case "staff":
-		if payload.Password == "" {
-			http.Error(w, "password required", http.StatusUnauthorized)
+	// password login is for staff only, everyone else signs in with a one-time code
+	storedPassword, _ := user.Password.(string)
+	if user.Role != "staff" || payload.Password == "" || !auth.CheckPassword(storedPassword, []byte(payload.Password)) {
+		h.loginFailed(w, r, payload.Email, ip)
+		return
+	}
+
+	// with 2FA on, the password only earns a challenge; the throttle is cleared once the code checks out
+	enabled, _, err := h.service.TOTPStatus(r.Context(), user.ID)
+	if err != nil {
+		log.Println(err)
+		http.Error(w, "failed to log in", http.StatusInternalServerError)
+		return
+	}
+	if enabled {
+		token, expiresAt, err := h.service.CreateMFAChallenge(r.Context(), user.ID)
+		if err != nil {
+			log.Println(err)
+			http.Error(w, "failed to log in", http.StatusInternalServerError)
 			return
 		}
+		json.Write(w, http.StatusOK, MFAChallengeResponse{
+			MFARequired:    true,
+			ChallengeToken: token,
+			ExpiresAt:      expiresAt,
+		})
+		return
+	}
 
-		storedPassword := user.Password.(string)
-		if !auth.CheckPassword(storedPassword, []byte(payload.Password)) {
-			http.Error(w, "invalid credentials", http.StatusUnauthorized)
+	if err := h.service.RecordLoginSuccess(r.Context(), payload.Email); err != nil {
+		log.Println(err)
+	}
+
+	h.completeLogin(w, r, user)
+}
+
+// last step of a staff login once password (and 2FA) passed
+func (h *handler) completeLogin(w http.ResponseWriter, r *http.Request, user repo.User) {
+	// temporary password: hand out a short-lived reset token instead of a session
+	if user.PasswordMustChange {
+		token, err := h.service.IssuePasswordChangeToken(r.Context(), user.ID)
+		if err != nil {
+			log.Println(err)
+			http.Error(w, "failed to log in", http.StatusInternalServerError)
 			return
 		}
-	default:
-		if payload.Phone == "" {
-			http.Error(w, "phone required", http.StatusUnauthorized)
+		json.Write(w, http.StatusForbidden, PasswordChangeRequiredResponse{
+			Error:         "password change required",
+			PasswordToken: token,
+		})
+		return
+	}
+
+	h.startSession(w, r, user)
+}
+
+// issue access and refresh tokens for a user who has proven their identity
+func (h *handler) startSession(w http.ResponseWriter, r *http.Request, user repo.User) {
+	resp, err := h.newSession(r, user)
+	if err != nil {
+		if errors.Is(err, ErrAccountNotActive) {
+			http.Error(w, err.Error(), http.StatusForbidden)
 			return
 		}
+		log.Println(err)
+		http.Error(w, "failed to create session", http.StatusInternalServerError)
+		return
+	}
 
-		storedPhone := user.Phone.(string)
-		if !auth.CheckPhone(storedPhone, []byte(payload.Phone)) {
-			http.Error(w, "invalid credentials", http.StatusUnauthorized)
+	// browser sessions get the tokens as HttpOnly cookies only
+	if auth.WantsCookies(r) {
+		if err := h.setSessionCookies(w, resp); err != nil {
+			log.Println(err)
+			http.Error(w, "failed to create session", http.StatusInternalServerError)
 			return
 		}
 	}
 
+	json.Write(w, http.StatusOK, resp)
+}
+
+// issue refresh and access tokens for a new session
+func (h *handler) newSession(r *http.Request, user repo.User) (*SessionResponse, error) {
+	// pending or rejected registrations cannot sign in
+	if user.Status != users.StatusActive {
+		return nil, ErrAccountNotActive
+	}
+
 	// create JWT token
-	accessToken, accessClaims, err := h.tokenMaker.CreateToken(int32(user.ID), user.Name, user.Role, 15*time.Minute)
+	refreshToken, refreshClaims, err := h.tokenMaker.CreateRefreshToken(int32(user.ID), user.Name, user.Role, 24*time.Hour)
 	if err != nil {
-		http.Error(w, "failed to create access token", http.StatusInternalServerError)
-		return
+		return nil, fmt.Errorf("failed to create refresh token: %w", err)
 	}
-	refreshToken, refreshClaims, err := h.tokenMaker.CreateToken(int32(user.ID), user.Name, user.Role, 24*time.Hour)
+	accessToken, accessClaims, err := h.tokenMaker.CreateSessionToken(int32(user.ID), user.Name, user.Role, refreshClaims.RegisteredClaims.ID, 15*time.Minute)
 	if err != nil {
-		http.Error(w, "failed to create refresh token", http.StatusInternalServerError)
-		return
+		return nil, fmt.Errorf("failed to create access token: %w", err)
 	}
 
 	// creating session 
 	session, err := h.service.CreateSession(r.Context(), CreateSessionParams{
+		ID:           refreshClaims.RegisteredClaims.ID,
+		FamilyID:     refreshClaims.RegisteredClaims.ID,
 		UserID:       int32(user.ID),
 		RefreshToken: refreshToken,
 		IsRevoked:    false,
 		ExpiresAt:    pgtype.Timestamp{Time: refreshClaims.ExpiresAt.Time, Valid: true},
 		CreatedAt:    pgtype.Timestamp{Time: time.Now(), Valid: true},
+		UserAgent:    r.UserAgent(),
+		IPAddress:    clientIP(r),
 	})
 	if err != nil {
-		http.Error(w, "failed to create session", http.StatusInternalServerError)
-		return
+		return nil, err
 	}
 
-	// return response
-	resp := SessionResponse{
+	return &SessionResponse{
 		SessionID:             session.ID,
 		AccessToken:           accessToken,
 		RefreshToken:          refreshToken,
 		AccessTokenExpiresAt:  accessClaims.ExpiresAt.Time,
 		RefreshTokenExpiresAt: refreshClaims.ExpiresAt.Time,
 		User:                  toUser(user),
+	}, nil
+}
+
+// move the tokens from the response into cookies
+func (h *handler) setSessionCookies(w http.ResponseWriter, resp *SessionResponse) error {
+	csrf, err := h.config.Cookies.SetSessionCookies(w, resp.AccessToken, resp.AccessTokenExpiresAt, resp.RefreshToken, resp.RefreshTokenExpiresAt)
+	if err != nil {
+		return err
+	}
+	resp.AccessToken, resp.RefreshToken, resp.CSRFToken = "", "", csrf
+	return nil
+}
+
+// send a one-time login code to a participant, caregiver or volunteer
+func (h *handler) RequestLoginCode(w http.ResponseWriter, r *http.Request) {
+	var payload RequestLoginCodePayload
+	if err := json.Read(r, &payload); err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if (payload.Email == "") == (payload.Phone == "") {
+		http.Error(w, "email or phone required", http.StatusBadRequest)
+		return
+	}
+
+	// same answer whether or not the account exists
+	accepted := map[string]string{"message": "if the account exists, a login code has been sent"}
+
+	identifier := h.codeLoginSubject(payload.Email, payload.Phone)
+	if _, err := h.service.CheckLoginAllowed(r.Context(), identifier, clientIP(r)); err != nil {
+		json.Write(w, http.StatusAccepted, accepted)
+		return
+	}
+
+	user, err := h.findCodeLoginUser(r, payload.Email, payload.Phone)
+	if err != nil {
+		json.Write(w, http.StatusAccepted, accepted)
+		return
+	}
+
+	channel, to, sender := "email", user.Email, h.notifier.Email
+	if payload.Phone != "" {
+		// the number on file, not however it was typed in
+		phone, err := h.userService.RevealPhone(user)
+		if err != nil {
+			log.Println(err)
+			json.Write(w, http.StatusAccepted, accepted)
+			return
+		}
+		channel, to, sender = "sms", phone, h.notifier.SMS
+	}
+
+	code, err := h.service.IssueLoginCode(r.Context(), user.ID, channel)
+	if err != nil {
+		if !errors.Is(err, ErrTooManyLoginCodes) {
+			log.Println(err)
+		}
+		json.Write(w, http.StatusAccepted, accepted)
+		return
+	}
+
+	if err := sender.Send(r.Context(), notify.Message{
+		To:      to,
+		Subject: "Your login code",
+		Body:    fmt.Sprintf("Your login code is %s. It expires in %d minutes. Do not share it with anyone.", code, int(loginCodeTTL.Minutes())),
+	}); err != nil {
+		log.Println(err)
+	}
+
+	json.Write(w, http.StatusAccepted, accepted)
+}
+
+// exchange a one-time login code for tokens
+func (h *handler) VerifyLoginCode(w http.ResponseWriter, r *http.Request) {
+	var payload VerifyLoginCodePayload
+	if err := json.Read(r, &payload); err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if (payload.Email == "") == (payload.Phone == "") || payload.Code == "" {
+		http.Error(w, "code and email or phone required", http.StatusBadRequest)
+		return
+	}
+
+	identifier := h.codeLoginSubject(payload.Email, payload.Phone)
+	ip := clientIP(r)
+	if wait, err := h.service.CheckLoginAllowed(r.Context(), identifier, ip); err != nil {
+		if errors.Is(err, ErrLoginLocked) {
+			w.Header().Set("Retry-After", strconv.Itoa(int(wait.Seconds())+1))
+			http.Error(w, "too many login attempts, try again later", http.StatusTooManyRequests)
+			return
+		}
+		log.Println(err)
+		http.Error(w, "failed to log in", http.StatusInternalServerError)
+		return
+	}
+
+	user, err := h.findCodeLoginUser(r, payload.Email, payload.Phone)
+	if err != nil {
+		h.loginFailed(w, r, identifier, ip)
+		return
+	}
+
+	if err := h.service.VerifyLoginCode(r.Context(), user.ID, payload.Code); err != nil {
+		if !errors.Is(err, ErrInvalidLoginCode) {
+			log.Println(err)
+		}
+		h.loginFailed(w, r, identifier, ip)
+		return
+	}
+
+	if err := h.service.RecordLoginSuccess(r.Context(), identifier); err != nil {
+		log.Println(err)
+	}
+
+	h.startSession(w, r, user)
+}
+
+// account the attempts of a code login count against: the email (normalised by the lockout),
+// or the phone's blind index so "+65 9123 4567" and "91234567" share one lockout without storing the number
+func (h *handler) codeLoginSubject(email, phone string) string {
+	if email != "" {
+		return email
+	}
+	index, err := h.userService.PhoneIndex(phone)
+	if err != nil {
+		// not a phone number, so no account can match it
+		return "phone:" + strings.TrimSpace(phone)
+	}
+	return "phone:" + index
+}
+
+// look up a non-staff user by email or phone for code login
+func (h *handler) findCodeLoginUser(r *http.Request, email, phone string) (repo.User, error) {
+	var user repo.User
+	var err error
+	if email != "" {
+		user, err = h.userService.GetUserByEmail(r.Context(), email)
+	} else {
+		user, err = h.userService.GetUserByPhone(r.Context(), phone)
+	}
+	if err != nil {
+		return repo.User{}, err
+	}
+	if user.Role == "staff" {
+		return repo.User{}, errors.New("staff must log in with a password")
+	}
+	if user.Status != users.StatusActive {
+		return repo.User{}, ErrAccountNotActive
+	}
+	return user, nil
+}
+
+// bcrypt hash of a throwaway value, compared against when the user does not exist
+const dummyHash = "$2a$10$9VVeCOCsUr47RowIgLXkxetelXvbhhqgQRpxPTxgWWB44wPgUKewC"
+
+// count the failure and answer the same way whatever went wrong
+func (h *handler) loginFailed(w http.ResponseWriter, r *http.Request, email, ip string) {
+	if err := h.service.RecordLoginFailure(r.Context(), email, ip); err != nil {
+		log.Println(err)
+	}
+	http.Error(w, "invalid credentials", http.StatusUnauthorized)
+}
+
+// list locked accounts and IPs (by staff)
+func (h *handler) ListLockouts(w http.ResponseWriter, r *http.Request) {
+	lockouts, err := h.service.ListLockouts(r.Context())
+	if err != nil {
+		log.Println(err)
+		http.Error(w, "failed to list lockouts", http.StatusInternalServerError)
+		return
+	}
+
+	resp := make([]LoginLockout, 0, len(lockouts))
+	for _, l := range lockouts {
+		resp = append(resp, toLoginLockout(l))
 	}
 
 	json.Write(w, http.StatusOK, resp)
 }
 
-// handle logout 
+// unlock an account or IP (by staff)
+func (h *handler) Unlock(w http.ResponseWriter, r *http.Request) {
+	var payload UnlockPayload
+	if err := json.Read(r, &payload); err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if (payload.Scope != scopeAccount && payload.Scope != scopeIP) || payload.Subject == "" {
+		http.Error(w, "scope must be account or ip and subject is required", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.service.Unlock(r.Context(), payload.Scope, payload.Subject); err != nil {
+		if errors.Is(err, ErrLockoutNotFound) {
+			http.Error(w, "lockout not found", http.StatusNotFound)
+			return
+		}
+		log.Println(err)
+		http.Error(w, "failed to unlock", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handle logout of the current session
 func (h *handler) HandleLogout(w http.ResponseWriter, r *http.Request) {
 	// getting claims from token 
-	claims := r.Context().Value(auth.AuthKey{}).(*auth.UserClaims)
-	if claims == nil {
+	claims, ok := auth.FromContext(r.Context())
+	if !ok || claims.SessionID == "" {
 		http.Error(w, "unauthorized", http.StatusUnauthorized)
 		return
 	}
 
-	if err := h.service.RevokeSession(r.Context(), claims.RegisteredClaims.ID); err != nil {
+	if err := h.service.RevokeSessionFamily(r.Context(), claims.SessionID); err != nil {
+		log.Println(err)
 		http.Error(w, "failed to revoke session", http.StatusInternalServerError)
 		return
 	}
 
+	h.config.Cookies.ClearSessionCookies(w)
 	json.Write(w, http.StatusOK, map[string]string{"message": "logged out successfully"})
 }
 
-// to renew access token for session
+// handle logout of every device for the current user
+func (h *handler) HandleLogoutAll(w http.ResponseWriter, r *http.Request) {
+	claims, ok := auth.FromContext(r.Context())
+	if !ok {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	if err := h.service.DeleteSessionsByUserID(r.Context(), claims.ID); err != nil {
+		log.Println(err)
+		http.Error(w, "failed to revoke sessions", http.StatusInternalServerError)
+		return
+	}
+
+	h.config.Cookies.ClearSessionCookies(w)
+	json.Write(w, http.StatusOK, map[string]string{"message": "logged out of all devices"})
+}
+
+// list active sessions (devices) of the current user
+func (h *handler) ListSessions(w http.ResponseWriter, r *http.Request) {
+	claims, ok := auth.FromContext(r.Context())
+	if !ok {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	sessions, err := h.service.ListActiveSessions(r.Context(), claims.ID)
+	if err != nil {
+		log.Println(err)
+		http.Error(w, "failed to list sessions", http.StatusInternalServerError)
+		return
+	}
+
+	resp := make([]SessionInfo, 0, len(sessions))
+	for _, s := range sessions {
+		resp = append(resp, toSessionInfo(s, claims.SessionID))
+	}
+
+	json.Write(w, http.StatusOK, resp)
+}
+
+// revoke one of the current user's sessions
+func (h *handler) RevokeSession(w http.ResponseWriter, r *http.Request) {
+	claims, ok := auth.FromContext(r.Context())
+	if !ok {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	sessionID := chi.URLParam(r, "id")
+	if sessionID == "" {
+		http.Error(w, "session id is required", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.service.RevokeUserSession(r.Context(), claims.ID, sessionID); err != nil {
+		if errors.Is(err, ErrSessionNotFound) {
+			http.Error(w, "session not found", http.StatusNotFound)
+			return
+		}
+		log.Println(err)
+		http.Error(w, "failed to revoke session", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// force logout of any user (by staff)
+func (h *handler) ForceLogoutUser(w http.ResponseWriter, r *http.Request) {
+	idStr := chi.URLParam(r, "id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		http.Error(w, "invalid user id", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.service.DeleteSessionsByUserID(r.Context(), int32(id)); err != nil {
+		log.Println(err)
+		http.Error(w, "failed to revoke sessions", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// to renew access token for session; the refresh token is rotated on every call
 func (h *handler) RenewAccessToken(w http.ResponseWriter, r *http.Request) {
 	var payload RenewAccessTokenPayload
-	if err := json.Read(r, &payload); err != nil {
+	cookieMode := auth.WantsCookies(r)
+	if cookieMode {
+		// the cookie is sent automatically, so require the CSRF header as well
+		cookie, err := r.Cookie(auth.RefreshTokenCookie)
+		if err != nil || !auth.ValidCSRF(r) {
+			http.Error(w, "invalid refresh token", http.StatusUnauthorized)
+			return
+		}
+		payload.RefreshToken = cookie.Value
+	} else if err := json.Read(r, &payload); err != nil {
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 
-	refreshClaims, err := h.tokenMaker.VerifyToken(payload.RefreshToken)
+	refreshClaims, err := h.tokenMaker.VerifyTokenType(payload.RefreshToken, auth.TokenRefresh)
 	if err != nil {
 		http.Error(w, "invalid refresh token", http.StatusUnauthorized)
 		return
 	}
 
-	session, err := h.service.GetSession(r.Context(), refreshClaims.RegisteredClaims.ID)
-	if err != nil || session.IsRevoked {
+	// name and role may have changed since login, so the new tokens carry the current ones
+	user, err := h.userService.GetUserByID(r.Context(), refreshClaims.ID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			http.Error(w, "session invalid or revoked", http.StatusUnauthorized)
+			return
+		}
+		log.Println(err)
+		http.Error(w, "failed to renew session", http.StatusInternalServerError)
+		return
+	}
+	// deactivated since login
+	if user.Status != users.StatusActive {
 		http.Error(w, "session invalid or revoked", http.StatusUnauthorized)
 		return
 	}
 
-	accessToken, accessClaims, err := h.tokenMaker.CreateToken(session.UserID, refreshClaims.Name, refreshClaims.Role, 15*time.Minute)
+	// new refresh token keeps the original expiry so rotation cannot extend a session forever
+	refreshToken, newRefreshClaims, err := h.tokenMaker.CreateRefreshToken(user.ID, user.Name, user.Role, time.Until(refreshClaims.ExpiresAt.Time))
+	if err != nil {
+		http.Error(w, "failed to create refresh token", http.StatusInternalServerError)
+		return
+	}
+
+	session, err := h.service.RefreshSession(r.Context(), refreshClaims.RegisteredClaims.ID, payload.RefreshToken, CreateSessionParams{
+		ID:           newRefreshClaims.RegisteredClaims.ID,
+		RefreshToken: refreshToken,
+		IsRevoked:    false,
+		ExpiresAt:    pgtype.Timestamp{Time: newRefreshClaims.ExpiresAt.Time, Valid: true},
+		CreatedAt:    pgtype.Timestamp{Time: time.Now(), Valid: true},
+		UserAgent:    r.UserAgent(),
+		IPAddress:    clientIP(r),
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrRefreshTokenReused):
+			log.Printf("refresh token reuse detected for user %d, session family revoked", refreshClaims.ID)
+			http.Error(w, "session invalid or revoked", http.StatusUnauthorized)
+		case errors.Is(err, ErrSessionInvalid):
+			http.Error(w, "session invalid or revoked", http.StatusUnauthorized)
+		default:
+			log.Println(err)
+			http.Error(w, "failed to renew session", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	accessToken, accessClaims, err := h.tokenMaker.CreateSessionToken(user.ID, user.Name, user.Role, session.FamilyID, 15*time.Minute)
 	if err != nil {
 		http.Error(w, "failed to create access token", http.StatusInternalServerError)
 		return
 	}
 
 	resp := RenewAccessTokenResponse{
-		AccessToken:          accessToken,
-		AccessTokenExpiresAt: accessClaims.ExpiresAt.Time,
+		SessionID:             session.ID,
+		AccessToken:           accessToken,
+		RefreshToken:          refreshToken,
+		AccessTokenExpiresAt:  accessClaims.ExpiresAt.Time,
+		RefreshTokenExpiresAt: newRefreshClaims.ExpiresAt.Time,
+	}
+
+	if cookieMode {
+		csrf, err := h.config.Cookies.SetSessionCookies(w, accessToken, resp.AccessTokenExpiresAt, refreshToken, resp.RefreshTokenExpiresAt)
+		if err != nil {
+			log.Println(err)
+			http.Error(w, "failed to renew session", http.StatusInternalServerError)
+			return
+		}
+		resp.AccessToken, resp.RefreshToken, resp.CSRFToken = "", "", csrf
 	}
 
 	json.Write(w, http.StatusOK, resp)
 }
 
+// publish public signing keys so other services can verify tokens
+func (h *handler) HandleJWKS(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Cache-Control", "public, max-age=300")
+	json.Write(w, http.StatusOK, h.tokenMaker.JWKS())
+}
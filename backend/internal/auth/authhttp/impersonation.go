@@ -0,0 +1,132 @@
+package authhttp
+
+import (
+	"context"
+	"fmt"
+	repo "hack4good-backend/db/sqlc"
+	"hack4good-backend/internal/auth"
+	"hack4good-backend/internal/json"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+const impersonationTTL = 30 * time.Minute
+
+func (s *svc) RecordImpersonation(ctx context.Context, entry repo.CreateImpersonationLogParams) error {
+	if err := s.repo.CreateImpersonationLog(ctx, entry); err != nil {
+		return fmt.Errorf("failed to record impersonation: %w", err)
+	}
+	return nil
+}
+
+func (s *svc) ListImpersonationLogs(ctx context.Context, limit int32) ([]repo.ImpersonationLog, error) {
+	logs, err := s.repo.ListImpersonationLogs(ctx, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list impersonation logs: %w", err)
+	}
+	return logs, nil
+}
+
+// issue a short-lived token to act on behalf of a participant, caregiver or volunteer (by staff)
+func (h *handler) StartImpersonation(w http.ResponseWriter, r *http.Request) {
+	claims, ok := auth.FromContext(r.Context())
+	if !ok {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	idStr := chi.URLParam(r, "id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		http.Error(w, "invalid user id", http.StatusBadRequest)
+		return
+	}
+
+	user, err := h.userService.GetUserByID(r.Context(), int32(id))
+	if err != nil {
+		http.Error(w, "user not found", http.StatusNotFound)
+		return
+	}
+	if user.Role == "staff" {
+		http.Error(w, "cannot act on behalf of staff", http.StatusForbidden)
+		return
+	}
+
+	token, tokenClaims, err := h.tokenMaker.CreateImpersonationToken(claims.ID, user.ID, user.Name, user.Role, impersonationTTL)
+	if err != nil {
+		log.Println(err)
+		http.Error(w, "failed to create token", http.StatusInternalServerError)
+		return
+	}
+
+	if err := h.service.RecordImpersonation(r.Context(), repo.CreateImpersonationLogParams{
+		StaffID: claims.ID,
+		UserID:  user.ID,
+		Method:  r.Method,
+		Path:    r.URL.Path,
+		Status:  http.StatusCreated,
+	}); err != nil {
+		log.Println(err)
+		http.Error(w, "failed to create token", http.StatusInternalServerError)
+		return
+	}
+
+	json.Write(w, http.StatusCreated, ImpersonationResponse{
+		AccessToken:          token,
+		AccessTokenExpiresAt: tokenClaims.ExpiresAt.Time,
+		UserID:               user.ID,
+		UserName:             user.Name,
+		UserRole:             user.Role,
+	})
+}
+
+// most recent impersonation starts and writes (by staff)
+func (h *handler) ListImpersonationLogs(w http.ResponseWriter, r *http.Request) {
+	limit := 100
+	if l, err := strconv.Atoi(r.URL.Query().Get("limit")); err == nil && l > 0 && l <= 1000 {
+		limit = l
+	}
+
+	logs, err := h.service.ListImpersonationLogs(r.Context(), int32(limit))
+	if err != nil {
+		log.Println(err)
+		http.Error(w, "failed to list impersonation logs", http.StatusInternalServerError)
+		return
+	}
+
+	json.Write(w, http.StatusOK, logs)
+}
+
+// record every write made with an impersonation token
+// must run after RequireRole or RequirePermission
+func (h *handler) AuditImpersonation(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		claims, ok := auth.FromContext(r.Context())
+		if !ok || !claims.Impersonated() || r.Method == http.MethodGet || r.Method == http.MethodHead || r.Method == http.MethodOptions {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+		next.ServeHTTP(ww, r)
+
+		status := ww.Status()
+		if status == 0 {
+			status = http.StatusOK
+		}
+		if err := h.service.RecordImpersonation(r.Context(), repo.CreateImpersonationLogParams{
+			StaffID: claims.ImpersonatorID,
+			UserID:  claims.ID,
+			Method:  r.Method,
+			Path:    r.URL.Path,
+			Status:  int32(status),
+		}); err != nil {
+			log.Println(err)
+		}
+	})
+}
@@ -0,0 +1,149 @@
+package authhttp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	repo "hack4good-backend/db/sqlc"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const (
+	scopeAccount = "account"
+	scopeIP      = "ip"
+
+	// failures allowed before lockouts start
+	maxAccountFailures = 5
+	maxIPFailures      = 20
+
+	// lockout doubles with every failure past the limit
+	lockoutBase = 30 * time.Second
+	lockoutMax  = 24 * time.Hour
+)
+
+var (
+	ErrLoginLocked     = errors.New("too many login attempts")
+	ErrLockoutNotFound = errors.New("lockout not found")
+)
+
+// lockout length for the n-th failure past the limit (n starts at 0)
+func lockoutDuration(n int32) time.Duration {
+	d := lockoutBase
+	for i := int32(0); i < n; i++ {
+		d *= 2
+		if d >= lockoutMax {
+			return lockoutMax
+		}
+	}
+	return d
+}
+
+func normalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+// client IP without port (middleware.RealIP already resolved proxies)
+func clientIP(r *http.Request) string {
+	if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		return host
+	}
+	return r.RemoteAddr
+}
+
+// check whether the account or IP is currently locked, returns time left on the lockout
+func (s *svc) CheckLoginAllowed(ctx context.Context, email, ip string) (time.Duration, error) {
+	var wait time.Duration
+	for _, key := range []repo.GetLoginThrottleParams{
+		{Scope: scopeAccount, Subject: normalizeEmail(email)},
+		{Scope: scopeIP, Subject: ip},
+	} {
+		throttle, err := s.repo.GetLoginThrottle(ctx, key)
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				continue
+			}
+			return 0, fmt.Errorf("failed to get login throttle: %w", err)
+		}
+		if left := time.Until(throttle.LockedUntil.Time); throttle.LockedUntil.Valid && left > wait {
+			wait = left
+		}
+	}
+
+	if wait > 0 {
+		return wait, ErrLoginLocked
+	}
+	return 0, nil
+}
+
+// count a failed attempt against the account and the IP, locking either once over the limit
+func (s *svc) RecordLoginFailure(ctx context.Context, email, ip string) error {
+	for _, key := range []struct {
+		params repo.RecordLoginFailureParams
+		limit  int32
+	}{
+		{repo.RecordLoginFailureParams{Scope: scopeAccount, Subject: normalizeEmail(email)}, maxAccountFailures},
+		{repo.RecordLoginFailureParams{Scope: scopeIP, Subject: ip}, maxIPFailures},
+	} {
+		throttle, err := s.repo.RecordLoginFailure(ctx, key.params)
+		if err != nil {
+			return fmt.Errorf("failed to record login failure: %w", err)
+		}
+		if throttle.FailedAttempts < key.limit {
+			continue
+		}
+
+		lockedUntil := time.Now().Add(lockoutDuration(throttle.FailedAttempts - key.limit))
+		if err := s.repo.SetLoginLockout(ctx, repo.SetLoginLockoutParams{
+			Scope:       key.params.Scope,
+			Subject:     key.params.Subject,
+			LockedUntil: pgtype.Timestamp{Time: lockedUntil, Valid: true},
+		}); err != nil {
+			return fmt.Errorf("failed to lock %s %s: %w", key.params.Scope, key.params.Subject, err)
+		}
+	}
+	return nil
+}
+
+// forget failed attempts for the account after a successful login
+func (s *svc) RecordLoginSuccess(ctx context.Context, email string) error {
+	if _, err := s.repo.ClearLoginThrottle(ctx, repo.ClearLoginThrottleParams{
+		Scope:   scopeAccount,
+		Subject: normalizeEmail(email),
+	}); err != nil {
+		return fmt.Errorf("failed to clear login throttle: %w", err)
+	}
+	return nil
+}
+
+// list accounts and IPs that are locked right now
+func (s *svc) ListLockouts(ctx context.Context) ([]repo.LoginThrottle, error) {
+	lockouts, err := s.repo.ListLockedLoginThrottles(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list lockouts: %w", err)
+	}
+	return lockouts, nil
+}
+
+// remove a lockout (by staff)
+func (s *svc) Unlock(ctx context.Context, scope, subject string) error {
+	if scope == scopeAccount {
+		subject = normalizeEmail(subject)
+	}
+	n, err := s.repo.ClearLoginThrottle(ctx, repo.ClearLoginThrottleParams{
+		Scope:   scope,
+		Subject: subject,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to unlock %s %s: %w", scope, subject, err)
+	}
+	if n == 0 {
+		return ErrLockoutNotFound
+	}
+	return nil
+}
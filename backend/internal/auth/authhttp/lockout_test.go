@@ -0,0 +1,63 @@
+package authhttp
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestLockoutDuration(t *testing.T) {
+	tests := []struct {
+		n    int32
+		want time.Duration
+	}{
+		{0, 30 * time.Second},
+		{1, time.Minute},
+		{2, 2 * time.Minute},
+		{5, 16 * time.Minute},
+		{11, 2048 * 30 * time.Second},
+		{12, lockoutMax}, // 34h would be past the cap
+		{100, lockoutMax},
+	}
+	for _, tt := range tests {
+		if got := lockoutDuration(tt.n); got != tt.want {
+			t.Errorf("lockoutDuration(%d) = %s, want %s", tt.n, got, tt.want)
+		}
+	}
+}
+
+func TestNormalizeEmail(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"ann@example.org", "ann@example.org"},
+		{"Ann@Example.ORG", "ann@example.org"},
+		{"  ann@example.org\t", "ann@example.org"},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := normalizeEmail(tt.in); got != tt.want {
+			t.Errorf("normalizeEmail(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestClientIP(t *testing.T) {
+	tests := []struct {
+		remoteAddr string
+		want       string
+	}{
+		{"203.0.113.7:52100", "203.0.113.7"},
+		{"[2001:db8::1]:443", "2001:db8::1"},
+		{"203.0.113.7", "203.0.113.7"}, // already without port (RealIP)
+		{"2001:db8::1", "2001:db8::1"},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest("POST", "/login", nil)
+		r.RemoteAddr = tt.remoteAddr
+		if got := clientIP(r); got != tt.want {
+			t.Errorf("clientIP(%q) = %q, want %q", tt.remoteAddr, got, tt.want)
+		}
+	}
+}
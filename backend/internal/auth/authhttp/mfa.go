@@ -0,0 +1,298 @@
+package authhttp
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	repo "hack4good-backend/db/sqlc"
+	"hack4good-backend/internal/auth"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const (
+	mfaChallengeTTL      = 5 * time.Minute
+	maxMFAChallengeTries = 5
+
+	recoveryCodeCount  = 10
+	recoveryCodeLength = 10
+)
+
+var (
+	ErrTOTPAlreadyEnabled  = errors.New("two-factor authentication is already enabled")
+	ErrTOTPNotEnabled      = errors.New("two-factor authentication is not enabled")
+	ErrInvalidTOTPCode     = errors.New("invalid two-factor code")
+	ErrInvalidMFAChallenge = errors.New("invalid or expired login challenge")
+)
+
+// unambiguous characters for recovery codes (no 0/O, 1/I/L)
+const recoveryAlphabet = "ABCDEFGHJKMNPQRSTUVWXYZ23456789"
+
+func newRecoveryCode() (string, error) {
+	max := big.NewInt(int64(len(recoveryAlphabet)))
+	b := make([]byte, recoveryCodeLength)
+	for i := range b {
+		n, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", err
+		}
+		b[i] = recoveryAlphabet[n.Int64()]
+	}
+	// XXXXX-XXXXX
+	return string(b[:recoveryCodeLength/2]) + "-" + string(b[recoveryCodeLength/2:]), nil
+}
+
+func normalizeRecoveryCode(code string) string {
+	return strings.ToUpper(strings.ReplaceAll(strings.TrimSpace(code), "-", ""))
+}
+
+// generate a new secret for the user; it only takes effect once confirmed with a code
+func (s *svc) BeginTOTPEnrollment(ctx context.Context, user repo.User) (string, string, error) {
+	secret, err := s.totp.GenerateSecret()
+	if err != nil {
+		return "", "", err
+	}
+	encrypted, err := s.secrets.Encrypt([]byte(secret))
+	if err != nil {
+		return "", "", fmt.Errorf("failed to encrypt TOTP secret: %w", err)
+	}
+
+	if _, err := s.repo.UpsertUserTOTP(ctx, repo.UpsertUserTOTPParams{
+		UserID:          user.ID,
+		SecretEncrypted: encrypted,
+	}); err != nil {
+		// the upsert skips confirmed secrets
+		if errors.Is(err, pgx.ErrNoRows) {
+			return "", "", ErrTOTPAlreadyEnabled
+		}
+		return "", "", fmt.Errorf("failed to save TOTP secret: %w", err)
+	}
+
+	return secret, s.totp.ProvisioningURI(secret, user.Email), nil
+}
+
+// enable 2FA after the user proves their app works, returns fresh recovery codes
+func (s *svc) ConfirmTOTPEnrollment(ctx context.Context, userID int32, code string) ([]string, error) {
+	totp, secret, err := s.userTOTP(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if totp.ConfirmedAt.Valid {
+		return nil, ErrTOTPAlreadyEnabled
+	}
+
+	step, ok := s.totp.Validate(secret, code, totp.LastUsedStep)
+	if !ok {
+		return nil, ErrInvalidTOTPCode
+	}
+
+	n, err := s.repo.ConfirmUserTOTP(ctx, repo.ConfirmUserTOTPParams{
+		UserID:       userID,
+		LastUsedStep: step,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to confirm TOTP: %w", err)
+	}
+	if n == 0 {
+		return nil, ErrTOTPAlreadyEnabled
+	}
+
+	return s.replaceRecoveryCodes(ctx, userID)
+}
+
+// whether the user has confirmed 2FA, and how many recovery codes they have left
+func (s *svc) TOTPStatus(ctx context.Context, userID int32) (bool, int64, error) {
+	totp, err := s.repo.GetUserTOTP(ctx, userID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return false, 0, nil
+		}
+		return false, 0, fmt.Errorf("failed to get TOTP: %w", err)
+	}
+	if !totp.ConfirmedAt.Valid {
+		return false, 0, nil
+	}
+
+	left, err := s.repo.CountUnusedRecoveryCodes(ctx, userID)
+	if err != nil {
+		return false, 0, fmt.Errorf("failed to count recovery codes: %w", err)
+	}
+	return true, left, nil
+}
+
+// replace the recovery codes after checking a current TOTP code
+func (s *svc) RegenerateRecoveryCodes(ctx context.Context, userID int32, code string) ([]string, error) {
+	if err := s.checkTOTPCode(ctx, userID, code); err != nil {
+		return nil, err
+	}
+	return s.replaceRecoveryCodes(ctx, userID)
+}
+
+// turn off 2FA for a user (by staff, e.g. after a lost phone)
+func (s *svc) ResetTOTP(ctx context.Context, userID int32) error {
+	n, err := s.repo.DeleteUserTOTP(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("failed to delete TOTP: %w", err)
+	}
+	if err := s.repo.DeleteRecoveryCodes(ctx, userID); err != nil {
+		return fmt.Errorf("failed to delete recovery codes: %w", err)
+	}
+	if err := s.repo.DeleteMFAChallenges(ctx, userID); err != nil {
+		return fmt.Errorf("failed to delete login challenges: %w", err)
+	}
+	if n == 0 {
+		return ErrTOTPNotEnabled
+	}
+	return nil
+}
+
+// short-lived token proving the password step passed
+func (s *svc) CreateMFAChallenge(ctx context.Context, userID int32) (string, time.Time, error) {
+	token, hash, err := auth.NewOpaqueToken()
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("failed to generate login challenge: %w", err)
+	}
+
+	expiresAt := time.Now().Add(mfaChallengeTTL)
+	if _, err := s.repo.CreateMFAChallenge(ctx, repo.CreateMFAChallengeParams{
+		UserID:    userID,
+		TokenHash: hash,
+		ExpiresAt: pgtype.Timestamp{Time: expiresAt, Valid: true},
+	}); err != nil {
+		return "", time.Time{}, fmt.Errorf("failed to create login challenge: %w", err)
+	}
+	return token, expiresAt, nil
+}
+
+// finish the second login step with either a TOTP code or a recovery code, returns the user ID
+// (also alongside ErrInvalidTOTPCode, so the failure can be counted against the account)
+func (s *svc) VerifyMFAChallenge(ctx context.Context, token, code, recoveryCode string) (int32, error) {
+	challenge, err := s.repo.GetActiveMFAChallenge(ctx, auth.HashToken(token))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return 0, ErrInvalidMFAChallenge
+		}
+		return 0, fmt.Errorf("failed to get login challenge: %w", err)
+	}
+
+	attempts, err := s.repo.IncrementMFAChallengeAttempts(ctx, challenge.ID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count login challenge attempt: %w", err)
+	}
+	if attempts > maxMFAChallengeTries {
+		return 0, ErrInvalidMFAChallenge
+	}
+
+	if recoveryCode != "" {
+		err = s.useRecoveryCode(ctx, challenge.UserID, recoveryCode)
+	} else {
+		err = s.checkTOTPCode(ctx, challenge.UserID, code)
+	}
+	if err != nil {
+		// burn the challenge once the last try is used
+		if errors.Is(err, ErrInvalidTOTPCode) && attempts == maxMFAChallengeTries {
+			if _, err := s.repo.ConsumeMFAChallenge(ctx, challenge.ID); err != nil {
+				return 0, fmt.Errorf("failed to expire login challenge: %w", err)
+			}
+		}
+		if errors.Is(err, ErrInvalidTOTPCode) {
+			return challenge.UserID, err
+		}
+		return 0, err
+	}
+
+	n, err := s.repo.ConsumeMFAChallenge(ctx, challenge.ID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to use login challenge: %w", err)
+	}
+	if n == 0 {
+		return 0, ErrInvalidMFAChallenge
+	}
+	return challenge.UserID, nil
+}
+
+// decrypted secret of the user's TOTP enrolment (confirmed or not)
+func (s *svc) userTOTP(ctx context.Context, userID int32) (repo.UserTotp, string, error) {
+	totp, err := s.repo.GetUserTOTP(ctx, userID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return repo.UserTotp{}, "", ErrTOTPNotEnabled
+		}
+		return repo.UserTotp{}, "", fmt.Errorf("failed to get TOTP: %w", err)
+	}
+	secret, err := s.secrets.Decrypt(totp.SecretEncrypted)
+	if err != nil {
+		return repo.UserTotp{}, "", fmt.Errorf("failed to decrypt TOTP secret: %w", err)
+	}
+	return totp, string(secret), nil
+}
+
+// check a code for a confirmed enrolment, refusing a time step that was already used
+func (s *svc) checkTOTPCode(ctx context.Context, userID int32, code string) error {
+	totp, secret, err := s.userTOTP(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if !totp.ConfirmedAt.Valid {
+		return ErrTOTPNotEnabled
+	}
+
+	step, ok := s.totp.Validate(secret, code, totp.LastUsedStep)
+	if !ok {
+		return ErrInvalidTOTPCode
+	}
+	// recorded only if no concurrent login used this step meanwhile
+	n, err := s.repo.UseTOTPStep(ctx, repo.UseTOTPStepParams{
+		UserID:       userID,
+		LastUsedStep: step,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to record TOTP use: %w", err)
+	}
+	if n == 0 {
+		return ErrInvalidTOTPCode
+	}
+	return nil
+}
+
+func (s *svc) useRecoveryCode(ctx context.Context, userID int32, code string) error {
+	n, err := s.repo.UseRecoveryCode(ctx, repo.UseRecoveryCodeParams{
+		UserID:   userID,
+		CodeHash: auth.HashToken(normalizeRecoveryCode(code)),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to use recovery code: %w", err)
+	}
+	if n == 0 {
+		return ErrInvalidTOTPCode
+	}
+	return nil
+}
+
+// codes are only ever returned here; the DB keeps their hashes
+func (s *svc) replaceRecoveryCodes(ctx context.Context, userID int32) ([]string, error) {
+	if err := s.repo.DeleteRecoveryCodes(ctx, userID); err != nil {
+		return nil, fmt.Errorf("failed to delete recovery codes: %w", err)
+	}
+
+	codes := make([]string, recoveryCodeCount)
+	for i := range codes {
+		code, err := newRecoveryCode()
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate recovery code: %w", err)
+		}
+		if err := s.repo.CreateRecoveryCode(ctx, repo.CreateRecoveryCodeParams{
+			UserID:   userID,
+			CodeHash: auth.HashToken(normalizeRecoveryCode(code)),
+		}); err != nil {
+			return nil, fmt.Errorf("failed to save recovery code: %w", err)
+		}
+		codes[i] = code
+	}
+	return codes, nil
+}
@@ -0,0 +1,213 @@
+package authhttp
+
+import (
+	"errors"
+	"hack4good-backend/internal/auth"
+	"hack4good-backend/internal/json"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// second login step for staff with 2FA: exchange the challenge token and a code for a session
+func (h *handler) VerifyMFA(w http.ResponseWriter, r *http.Request) {
+	var payload VerifyMFAPayload
+	if err := json.Read(r, &payload); err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+	if payload.ChallengeToken == "" || (payload.Code == "" && payload.RecoveryCode == "") {
+		http.Error(w, "challenge token and code required", http.StatusBadRequest)
+		return
+	}
+
+	ip := clientIP(r)
+	if wait, err := h.service.CheckLoginAllowed(r.Context(), "", ip); err != nil {
+		if errors.Is(err, ErrLoginLocked) {
+			w.Header().Set("Retry-After", strconv.Itoa(int(wait.Seconds())+1))
+			http.Error(w, "too many login attempts, try again later", http.StatusTooManyRequests)
+			return
+		}
+		log.Println(err)
+		http.Error(w, "failed to log in", http.StatusInternalServerError)
+		return
+	}
+
+	userID, err := h.service.VerifyMFAChallenge(r.Context(), payload.ChallengeToken, payload.Code, payload.RecoveryCode)
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrInvalidMFAChallenge):
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+		case errors.Is(err, ErrInvalidTOTPCode):
+			// wrong codes count like wrong passwords, so new challenges don't give unlimited guesses
+			if user, err := h.userService.GetUserByID(r.Context(), userID); err == nil {
+				if err := h.service.RecordLoginFailure(r.Context(), user.Email, ip); err != nil {
+					log.Println(err)
+				}
+			}
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+		default:
+			log.Println(err)
+			http.Error(w, "failed to log in", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	user, err := h.userService.GetUserByID(r.Context(), userID)
+	if err != nil {
+		log.Println(err)
+		http.Error(w, "failed to log in", http.StatusInternalServerError)
+		return
+	}
+	if err := h.service.RecordLoginSuccess(r.Context(), user.Email); err != nil {
+		log.Println(err)
+	}
+
+	h.completeLogin(w, r, user)
+}
+
+// 2FA state of the signed-in staff member
+func (h *handler) TOTPStatus(w http.ResponseWriter, r *http.Request) {
+	claims, ok := auth.FromContext(r.Context())
+	if !ok {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	enabled, left, err := h.service.TOTPStatus(r.Context(), claims.ID)
+	if err != nil {
+		log.Println(err)
+		http.Error(w, "failed to get two-factor status", http.StatusInternalServerError)
+		return
+	}
+
+	json.Write(w, http.StatusOK, TOTPStatusResponse{Enabled: enabled, RecoveryCodesLeft: left})
+}
+
+// start enrolment: returns the secret and an otpauth:// URI for the QR code
+func (h *handler) EnrollTOTP(w http.ResponseWriter, r *http.Request) {
+	claims, ok := auth.FromContext(r.Context())
+	if !ok {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	user, err := h.userService.GetUserByID(r.Context(), claims.ID)
+	if err != nil {
+		log.Println(err)
+		http.Error(w, "failed to start enrolment", http.StatusInternalServerError)
+		return
+	}
+
+	secret, uri, err := h.service.BeginTOTPEnrollment(r.Context(), user)
+	if err != nil {
+		if errors.Is(err, ErrTOTPAlreadyEnabled) {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
+		log.Println(err)
+		http.Error(w, "failed to start enrolment", http.StatusInternalServerError)
+		return
+	}
+
+	json.Write(w, http.StatusOK, TOTPEnrollmentResponse{Secret: secret, ProvisioningURI: uri})
+}
+
+// finish enrolment with a code from the app, returns recovery codes
+func (h *handler) ConfirmTOTP(w http.ResponseWriter, r *http.Request) {
+	claims, ok := auth.FromContext(r.Context())
+	if !ok {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var payload TOTPCodePayload
+	if err := json.Read(r, &payload); err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	codes, err := h.service.ConfirmTOTPEnrollment(r.Context(), claims.ID, payload.Code)
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrInvalidTOTPCode):
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		case errors.Is(err, ErrTOTPNotEnabled):
+			http.Error(w, "start enrolment first", http.StatusBadRequest)
+		case errors.Is(err, ErrTOTPAlreadyEnabled):
+			http.Error(w, err.Error(), http.StatusConflict)
+		default:
+			log.Println(err)
+			http.Error(w, "failed to confirm two-factor authentication", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	json.Write(w, http.StatusOK, RecoveryCodesResponse{RecoveryCodes: codes})
+}
+
+// replace recovery codes, requires a current code from the app
+func (h *handler) RegenerateRecoveryCodes(w http.ResponseWriter, r *http.Request) {
+	claims, ok := auth.FromContext(r.Context())
+	if !ok {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var payload TOTPCodePayload
+	if err := json.Read(r, &payload); err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	codes, err := h.service.RegenerateRecoveryCodes(r.Context(), claims.ID, payload.Code)
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrInvalidTOTPCode):
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		case errors.Is(err, ErrTOTPNotEnabled):
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		default:
+			log.Println(err)
+			http.Error(w, "failed to regenerate recovery codes", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	json.Write(w, http.StatusOK, RecoveryCodesResponse{RecoveryCodes: codes})
+}
+
+// turn off another staff member's 2FA, e.g. after a lost phone (by staff)
+func (h *handler) ResetUserTOTP(w http.ResponseWriter, r *http.Request) {
+	claims, ok := auth.FromContext(r.Context())
+	if !ok {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	idStr := chi.URLParam(r, "id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		http.Error(w, "invalid user id", http.StatusBadRequest)
+		return
+	}
+	// a stolen session must not be able to switch off its own 2FA
+	if int32(id) == claims.ID {
+		http.Error(w, "cannot reset your own two-factor authentication", http.StatusForbidden)
+		return
+	}
+
+	if err := h.service.ResetTOTP(r.Context(), int32(id)); err != nil {
+		if errors.Is(err, ErrTOTPNotEnabled) {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		log.Println(err)
+		http.Error(w, "failed to reset two-factor authentication", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,117 @@
+package authhttp
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	repo "hack4good-backend/db/sqlc"
+	"hack4good-backend/internal/auth"
+	"math/big"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const (
+	loginCodeDigits = 6
+	loginCodeTTL    = 10 * time.Minute
+
+	// at most this many codes per user within the window
+	maxLoginCodes     = 3
+	loginCodeWindow   = 15 * time.Minute
+	maxLoginCodeTries = 5
+)
+
+var (
+	ErrTooManyLoginCodes = errors.New("too many login codes requested")
+	ErrInvalidLoginCode  = errors.New("invalid or expired login code")
+)
+
+func randomDigits(n int) (string, error) {
+	max := big.NewInt(10)
+	code := make([]byte, n)
+	for i := range code {
+		d, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", err
+		}
+		code[i] = byte('0' + d.Int64())
+	}
+	return string(code), nil
+}
+
+// create a new single-use code for the user, replacing any earlier one
+func (s *svc) IssueLoginCode(ctx context.Context, userID int32, channel string) (string, error) {
+	recent, err := s.repo.CountRecentLoginCodes(ctx, repo.CountRecentLoginCodesParams{
+		UserID:    userID,
+		CreatedAt: pgtype.Timestamp{Time: time.Now().Add(-loginCodeWindow), Valid: true},
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to count login codes: %w", err)
+	}
+	if recent >= maxLoginCodes {
+		return "", ErrTooManyLoginCodes
+	}
+
+	code, err := randomDigits(loginCodeDigits)
+	if err != nil {
+		return "", fmt.Errorf("failed to generate login code: %w", err)
+	}
+	hash, err := auth.HashPassword(code)
+	if err != nil {
+		return "", fmt.Errorf("failed to hash login code: %w", err)
+	}
+
+	if err := s.repo.InvalidateLoginCodes(ctx, userID); err != nil {
+		return "", fmt.Errorf("failed to invalidate login codes: %w", err)
+	}
+	if _, err := s.repo.CreateLoginCode(ctx, repo.CreateLoginCodeParams{
+		UserID:    userID,
+		CodeHash:  hash,
+		Channel:   channel,
+		ExpiresAt: pgtype.Timestamp{Time: time.Now().Add(loginCodeTTL), Valid: true},
+	}); err != nil {
+		return "", fmt.Errorf("failed to create login code: %w", err)
+	}
+	return code, nil
+}
+
+// check the code against the user's active code and use it up
+func (s *svc) VerifyLoginCode(ctx context.Context, userID int32, code string) error {
+	active, err := s.repo.GetActiveLoginCode(ctx, userID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrInvalidLoginCode
+		}
+		return fmt.Errorf("failed to get login code: %w", err)
+	}
+
+	attempts, err := s.repo.IncrementLoginCodeAttempts(ctx, active.ID)
+	if err != nil {
+		return fmt.Errorf("failed to count login code attempt: %w", err)
+	}
+	if attempts > maxLoginCodeTries {
+		return ErrInvalidLoginCode
+	}
+
+	if !auth.CheckPassword(active.CodeHash, []byte(code)) {
+		// burn the code once the last try is used
+		if attempts == maxLoginCodeTries {
+			if _, err := s.repo.ConsumeLoginCode(ctx, active.ID); err != nil {
+				return fmt.Errorf("failed to expire login code: %w", err)
+			}
+		}
+		return ErrInvalidLoginCode
+	}
+
+	n, err := s.repo.ConsumeLoginCode(ctx, active.ID)
+	if err != nil {
+		return fmt.Errorf("failed to use login code: %w", err)
+	}
+	if n == 0 {
+		return ErrInvalidLoginCode
+	}
+	return nil
+}
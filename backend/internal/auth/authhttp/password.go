@@ -0,0 +1,123 @@
+package authhttp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	repo "hack4good-backend/db/sqlc"
+	"hack4good-backend/internal/auth"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const (
+	purposeSetup  = "setup"
+	purposeReset  = "reset"
+	purposeChange = "change" // handed out at login, not emailed
+
+	passwordSetupTTL  = 72 * time.Hour
+	passwordResetTTL  = 30 * time.Minute
+	passwordChangeTTL = 15 * time.Minute // forced change after login
+
+	// at most this many setup/reset links per user within the window
+	maxPasswordTokens   = 3
+	passwordTokenWindow = time.Hour
+)
+
+var (
+	ErrInvalidPasswordToken  = errors.New("invalid or expired password link")
+	ErrTooManyPasswordTokens = errors.New("too many password links requested")
+)
+
+// create a single-use set/reset password link, replacing earlier links
+func (s *svc) IssuePasswordToken(ctx context.Context, userID int32, purpose string, ttl time.Duration) (string, error) {
+	recent, err := s.repo.CountRecentPasswordTokens(ctx, repo.CountRecentPasswordTokensParams{
+		UserID:    userID,
+		CreatedAt: pgtype.Timestamp{Time: time.Now().Add(-passwordTokenWindow), Valid: true},
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to count password tokens: %w", err)
+	}
+	if recent >= maxPasswordTokens {
+		return "", ErrTooManyPasswordTokens
+	}
+
+	if err := s.repo.InvalidatePasswordLinks(ctx, userID); err != nil {
+		return "", fmt.Errorf("failed to invalidate password tokens: %w", err)
+	}
+	return s.createPasswordToken(ctx, userID, purpose, ttl)
+}
+
+// token for a forced change after a login with a temporary password; only someone who knows
+// the password gets one, so it is not rate limited and neither replaces nor is replaced by links
+func (s *svc) IssuePasswordChangeToken(ctx context.Context, userID int32) (string, error) {
+	return s.createPasswordToken(ctx, userID, purposeChange, passwordChangeTTL)
+}
+
+func (s *svc) createPasswordToken(ctx context.Context, userID int32, purpose string, ttl time.Duration) (string, error) {
+	token, hash, err := auth.NewOpaqueToken()
+	if err != nil {
+		return "", fmt.Errorf("failed to generate password token: %w", err)
+	}
+
+	if _, err := s.repo.CreatePasswordToken(ctx, repo.CreatePasswordTokenParams{
+		UserID:    userID,
+		TokenHash: hash,
+		Purpose:   purpose,
+		ExpiresAt: pgtype.Timestamp{Time: time.Now().Add(ttl), Valid: true},
+	}); err != nil {
+		return "", fmt.Errorf("failed to create password token: %w", err)
+	}
+	return token, nil
+}
+
+// find the user a still-valid token belongs to, without using it up
+func (s *svc) PasswordTokenUser(ctx context.Context, token string) (repo.User, error) {
+	t, err := s.repo.GetValidPasswordToken(ctx, auth.HashToken(token))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return repo.User{}, ErrInvalidPasswordToken
+		}
+		return repo.User{}, fmt.Errorf("failed to get password token: %w", err)
+	}
+
+	user, err := s.repo.GetUserByID(ctx, t.UserID)
+	if err != nil {
+		return repo.User{}, fmt.Errorf("failed to get user %d: %w", t.UserID, err)
+	}
+	return user, nil
+}
+
+// use up the token and set the new password
+func (s *svc) ResetPassword(ctx context.Context, token, password string) error {
+	t, err := s.repo.ConsumePasswordToken(ctx, auth.HashToken(token))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrInvalidPasswordToken
+		}
+		return fmt.Errorf("failed to use password token: %w", err)
+	}
+	return s.SetPassword(ctx, t.UserID, password, false)
+}
+
+// hash and store a password, dropping outstanding links and signed-in sessions
+func (s *svc) SetPassword(ctx context.Context, userID int32, password string, mustChange bool) error {
+	hash, err := auth.HashPassword(password)
+	if err != nil {
+		return fmt.Errorf("failed to hash password: %w", err)
+	}
+
+	if err := s.repo.SetUserPassword(ctx, repo.SetUserPasswordParams{
+		ID:                 userID,
+		Password:           hash,
+		PasswordMustChange: mustChange,
+	}); err != nil {
+		return fmt.Errorf("failed to set password for user %d: %w", userID, err)
+	}
+	if err := s.repo.InvalidatePasswordTokens(ctx, userID); err != nil {
+		return fmt.Errorf("failed to invalidate password tokens: %w", err)
+	}
+	return s.DeleteSessionsByUserID(ctx, userID)
+}
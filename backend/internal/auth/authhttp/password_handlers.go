@@ -0,0 +1,200 @@
+package authhttp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	repo "hack4good-backend/db/sqlc"
+	"hack4good-backend/internal/auth"
+	"hack4good-backend/internal/json"
+	"hack4good-backend/internal/notify"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// send a newly created staff account a link to choose their first password
+// (satisfies users.Onboarder)
+func (h *handler) Onboard(ctx context.Context, user repo.User) error {
+	if user.Role != "staff" {
+		return nil
+	}
+
+	token, err := h.service.IssuePasswordToken(ctx, user.ID, purposeSetup, passwordSetupTTL)
+	if err != nil {
+		return err
+	}
+
+	return h.notifier.Email.Send(ctx, notify.Message{
+		To:      user.Email,
+		Subject: "Set up your staff account",
+		Body: fmt.Sprintf("Hi %s,\n\nA staff account has been created for you. Choose your password here:\n%s\n\nThe link expires in %d hours.",
+			user.Name, h.link("/set-password", token), int(passwordSetupTTL.Hours())),
+	})
+}
+
+func (h *handler) link(path, token string) string {
+	return h.config.AppURL + path + "?token=" + url.QueryEscape(token)
+}
+
+// email a reset link to a staff account
+func (h *handler) ForgotPassword(w http.ResponseWriter, r *http.Request) {
+	var payload ForgotPasswordPayload
+	if err := json.Read(r, &payload); err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	// same answer whether or not the account exists
+	accepted := map[string]string{"message": "if the account exists, a reset link has been sent"}
+
+	user, err := h.userService.GetUserByEmail(r.Context(), payload.Email)
+	if err != nil || user.Role != "staff" {
+		json.Write(w, http.StatusAccepted, accepted)
+		return
+	}
+
+	token, err := h.service.IssuePasswordToken(r.Context(), user.ID, purposeReset, passwordResetTTL)
+	if err != nil {
+		if !errors.Is(err, ErrTooManyPasswordTokens) {
+			log.Println(err)
+		}
+		json.Write(w, http.StatusAccepted, accepted)
+		return
+	}
+
+	if err := h.notifier.Email.Send(r.Context(), notify.Message{
+		To:      user.Email,
+		Subject: "Reset your password",
+		Body: fmt.Sprintf("Hi %s,\n\nReset your password here:\n%s\n\nThe link expires in %d minutes. If you did not ask for this, ignore this email.",
+			user.Name, h.link("/reset-password", token), int(passwordResetTTL.Minutes())),
+	}); err != nil {
+		log.Println(err)
+	}
+
+	json.Write(w, http.StatusAccepted, accepted)
+}
+
+// set a password with a setup/reset token
+func (h *handler) ResetPassword(w http.ResponseWriter, r *http.Request) {
+	var payload ResetPasswordPayload
+	if err := json.Read(r, &payload); err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	user, err := h.service.PasswordTokenUser(r.Context(), payload.Token)
+	if err != nil {
+		if errors.Is(err, ErrInvalidPasswordToken) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		log.Println(err)
+		http.Error(w, "failed to reset password", http.StatusInternalServerError)
+		return
+	}
+
+	if err := h.config.PasswordPolicy.Validate(payload.NewPassword, user.Email, user.Name); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := h.service.ResetPassword(r.Context(), payload.Token, payload.NewPassword); err != nil {
+		if errors.Is(err, ErrInvalidPasswordToken) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		log.Println(err)
+		http.Error(w, "failed to reset password", http.StatusInternalServerError)
+		return
+	}
+
+	json.Write(w, http.StatusOK, map[string]string{"message": "password updated, please log in"})
+}
+
+// change own password (staff)
+func (h *handler) ChangePassword(w http.ResponseWriter, r *http.Request) {
+	claims, ok := auth.FromContext(r.Context())
+	if !ok {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var payload ChangePasswordPayload
+	if err := json.Read(r, &payload); err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	user, err := h.userService.GetUserByID(r.Context(), claims.ID)
+	if err != nil {
+		log.Println(err)
+		http.Error(w, "failed to change password", http.StatusInternalServerError)
+		return
+	}
+
+	storedPassword, _ := user.Password.(string)
+	if storedPassword == "" || !auth.CheckPassword(storedPassword, []byte(payload.CurrentPassword)) {
+		http.Error(w, "current password is incorrect", http.StatusUnauthorized)
+		return
+	}
+
+	if payload.NewPassword == payload.CurrentPassword {
+		http.Error(w, "new password must be different", http.StatusBadRequest)
+		return
+	}
+	if err := h.config.PasswordPolicy.Validate(payload.NewPassword, user.Email, user.Name); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := h.service.SetPassword(r.Context(), user.ID, payload.NewPassword, false); err != nil {
+		log.Println(err)
+		http.Error(w, "failed to change password", http.StatusInternalServerError)
+		return
+	}
+
+	json.Write(w, http.StatusOK, map[string]string{"message": "password changed, please log in again"})
+}
+
+// give a staff account a temporary password that must be changed on first login (by staff)
+func (h *handler) SetTemporaryPassword(w http.ResponseWriter, r *http.Request) {
+	idStr := chi.URLParam(r, "id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		http.Error(w, "invalid user id", http.StatusBadRequest)
+		return
+	}
+
+	var payload SetPasswordPayload
+	if err := json.Read(r, &payload); err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	user, err := h.userService.GetUserByID(r.Context(), int32(id))
+	if err != nil {
+		http.Error(w, "user not found", http.StatusNotFound)
+		return
+	}
+	if user.Role != "staff" {
+		http.Error(w, "only staff accounts have passwords", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.config.PasswordPolicy.Validate(payload.Password, user.Email, user.Name); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := h.service.SetPassword(r.Context(), user.ID, payload.Password, true); err != nil {
+		log.Println(err)
+		http.Error(w, "failed to set password", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,127 @@
+package authhttp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	repo "hack4good-backend/db/sqlc"
+	"hack4good-backend/internal/auth"
+	"hack4good-backend/internal/json"
+	"hack4good-backend/internal/notify"
+	"hack4good-backend/internal/users"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func (h *handler) toRegistration(u repo.User) Registration {
+	reg := Registration{
+		ID:           u.ID,
+		Name:         u.Name,
+		Email:        u.Email,
+		Role:         u.Role,
+		Status:       u.Status,
+		StatusReason: u.StatusReason.String,
+		CreatedAt:    u.CreatedAt.Time,
+	}
+	if u.StatusChangedBy.Valid {
+		reg.ReviewedBy = &u.StatusChangedBy.Int32
+	}
+	if u.StatusChangedAt.Valid {
+		reg.ReviewedAt = &u.StatusChangedAt.Time
+	}
+	if p, err := h.userService.RevealPhone(u); err != nil {
+		log.Println(err)
+	} else {
+		reg.Phone = p
+	}
+	return reg
+}
+
+// registrations waiting for review, oldest first (by staff)
+func (h *handler) ListRegistrations(w http.ResponseWriter, r *http.Request) {
+	pending, err := h.userService.ListUsersByStatus(r.Context(), users.StatusPending)
+	if err != nil {
+		log.Println(err)
+		http.Error(w, "failed to list registrations", http.StatusInternalServerError)
+		return
+	}
+
+	resp := make([]Registration, 0, len(pending))
+	for _, u := range pending {
+		resp = append(resp, h.toRegistration(u))
+	}
+
+	json.Write(w, http.StatusOK, resp)
+}
+
+// let a self-registered account log in (by staff)
+func (h *handler) ApproveRegistration(w http.ResponseWriter, r *http.Request) {
+	h.reviewRegistration(w, r, true, "")
+}
+
+// turn down a registration with a reason for the applicant (by staff)
+func (h *handler) RejectRegistration(w http.ResponseWriter, r *http.Request) {
+	var payload RejectRegistrationPayload
+	if err := json.Read(r, &payload); err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+	payload.Reason = strings.TrimSpace(payload.Reason)
+	if payload.Reason == "" {
+		http.Error(w, "reason is required", http.StatusBadRequest)
+		return
+	}
+
+	h.reviewRegistration(w, r, false, payload.Reason)
+}
+
+func (h *handler) reviewRegistration(w http.ResponseWriter, r *http.Request, approve bool, reason string) {
+	claims, ok := auth.FromContext(r.Context())
+	if !ok {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "invalid user id", http.StatusBadRequest)
+		return
+	}
+
+	user, err := h.userService.ReviewRegistration(r.Context(), int32(id), approve, reason, claims.ID)
+	if err != nil {
+		if errors.Is(err, users.ErrNotPending) {
+			http.Error(w, "no pending registration for this user", http.StatusNotFound)
+			return
+		}
+		log.Println(err)
+		http.Error(w, "failed to review registration", http.StatusInternalServerError)
+		return
+	}
+
+	if err := h.notifyReviewed(r.Context(), user); err != nil {
+		log.Println(err)
+	}
+
+	json.Write(w, http.StatusOK, h.toRegistration(user))
+}
+
+// tell the applicant the outcome
+func (h *handler) notifyReviewed(ctx context.Context, user repo.User) error {
+	msg := notify.Message{
+		To:      user.Email,
+		Subject: "Your Hack4Good registration",
+	}
+	if user.Status == users.StatusActive {
+		msg.Body = fmt.Sprintf("Hi %s,\n\nYour registration has been approved. You can now log in with a code sent to your email or phone:\n%s/login",
+			user.Name, h.config.AppURL)
+	} else {
+		msg.Body = fmt.Sprintf("Hi %s,\n\nUnfortunately your registration was not approved.\n\nReason: %s\n\nPlease contact us if you have any questions.",
+			user.Name, user.StatusReason.String)
+	}
+	return h.notifier.Email.Send(ctx, msg)
+}
@@ -2,36 +2,96 @@ package authhttp
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	repo "hack4good-backend/db/sqlc"
+	"hack4good-backend/internal/auth"
+	"hack4good-backend/internal/auth/oidc"
+	"hack4good-backend/internal/secure"
+	"time"
 
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+var (
+	ErrSessionInvalid     = errors.New("session invalid or revoked")
+	ErrRefreshTokenReused = errors.New("refresh token reuse detected")
+	ErrSessionNotFound    = errors.New("session not found")
+	ErrAccountNotActive   = errors.New("account is not active")
 )
 
 type Service interface {
 	CreateSession(ctx context.Context, params CreateSessionParams) (*repo.Session, error)
 	GetSession(ctx context.Context, sessionID string) (*repo.Session, error)
 	RevokeSession(ctx context.Context, sessionID string) error
+	RefreshSession(ctx context.Context, sessionID, refreshToken string, next CreateSessionParams) (*repo.Session, error)
+	RevokeSessionFamily(ctx context.Context, familyID string) error
+	RevokeUserSession(ctx context.Context, userID int32, familyID string) error
+	ListActiveSessions(ctx context.Context, userID int32) ([]repo.ListActiveSessionsByUserIDRow, error)
 	DeleteSessionsByUserID(ctx context.Context, userID int32) error
-
+	CheckLoginAllowed(ctx context.Context, email, ip string) (time.Duration, error)
+	RecordLoginFailure(ctx context.Context, email, ip string) error
+	RecordLoginSuccess(ctx context.Context, email string) error
+	ListLockouts(ctx context.Context) ([]repo.LoginThrottle, error)
+	Unlock(ctx context.Context, scope, subject string) error
+	IssueLoginCode(ctx context.Context, userID int32, channel string) (string, error)
+	VerifyLoginCode(ctx context.Context, userID int32, code string) error
+	IssuePasswordToken(ctx context.Context, userID int32, purpose string, ttl time.Duration) (string, error)
+	IssuePasswordChangeToken(ctx context.Context, userID int32) (string, error)
+	PasswordTokenUser(ctx context.Context, token string) (repo.User, error)
+	ResetPassword(ctx context.Context, token, password string) error
+	SetPassword(ctx context.Context, userID int32, password string, mustChange bool) error
+	BeginTOTPEnrollment(ctx context.Context, user repo.User) (secret, uri string, err error)
+	ConfirmTOTPEnrollment(ctx context.Context, userID int32, code string) ([]string, error)
+	TOTPStatus(ctx context.Context, userID int32) (enabled bool, recoveryCodesLeft int64, err error)
+	RegenerateRecoveryCodes(ctx context.Context, userID int32, code string) ([]string, error)
+	ResetTOTP(ctx context.Context, userID int32) error
+	CreateMFAChallenge(ctx context.Context, userID int32) (string, time.Time, error)
+	VerifyMFAChallenge(ctx context.Context, token, code, recoveryCode string) (int32, error)
+	RecordImpersonation(ctx context.Context, entry repo.CreateImpersonationLogParams) error
+	ListImpersonationLogs(ctx context.Context, limit int32) ([]repo.ImpersonationLog, error)
+	VerifyAPIKey(ctx context.Context, key string) (*auth.APIKey, error)
+	CreateAPIKey(ctx context.Context, name string, scopes []auth.Permission, createdBy int32, expiresAt *time.Time) (string, repo.ApiKey, error)
+	ListAPIKeys(ctx context.Context) ([]repo.ApiKey, error)
+	RevokeAPIKey(ctx context.Context, id int32) error
+	SessionActive(ctx context.Context, claims *auth.UserClaims) (bool, error)
+	SaveSSOState(ctx context.Context, req oidc.AuthRequest) error
+	ConsumeSSOState(ctx context.Context, state string) (oidc.AuthRequest, error)
 }
 
 type svc struct {
-	repo *repo.Queries
+	db      *pgxpool.Pool // for rotating refresh tokens
+	repo    *repo.Queries
+	totp    *auth.TOTP
+	secrets *secure.Cipher // encrypts TOTP secrets at rest
 }
 
-func NewService(repo *repo.Queries) Service {
+func NewService(db *pgxpool.Pool, totp *auth.TOTP, secrets *secure.Cipher) Service {
 	return &svc{
-		repo: repo,
+		db:      db,
+		repo:    repo.New(db),
+		totp:    totp,
+		secrets: secrets,
 	}
 }
 
 // create new session in DB
 func (s *svc) CreateSession(ctx context.Context, params CreateSessionParams) (*repo.Session, error) {
-	session, err := s.repo.CreateSession(ctx, repo.CreateSessionParams{
+	return createSession(ctx, s.repo, params)
+}
+
+func createSession(ctx context.Context, q *repo.Queries, params CreateSessionParams) (*repo.Session, error) {
+	session, err := q.CreateSession(ctx, repo.CreateSessionParams{
+		ID:           params.ID,
 		UserID:       params.UserID,
 		RefreshToken: params.RefreshToken,
 		IsRevoked:    params.IsRevoked,
 		ExpiresAt:    params.ExpiresAt,
+		FamilyID:     params.FamilyID,
+		UserAgent:    pgtype.Text{String: params.UserAgent, Valid: params.UserAgent != ""},
+		IpAddress:    pgtype.Text{String: params.IPAddress, Valid: params.IPAddress != ""},
 	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to create session: %w", err)
@@ -56,10 +116,140 @@ func (s *svc) RevokeSession(ctx context.Context, sessionID string) error {
 	return nil
 }
 
+// replace a session with next (same family and user) when its refresh token is used.
+// rotation and the new session are committed together, so a failure cannot leave the user
+// without a usable refresh token
+func (s *svc) RefreshSession(ctx context.Context, sessionID, refreshToken string, next CreateSessionParams) (*repo.Session, error) {
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+	q := s.repo.WithTx(tx)
+
+	old, err := s.rotateSession(ctx, q, sessionID, refreshToken, next.ID)
+	if err != nil {
+		return nil, err
+	}
+	next.FamilyID = old.FamilyID
+	next.UserID = old.UserID
+	session, err := createSession(ctx, q, next)
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit session: %w", err)
+	}
+	return session, nil
+}
+
+// mark session as used by a refresh and point it at its replacement.
+// presenting a refresh token that was already rotated revokes the whole family
+// (outside q's transaction, so the revocation sticks)
+func (s *svc) rotateSession(ctx context.Context, q *repo.Queries, sessionID, refreshToken, replacedBy string) (*repo.Session, error) {
+	session, err := q.GetSession(ctx, sessionID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrSessionInvalid
+		}
+		return nil, fmt.Errorf("failed to get session: %w", err)
+	}
+
+	if err := checkRefresh(session, refreshToken, time.Now()); err != nil {
+		if errors.Is(err, ErrRefreshTokenReused) {
+			if err := s.RevokeSessionFamily(ctx, session.FamilyID); err != nil {
+				return nil, err
+			}
+		}
+		return nil, err
+	}
+
+	rotated, err := q.RotateSession(ctx, repo.RotateSessionParams{
+		ID:         sessionID,
+		ReplacedBy: pgtype.Text{String: replacedBy, Valid: true},
+	})
+	if err != nil {
+		// another request rotated this token first
+		if errors.Is(err, pgx.ErrNoRows) {
+			if err := s.RevokeSessionFamily(ctx, session.FamilyID); err != nil {
+				return nil, err
+			}
+			return nil, ErrRefreshTokenReused
+		}
+		return nil, fmt.Errorf("failed to rotate session: %w", err)
+	}
+	return &rotated, nil
+}
+
+// whether refreshToken may still be exchanged for session's replacement
+func checkRefresh(session repo.Session, refreshToken string, now time.Time) error {
+	if session.RefreshToken != refreshToken {
+		return ErrSessionInvalid
+	}
+	// checked before revocation: a rotated token showing up again means it leaked
+	if session.RotatedAt.Valid {
+		return ErrRefreshTokenReused
+	}
+	if session.IsRevoked || now.After(session.ExpiresAt.Time) {
+		return ErrSessionInvalid
+	}
+	return nil
+}
+
+// revoke every session descended from the same login
+func (s *svc) RevokeSessionFamily(ctx context.Context, familyID string) error {
+	if err := s.repo.RevokeSessionFamily(ctx, familyID); err != nil {
+		return fmt.Errorf("failed to revoke session family %s: %w", familyID, err)
+	}
+	return nil
+}
+
+// revoke a session family only if it belongs to the given user
+func (s *svc) RevokeUserSession(ctx context.Context, userID int32, familyID string) error {
+	n, err := s.repo.RevokeSessionFamilyForUser(ctx, repo.RevokeSessionFamilyForUserParams{
+		FamilyID: familyID,
+		UserID:   userID,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to revoke session %s: %w", familyID, err)
+	}
+	if n == 0 {
+		return ErrSessionNotFound
+	}
+	return nil
+}
+
+// list sessions that can still be refreshed, newest activity first
+func (s *svc) ListActiveSessions(ctx context.Context, userID int32) ([]repo.ListActiveSessionsByUserIDRow, error) {
+	sessions, err := s.repo.ListActiveSessionsByUserID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list sessions for user %d: %w", userID, err)
+	}
+	return sessions, nil
+}
+
 // delete session for given user
 func (s *svc) DeleteSessionsByUserID(ctx context.Context, userID int32) error {
 	if err := s.repo.DeleteSessionsByUserID(ctx, userID); err != nil {
 		return fmt.Errorf("failed to delete sessions for user %d: %w", userID, err)
 	}
 	return nil
-}
\ No newline at end of file
+}
+// an access token is good while its session family has an unrevoked session and the account
+// is active (satisfies auth.SessionChecker); impersonation tokens have no session, so they only
+// need both the user and the staff member behind them to be active
+func (s *svc) SessionActive(ctx context.Context, claims *auth.UserClaims) (bool, error) {
+	if claims.Impersonated() {
+		return s.repo.AreImpersonationUsersActive(ctx, repo.AreImpersonationUsersActiveParams{
+			UserID:         claims.ID,
+			ImpersonatorID: claims.ImpersonatorID,
+		})
+	}
+	if claims.SessionID == "" {
+		return false, nil
+	}
+	return s.repo.IsSessionActive(ctx, repo.IsSessionActiveParams{
+		FamilyID: claims.SessionID,
+		UserID:   claims.ID,
+	})
+}
@@ -0,0 +1,45 @@
+package authhttp
+
+import (
+	"errors"
+	repo "hack4good-backend/db/sqlc"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+func TestCheckRefresh(t *testing.T) {
+	now := time.Now()
+	at := func(t time.Time) pgtype.Timestamp { return pgtype.Timestamp{Time: t, Valid: true} }
+	live := repo.Session{ID: "s1", FamilyID: "f1", RefreshToken: "token-1", ExpiresAt: at(now.Add(time.Hour))}
+
+	tests := []struct {
+		name    string
+		change  func(s *repo.Session)
+		token   string
+		wantErr error
+	}{
+		{"live session", func(s *repo.Session) {}, "token-1", nil},
+		{"other token", func(s *repo.Session) {}, "token-2", ErrSessionInvalid},
+		{"expired", func(s *repo.Session) { s.ExpiresAt = at(now.Add(-time.Second)) }, "token-1", ErrSessionInvalid},
+		{"revoked", func(s *repo.Session) { s.IsRevoked = true }, "token-1", ErrSessionInvalid},
+		{"already rotated", func(s *repo.Session) { s.RotatedAt = at(now.Add(-time.Minute)) }, "token-1", ErrRefreshTokenReused},
+		{"rotated then revoked", func(s *repo.Session) {
+			s.RotatedAt = at(now.Add(-time.Minute))
+			s.IsRevoked = true
+		}, "token-1", ErrRefreshTokenReused},
+		{"rotated then expired", func(s *repo.Session) {
+			s.RotatedAt = at(now.Add(-time.Minute))
+			s.ExpiresAt = at(now.Add(-time.Second))
+		}, "token-1", ErrRefreshTokenReused},
+		{"rotated, other token", func(s *repo.Session) { s.RotatedAt = at(now.Add(-time.Minute)) }, "token-2", ErrSessionInvalid},
+	}
+	for _, tt := range tests {
+		session := live
+		tt.change(&session)
+		if err := checkRefresh(session, tt.token, now); !errors.Is(err, tt.wantErr) || (tt.wantErr == nil && err != nil) {
+			t.Errorf("%s: checkRefresh = %v, want %v", tt.name, err, tt.wantErr)
+		}
+	}
+}
@@ -0,0 +1,209 @@
+package authhttp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	repo "hack4good-backend/db/sqlc"
+	"hack4good-backend/internal/auth"
+	"hack4good-backend/internal/auth/oidc"
+	"hack4good-backend/internal/users"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const (
+	ssoStateTTL = 10 * time.Minute
+
+	// ties the callback to the browser that started the login
+	ssoStateCookie = "h4g_sso_state"
+	ssoCookiePath  = "/api/sso"
+)
+
+var ErrInvalidSSOState = errors.New("invalid or expired SSO state")
+
+// remember nonce and PKCE verifier until the provider redirects back
+func (s *svc) SaveSSOState(ctx context.Context, req oidc.AuthRequest) error {
+	if err := s.repo.DeleteExpiredOIDCLoginStates(ctx); err != nil {
+		return fmt.Errorf("failed to delete expired SSO states: %w", err)
+	}
+	if err := s.repo.CreateOIDCLoginState(ctx, repo.CreateOIDCLoginStateParams{
+		StateHash:    auth.HashToken(req.State),
+		Nonce:        req.Nonce,
+		CodeVerifier: req.CodeVerifier,
+		ExpiresAt:    pgtype.Timestamp{Time: time.Now().Add(ssoStateTTL), Valid: true},
+	}); err != nil {
+		return fmt.Errorf("failed to save SSO state: %w", err)
+	}
+	return nil
+}
+
+// a state can only be used once
+func (s *svc) ConsumeSSOState(ctx context.Context, state string) (oidc.AuthRequest, error) {
+	stored, err := s.repo.ConsumeOIDCLoginState(ctx, auth.HashToken(state))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return oidc.AuthRequest{}, ErrInvalidSSOState
+		}
+		return oidc.AuthRequest{}, fmt.Errorf("failed to get SSO state: %w", err)
+	}
+	return oidc.AuthRequest{
+		State:        state,
+		Nonce:        stored.Nonce,
+		CodeVerifier: stored.CodeVerifier,
+	}, nil
+}
+
+// redirect a staff member to the organisation's identity provider
+func (h *handler) StartSSO(w http.ResponseWriter, r *http.Request) {
+	if h.config.SSO == nil {
+		http.Error(w, "single sign-on is not configured", http.StatusNotFound)
+		return
+	}
+
+	req, err := oidc.NewAuthRequest()
+	if err != nil {
+		log.Println(err)
+		http.Error(w, "failed to start single sign-on", http.StatusInternalServerError)
+		return
+	}
+	if err := h.service.SaveSSOState(r.Context(), req); err != nil {
+		log.Println(err)
+		http.Error(w, "failed to start single sign-on", http.StatusInternalServerError)
+		return
+	}
+
+	authURL, err := h.config.SSO.AuthCodeURL(r.Context(), req)
+	if err != nil {
+		log.Println(err)
+		http.Error(w, "identity provider unavailable", http.StatusBadGateway)
+		return
+	}
+
+	// Lax so the cookie comes back on the provider's top-level redirect
+	http.SetCookie(w, &http.Cookie{
+		Name:     ssoStateCookie,
+		Value:    req.State,
+		Path:     ssoCookiePath,
+		MaxAge:   int(ssoStateTTL.Seconds()),
+		Secure:   h.config.Cookies.Secure,
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	})
+	http.Redirect(w, r, authURL, http.StatusFound)
+}
+
+// the provider redirects here; sign the staff member in with session cookies and send them to the dashboard
+func (h *handler) SSOCallback(w http.ResponseWriter, r *http.Request) {
+	if h.config.SSO == nil {
+		http.Error(w, "single sign-on is not configured", http.StatusNotFound)
+		return
+	}
+
+	http.SetCookie(w, &http.Cookie{Name: ssoStateCookie, Path: ssoCookiePath, MaxAge: -1})
+
+	q := r.URL.Query()
+	if e := q.Get("error"); e != "" {
+		h.ssoFailed(w, r, e)
+		return
+	}
+
+	state := q.Get("state")
+	cookie, err := r.Cookie(ssoStateCookie)
+	if state == "" || err != nil || cookie.Value != state {
+		h.ssoFailed(w, r, "invalid_state")
+		return
+	}
+
+	req, err := h.service.ConsumeSSOState(r.Context(), state)
+	if err != nil {
+		if !errors.Is(err, ErrInvalidSSOState) {
+			log.Println(err)
+		}
+		h.ssoFailed(w, r, "invalid_state")
+		return
+	}
+
+	claims, err := h.config.SSO.Exchange(r.Context(), q.Get("code"), req)
+	if err != nil {
+		log.Println(err)
+		h.ssoFailed(w, r, "invalid_token")
+		return
+	}
+	if claims.Email == "" || !claims.EmailVerified {
+		h.ssoFailed(w, r, "email_not_verified")
+		return
+	}
+
+	user, err := h.ssoUser(r.Context(), claims)
+	if err != nil {
+		if !errors.Is(err, errSSONotAllowed) {
+			log.Println(err)
+		}
+		h.ssoFailed(w, r, "not_allowed")
+		return
+	}
+
+	// a staff member with an admin-set temporary password still signs in; the provider vouches for them
+	resp, err := h.newSession(r, user)
+	if err != nil {
+		log.Println(err)
+		h.ssoFailed(w, r, "server_error")
+		return
+	}
+	if err := h.setSessionCookies(w, resp); err != nil {
+		log.Println(err)
+		h.ssoFailed(w, r, "server_error")
+		return
+	}
+
+	http.Redirect(w, r, h.config.AppURL+"/dashboard/staff", http.StatusFound)
+}
+
+var errSSONotAllowed = errors.New("no staff account for this email")
+
+// existing staff account for the verified email, or a new one when auto-provisioning allows it
+func (h *handler) ssoUser(ctx context.Context, claims *oidc.Claims) (repo.User, error) {
+	email := normalizeEmail(claims.Email)
+
+	user, err := h.userService.GetUserByEmail(ctx, email)
+	if err == nil {
+		if user.Role != "staff" {
+			return repo.User{}, errSSONotAllowed
+		}
+		return user, nil
+	}
+	if !errors.Is(err, pgx.ErrNoRows) {
+		return repo.User{}, fmt.Errorf("failed to get user: %w", err)
+	}
+
+	if !h.config.SSO.Config.CanProvision(email) {
+		return repo.User{}, errSSONotAllowed
+	}
+
+	name := strings.TrimSpace(claims.Name)
+	if name == "" {
+		name = email
+	}
+	user, err = h.userService.CreateUser(ctx, users.CreateUserParams{
+		Name:  name,
+		Email: email,
+		Role:  "staff",
+	})
+	if err != nil {
+		return repo.User{}, fmt.Errorf("failed to provision staff account: %w", err)
+	}
+	log.Printf("provisioned staff account %d for %s via SSO", user.ID, email)
+	return user, nil
+}
+
+// back to the frontend login page with a short reason
+func (h *handler) ssoFailed(w http.ResponseWriter, r *http.Request, reason string) {
+	http.Redirect(w, r, h.config.AppURL+"/login?sso_error="+url.QueryEscape(reason), http.StatusFound)
+}
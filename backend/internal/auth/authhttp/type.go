@@ -2,11 +2,20 @@ package authhttp
 
 import (
 	repo "hack4good-backend/db/sqlc"
+	"hack4good-backend/internal/auth"
+	"hack4good-backend/internal/auth/oidc"
 	"time"
 
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
+type Config struct {
+	AppURL         string // frontend base URL used in links sent to users
+	PasswordPolicy auth.PasswordPolicy
+	Cookies        auth.CookieConfig // for browser sessions (X-Auth-Mode: cookie)
+	SSO            *oidc.Provider    // nil when single sign-on is not configured
+}
+
 type User struct {
 	ID int32
 	Name string
@@ -29,37 +38,210 @@ type RegisterUserPayload struct {
 	Name  string `json:"name"`
 	Phone string `json:"phone"`
 	Email string `json:"email"`
-	Role  string `json:"role"` // participant, caregiver, volunteer, staff
+	Role  string `json:"role"` // participant, caregiver or volunteer
 }
 
 type LoginUserPayload struct {
 	Email  string `json:"email"`
-	Phone string `json:"phone,omitempty"`
 	Password string `json:"password,omitempty"`
 }
 
+// identify the account by email or phone, exactly one of them
+type RequestLoginCodePayload struct {
+	Email string `json:"email,omitempty"`
+	Phone string `json:"phone,omitempty"`
+}
+
+type VerifyLoginCodePayload struct {
+	Email string `json:"email,omitempty"`
+	Phone string `json:"phone,omitempty"`
+	Code  string `json:"code"`
+}
+
 type RenewAccessTokenPayload struct {
-	RefreshToken string `json:"refresh_token"`
+	RefreshToken string `json:"refresh_token"` // empty in cookie mode
 }
 
 type RenewAccessTokenResponse struct {
-	AccessToken          string    `json:"access_token"`
-	AccessTokenExpiresAt time.Time `json:"access_token_expires_at"`
+	SessionID             string    `json:"session_id"`
+	AccessToken           string    `json:"access_token,omitempty"`
+	RefreshToken          string    `json:"refresh_token,omitempty"`
+	AccessTokenExpiresAt  time.Time `json:"access_token_expires_at"`
+	RefreshTokenExpiresAt time.Time `json:"refresh_token_expires_at"`
+	CSRFToken             string    `json:"csrf_token,omitempty"` // cookie mode: send back in X-CSRF-Token
 }
 
 type CreateSessionParams struct {
+	ID           string // jti of the refresh token
+	FamilyID     string // ID of the first session created at login
 	UserID       int32
 	RefreshToken string
 	IsRevoked    bool
 	ExpiresAt    pgtype.Timestamp
 	CreatedAt    pgtype.Timestamp
+	UserAgent    string
+	IPAddress    string
+}
+
+// a signed-in device as shown to the user
+type SessionInfo struct {
+	ID         string    `json:"id"`
+	UserAgent  string    `json:"user_agent"`
+	IPAddress  string    `json:"ip_address"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastUsedAt time.Time `json:"last_used_at"`
+	ExpiresAt  time.Time `json:"expires_at"`
+	Current    bool      `json:"current"`
+}
+
+// convert DB session row to response session
+func toSessionInfo(s repo.ListActiveSessionsByUserIDRow, currentSessionID string) SessionInfo {
+	return SessionInfo{
+		ID:         s.FamilyID,
+		UserAgent:  s.UserAgent.String,
+		IPAddress:  s.IpAddress.String,
+		CreatedAt:  s.SignedInAt.Time,
+		LastUsedAt: s.LastUsedAt.Time,
+		ExpiresAt:  s.ExpiresAt.Time,
+		Current:    s.FamilyID == currentSessionID,
+	}
 }
 
 type SessionResponse struct {
 	SessionID             string    `json:"session_id"`
-	AccessToken           string    `json:"access_token"`
-	RefreshToken          string    `json:"refresh_token"`
+	AccessToken           string    `json:"access_token,omitempty"`
+	RefreshToken          string    `json:"refresh_token,omitempty"`
 	AccessTokenExpiresAt  time.Time `json:"access_token_expires_at"`
 	RefreshTokenExpiresAt time.Time `json:"refresh_token_expires_at"`
+	CSRFToken             string    `json:"csrf_token,omitempty"` // cookie mode: send back in X-CSRF-Token
 	User                  User      `json:"user"`
-}
\ No newline at end of file
+}
+
+type UnlockPayload struct {
+	Scope   string `json:"scope"`   // account or ip
+	Subject string `json:"subject"` // email or IP address
+}
+
+type LoginLockout struct {
+	Scope          string    `json:"scope"`
+	Subject        string    `json:"subject"`
+	FailedAttempts int32     `json:"failed_attempts"`
+	LockedUntil    time.Time `json:"locked_until"`
+	LastFailedAt   time.Time `json:"last_failed_at"`
+}
+
+// convert DB throttle to response lockout
+func toLoginLockout(t repo.LoginThrottle) LoginLockout {
+	return LoginLockout{
+		Scope:          t.Scope,
+		Subject:        t.Subject,
+		FailedAttempts: t.FailedAttempts,
+		LockedUntil:    t.LockedUntil.Time,
+		LastFailedAt:   t.LastFailedAt.Time,
+	}
+}
+
+type ForgotPasswordPayload struct {
+	Email string `json:"email"`
+}
+
+type ResetPasswordPayload struct {
+	Token       string `json:"token"`
+	NewPassword string `json:"new_password"`
+}
+
+type ChangePasswordPayload struct {
+	CurrentPassword string `json:"current_password"`
+	NewPassword     string `json:"new_password"`
+}
+
+type SetPasswordPayload struct {
+	Password string `json:"password"` // temporary, must be changed on first login
+}
+
+// returned instead of tokens when a staff member must pick a new password
+type PasswordChangeRequiredResponse struct {
+	Error         string `json:"error"`
+	PasswordToken string `json:"password_token"`
+}
+
+// returned by login instead of tokens when the account has 2FA enabled
+type MFAChallengeResponse struct {
+	MFARequired    bool      `json:"mfa_required"`
+	ChallengeToken string    `json:"challenge_token"`
+	ExpiresAt      time.Time `json:"expires_at"`
+}
+
+type VerifyMFAPayload struct {
+	ChallengeToken string `json:"challenge_token"`
+	Code           string `json:"code"`          // from the authenticator app
+	RecoveryCode   string `json:"recovery_code"` // instead of code
+}
+
+type TOTPCodePayload struct {
+	Code string `json:"code"`
+}
+
+type TOTPEnrollmentResponse struct {
+	Secret          string `json:"secret"`
+	ProvisioningURI string `json:"provisioning_uri"` // render as QR code
+}
+
+type RecoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recovery_codes"` // shown once, store them safely
+}
+
+type TOTPStatusResponse struct {
+	Enabled           bool  `json:"enabled"`
+	RecoveryCodesLeft int64 `json:"recovery_codes_left"`
+}
+
+type ImpersonationResponse struct {
+	AccessToken          string    `json:"access_token"`
+	AccessTokenExpiresAt time.Time `json:"access_token_expires_at"`
+	UserID               int32     `json:"user_id"`
+	UserName             string    `json:"user_name"`
+	UserRole             string    `json:"user_role"`
+}
+
+type CreateAPIKeyPayload struct {
+	Name      string     `json:"name"`
+	Scopes    []string   `json:"scopes"`     // permissions, e.g. "activities:read"
+	ExpiresAt *time.Time `json:"expires_at"` // optional
+}
+
+// an API key as shown to staff (the key itself is only returned on creation)
+type APIKeyResponse struct {
+	ID         int32      `json:"id"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	Scopes     []string   `json:"scopes"`
+	CreatedBy  *int32     `json:"created_by"`
+	ExpiresAt  *time.Time `json:"expires_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	RevokedAt  *time.Time `json:"revoked_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+type CreatedAPIKeyResponse struct {
+	APIKeyResponse
+	Key string `json:"key"` // shown once, store it safely
+}
+
+type RejectRegistrationPayload struct {
+	Reason string `json:"reason"` // sent to the applicant
+}
+
+// self-registered account waiting for staff
+type Registration struct {
+	ID           int32      `json:"id"`
+	Name         string     `json:"name"`
+	Email        string     `json:"email"`
+	Phone        string     `json:"phone"`
+	Role         string     `json:"role"`
+	Status       string     `json:"status"`
+	StatusReason string     `json:"status_reason,omitempty"`
+	ReviewedBy   *int32     `json:"reviewed_by,omitempty"`
+	ReviewedAt   *time.Time `json:"reviewed_at,omitempty"`
+	CreatedAt    time.Time  `json:"created_at"`
+}
@@ -0,0 +1,110 @@
+package auth
+
+import (
+	"crypto/subtle"
+	"hack4good-backend/internal/env"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// browser sessions keep tokens in HttpOnly cookies instead of JS-readable storage;
+// state-changing requests must echo the (readable) CSRF cookie in a header (double submit)
+const (
+	AccessTokenCookie  = "h4g_access"
+	RefreshTokenCookie = "h4g_refresh"
+	CSRFCookie         = "h4g_csrf"
+	CSRFHeader         = "X-CSRF-Token"
+
+	// clients opt into cookies by sending this header with login / refresh requests
+	AuthModeHeader = "X-Auth-Mode"
+	AuthModeCookie = "cookie"
+
+	// refresh cookie is only sent to the auth endpoints
+	refreshCookiePath = "/api"
+)
+
+type CookieConfig struct {
+	Secure   bool
+	SameSite http.SameSite
+	Domain   string
+}
+
+// COOKIE_SECURE   (default true, set false for plain http in development)
+// COOKIE_SAMESITE (strict, lax or none; default lax)
+// COOKIE_DOMAIN   (default host-only)
+func CookieConfigFromEnv() CookieConfig {
+	sameSite := http.SameSiteLaxMode
+	switch strings.ToLower(env.GetString("COOKIE_SAMESITE", "lax")) {
+	case "strict":
+		sameSite = http.SameSiteStrictMode
+	case "none":
+		sameSite = http.SameSiteNoneMode
+	}
+	return CookieConfig{
+		Secure:   env.GetString("COOKIE_SECURE", "true") != "false",
+		SameSite: sameSite,
+		Domain:   env.GetString("COOKIE_DOMAIN", ""),
+	}
+}
+
+// whether the client asked for cookie-based auth
+func WantsCookies(r *http.Request) bool {
+	return strings.EqualFold(r.Header.Get(AuthModeHeader), AuthModeCookie)
+}
+
+// set access, refresh and CSRF cookies, returns the CSRF token for the response body
+func (c CookieConfig) SetSessionCookies(w http.ResponseWriter, accessToken string, accessExpiresAt time.Time, refreshToken string, refreshExpiresAt time.Time) (string, error) {
+	csrf, _, err := NewOpaqueToken()
+	if err != nil {
+		return "", err
+	}
+
+	http.SetCookie(w, c.cookie(AccessTokenCookie, accessToken, "/", accessExpiresAt, true))
+	http.SetCookie(w, c.cookie(RefreshTokenCookie, refreshToken, refreshCookiePath, refreshExpiresAt, true))
+	// readable by the frontend so it can copy it into the CSRF header
+	http.SetCookie(w, c.cookie(CSRFCookie, csrf, "/", refreshExpiresAt, false))
+	return csrf, nil
+}
+
+// expire all session cookies (logout)
+func (c CookieConfig) ClearSessionCookies(w http.ResponseWriter) {
+	for _, ck := range []*http.Cookie{
+		c.cookie(AccessTokenCookie, "", "/", time.Time{}, true),
+		c.cookie(RefreshTokenCookie, "", refreshCookiePath, time.Time{}, true),
+		c.cookie(CSRFCookie, "", "/", time.Time{}, false),
+	} {
+		ck.MaxAge = -1
+		http.SetCookie(w, ck)
+	}
+}
+
+func (c CookieConfig) cookie(name, value, path string, expires time.Time, httpOnly bool) *http.Cookie {
+	return &http.Cookie{
+		Name:     name,
+		Value:    value,
+		Path:     path,
+		Domain:   c.Domain,
+		Expires:  expires,
+		Secure:   c.Secure,
+		HttpOnly: httpOnly,
+		SameSite: c.SameSite,
+	}
+}
+
+func isSafeMethod(method string) bool {
+	return method == http.MethodGet || method == http.MethodHead || method == http.MethodOptions
+}
+
+// double-submit check: the CSRF header must match the CSRF cookie
+func ValidCSRF(r *http.Request) bool {
+	if isSafeMethod(r.Method) {
+		return true
+	}
+	cookie, err := r.Cookie(CSRFCookie)
+	if err != nil || cookie.Value == "" {
+		return false
+	}
+	header := r.Header.Get(CSRFHeader)
+	return subtle.ConstantTimeCompare([]byte(cookie.Value), []byte(header)) == 1
+}
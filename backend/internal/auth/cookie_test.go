@@ -0,0 +1,120 @@
+package auth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestValidCSRF(t *testing.T) {
+	tests := []struct {
+		name   string
+		method string
+		cookie string
+		header string
+		want   bool
+	}{
+		{"GET without token", http.MethodGet, "", "", true},
+		{"HEAD without token", http.MethodHead, "", "", true},
+		{"OPTIONS without token", http.MethodOptions, "", "", true},
+		{"POST with matching token", http.MethodPost, "abc", "abc", true},
+		{"PATCH with matching token", http.MethodPatch, "abc", "abc", true},
+		{"POST without cookie", http.MethodPost, "", "abc", false},
+		{"POST without header", http.MethodPost, "abc", "", false},
+		{"POST with neither", http.MethodPost, "", "", false},
+		{"DELETE with other token", http.MethodDelete, "abc", "abd", false},
+		{"PUT with a prefix of the token", http.MethodPut, "abc", "ab", false},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest(tt.method, "/", nil)
+		if tt.cookie != "" {
+			r.AddCookie(&http.Cookie{Name: CSRFCookie, Value: tt.cookie})
+		}
+		if tt.header != "" {
+			r.Header.Set(CSRFHeader, tt.header)
+		}
+		if got := ValidCSRF(r); got != tt.want {
+			t.Errorf("%s: ValidCSRF = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestSetSessionCookies(t *testing.T) {
+	c := CookieConfig{Secure: true, SameSite: http.SameSiteLaxMode}
+	w := httptest.NewRecorder()
+	csrf, err := c.SetSessionCookies(w, "access-token", time.Now().Add(time.Minute), "refresh-token", time.Now().Add(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cookies := map[string]*http.Cookie{}
+	for _, ck := range w.Result().Cookies() {
+		cookies[ck.Name] = ck
+	}
+	tests := []struct {
+		name     string
+		value    string
+		path     string
+		httpOnly bool
+	}{
+		{AccessTokenCookie, "access-token", "/", true},
+		{RefreshTokenCookie, "refresh-token", "/api", true},
+		{CSRFCookie, csrf, "/", false}, // the frontend copies it into the header
+	}
+	for _, tt := range tests {
+		ck, ok := cookies[tt.name]
+		if !ok {
+			t.Errorf("cookie %s not set", tt.name)
+			continue
+		}
+		if ck.Value != tt.value || ck.Path != tt.path || ck.HttpOnly != tt.httpOnly || !ck.Secure || ck.SameSite != http.SameSiteLaxMode {
+			t.Errorf("cookie %s = %+v", tt.name, ck)
+		}
+	}
+	if csrf == "" {
+		t.Error("SetSessionCookies returned an empty CSRF token")
+	}
+}
+
+func TestCookieAuthNeedsCSRF(t *testing.T) {
+	maker := NewJWTMaker("test-secret")
+	token, _, err := maker.CreateSessionToken(1, "Ann", "participant", "family-1", time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	handler := RequireRole(maker)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	tests := []struct {
+		name   string
+		method string
+		bearer bool // token in the Authorization header instead of the cookie
+		csrf   string
+		want   int
+	}{
+		{"cookie GET", http.MethodGet, false, "", http.StatusNoContent},
+		{"cookie POST with CSRF header", http.MethodPost, false, "csrf-1", http.StatusNoContent},
+		{"cookie POST without CSRF header", http.MethodPost, false, "", http.StatusForbidden},
+		{"cookie POST with wrong CSRF header", http.MethodPost, false, "csrf-2", http.StatusForbidden},
+		{"bearer POST without CSRF header", http.MethodPost, true, "", http.StatusNoContent},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest(tt.method, "/", nil)
+		if tt.bearer {
+			r.Header.Set("Authorization", "Bearer "+token)
+		} else {
+			r.AddCookie(&http.Cookie{Name: AccessTokenCookie, Value: token})
+			r.AddCookie(&http.Cookie{Name: CSRFCookie, Value: "csrf-1"})
+		}
+		if tt.csrf != "" {
+			r.Header.Set(CSRFHeader, tt.csrf)
+		}
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		if w.Code != tt.want {
+			t.Errorf("%s: status = %d, want %d", tt.name, w.Code, tt.want)
+		}
+	}
+}
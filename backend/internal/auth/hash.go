@@ -1,12 +1,16 @@
 package auth
 
 import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
 	"log"
 
 	"golang.org/x/crypto/bcrypt"
 )
 
-// hash a phone number (replaces password)
+// hash a phone number (legacy, phones are now encrypted; see internal/phone)
 func HashPhone(phone string) (string, error) {
 	hash, err := bcrypt.GenerateFromPassword([]byte(phone), bcrypt.DefaultCost)
 	if err != nil {
@@ -17,7 +21,7 @@ func HashPhone(phone string) (string, error) {
 	return string(hash), nil
 }
 
-// compare phone with hash
+// compare phone with legacy hash (used by cmd/migratephones)
 func CheckPhone (hashed string, plain []byte) bool {
 	err := bcrypt.CompareHashAndPassword([]byte(hashed), plain)
 	return err == nil
@@ -38,4 +42,20 @@ func HashPassword(password string) (string, error) {
 func CheckPassword (hashed string, plain []byte) bool {
 	err := bcrypt.CompareHashAndPassword([]byte(hashed), plain)
 	return err == nil
-}
\ No newline at end of file
+}
+
+// random URL-safe token for links sent to users; only its hash is stored
+func NewOpaqueToken() (token, hash string, err error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", err
+	}
+	token = base64.RawURLEncoding.EncodeToString(b)
+	return token, HashToken(token), nil
+}
+
+// sha256 of a high-entropy token (bcrypt is unnecessary and prevents lookups)
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -1,6 +1,8 @@
 package auth
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"time"
 
@@ -8,8 +10,21 @@ import (
 	"github.com/google/uuid"
 )
 
+var (
+	ErrSessionRevoked     = errors.New("session revoked")
+	ErrSessionCheckFailed = errors.New("failed to check session")
+)
+
 type JWTMaker struct {
-	secretKey string
+	secretKey string         // HS256 shared secret, used when keys is nil
+	keys      *KeyStore      // asymmetric signing keys (EdDSA/RS256)
+	sessions  SessionChecker // nil trusts access tokens until they expire
+}
+
+// reports whether the session an access token belongs to is still live,
+// so logging out or revoking it takes effect right away
+type SessionChecker interface {
+	SessionActive(ctx context.Context, claims *UserClaims) (bool, error)
 }
 
 func NewJWTMaker(secretKey string) *JWTMaker {
@@ -18,15 +33,69 @@ func NewJWTMaker(secretKey string) *JWTMaker {
 	}
 }
 
-// generate JWT for user
-func (maker *JWTMaker) CreateToken(userID int32, name, role string, duration time.Duration) (string, *UserClaims, error) {
+// token maker signing with the current key of the key store
+func NewAsymmetricJWTMaker(keys *KeyStore) *JWTMaker {
+	return &JWTMaker{
+		keys: keys,
+	}
+}
+
+// check every access token against its session from now on
+func (maker *JWTMaker) CheckSessions(sessions SessionChecker) {
+	maker.sessions = sessions
+}
+
+// generate refresh JWT for user; its ID is the session it belongs to
+func (maker *JWTMaker) CreateRefreshToken(userID int32, name, role string, duration time.Duration) (string, *UserClaims, error) {
 	claims, err := NewUserClaims(userID, name, role, duration)
 	if err != nil {
 		return "", nil, fmt.Errorf("failed to create user claims: %w", err)
 	}
+	claims.TokenType = TokenRefresh
+
+	return maker.sign(claims)
+}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	signedToken, err := token.SignedString([]byte(maker.secretKey))
+// generate JWT for user bound to a login session
+func (maker *JWTMaker) CreateSessionToken(userID int32, name, role, sessionID string, duration time.Duration) (string, *UserClaims, error) {
+	claims, err := NewUserClaims(userID, name, role, duration)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to create user claims: %w", err)
+	}
+	claims.SessionID = sessionID
+	claims.TokenType = TokenAccess
+
+	return maker.sign(claims)
+}
+
+// generate short-lived JWT for staff acting on behalf of a user; the claims are the user's
+// so the staff member sees exactly what they see. not tied to a session and not refreshable
+func (maker *JWTMaker) CreateImpersonationToken(staffID, userID int32, name, role string, duration time.Duration) (string, *UserClaims, error) {
+	claims, err := NewUserClaims(userID, name, role, duration)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to create user claims: %w", err)
+	}
+	claims.ImpersonatorID = staffID
+	claims.TokenType = TokenAccess
+
+	return maker.sign(claims)
+}
+
+func (maker *JWTMaker) sign(claims *UserClaims) (string, *UserClaims, error) {
+	var signedToken string
+	var err error
+	if maker.keys != nil {
+		key := maker.keys.Current()
+		if key == nil {
+			return "", nil, fmt.Errorf("no signing key available")
+		}
+		token := jwt.NewWithClaims(key.Method, claims)
+		token.Header["kid"] = key.ID
+		signedToken, err = token.SignedString(key.Private)
+	} else {
+		token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+		signedToken, err = token.SignedString([]byte(maker.secretKey))
+	}
 	if err != nil {
 		return "", nil, fmt.Errorf("failed to sign token: %w", err)
 	}
@@ -35,13 +104,7 @@ func (maker *JWTMaker) CreateToken(userID int32, name, role string, duration tim
 
 // validates JWT
 func (maker *JWTMaker) VerifyToken(tokenString string) (*UserClaims, error) {
-	token, err := jwt.ParseWithClaims(tokenString, &UserClaims{}, func(token *jwt.Token) (interface{}, error) {
-		// Ensure the signing method is HMAC
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, fmt.Errorf("invalid signing method")
-		}
-		return []byte(maker.secretKey), nil
-	})
+	token, err := jwt.ParseWithClaims(tokenString, &UserClaims{}, maker.keyFunc)
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse token: %w", err)
 	}
@@ -51,7 +114,74 @@ func (maker *JWTMaker) VerifyToken(tokenString string) (*UserClaims, error) {
 		return nil, fmt.Errorf("invalid token")
 	}
 	return claims, nil
-}	
+}
+
+// validates a JWT and checks it is of the given type, so a refresh token cannot be used as an access token
+func (maker *JWTMaker) VerifyTokenType(tokenString, tokenType string) (*UserClaims, error) {
+	claims, err := maker.VerifyToken(tokenString)
+	if err != nil {
+		return nil, err
+	}
+	if claims.TokenType != tokenType {
+		return nil, fmt.Errorf("expected a %s token, got %q", tokenType, claims.TokenType)
+	}
+	return claims, nil
+}
+
+// validates an access token and, when sessions are checked, that its session was not revoked
+func (maker *JWTMaker) VerifyAccessToken(ctx context.Context, tokenString string) (*UserClaims, error) {
+	claims, err := maker.VerifyTokenType(tokenString, TokenAccess)
+	if err != nil {
+		return nil, err
+	}
+	if maker.sessions == nil {
+		return claims, nil
+	}
+	active, err := maker.sessions.SessionActive(ctx, claims)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrSessionCheckFailed, err)
+	}
+	if !active {
+		return nil, ErrSessionRevoked
+	}
+	return claims, nil
+}
+
+// pick the verification key for a token
+func (maker *JWTMaker) keyFunc(token *jwt.Token) (interface{}, error) {
+	if maker.keys == nil {
+		// Ensure the signing method is HMAC
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("invalid signing method")
+		}
+		return []byte(maker.secretKey), nil
+	}
+
+	kid, _ := token.Header["kid"].(string)
+	key, ok := maker.keys.Lookup(kid)
+	if !ok {
+		return nil, fmt.Errorf("unknown signing key %q", kid)
+	}
+	// Ensure the token is signed with the key's algorithm
+	if token.Method.Alg() != key.Method.Alg() {
+		return nil, fmt.Errorf("invalid signing method")
+	}
+	return key.Public(), nil
+}
+
+// public keys for /.well-known/jwks.json (empty for HS256)
+func (maker *JWTMaker) JWKS() JWKSet {
+	set := JWKSet{Keys: []JWK{}}
+	if maker.keys == nil {
+		return set
+	}
+	for _, key := range maker.keys.PublicKeys() {
+		if jwk, ok := toJWK(key); ok {
+			set.Keys = append(set.Keys, jwk)
+		}
+	}
+	return set
+}
 
 // create JWT claims for user
 func NewUserClaims(userID int32, name, role string, duration time.Duration) (*UserClaims, error) {
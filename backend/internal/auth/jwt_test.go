@@ -0,0 +1,73 @@
+package auth
+
+import (
+	"testing"
+	"time"
+)
+
+func TestVerifyTokenType(t *testing.T) {
+	maker := NewJWTMaker("test-secret")
+	token := func(create func() (string, *UserClaims, error)) string {
+		t.Helper()
+		raw, _, err := create()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return raw
+	}
+	access := token(func() (string, *UserClaims, error) {
+		return maker.CreateSessionToken(1, "Ann", "participant", "family-1", time.Minute)
+	})
+	refresh := token(func() (string, *UserClaims, error) {
+		return maker.CreateRefreshToken(1, "Ann", "participant", time.Hour)
+	})
+	expired := token(func() (string, *UserClaims, error) {
+		return maker.CreateRefreshToken(1, "Ann", "participant", -time.Minute)
+	})
+	otherSecret := token(func() (string, *UserClaims, error) {
+		return NewJWTMaker("other-secret").CreateRefreshToken(1, "Ann", "participant", time.Hour)
+	})
+
+	tests := []struct {
+		name      string
+		token     string
+		tokenType string
+		wantErr   bool
+	}{
+		{"refresh token to refresh", refresh, TokenRefresh, false},
+		{"access token to refresh", access, TokenRefresh, true},
+		{"access token to authenticate", access, TokenAccess, false},
+		{"refresh token to authenticate", refresh, TokenAccess, true},
+		{"expired refresh token", expired, TokenRefresh, true},
+		{"signed with another secret", otherSecret, TokenRefresh, true},
+		{"not a token", "not-a-token", TokenRefresh, true},
+	}
+	for _, tt := range tests {
+		claims, err := maker.VerifyTokenType(tt.token, tt.tokenType)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("%s: VerifyTokenType = %+v, want error", tt.name, claims)
+			}
+			continue
+		}
+		if err != nil || claims.ID != 1 || claims.TokenType != tt.tokenType {
+			t.Errorf("%s: VerifyTokenType = %+v, %v", tt.name, claims, err)
+		}
+	}
+}
+
+func TestRefreshTokensAreDistinct(t *testing.T) {
+	maker := NewJWTMaker("test-secret")
+	_, a, err := maker.CreateRefreshToken(1, "Ann", "participant", time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, b, err := maker.CreateRefreshToken(1, "Ann", "participant", time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the token ID is the session ID, so two logins must not share one
+	if a.RegisteredClaims.ID == "" || a.RegisteredClaims.ID == b.RegisteredClaims.ID {
+		t.Errorf("refresh token IDs %q and %q", a.RegisteredClaims.ID, b.RegisteredClaims.ID)
+	}
+}
@@ -0,0 +1,295 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+// asymmetric key used to sign tokens, identified by kid
+type SigningKey struct {
+	ID        string
+	Method    jwt.SigningMethod
+	Private   crypto.Signer
+	CreatedAt time.Time
+}
+
+func (k *SigningKey) Public() crypto.PublicKey {
+	return k.Private.Public()
+}
+
+// KeyStore keeps the signing keys on disk, signs with the newest one and
+// keeps older keys around for verification until tokens signed by them expire
+type KeyStore struct {
+	mu          sync.RWMutex
+	dir         string
+	method      jwt.SigningMethod
+	rotateEvery time.Duration
+	retainFor   time.Duration
+	keys        []*SigningKey // oldest first
+}
+
+// create key store for "EdDSA" or "RS256"; retainFor must cover the longest token lifetime
+func NewKeyStore(dir, algorithm string, rotateEvery, retainFor time.Duration) (*KeyStore, error) {
+	method, err := signingMethod(algorithm)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("failed to create key directory: %w", err)
+	}
+
+	s := &KeyStore{
+		dir:         dir,
+		method:      method,
+		rotateEvery: rotateEvery,
+		retainFor:   retainFor,
+	}
+	if err := s.load(); err != nil {
+		return nil, err
+	}
+
+	if current := s.Current(); current == nil || time.Since(current.CreatedAt) >= rotateEvery {
+		if err := s.Rotate(); err != nil {
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+func signingMethod(algorithm string) (jwt.SigningMethod, error) {
+	switch algorithm {
+	case "EdDSA":
+		return jwt.SigningMethodEdDSA, nil
+	case "RS256":
+		return jwt.SigningMethodRS256, nil
+	default:
+		return nil, fmt.Errorf("unsupported signing algorithm %q", algorithm)
+	}
+}
+
+// keys are stored as <created unix time>_<kid>.pem, so their age survives copies and backups
+func keyFileName(key *SigningKey) string {
+	return fmt.Sprintf("%d_%s.pem", key.CreatedAt.Unix(), key.ID)
+}
+
+// kid and creation time from a key file name; ok is false for the old <kid>.pem names
+func parseKeyFileName(path string) (kid string, createdAt time.Time, ok bool) {
+	name := strings.TrimSuffix(filepath.Base(path), ".pem")
+	created, kid, found := strings.Cut(name, "_")
+	if !found {
+		return name, time.Time{}, false
+	}
+	unix, err := strconv.ParseInt(created, 10, 64)
+	if err != nil {
+		return name, time.Time{}, false
+	}
+	return kid, time.Unix(unix, 0), true
+}
+
+// read every key file in the key directory
+func (s *KeyStore) load() error {
+	paths, err := filepath.Glob(filepath.Join(s.dir, "*.pem"))
+	if err != nil {
+		return fmt.Errorf("failed to list keys: %w", err)
+	}
+
+	for _, path := range paths {
+		key, err := readKey(path)
+		if err != nil {
+			return err
+		}
+		if want := filepath.Join(s.dir, keyFileName(key)); path != want {
+			// old name, keep the time it was created from now on
+			if err := os.Rename(path, want); err != nil {
+				return fmt.Errorf("failed to rename key %s: %w", path, err)
+			}
+		}
+		if key.Method.Alg() != s.method.Alg() {
+			log.Printf("skipping key %s: algorithm %s does not match %s", key.ID, key.Method.Alg(), s.method.Alg())
+			continue
+		}
+		s.keys = append(s.keys, key)
+	}
+
+	sort.Slice(s.keys, func(i, j int) bool {
+		return s.keys[i].CreatedAt.Before(s.keys[j].CreatedAt)
+	})
+	s.prune(time.Now())
+	return nil
+}
+
+func readKey(path string) (*SigningKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read key %s: %w", path, err)
+	}
+	kid, createdAt, ok := parseKeyFileName(path)
+	if !ok {
+		// keys written before the creation time was in the name
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to stat key %s: %w", path, err)
+		}
+		createdAt = info.ModTime()
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("key %s is not PEM encoded", path)
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse key %s: %w", path, err)
+	}
+
+	key := &SigningKey{
+		ID:        kid,
+		CreatedAt: createdAt,
+	}
+	switch k := parsed.(type) {
+	case ed25519.PrivateKey:
+		key.Method = jwt.SigningMethodEdDSA
+		key.Private = k
+	case *rsa.PrivateKey:
+		key.Method = jwt.SigningMethodRS256
+		key.Private = k
+	default:
+		return nil, fmt.Errorf("key %s has unsupported type %T", path, parsed)
+	}
+	return key, nil
+}
+
+// generate a new signing key, persist it and make it current
+func (s *KeyStore) Rotate() error {
+	var private crypto.Signer
+	switch s.method {
+	case jwt.SigningMethodEdDSA:
+		_, k, err := ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			return fmt.Errorf("failed to generate key: %w", err)
+		}
+		private = k
+	default:
+		k, err := rsa.GenerateKey(rand.Reader, 2048)
+		if err != nil {
+			return fmt.Errorf("failed to generate key: %w", err)
+		}
+		private = k
+	}
+
+	der, err := x509.MarshalPKCS8PrivateKey(private)
+	if err != nil {
+		return fmt.Errorf("failed to encode key: %w", err)
+	}
+
+	key := &SigningKey{
+		ID:        uuid.NewString(),
+		Method:    s.method,
+		Private:   private,
+		CreatedAt: time.Now().Truncate(time.Second), // as stored in the file name
+	}
+
+	path := filepath.Join(s.dir, keyFileName(key))
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), 0o600); err != nil {
+		return fmt.Errorf("failed to write key: %w", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.keys = append(s.keys, key)
+	s.prune(key.CreatedAt)
+
+	log.Printf("rotated JWT signing key, new kid %s", key.ID)
+	return nil
+}
+
+// drop keys whose successor took over longer ago than any token can live
+func (s *KeyStore) prune(now time.Time) {
+	kept := s.keys[:0]
+	for i, key := range s.keys {
+		if i+1 < len(s.keys) && s.keys[i+1].CreatedAt.Add(s.retainFor).Before(now) {
+			if err := os.Remove(filepath.Join(s.dir, keyFileName(key))); err != nil && !os.IsNotExist(err) {
+				log.Println(err)
+			}
+			continue
+		}
+		kept = append(kept, key)
+	}
+	s.keys = kept
+}
+
+// rotate in the background whenever the current key is older than rotateEvery
+func (s *KeyStore) StartRotation(checkEvery time.Duration) (stop func()) {
+	ticker := time.NewTicker(checkEvery)
+	done := make(chan struct{})
+
+	go func() {
+		for {
+			select {
+			case <-ticker.C:
+				if current := s.Current(); current != nil && time.Since(current.CreatedAt) < s.rotateEvery {
+					s.mu.Lock()
+					s.prune(time.Now())
+					s.mu.Unlock()
+					continue
+				}
+				if err := s.Rotate(); err != nil {
+					log.Println(err)
+				}
+			case <-done:
+				ticker.Stop()
+				return
+			}
+		}
+	}()
+
+	return func() { close(done) }
+}
+
+// key used for signing new tokens
+func (s *KeyStore) Current() *SigningKey {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if len(s.keys) == 0 {
+		return nil
+	}
+	return s.keys[len(s.keys)-1]
+}
+
+// key for verifying a token with the given kid
+func (s *KeyStore) Lookup(kid string) (*SigningKey, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for _, key := range s.keys {
+		if key.ID == kid {
+			return key, true
+		}
+	}
+	return nil, false
+}
+
+// public halves of all keys still valid for verification
+func (s *KeyStore) PublicKeys() []*SigningKey {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	keys := make([]*SigningKey, len(s.keys))
+	copy(keys, s.keys)
+	return keys
+}
@@ -2,7 +2,9 @@ package auth
 
 import (
 	"context"
+	"errors"
 	"hack4good-backend/internal/json"
+	"log"
 	"net/http"
 	"strings"
 )
@@ -17,33 +19,8 @@ func RequireRole(tokenMaker *JWTMaker, allowedRoles ...string) func(http.Handler
 
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-
-			// 1. Read Authorization header
-			authHeader := r.Header.Get("Authorization")
-			if authHeader == "" {
-				json.Write(w,http.StatusUnauthorized, map[string]string{
-					"error": "missing authorization header",
-				})
-				return
-			}
-
-			// Expect: "Bearer <token>"
-			parts := strings.Split(authHeader, " ")
-			if len(parts) != 2 || strings.ToLower(parts[0]) != "bearer" {
-				json.Write(w, http.StatusUnauthorized, map[string]string{
-					"error": "invalid authorization format",
-				})
-				return
-			}
-
-			token := parts[1]
-
-			// 2. Verify JWT
-			claims, err := tokenMaker.VerifyToken(token)
-			if err != nil {
-				json.Write(w, http.StatusUnauthorized, map[string]string{
-					"error": "invalid or expired token",
-				})
+			claims, ok := authenticate(w, r, tokenMaker)
+			if !ok {
 				return
 			}
 
@@ -64,6 +41,168 @@ func RequireRole(tokenMaker *JWTMaker, allowedRoles ...string) func(http.Handler
 	}
 }
 
+// checks permissions for route groups, for users (JWT) and integrations (API key)
+type Guard struct {
+	tokenMaker *JWTMaker
+	roles      RolePermissions
+	apiKeys    APIKeyVerifier // nil disables API keys
+}
+
+func NewGuard(tokenMaker *JWTMaker, roles RolePermissions, apiKeys APIKeyVerifier) *Guard {
+	return &Guard{
+		tokenMaker: tokenMaker,
+		roles:      roles,
+		apiKeys:    apiKeys,
+	}
+}
+
+// allow the request when the user's role (or the API key's scopes) has at least one of perms
+func (g *Guard) RequirePermission(perms ...Permission) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var claims *UserClaims
+			var granted map[Permission]struct{}
+
+			if key, ok := apiKeyFromRequest(r); ok && g.apiKeys != nil {
+				apiKey, err := g.apiKeys.VerifyAPIKey(r.Context(), key)
+				if err != nil {
+					json.Write(w, http.StatusUnauthorized, map[string]string{
+						"error": "invalid or expired API key",
+					})
+					return
+				}
+				claims = apiKey.Claims()
+				granted = set(apiKey.Scopes...)
+			} else {
+				c, ok := authenticate(w, r, g.tokenMaker)
+				if !ok {
+					return
+				}
+				claims = c
+				granted = g.roles[claims.Role]
+			}
+
+			allowed := len(perms) == 0
+			for _, p := range perms {
+				if _, ok := granted[p]; ok {
+					allowed = true
+					break
+				}
+			}
+			if !allowed {
+				json.Write(w, http.StatusForbidden, map[string]string{
+					"error": "forbidden",
+				})
+				return
+			}
+
+			// claims and granted permissions for resource-level checks in handlers
+			ctx := context.WithValue(r.Context(), AuthKey{}, claims)
+			ctx = context.WithValue(ctx, PermissionsKey{}, granted)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// read and verify the bearer token (or the access cookie for browser sessions), writing 401 on failure
+func authenticate(w http.ResponseWriter, r *http.Request, tokenMaker *JWTMaker) (*UserClaims, bool) {
+	// 1. Read Authorization header
+	authHeader := r.Header.Get("Authorization")
+	if authHeader == "" {
+		return authenticateCookie(w, r, tokenMaker)
+	}
+
+	// Expect: "Bearer <token>"
+	parts := strings.Split(authHeader, " ")
+	if len(parts) != 2 || strings.ToLower(parts[0]) != "bearer" {
+		json.Write(w, http.StatusUnauthorized, map[string]string{
+			"error": "invalid authorization format",
+		})
+		return nil, false
+	}
+
+	token := parts[1]
+
+	// 2. Verify JWT
+	claims, err := tokenMaker.VerifyAccessToken(r.Context(), token)
+	if err != nil {
+		return nil, tokenError(w, err)
+	}
+	return claims, true
+}
+
+// browsers send cookies automatically, so writes also need the CSRF header
+func authenticateCookie(w http.ResponseWriter, r *http.Request, tokenMaker *JWTMaker) (*UserClaims, bool) {
+	cookie, err := r.Cookie(AccessTokenCookie)
+	if err != nil || cookie.Value == "" {
+		json.Write(w, http.StatusUnauthorized, map[string]string{
+			"error": "missing authorization header",
+		})
+		return nil, false
+	}
+
+	if !ValidCSRF(r) {
+		json.Write(w, http.StatusForbidden, map[string]string{
+			"error": "invalid CSRF token",
+		})
+		return nil, false
+	}
+
+	claims, err := tokenMaker.VerifyAccessToken(r.Context(), cookie.Value)
+	if err != nil {
+		return nil, tokenError(w, err)
+	}
+	return claims, true
+}
+
+// write the 401 for a token that failed verification (500 when its session could not be looked up)
+func tokenError(w http.ResponseWriter, err error) bool {
+	switch {
+	case errors.Is(err, ErrSessionRevoked):
+		json.Write(w, http.StatusUnauthorized, map[string]string{
+			"error": "session revoked",
+		})
+	case errors.Is(err, ErrSessionCheckFailed):
+		log.Println(err)
+		json.Write(w, http.StatusInternalServerError, map[string]string{
+			"error": "failed to check session",
+		})
+	default:
+		json.Write(w, http.StatusUnauthorized, map[string]string{
+			"error": "invalid or expired token",
+		})
+	}
+	return false
+}
+
+// refuse impersonation tokens on account-level routes (password, sessions, 2FA)
+// must run after RequireRole or RequirePermission
+func RejectImpersonation(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if claims, ok := FromContext(r.Context()); ok && claims.Impersonated() {
+			json.Write(w, http.StatusForbidden, map[string]string{
+				"error": "not allowed while acting on behalf of a user",
+			})
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// refuse API keys on routes that record who acted (bookings, account changes, impersonation)
+// must run after RequirePermission
+func RejectAPIKeys(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if claims, ok := FromContext(r.Context()); ok && claims.IsAPIKey() {
+			json.Write(w, http.StatusForbidden, map[string]string{
+				"error": "not allowed with an API key",
+			})
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
 func FromContext(ctx context.Context) (*UserClaims, bool) {
 	claims, ok := ctx.Value(AuthKey{}).(*UserClaims)
 	return claims, ok
@@ -0,0 +1,70 @@
+package oidc
+
+import (
+	"hack4good-backend/internal/env"
+	"strings"
+)
+
+type Config struct {
+	IssuerURL    string
+	ClientID     string
+	ClientSecret string // empty for public clients (PKCE only)
+	RedirectURL  string // our callback, registered with the provider
+	Scopes       []string
+
+	// create staff accounts for unknown emails in these domains (empty: any domain)
+	AutoProvision  bool
+	AllowedDomains []string
+}
+
+// OIDC_ISSUER_URL (SSO is disabled when empty)
+// OIDC_CLIENT_ID, OIDC_CLIENT_SECRET, OIDC_REDIRECT_URL
+// OIDC_SCOPES          (default "openid email profile")
+// OIDC_AUTO_PROVISION  (default false)
+// OIDC_ALLOWED_DOMAINS (comma separated)
+func ConfigFromEnv() (Config, bool) {
+	issuer := env.GetString("OIDC_ISSUER_URL", "")
+	if issuer == "" {
+		return Config{}, false
+	}
+	return Config{
+		IssuerURL:      strings.TrimRight(issuer, "/"),
+		ClientID:       env.GetString("OIDC_CLIENT_ID", ""),
+		ClientSecret:   env.GetString("OIDC_CLIENT_SECRET", ""),
+		RedirectURL:    env.GetString("OIDC_REDIRECT_URL", ""),
+		Scopes:         strings.Fields(env.GetString("OIDC_SCOPES", "openid email profile")),
+		AutoProvision:  env.GetString("OIDC_AUTO_PROVISION", "false") == "true",
+		AllowedDomains: splitList(env.GetString("OIDC_ALLOWED_DOMAINS", "")),
+	}, true
+}
+
+func splitList(s string) []string {
+	var out []string
+	for _, part := range strings.Split(s, ",") {
+		if part = strings.ToLower(strings.TrimSpace(part)); part != "" {
+			out = append(out, part)
+		}
+	}
+	return out
+}
+
+// whether an unknown email may get a new staff account
+func (c Config) CanProvision(email string) bool {
+	if !c.AutoProvision {
+		return false
+	}
+	if len(c.AllowedDomains) == 0 {
+		return true
+	}
+	at := strings.LastIndex(email, "@")
+	if at < 0 {
+		return false
+	}
+	domain := strings.ToLower(email[at+1:])
+	for _, d := range c.AllowedDomains {
+		if domain == d {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,234 @@
+package oidc
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	stdjson "encoding/json"
+	"errors"
+	"hack4good-backend/internal/auth"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	testClientID = "h4g-dashboard"
+	testKeyID    = "issuer-key-1"
+	testNonce    = "nonce-123"
+)
+
+// OIDC provider serving discovery, its signing key and a token endpoint returning idToken
+type fakeIssuer struct {
+	*httptest.Server
+	key     ed25519.PrivateKey
+	idToken string
+}
+
+func newFakeIssuer(t *testing.T) *fakeIssuer {
+	t.Helper()
+	pub, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f := &fakeIssuer{key: key}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, metadata{
+			Issuer:                f.URL,
+			AuthorizationEndpoint: f.URL + "/authorize",
+			TokenEndpoint:         f.URL + "/token",
+			JWKSURI:               f.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("GET /jwks", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, auth.JWKSet{Keys: []auth.JWK{{
+			Kty: "OKP",
+			Kid: testKeyID,
+			Alg: "EdDSA",
+			Use: "sig",
+			Crv: "Ed25519",
+			X:   base64.RawURLEncoding.EncodeToString(pub),
+		}}})
+	})
+	mux.HandleFunc("POST /token", func(w http.ResponseWriter, r *http.Request) {
+		if r.FormValue("code") != "good-code" || r.FormValue("code_verifier") == "" {
+			http.Error(w, `{"error":"invalid_grant"}`, http.StatusBadRequest)
+			return
+		}
+		writeJSON(w, map[string]string{"id_token": f.idToken})
+	})
+	f.Server = httptest.NewServer(mux)
+	t.Cleanup(f.Close)
+	return f
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	stdjson.NewEncoder(w).Encode(v)
+}
+
+func (f *fakeIssuer) provider() *Provider {
+	return NewProvider(Config{
+		IssuerURL:   f.URL,
+		ClientID:    testClientID,
+		RedirectURL: "http://localhost:3000/sso/callback",
+		Scopes:      []string{"openid", "email"},
+	}, f.Client())
+}
+
+// claims of a token the provider accepts
+func (f *fakeIssuer) claims() Claims {
+	now := time.Now()
+	return Claims{
+		Email:         "staff@example.org",
+		EmailVerified: true,
+		Name:          "Staff Member",
+		Nonce:         testNonce,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    f.URL,
+			Subject:   "staff-1",
+			Audience:  jwt.ClaimStrings{testClientID},
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(5 * time.Minute)),
+		},
+	}
+}
+
+func (f *fakeIssuer) sign(t *testing.T, claims Claims, key ed25519.PrivateKey, kid string) string {
+	t.Helper()
+	token := jwt.NewWithClaims(jwt.SigningMethodEdDSA, claims)
+	token.Header["kid"] = kid
+	raw, err := token.SignedString(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return raw
+}
+
+func TestVerifyIDToken(t *testing.T) {
+	f := newFakeIssuer(t)
+	_, otherKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		token   func(t *testing.T) string
+		nonce   string
+		wantErr bool
+	}{
+		{"valid", func(t *testing.T) string { return f.sign(t, f.claims(), f.key, testKeyID) }, testNonce, false},
+		{"nonce mismatch", func(t *testing.T) string { return f.sign(t, f.claims(), f.key, testKeyID) }, "other-nonce", true},
+		{"missing nonce", func(t *testing.T) string {
+			c := f.claims()
+			c.Nonce = ""
+			return f.sign(t, c, f.key, testKeyID)
+		}, testNonce, true},
+		{"other audience", func(t *testing.T) string {
+			c := f.claims()
+			c.Audience = jwt.ClaimStrings{"someone-else"}
+			return f.sign(t, c, f.key, testKeyID)
+		}, testNonce, true},
+		{"expired", func(t *testing.T) string {
+			c := f.claims()
+			c.ExpiresAt = jwt.NewNumericDate(time.Now().Add(-2 * time.Minute)) // past the 1 minute leeway
+			return f.sign(t, c, f.key, testKeyID)
+		}, testNonce, true},
+		{"expired within leeway", func(t *testing.T) string {
+			c := f.claims()
+			c.ExpiresAt = jwt.NewNumericDate(time.Now().Add(-30 * time.Second))
+			return f.sign(t, c, f.key, testKeyID)
+		}, testNonce, false},
+		{"no expiry", func(t *testing.T) string {
+			c := f.claims()
+			c.ExpiresAt = nil
+			return f.sign(t, c, f.key, testKeyID)
+		}, testNonce, true},
+		{"other issuer", func(t *testing.T) string {
+			c := f.claims()
+			c.Issuer = "https://evil.example.org"
+			return f.sign(t, c, f.key, testKeyID)
+		}, testNonce, true},
+		{"missing subject", func(t *testing.T) string {
+			c := f.claims()
+			c.Subject = ""
+			return f.sign(t, c, f.key, testKeyID)
+		}, testNonce, true},
+		{"signed by another key", func(t *testing.T) string { return f.sign(t, f.claims(), otherKey, testKeyID) }, testNonce, true},
+		{"unknown key id", func(t *testing.T) string { return f.sign(t, f.claims(), f.key, "issuer-key-2") }, testNonce, true},
+		{"tampered payload", func(t *testing.T) string {
+			// another payload with the issuer's signature of the original one
+			raw := f.sign(t, f.claims(), f.key, testKeyID)
+			c := f.claims()
+			c.Email = "admin@example.org"
+			forged := f.sign(t, c, otherKey, testKeyID)
+			return forged[:strings.LastIndex(forged, ".")] + raw[strings.LastIndex(raw, "."):]
+		}, testNonce, true},
+		{"unsigned", func(t *testing.T) string {
+			raw, err := jwt.NewWithClaims(jwt.SigningMethodNone, f.claims()).SignedString(jwt.UnsafeAllowNoneSignatureType)
+			if err != nil {
+				t.Fatal(err)
+			}
+			return raw
+		}, testNonce, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims, err := f.provider().VerifyIDToken(context.Background(), tt.token(t), tt.nonce)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidIDToken) {
+					t.Errorf("VerifyIDToken error = %v, want ErrInvalidIDToken", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("VerifyIDToken: %v", err)
+			}
+			if claims.Email != "staff@example.org" || claims.Subject != "staff-1" {
+				t.Errorf("VerifyIDToken claims = %+v", claims)
+			}
+		})
+	}
+}
+
+func TestVerifyIDTokenIssuerMismatch(t *testing.T) {
+	f := newFakeIssuer(t)
+	p := f.provider()
+	p.Config.IssuerURL = f.URL + "/other" // discovery answers with a different issuer
+
+	_, err := p.VerifyIDToken(context.Background(), f.sign(t, f.claims(), f.key, testKeyID), testNonce)
+	if err == nil {
+		t.Error("VerifyIDToken with mismatched discovery issuer: want error")
+	}
+}
+
+func TestExchange(t *testing.T) {
+	f := newFakeIssuer(t)
+	f.idToken = f.sign(t, f.claims(), f.key, testKeyID)
+	req := AuthRequest{State: "state", Nonce: testNonce, CodeVerifier: "verifier"}
+
+	claims, err := f.provider().Exchange(context.Background(), "good-code", req)
+	if err != nil {
+		t.Fatalf("Exchange: %v", err)
+	}
+	if claims.Email != "staff@example.org" {
+		t.Errorf("Exchange email = %q", claims.Email)
+	}
+
+	if _, err := f.provider().Exchange(context.Background(), "bad-code", req); err == nil {
+		t.Error("Exchange with a rejected code: want error")
+	}
+
+	req.Nonce = "other-nonce"
+	if _, err := f.provider().Exchange(context.Background(), "good-code", req); !errors.Is(err, ErrInvalidIDToken) {
+		t.Errorf("Exchange with another nonce error = %v, want ErrInvalidIDToken", err)
+	}
+}
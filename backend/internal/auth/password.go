@@ -0,0 +1,76 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"hack4good-backend/internal/env"
+	"strings"
+	"unicode"
+)
+
+var ErrWeakPassword = errors.New("password does not meet the password policy")
+
+// rules a staff password must satisfy
+type PasswordPolicy struct {
+	MinLength  int
+	MinClasses int // of lower case, upper case, digits, symbols
+}
+
+// common passwords rejected regardless of length
+var commonPasswords = map[string]struct{}{
+	"password1234": {}, "passwordpassword": {}, "123456789012": {}, "qwertyuiopas": {},
+	"iloveyou1234": {}, "welcome12345": {}, "hack4good2026": {}, "changeme1234": {},
+}
+
+// PASSWORD_MIN_LENGTH  (default 12)
+// PASSWORD_MIN_CLASSES (default 3)
+func PasswordPolicyFromEnv() PasswordPolicy {
+	return PasswordPolicy{
+		MinLength:  env.GetInt("PASSWORD_MIN_LENGTH", 12),
+		MinClasses: env.GetInt("PASSWORD_MIN_CLASSES", 3),
+	}
+}
+
+// check password against the policy; email and name must not be part of it
+func (p PasswordPolicy) Validate(password, email, name string) error {
+	if len([]rune(password)) < p.MinLength {
+		return fmt.Errorf("%w: must be at least %d characters", ErrWeakPassword, p.MinLength)
+	}
+
+	var lower, upper, digit, symbol bool
+	for _, c := range password {
+		switch {
+		case unicode.IsLower(c):
+			lower = true
+		case unicode.IsUpper(c):
+			upper = true
+		case unicode.IsDigit(c):
+			digit = true
+		default:
+			symbol = true
+		}
+	}
+	classes := 0
+	for _, ok := range []bool{lower, upper, digit, symbol} {
+		if ok {
+			classes++
+		}
+	}
+	if classes < p.MinClasses {
+		return fmt.Errorf("%w: must mix at least %d of lower case, upper case, digits and symbols", ErrWeakPassword, p.MinClasses)
+	}
+
+	lowered := strings.ToLower(password)
+	if _, ok := commonPasswords[lowered]; ok {
+		return fmt.Errorf("%w: too common", ErrWeakPassword)
+	}
+	if local, _, _ := strings.Cut(strings.ToLower(email), "@"); len(local) >= 3 && strings.Contains(lowered, local) {
+		return fmt.Errorf("%w: must not contain your email", ErrWeakPassword)
+	}
+	for _, part := range strings.Fields(strings.ToLower(name)) {
+		if len(part) >= 3 && strings.Contains(lowered, part) {
+			return fmt.Errorf("%w: must not contain your name", ErrWeakPassword)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,207 @@
+package auth
+
+import (
+	"context"
+	stdjson "encoding/json"
+	"fmt"
+	"hack4good-backend/internal/env"
+	"os"
+)
+
+type Permission string
+
+const (
+	PermActivitiesRead  Permission = "activities:read"
+	PermActivitiesWrite Permission = "activities:write"
+
+	PermBookingsReadOwn         Permission = "bookings:read:own"
+	PermBookingsReadDependents  Permission = "bookings:read:dependents"
+	PermBookingsReadAny         Permission = "bookings:read:any"
+	PermBookingsWriteOwn        Permission = "bookings:write:own"
+	PermBookingsWriteDependents Permission = "bookings:write:dependents"
+	PermBookingsWriteAny        Permission = "bookings:write:any"
+
+	PermProfilesReadOwn         Permission = "profiles:read:own"
+	PermProfilesReadDependents  Permission = "profiles:read:dependents"
+	PermProfilesReadAny         Permission = "profiles:read:any"
+	PermProfilesWriteOwn        Permission = "profiles:write:own"
+	PermProfilesWriteDependents Permission = "profiles:write:dependents"
+	PermProfilesWriteAny        Permission = "profiles:write:any"
+
+	PermMedicalReadAny         Permission = "medical:read:any"
+	PermMedicalWriteOwn        Permission = "medical:write:own"
+	PermMedicalWriteDependents Permission = "medical:write:dependents"
+	PermMedicalWriteAny        Permission = "medical:write:any"
+
+	PermVolunteersReadOwn  Permission = "volunteers:read:own"
+	PermVolunteersReadAny  Permission = "volunteers:read:any"
+	PermVolunteersWriteOwn Permission = "volunteers:write:own"
+	PermVolunteersWriteAny Permission = "volunteers:write:any"
+
+	PermUsersRead        Permission = "users:read"
+	PermUsersManage      Permission = "users:manage"
+	PermUsersImpersonate Permission = "users:impersonate"
+
+	PermAPIKeysManage Permission = "api_keys:manage"
+)
+
+var knownPermissions = map[Permission]struct{}{
+	PermActivitiesRead: {}, PermActivitiesWrite: {},
+	PermBookingsReadOwn: {}, PermBookingsReadDependents: {}, PermBookingsReadAny: {},
+	PermBookingsWriteOwn: {}, PermBookingsWriteDependents: {}, PermBookingsWriteAny: {},
+	PermProfilesReadOwn: {}, PermProfilesReadDependents: {}, PermProfilesReadAny: {},
+	PermProfilesWriteOwn: {}, PermProfilesWriteDependents: {}, PermProfilesWriteAny: {},
+	PermMedicalReadAny: {}, PermMedicalWriteOwn: {}, PermMedicalWriteDependents: {}, PermMedicalWriteAny: {},
+	PermVolunteersReadOwn: {}, PermVolunteersReadAny: {}, PermVolunteersWriteOwn: {}, PermVolunteersWriteAny: {},
+	PermUsersRead: {}, PermUsersManage: {}, PermUsersImpersonate: {},
+	PermAPIKeysManage: {},
+}
+
+// scopes an API key can carry: reading and reporting only, since a key has no user
+// behind it to own a write, manage accounts or create further keys
+var apiKeyScopes = set(
+	PermActivitiesRead,
+	PermBookingsReadAny,
+	PermProfilesReadAny,
+	PermVolunteersReadAny,
+	PermUsersRead,
+)
+
+func GrantableToAPIKey(p Permission) bool {
+	_, ok := apiKeyScopes[p]
+	return ok
+}
+
+func ParsePermission(s string) (Permission, bool) {
+	_, ok := knownPermissions[Permission(s)]
+	return Permission(s), ok
+}
+
+// permission that applies to a resource owned by a user, split by whose it is
+type ScopedPermission struct {
+	Own        Permission // the signed-in user's own
+	Dependents Permission // participants the signed-in caregiver is linked to
+	Any        Permission
+}
+
+var (
+	BookingsRead  = ScopedPermission{PermBookingsReadOwn, PermBookingsReadDependents, PermBookingsReadAny}
+	BookingsWrite = ScopedPermission{PermBookingsWriteOwn, PermBookingsWriteDependents, PermBookingsWriteAny}
+	ProfilesRead  = ScopedPermission{PermProfilesReadOwn, PermProfilesReadDependents, PermProfilesReadAny}
+	ProfilesWrite = ScopedPermission{PermProfilesWriteOwn, PermProfilesWriteDependents, PermProfilesWriteAny}
+	MedicalWrite  = ScopedPermission{PermMedicalWriteOwn, PermMedicalWriteDependents, PermMedicalWriteAny}
+
+	// only staff read medical details; volunteer leads get them through their activity on the day
+	MedicalRead = ScopedPermission{Any: PermMedicalReadAny}
+
+	// volunteers have no dependents
+	VolunteersRead  = ScopedPermission{Own: PermVolunteersReadOwn, Any: PermVolunteersReadAny}
+	VolunteersWrite = ScopedPermission{Own: PermVolunteersWriteOwn, Any: PermVolunteersWriteAny}
+)
+
+// permissions granted to each role
+type RolePermissions map[string]map[Permission]struct{}
+
+func DefaultRolePermissions() RolePermissions {
+	return RolePermissions{
+		"participant": set(
+			PermActivitiesRead, PermBookingsReadOwn, PermBookingsWriteOwn,
+			PermProfilesReadOwn, PermProfilesWriteOwn,
+			PermMedicalWriteOwn,
+		),
+		"volunteer": set(
+			PermActivitiesRead, PermBookingsReadOwn, PermBookingsWriteOwn,
+			PermVolunteersReadOwn, PermVolunteersWriteOwn,
+		),
+		"caregiver": set(
+			PermActivitiesRead, PermBookingsReadOwn, PermBookingsReadDependents, PermBookingsWriteDependents,
+			PermProfilesReadDependents, PermProfilesWriteDependents,
+			PermMedicalWriteDependents,
+		),
+		"staff": set(
+			PermActivitiesRead, PermActivitiesWrite,
+			PermBookingsReadAny, PermBookingsWriteAny,
+			PermProfilesReadAny, PermProfilesWriteAny,
+			PermMedicalReadAny, PermMedicalWriteAny,
+			PermVolunteersReadAny, PermVolunteersWriteAny,
+			PermUsersRead, PermUsersManage, PermUsersImpersonate,
+			PermAPIKeysManage,
+		),
+	}
+}
+
+func set(perms ...Permission) map[Permission]struct{} {
+	s := make(map[Permission]struct{}, len(perms))
+	for _, p := range perms {
+		s[p] = struct{}{}
+	}
+	return s
+}
+
+// role mapping from the JSON file in ROLE_PERMISSIONS_FILE ({"role": ["perm", ...]}), defaults otherwise
+func PermissionsFromEnv() (RolePermissions, error) {
+	path := env.GetString("ROLE_PERMISSIONS_FILE", "")
+	if path == "" {
+		return DefaultRolePermissions(), nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read role permissions: %w", err)
+	}
+
+	var raw map[string][]Permission
+	if err := stdjson.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("failed to parse role permissions: %w", err)
+	}
+
+	roles := make(RolePermissions, len(raw))
+	for role, perms := range raw {
+		for _, p := range perms {
+			if _, ok := knownPermissions[p]; !ok {
+				return nil, fmt.Errorf("unknown permission %q for role %s", p, role)
+			}
+		}
+		roles[role] = set(perms...)
+	}
+	return roles, nil
+}
+
+func (p RolePermissions) Allows(role string, perm Permission) bool {
+	_, ok := p[role][perm]
+	return ok
+}
+
+type PermissionsKey struct{}
+
+// whether the signed-in user's role (or API key) has perm (set by Guard.RequirePermission)
+func Can(ctx context.Context, perm Permission) bool {
+	perms, ok := ctx.Value(PermissionsKey{}).(map[Permission]struct{})
+	if !ok {
+		return false
+	}
+	_, ok = perms[perm]
+	return ok
+}
+
+// reports whether a caregiver is linked to a participant
+type LinkChecker func(ctx context.Context, caregiverID, participantID int32) (bool, error)
+
+// whether the signed-in user may act on a resource belonging to subjectID
+func CanActFor(ctx context.Context, perm ScopedPermission, subjectID int32, linked LinkChecker) (bool, error) {
+	claims, ok := FromContext(ctx)
+	if !ok {
+		return false, nil
+	}
+
+	if Can(ctx, perm.Any) {
+		return true, nil
+	}
+	if subjectID == claims.ID {
+		return Can(ctx, perm.Own), nil
+	}
+	if !Can(ctx, perm.Dependents) {
+		return false, nil
+	}
+	return linked(ctx, claims.ID, subjectID)
+}
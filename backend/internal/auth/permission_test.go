@@ -0,0 +1,185 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// context of a signed-in user holding the permissions of role
+func roleContext(userID int32, role string) context.Context {
+	ctx := context.WithValue(context.Background(), AuthKey{}, &UserClaims{ID: userID, Role: role})
+	return context.WithValue(ctx, PermissionsKey{}, DefaultRolePermissions()[role])
+}
+
+func TestCanActFor(t *testing.T) {
+	const (
+		participant = 10
+		caregiver   = 20
+		staff       = 30
+		linked      = 11 // linked to the caregiver
+		other       = 12
+	)
+	links := func(ctx context.Context, caregiverID, participantID int32) (bool, error) {
+		return caregiverID == caregiver && participantID == linked, nil
+	}
+
+	tests := []struct {
+		name    string
+		ctx     context.Context
+		perm    ScopedPermission
+		subject int32
+		want    bool
+	}{
+		{"participant reads own bookings", roleContext(participant, "participant"), BookingsRead, participant, true},
+		{"participant writes own bookings", roleContext(participant, "participant"), BookingsWrite, participant, true},
+		{"participant reads another's bookings", roleContext(participant, "participant"), BookingsRead, other, false},
+		{"caregiver reads own bookings", roleContext(caregiver, "caregiver"), BookingsRead, caregiver, true},
+		{"caregiver books for self", roleContext(caregiver, "caregiver"), BookingsWrite, caregiver, false},
+		{"caregiver reads linked participant", roleContext(caregiver, "caregiver"), BookingsRead, linked, true},
+		{"caregiver books for linked participant", roleContext(caregiver, "caregiver"), BookingsWrite, linked, true},
+		{"caregiver reads unlinked participant", roleContext(caregiver, "caregiver"), BookingsRead, other, false},
+		{"volunteer reads another's bookings", roleContext(40, "volunteer"), BookingsRead, other, false},
+		{"staff reads anyone", roleContext(staff, "staff"), BookingsRead, other, true},
+		{"staff books for anyone", roleContext(staff, "staff"), BookingsWrite, linked, true},
+		{"unknown role", roleContext(50, "guest"), BookingsRead, 50, false},
+		{"not signed in", context.Background(), BookingsRead, participant, false},
+	}
+	for _, tt := range tests {
+		got, err := CanActFor(tt.ctx, tt.perm, tt.subject, links)
+		if err != nil {
+			t.Fatalf("%s: CanActFor: %v", tt.name, err)
+		}
+		if got != tt.want {
+			t.Errorf("%s: CanActFor = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestCanActForLinkError(t *testing.T) {
+	failed := errors.New("db down")
+	links := func(ctx context.Context, caregiverID, participantID int32) (bool, error) {
+		return false, failed
+	}
+	if _, err := CanActFor(roleContext(20, "caregiver"), BookingsRead, 11, links); !errors.Is(err, failed) {
+		t.Errorf("CanActFor error = %v, want the link check's", err)
+	}
+	// own and any never ask for links
+	if ok, err := CanActFor(roleContext(30, "staff"), BookingsRead, 11, links); err != nil || !ok {
+		t.Errorf("CanActFor for staff = %v, %v", ok, err)
+	}
+}
+
+func TestDefaultRolePermissions(t *testing.T) {
+	tests := []struct {
+		role string
+		perm Permission
+		want bool
+	}{
+		{"participant", PermActivitiesRead, true},
+		{"participant", PermBookingsWriteOwn, true},
+		{"participant", PermBookingsReadAny, false},
+		{"participant", PermActivitiesWrite, false},
+		{"volunteer", PermBookingsWriteOwn, true},
+		{"volunteer", PermBookingsWriteDependents, false},
+		{"caregiver", PermBookingsWriteDependents, true},
+		{"caregiver", PermBookingsWriteOwn, false},
+		{"caregiver", PermUsersRead, false},
+		{"staff", PermBookingsWriteAny, true},
+		{"staff", PermUsersManage, true},
+		{"staff", PermBookingsWriteOwn, false},
+		{"participant", PermMedicalWriteOwn, true},
+		{"participant", PermMedicalReadAny, false},
+		{"caregiver", PermMedicalWriteDependents, true},
+		{"caregiver", PermMedicalReadAny, false},
+		{"volunteer", PermMedicalReadAny, false},
+		{"staff", PermMedicalReadAny, true},
+	}
+	roles := DefaultRolePermissions()
+	for _, tt := range tests {
+		if _, got := roles[tt.role][tt.perm]; got != tt.want {
+			t.Errorf("%s has %s = %v, want %v", tt.role, tt.perm, got, tt.want)
+		}
+	}
+
+	for role, perms := range roles {
+		for p := range perms {
+			if _, ok := ParsePermission(string(p)); !ok {
+				t.Errorf("%s is granted unknown permission %s", role, p)
+			}
+		}
+	}
+}
+
+func TestGrantableToAPIKey(t *testing.T) {
+	tests := []struct {
+		perm Permission
+		want bool
+	}{
+		{PermActivitiesRead, true},
+		{PermBookingsReadAny, true},
+		{PermUsersRead, true},
+		{PermActivitiesWrite, false},
+		{PermBookingsReadOwn, false},
+		{PermBookingsWriteAny, false},
+		{PermUsersManage, false},
+		{PermUsersImpersonate, false},
+		{PermAPIKeysManage, false},
+		{PermMedicalReadAny, false},
+	}
+	for _, tt := range tests {
+		if got := GrantableToAPIKey(tt.perm); got != tt.want {
+			t.Errorf("GrantableToAPIKey(%s) = %v, want %v", tt.perm, got, tt.want)
+		}
+	}
+}
+
+func TestPermissionsFromEnv(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	tests := []struct {
+		name    string
+		path    string
+		wantErr string
+	}{
+		{"default", "", ""},
+		{"custom", write("custom.json", `{"kiosk": ["activities:read"]}`), ""},
+		{"unknown permission", write("unknown.json", `{"kiosk": ["activities:delete"]}`), `unknown permission "activities:delete"`},
+		{"not JSON", write("broken.json", `kiosk: activities:read`), "failed to parse"},
+		{"missing file", filepath.Join(dir, "missing.json"), "failed to read"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("ROLE_PERMISSIONS_FILE", tt.path)
+			roles, err := PermissionsFromEnv()
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("PermissionsFromEnv error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.path == "" {
+				if len(roles) != len(DefaultRolePermissions()) {
+					t.Errorf("PermissionsFromEnv = %v, want the defaults", roles)
+				}
+				return
+			}
+			if _, ok := roles["kiosk"][PermActivitiesRead]; !ok || len(roles) != 1 {
+				t.Errorf("PermissionsFromEnv = %v, want only kiosk with activities:read", roles)
+			}
+		})
+	}
+}
@@ -0,0 +1,116 @@
+package auth
+
+import (
+	"testing"
+	"time"
+)
+
+// RFC 6238 appendix B secret ("12345678901234567890"), base32 encoded
+const rfcSecret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func totpAt(unix int64) *TOTP {
+	t := NewTOTP("Hack4Good")
+	t.Now = func() time.Time { return time.Unix(unix, 0) }
+	return t
+}
+
+func TestTOTPCode(t *testing.T) {
+	tests := []struct {
+		unix   int64
+		digits int
+		want   string
+	}{
+		{59, 8, "94287082"},
+		{1111111109, 8, "07081804"},
+		{1111111111, 8, "14050471"},
+		{1234567890, 8, "89005924"},
+		{2000000000, 8, "69279037"},
+		{59, 6, "287082"},
+		{1111111109, 6, "081804"},
+		{20000000000, 6, "353130"},
+	}
+	for _, tt := range tests {
+		totp := totpAt(tt.unix)
+		totp.Digits = tt.digits
+		got, err := totp.Code(rfcSecret)
+		if err != nil {
+			t.Fatalf("Code at %d: %v", tt.unix, err)
+		}
+		if got != tt.want {
+			t.Errorf("Code at %d with %d digits = %s, want %s", tt.unix, tt.digits, got, tt.want)
+		}
+	}
+}
+
+func TestTOTPCodeInvalidSecret(t *testing.T) {
+	if _, err := totpAt(59).Code("not base32!"); err == nil {
+		t.Error("Code with an invalid secret: want error")
+	}
+}
+
+func TestTOTPValidateWindow(t *testing.T) {
+	const now = 1111111111
+	step := int64(now / 30)
+
+	tests := []struct {
+		name     string
+		code     string
+		wantStep int64
+		wantOK   bool
+	}{
+		{"current step", codeAt(t, now), step, true},
+		{"one step behind", codeAt(t, now-30), step - 1, true},
+		{"one step ahead", codeAt(t, now+30), step + 1, true},
+		{"two steps behind", codeAt(t, now-60), 0, false},
+		{"two steps ahead", codeAt(t, now+60), 0, false},
+		{"surrounding spaces", " " + codeAt(t, now) + "\n", step, true},
+		{"wrong code", "000000", 0, false},
+		{"too short", "12345", 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotStep, ok := totpAt(now).Validate(rfcSecret, tt.code, 0)
+			if ok != tt.wantOK || gotStep != tt.wantStep {
+				t.Errorf("Validate = (%d, %v), want (%d, %v)", gotStep, ok, tt.wantStep, tt.wantOK)
+			}
+		})
+	}
+}
+
+// code of rfcSecret at the given time
+func codeAt(t *testing.T, unix int64) string {
+	t.Helper()
+	code, err := totpAt(unix).Code(rfcSecret)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return code
+}
+
+func TestTOTPValidateReplay(t *testing.T) {
+	const now = 1234567890
+	step := int64(now / 30)
+	totp := totpAt(now)
+	code, previous := codeAt(t, now), codeAt(t, now-30)
+
+	tests := []struct {
+		name     string
+		code     string
+		lastUsed int64
+		wantOK   bool
+	}{
+		{"never used", code, 0, true},
+		{"earlier step used", code, step - 1, true},
+		{"same step used", code, step, false},
+		{"later step used", code, step + 1, false},
+		{"previous code after current used", previous, step, false},
+		{"previous code after it was used", previous, step - 1, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, ok := totp.Validate(rfcSecret, tt.code, tt.lastUsed); ok != tt.wantOK {
+				t.Errorf("Validate with last used step %d = %v, want %v", tt.lastUsed, ok, tt.wantOK)
+			}
+		})
+	}
+}
@@ -9,10 +9,30 @@ type User struct {
 	Role string
 }
 
+// UserClaims.TokenType
+const (
+	TokenAccess  = "access"
+	TokenRefresh = "refresh" // only accepted by the refresh endpoint
+)
+
 type UserClaims struct {
 	ID       int32 `json:"id"`
 	Name     string `json:"name"`
 	Role 	 string `json:"role"`
+	TokenType string `json:"typ"`
+	SessionID string `json:"sid,omitempty"` // session family the token belongs to
+	ImpersonatorID int32 `json:"impersonator_id,omitempty"` // staff member acting on behalf of the user
+	APIKeyID int32 `json:"-"` // set instead of ID for requests made with an API key
 	jwt.RegisteredClaims
 }
 
+// whether the token was issued to staff acting on behalf of the user
+func (c *UserClaims) Impersonated() bool {
+	return c.ImpersonatorID != 0
+}
+
+// whether the request was made with an API key rather than by a user (ID is 0)
+func (c *UserClaims) IsAPIKey() bool {
+	return c.APIKeyID != 0
+}
+
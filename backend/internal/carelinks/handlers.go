@@ -0,0 +1,270 @@
+package carelinks
+
+import (
+	"errors"
+	"hack4good-backend/internal/auth"
+	"hack4good-backend/internal/json"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+)
+
+type Handler struct {
+	service Service
+}
+
+func NewHandler(service Service) *Handler {
+	return &Handler{
+		service: service,
+	}
+}
+
+func userIDParam(w http.ResponseWriter, r *http.Request, name string) (int32, bool) {
+	id, err := strconv.Atoi(chi.URLParam(r, name))
+	if err != nil {
+		http.Error(w, "invalid user id", http.StatusBadRequest)
+		return 0, false
+	}
+	return int32(id), true
+}
+
+func writeError(w http.ResponseWriter, err error, msg string) {
+	switch {
+	case errors.Is(err, ErrLinkNotFound), errors.Is(err, ErrNotParticipant), errors.Is(err, ErrNotCaregiver):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, ErrAlreadyLinked), errors.Is(err, ErrLinkNotPending):
+		http.Error(w, err.Error(), http.StatusConflict)
+	case errors.Is(err, ErrInvalidRequest), errors.Is(err, ErrInvalidCode):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	default:
+		log.Println(err)
+		http.Error(w, msg, http.StatusInternalServerError)
+	}
+}
+
+// new one-time code for the signed-in participant to give to a caregiver
+func (h *Handler) IssueLinkCode(w http.ResponseWriter, r *http.Request) {
+	claims, ok := auth.FromContext(r.Context())
+	if !ok {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	code, expiresAt, err := h.service.IssueLinkCode(r.Context(), claims.ID)
+	if err != nil {
+		writeError(w, err, "failed to create link code")
+		return
+	}
+
+	json.Write(w, http.StatusCreated, LinkCodeResponse{Code: code, ExpiresAt: expiresAt})
+}
+
+// caregivers linked to (or asking to be linked to) the signed-in participant
+func (h *Handler) ListCaregivers(w http.ResponseWriter, r *http.Request) {
+	claims, ok := auth.FromContext(r.Context())
+	if !ok {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	links, err := h.service.ListCaregivers(r.Context(), claims.ID)
+	if err != nil {
+		writeError(w, err, "failed to list caregivers")
+		return
+	}
+
+	json.Write(w, http.StatusOK, links)
+}
+
+// the signed-in participant accepts a caregiver's request
+func (h *Handler) ConfirmCaregiver(w http.ResponseWriter, r *http.Request) {
+	claims, ok := auth.FromContext(r.Context())
+	if !ok {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	caregiverID, ok := userIDParam(w, r, "id")
+	if !ok {
+		return
+	}
+
+	link, err := h.service.ConfirmLink(r.Context(), claims.ID, caregiverID, claims.ID)
+	if err != nil {
+		writeError(w, err, "failed to confirm caregiver")
+		return
+	}
+
+	json.Write(w, http.StatusOK, link)
+}
+
+// the signed-in participant removes a caregiver or declines a request
+func (h *Handler) RemoveCaregiver(w http.ResponseWriter, r *http.Request) {
+	claims, ok := auth.FromContext(r.Context())
+	if !ok {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	caregiverID, ok := userIDParam(w, r, "id")
+	if !ok {
+		return
+	}
+
+	if err := h.service.RevokeLink(r.Context(), claims.ID, caregiverID); err != nil {
+		writeError(w, err, "failed to remove caregiver")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// the signed-in caregiver asks to be linked to a participant (with a code, or by email for approval)
+func (h *Handler) RequestLink(w http.ResponseWriter, r *http.Request) {
+	claims, ok := auth.FromContext(r.Context())
+	if !ok {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var req RequestLinkRequest
+	if err := json.Read(r, &req); err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	link, err := h.service.RequestLink(r.Context(), claims.ID, req)
+	if err != nil {
+		writeError(w, err, "failed to request link")
+		return
+	}
+
+	status := http.StatusCreated
+	if link.Status == StatusPending {
+		status = http.StatusAccepted
+	}
+	json.Write(w, status, link)
+}
+
+// participants the signed-in caregiver is linked to, including pending requests
+func (h *Handler) ListDependents(w http.ResponseWriter, r *http.Request) {
+	claims, ok := auth.FromContext(r.Context())
+	if !ok {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	links, err := h.service.ListDependents(r.Context(), claims.ID)
+	if err != nil {
+		writeError(w, err, "failed to list dependents")
+		return
+	}
+
+	json.Write(w, http.StatusOK, links)
+}
+
+// the signed-in caregiver removes a dependent or withdraws a request
+func (h *Handler) RemoveDependent(w http.ResponseWriter, r *http.Request) {
+	claims, ok := auth.FromContext(r.Context())
+	if !ok {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	participantID, ok := userIDParam(w, r, "id")
+	if !ok {
+		return
+	}
+
+	if err := h.service.RevokeLink(r.Context(), participantID, claims.ID); err != nil {
+		writeError(w, err, "failed to remove dependent")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// list all links, optionally filtered by ?status=pending|active (by staff)
+func (h *Handler) ListLinks(w http.ResponseWriter, r *http.Request) {
+	links, err := h.service.ListLinks(r.Context())
+	if err != nil {
+		writeError(w, err, "failed to list links")
+		return
+	}
+
+	filter := r.URL.Query().Get("status")
+	resp := make([]Link, 0, len(links))
+	for _, l := range links {
+		if filter == "" || l.Status == filter {
+			resp = append(resp, l)
+		}
+	}
+
+	json.Write(w, http.StatusOK, resp)
+}
+
+// link a caregiver to a participant (by staff)
+func (h *Handler) CreateLink(w http.ResponseWriter, r *http.Request) {
+	claims, ok := auth.FromContext(r.Context())
+	if !ok {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var req StaffLinkRequest
+	if err := json.Read(r, &req); err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	link, err := h.service.CreateLink(r.Context(), req.ParticipantID, req.CaregiverID, claims.ID)
+	if err != nil {
+		writeError(w, err, "failed to create link")
+		return
+	}
+
+	json.Write(w, http.StatusCreated, link)
+}
+
+// approve a caregiver's pending request (by staff)
+func (h *Handler) ApproveLink(w http.ResponseWriter, r *http.Request) {
+	claims, ok := auth.FromContext(r.Context())
+	if !ok {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	participantID, ok := userIDParam(w, r, "participantID")
+	if !ok {
+		return
+	}
+	caregiverID, ok := userIDParam(w, r, "caregiverID")
+	if !ok {
+		return
+	}
+
+	link, err := h.service.ConfirmLink(r.Context(), participantID, caregiverID, claims.ID)
+	if err != nil {
+		writeError(w, err, "failed to approve link")
+		return
+	}
+
+	json.Write(w, http.StatusOK, link)
+}
+
+// revoke any link (by staff)
+func (h *Handler) RevokeLink(w http.ResponseWriter, r *http.Request) {
+	participantID, ok := userIDParam(w, r, "participantID")
+	if !ok {
+		return
+	}
+	caregiverID, ok := userIDParam(w, r, "caregiverID")
+	if !ok {
+		return
+	}
+
+	if err := h.service.RevokeLink(r.Context(), participantID, caregiverID); err != nil {
+		writeError(w, err, "failed to revoke link")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,340 @@
+package carelinks
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	repo "hack4good-backend/db/sqlc"
+	"hack4good-backend/internal/auth"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const (
+	linkCodeTTL    = 24 * time.Hour
+	linkCodeLength = 8
+
+	// unambiguous characters (no 0/O, 1/I/L)
+	linkCodeAlphabet = "ABCDEFGHJKMNPQRSTUVWXYZ23456789"
+)
+
+var (
+	ErrInvalidRequest = errors.New("code or participant_email required")
+	ErrInvalidCode    = errors.New("invalid or expired link code")
+	ErrNotParticipant = errors.New("participant not found")
+	ErrNotCaregiver   = errors.New("caregiver not found")
+	ErrAlreadyLinked  = errors.New("link already exists")
+	ErrLinkNotFound   = errors.New("link not found")
+	ErrLinkNotPending = errors.New("link is already active")
+)
+
+type Service interface {
+	IssueLinkCode(ctx context.Context, participantID int32) (string, time.Time, error)
+	RequestLink(ctx context.Context, caregiverID int32, req RequestLinkRequest) (Link, error)
+	ConfirmLink(ctx context.Context, participantID, caregiverID, approvedBy int32) (Link, error)
+	CreateLink(ctx context.Context, participantID, caregiverID, staffID int32) (Link, error)
+	RevokeLink(ctx context.Context, participantID, caregiverID int32) error
+	ListLinks(ctx context.Context) ([]Link, error)
+	ListDependents(ctx context.Context, caregiverID int32) ([]Link, error)
+	ListCaregivers(ctx context.Context, participantID int32) ([]Link, error)
+}
+
+type svc struct {
+	db   *pgxpool.Pool // for redeeming codes
+	repo *repo.Queries
+}
+
+func NewService(db *pgxpool.Pool) Service {
+	return &svc{
+		db:   db,
+		repo: repo.New(db),
+	}
+}
+
+func newLinkCode() (string, error) {
+	max := big.NewInt(int64(len(linkCodeAlphabet)))
+	b := make([]byte, linkCodeLength)
+	for i := range b {
+		n, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", err
+		}
+		b[i] = linkCodeAlphabet[n.Int64()]
+	}
+	// XXXX-XXXX
+	return string(b[:linkCodeLength/2]) + "-" + string(b[linkCodeLength/2:]), nil
+}
+
+func normalizeLinkCode(code string) string {
+	return strings.ToUpper(strings.NewReplacer("-", "", " ", "").Replace(code))
+}
+
+// new code for the participant to share; an earlier code stops working
+func (s *svc) IssueLinkCode(ctx context.Context, participantID int32) (string, time.Time, error) {
+	if _, err := s.userWithRole(ctx, s.repo, participantID, "participant"); err != nil {
+		return "", time.Time{}, err
+	}
+
+	code, err := newLinkCode()
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("failed to generate link code: %w", err)
+	}
+	expiresAt := time.Now().Add(linkCodeTTL)
+	if err := s.repo.UpsertCareLinkCode(ctx, repo.UpsertCareLinkCodeParams{
+		ParticipantID: participantID,
+		CodeHash:      auth.HashToken(normalizeLinkCode(code)),
+		ExpiresAt:     pgtype.Timestamp{Time: expiresAt, Valid: true},
+	}); err != nil {
+		return "", time.Time{}, fmt.Errorf("failed to save link code: %w", err)
+	}
+	return code, expiresAt, nil
+}
+
+func (s *svc) RequestLink(ctx context.Context, caregiverID int32, req RequestLinkRequest) (Link, error) {
+	switch {
+	case req.Code != "":
+		return s.redeemCode(ctx, caregiverID, req.Code)
+	case req.ParticipantEmail != "":
+		participant, err := s.repo.GetUserByEmail(ctx, strings.TrimSpace(req.ParticipantEmail))
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return Link{}, ErrNotParticipant
+			}
+			return Link{}, fmt.Errorf("failed to get participant: %w", err)
+		}
+		if participant.Role != "participant" {
+			return Link{}, ErrNotParticipant
+		}
+		rel, err := s.repo.CreateCareRelationship(ctx, repo.CreateCareRelationshipParams{
+			ParticipantID: participant.ID,
+			CaregiverID:   caregiverID,
+			Status:        StatusPending,
+			RequestedBy:   pgtype.Int4{Int32: caregiverID, Valid: true},
+		})
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return Link{}, ErrAlreadyLinked
+			}
+			return Link{}, fmt.Errorf("failed to request link: %w", err)
+		}
+		return s.toLink(ctx, rel)
+	default:
+		return Link{}, ErrInvalidRequest
+	}
+}
+
+// the code proves the participant agreed, so the link is active right away
+func (s *svc) redeemCode(ctx context.Context, caregiverID int32, code string) (Link, error) {
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return Link{}, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+	q := s.repo.WithTx(tx)
+
+	participantID, err := q.ConsumeCareLinkCode(ctx, auth.HashToken(normalizeLinkCode(code)))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return Link{}, ErrInvalidCode
+		}
+		return Link{}, fmt.Errorf("failed to use link code: %w", err)
+	}
+
+	rel, err := s.activate(ctx, q, participantID, caregiverID, caregiverID, participantID)
+	if err != nil {
+		return Link{}, err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return Link{}, fmt.Errorf("failed to commit link: %w", err)
+	}
+	return s.toLink(ctx, rel)
+}
+
+func (s *svc) ConfirmLink(ctx context.Context, participantID, caregiverID, approvedBy int32) (Link, error) {
+	rel, err := s.repo.ActivateCareRelationship(ctx, repo.ActivateCareRelationshipParams{
+		ParticipantID: participantID,
+		CaregiverID:   caregiverID,
+		ApprovedBy:    pgtype.Int4{Int32: approvedBy, Valid: true},
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			if _, err := s.getLink(ctx, participantID, caregiverID); err != nil {
+				return Link{}, err
+			}
+			return Link{}, ErrLinkNotPending
+		}
+		return Link{}, fmt.Errorf("failed to confirm link: %w", err)
+	}
+	return s.toLink(ctx, rel)
+}
+
+// link directly (by staff), also approving a pending request
+func (s *svc) CreateLink(ctx context.Context, participantID, caregiverID, staffID int32) (Link, error) {
+	if _, err := s.userWithRole(ctx, s.repo, participantID, "participant"); err != nil {
+		return Link{}, err
+	}
+	if _, err := s.userWithRole(ctx, s.repo, caregiverID, "caregiver"); err != nil {
+		return Link{}, err
+	}
+
+	rel, err := s.activate(ctx, s.repo, participantID, caregiverID, staffID, staffID)
+	if err != nil {
+		return Link{}, err
+	}
+	return s.toLink(ctx, rel)
+}
+
+// create an active link, or activate a pending one
+func (s *svc) activate(ctx context.Context, q *repo.Queries, participantID, caregiverID, requestedBy, approvedBy int32) (repo.CareRelationship, error) {
+	approver := pgtype.Int4{Int32: approvedBy, Valid: true}
+	rel, err := q.CreateCareRelationship(ctx, repo.CreateCareRelationshipParams{
+		ParticipantID: participantID,
+		CaregiverID:   caregiverID,
+		Status:        StatusActive,
+		RequestedBy:   pgtype.Int4{Int32: requestedBy, Valid: true},
+		ApprovedBy:    approver,
+		ApprovedAt:    pgtype.Timestamp{Time: time.Now(), Valid: true},
+	})
+	if err == nil {
+		return rel, nil
+	}
+	if !errors.Is(err, pgx.ErrNoRows) {
+		return repo.CareRelationship{}, fmt.Errorf("failed to create link: %w", err)
+	}
+
+	rel, err = q.ActivateCareRelationship(ctx, repo.ActivateCareRelationshipParams{
+		ParticipantID: participantID,
+		CaregiverID:   caregiverID,
+		ApprovedBy:    approver,
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return repo.CareRelationship{}, ErrAlreadyLinked
+		}
+		return repo.CareRelationship{}, fmt.Errorf("failed to activate link: %w", err)
+	}
+	return rel, nil
+}
+
+// remove a link or decline a request; the caregiver loses access immediately
+func (s *svc) RevokeLink(ctx context.Context, participantID, caregiverID int32) error {
+	n, err := s.repo.DeleteCareRelationship(ctx, repo.DeleteCareRelationshipParams{
+		ParticipantID: participantID,
+		CaregiverID:   caregiverID,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to revoke link: %w", err)
+	}
+	if n == 0 {
+		return ErrLinkNotFound
+	}
+	return nil
+}
+
+func (s *svc) ListLinks(ctx context.Context) ([]Link, error) {
+	rels, err := s.repo.ListCareRelationships(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list links: %w", err)
+	}
+	return s.toLinks(ctx, rels)
+}
+
+func (s *svc) ListDependents(ctx context.Context, caregiverID int32) ([]Link, error) {
+	rels, err := s.repo.ListCareRelationshipsByCaregiver(ctx, caregiverID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list dependents: %w", err)
+	}
+	return s.toLinks(ctx, rels)
+}
+
+func (s *svc) ListCaregivers(ctx context.Context, participantID int32) ([]Link, error) {
+	rels, err := s.repo.ListCareRelationshipsByParticipant(ctx, participantID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list caregivers: %w", err)
+	}
+	return s.toLinks(ctx, rels)
+}
+
+func (s *svc) getLink(ctx context.Context, participantID, caregiverID int32) (repo.CareRelationship, error) {
+	rel, err := s.repo.GetCareRelationship(ctx, repo.GetCareRelationshipParams{
+		ParticipantID: participantID,
+		CaregiverID:   caregiverID,
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return repo.CareRelationship{}, ErrLinkNotFound
+		}
+		return repo.CareRelationship{}, fmt.Errorf("failed to get link: %w", err)
+	}
+	return rel, nil
+}
+
+func (s *svc) userWithRole(ctx context.Context, q *repo.Queries, id int32, role string) (repo.User, error) {
+	notFound := ErrNotParticipant
+	if role == "caregiver" {
+		notFound = ErrNotCaregiver
+	}
+
+	user, err := q.GetUserByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return repo.User{}, notFound
+		}
+		return repo.User{}, fmt.Errorf("failed to get user: %w", err)
+	}
+	if user.Role != role {
+		return repo.User{}, notFound
+	}
+	return user, nil
+}
+
+func (s *svc) toLinks(ctx context.Context, rels []repo.CareRelationship) ([]Link, error) {
+	links := make([]Link, 0, len(rels))
+	for _, rel := range rels {
+		link, err := s.toLink(ctx, rel)
+		if err != nil {
+			return nil, err
+		}
+		links = append(links, link)
+	}
+	return links, nil
+}
+
+func (s *svc) toLink(ctx context.Context, rel repo.CareRelationship) (Link, error) {
+	link := Link{
+		ParticipantID: rel.ParticipantID,
+		CaregiverID:   rel.CaregiverID,
+		Status:        rel.Status,
+		CreatedAt:     rel.CreatedAt.Time,
+	}
+	if rel.RequestedBy.Valid {
+		link.RequestedBy = &rel.RequestedBy.Int32
+	}
+	if rel.ApprovedBy.Valid {
+		link.ApprovedBy = &rel.ApprovedBy.Int32
+	}
+	if rel.ApprovedAt.Valid {
+		link.ApprovedAt = &rel.ApprovedAt.Time
+	}
+
+	for _, u := range []struct {
+		id   int32
+		name *string
+	}{
+		{rel.ParticipantID, &link.ParticipantName},
+		{rel.CaregiverID, &link.CaregiverName},
+	} {
+		user, err := s.repo.GetUserByID(ctx, u.id)
+		if err != nil {
+			return Link{}, fmt.Errorf("failed to get user %d: %w", u.id, err)
+		}
+		*u.name = user.Name
+	}
+	return link, nil
+}
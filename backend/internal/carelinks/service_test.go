@@ -0,0 +1,83 @@
+package carelinks
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestNewLinkCode(t *testing.T) {
+	seen := map[string]bool{}
+	for range 50 {
+		code, err := newLinkCode()
+		if err != nil {
+			t.Fatal(err)
+		}
+		first, second, ok := strings.Cut(code, "-")
+		if !ok || len(first) != linkCodeLength/2 || len(second) != linkCodeLength/2 {
+			t.Fatalf("newLinkCode = %q, want XXXX-XXXX", code)
+		}
+		for _, c := range first + second {
+			if !strings.ContainsRune(linkCodeAlphabet, c) {
+				t.Fatalf("newLinkCode = %q, %q is not in the alphabet", code, c)
+			}
+		}
+		if seen[code] {
+			t.Fatalf("newLinkCode repeated %q", code)
+		}
+		seen[code] = true
+	}
+}
+
+func TestNormalizeLinkCode(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"ABCD-EFGH", "ABCDEFGH"},
+		{"abcd-efgh", "ABCDEFGH"},
+		{"ABCDEFGH", "ABCDEFGH"},
+		{"abcd efgh", "ABCDEFGH"},
+		{" AB-CD EF-GH ", "ABCDEFGH"},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := normalizeLinkCode(tt.in); got != tt.want {
+			t.Errorf("normalizeLinkCode(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestRequestLinkNeedsCodeOrEmail(t *testing.T) {
+	if _, err := (&svc{}).RequestLink(context.Background(), 1, RequestLinkRequest{}); !errors.Is(err, ErrInvalidRequest) {
+		t.Errorf("RequestLink without code or email error = %v, want ErrInvalidRequest", err)
+	}
+}
+
+func TestWriteError(t *testing.T) {
+	tests := []struct {
+		err  error
+		want int
+	}{
+		{ErrLinkNotFound, http.StatusNotFound},
+		{ErrNotParticipant, http.StatusNotFound},
+		{ErrNotCaregiver, http.StatusNotFound},
+		{ErrAlreadyLinked, http.StatusConflict},
+		{ErrLinkNotPending, http.StatusConflict},
+		{ErrInvalidRequest, http.StatusBadRequest},
+		{ErrInvalidCode, http.StatusBadRequest},
+		{fmt.Errorf("wrapped: %w", ErrInvalidCode), http.StatusBadRequest},
+		{errors.New("connection refused"), http.StatusInternalServerError},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		writeError(w, tt.err, "failed")
+		if w.Code != tt.want {
+			t.Errorf("writeError(%v) status = %d, want %d", tt.err, w.Code, tt.want)
+		}
+	}
+}
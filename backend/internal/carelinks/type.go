@@ -0,0 +1,38 @@
+package carelinks
+
+import "time"
+
+const (
+	StatusPending = "pending" // waiting for the participant or staff
+	StatusActive  = "active"
+)
+
+// link between a caregiver and a participant, with both names for display
+type Link struct {
+	ParticipantID   int32      `json:"participant_id"`
+	ParticipantName string     `json:"participant_name"`
+	CaregiverID     int32      `json:"caregiver_id"`
+	CaregiverName   string     `json:"caregiver_name"`
+	Status          string     `json:"status"`
+	RequestedBy     *int32     `json:"requested_by"`
+	ApprovedBy      *int32     `json:"approved_by"`
+	ApprovedAt      *time.Time `json:"approved_at"`
+	CreatedAt       time.Time  `json:"created_at"`
+}
+
+type LinkCodeResponse struct {
+	Code      string    `json:"code"` // give this to the caregiver
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// a caregiver asks to be linked, either with the participant's code (linked right away)
+// or with the participant's email (pending until the participant or staff confirms)
+type RequestLinkRequest struct {
+	Code             string `json:"code,omitempty"`
+	ParticipantEmail string `json:"participant_email,omitempty"`
+}
+
+type StaffLinkRequest struct {
+	ParticipantID int32 `json:"participant_id"`
+	CaregiverID   int32 `json:"caregiver_id"`
+}
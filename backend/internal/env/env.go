@@ -1,10 +1,41 @@
 package env
 
-import "os"
+import (
+	"log"
+	"os"
+	"strconv"
+	"time"
+)
 
 func GetString(key, fallback string) string {
 	if val := os.Getenv(key); val != "" {
 		return val
 	}
 	return fallback
-}
\ No newline at end of file
+}
+
+func GetDuration(key string, fallback time.Duration) time.Duration {
+	val := os.Getenv(key)
+	if val == "" {
+		return fallback
+	}
+	d, err := time.ParseDuration(val)
+	if err != nil {
+		log.Printf("invalid duration for %s: %v, using %s", key, err, fallback)
+		return fallback
+	}
+	return d
+}
+
+func GetInt(key string, fallback int) int {
+	val := os.Getenv(key)
+	if val == "" {
+		return fallback
+	}
+	i, err := strconv.Atoi(val)
+	if err != nil {
+		log.Printf("invalid integer for %s: %v, using %d", key, err, fallback)
+		return fallback
+	}
+	return i
+}
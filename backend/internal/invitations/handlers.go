@@ -0,0 +1,284 @@
+package invitations
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	repo "hack4good-backend/db/sqlc"
+	"hack4good-backend/internal/auth"
+	"hack4good-backend/internal/json"
+	"hack4good-backend/internal/notify"
+	"hack4good-backend/internal/phone"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+)
+
+type Handler struct {
+	service  Service
+	notifier *notify.Notifier
+	config   Config
+}
+
+func NewHandler(service Service, notifier *notify.Notifier, config Config) *Handler {
+	return &Handler{
+		service:  service,
+		notifier: notifier,
+		config:   config,
+	}
+}
+
+func (h *Handler) toInvitation(inv repo.Invitation) Invitation {
+	out := Invitation{
+		ID:        inv.ID,
+		Name:      inv.Name,
+		Email:     inv.Email,
+		Role:      inv.Role,
+		Channel:   inv.Channel,
+		Status:    status(inv, time.Now()),
+		ExpiresAt: inv.ExpiresAt.Time,
+		SentAt:    inv.SentAt.Time,
+		CreatedAt: inv.CreatedAt.Time,
+	}
+	if inv.InvitedBy.Valid {
+		out.InvitedBy = &inv.InvitedBy.Int32
+	}
+	if inv.AcceptedAt.Valid {
+		out.AcceptedAt = &inv.AcceptedAt.Time
+	}
+	if p, err := h.service.RevealPhone(inv); err != nil {
+		log.Println(err)
+	} else {
+		out.Phone = p
+	}
+	return out
+}
+
+// deliver the invitation link over the invitation's channel
+func (h *Handler) send(ctx context.Context, inv repo.Invitation, token string) error {
+	link := h.config.AppURL + "/accept-invitation?token=" + url.QueryEscape(token)
+	days := int(h.config.TTL.Hours() / 24)
+
+	if inv.Channel == "sms" {
+		to, err := h.service.RevealPhone(inv)
+		if err != nil {
+			return err
+		}
+		return h.notifier.SMS.Send(ctx, notify.Message{
+			To:   to,
+			Body: fmt.Sprintf("Hi %s, you have been invited to Hack4Good. Set up your account within %d days: %s", inv.Name, days, link),
+		})
+	}
+
+	return h.notifier.Email.Send(ctx, notify.Message{
+		To:      inv.Email,
+		Subject: "You're invited to Hack4Good",
+		Body: fmt.Sprintf("Hi %s,\n\nAn account has been prepared for you. Confirm your details and finish setting it up here:\n%s\n\nThe link expires in %d days.",
+			inv.Name, link, days),
+	})
+}
+
+func invitationID(r *http.Request) (int32, error) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	return int32(id), err
+}
+
+// invite someone (by staff)
+func (h *Handler) CreateInvitation(w http.ResponseWriter, r *http.Request) {
+	claims, ok := auth.FromContext(r.Context())
+	if !ok {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var req CreateInvitationRequest
+	if err := json.Read(r, &req); err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	inv, token, err := h.service.CreateInvitation(r.Context(), req, claims.ID)
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrInvalidInvitation), errors.Is(err, ErrPhoneRequired):
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		case errors.Is(err, phone.ErrInvalidPhone):
+			http.Error(w, "invalid phone number", http.StatusBadRequest)
+		case errors.Is(err, ErrAlreadyInvited), errors.Is(err, ErrAlreadyRegistered):
+			http.Error(w, err.Error(), http.StatusConflict)
+		default:
+			log.Println(err)
+			http.Error(w, "failed to create invitation", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	if err := h.send(r.Context(), inv, token); err != nil {
+		// the invitation exists, staff can resend
+		log.Println(err)
+	}
+
+	json.Write(w, http.StatusCreated, h.toInvitation(inv))
+}
+
+// list invitations, optionally filtered by ?status=pending|expired|accepted|revoked (by staff)
+func (h *Handler) ListInvitations(w http.ResponseWriter, r *http.Request) {
+	invitations, err := h.service.ListInvitations(r.Context())
+	if err != nil {
+		log.Println(err)
+		http.Error(w, "failed to list invitations", http.StatusInternalServerError)
+		return
+	}
+
+	filter := r.URL.Query().Get("status")
+	out := make([]Invitation, 0, len(invitations))
+	for _, inv := range invitations {
+		i := h.toInvitation(inv)
+		if filter != "" && i.Status != filter {
+			continue
+		}
+		out = append(out, i)
+	}
+
+	json.Write(w, http.StatusOK, out)
+}
+
+// send a fresh link, also revives expired invitations (by staff)
+func (h *Handler) ResendInvitation(w http.ResponseWriter, r *http.Request) {
+	id, err := invitationID(r)
+	if err != nil {
+		http.Error(w, "invalid invitation id", http.StatusBadRequest)
+		return
+	}
+
+	inv, token, err := h.service.ResendInvitation(r.Context(), id)
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrInvitationNotFound):
+			http.Error(w, err.Error(), http.StatusNotFound)
+		case errors.Is(err, ErrInvitationClosed):
+			http.Error(w, err.Error(), http.StatusConflict)
+		default:
+			log.Println(err)
+			http.Error(w, "failed to resend invitation", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	if err := h.send(r.Context(), inv, token); err != nil {
+		log.Println(err)
+		http.Error(w, "failed to send invitation", http.StatusBadGateway)
+		return
+	}
+
+	json.Write(w, http.StatusOK, h.toInvitation(inv))
+}
+
+// revoke an open invitation (by staff)
+func (h *Handler) RevokeInvitation(w http.ResponseWriter, r *http.Request) {
+	id, err := invitationID(r)
+	if err != nil {
+		http.Error(w, "invalid invitation id", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.service.RevokeInvitation(r.Context(), id); err != nil {
+		switch {
+		case errors.Is(err, ErrInvitationNotFound):
+			http.Error(w, err.Error(), http.StatusNotFound)
+		case errors.Is(err, ErrInvitationClosed):
+			http.Error(w, err.Error(), http.StatusConflict)
+		default:
+			log.Println(err)
+			http.Error(w, "failed to revoke invitation", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// details behind an invitation link, for the invitee to confirm
+func (h *Handler) PreviewInvitation(w http.ResponseWriter, r *http.Request) {
+	var req TokenRequest
+	if err := json.Read(r, &req); err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	inv, err := h.service.PreviewInvitation(r.Context(), req.Token)
+	if err != nil {
+		if errors.Is(err, ErrInvalidToken) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		log.Println(err)
+		http.Error(w, "failed to get invitation", http.StatusInternalServerError)
+		return
+	}
+
+	json.Write(w, http.StatusOK, InvitationPreview{Name: inv.Name, Email: inv.Email, Role: inv.Role})
+}
+
+// create the account behind an invitation link
+func (h *Handler) AcceptInvitation(w http.ResponseWriter, r *http.Request) {
+	var req AcceptInvitationRequest
+	if err := json.Read(r, &req); err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	// check the password against the policy before anything is written
+	if req.Password != "" {
+		inv, err := h.service.PreviewInvitation(r.Context(), req.Token)
+		if err != nil {
+			if errors.Is(err, ErrInvalidToken) {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			log.Println(err)
+			http.Error(w, "failed to accept invitation", http.StatusInternalServerError)
+			return
+		}
+		name := req.Name
+		if name == "" {
+			name = inv.Name
+		}
+		if err := h.config.PasswordPolicy.Validate(req.Password, inv.Email, name); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	user, err := h.service.AcceptInvitation(r.Context(), req.Token, AcceptParams{
+		Name:     req.Name,
+		Phone:    req.Phone,
+		Password: req.Password,
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrInvalidToken), errors.Is(err, ErrPhoneRequired), errors.Is(err, ErrPasswordRequired):
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		case errors.Is(err, phone.ErrInvalidPhone):
+			http.Error(w, "invalid phone number", http.StatusBadRequest)
+		case errors.Is(err, ErrAlreadyRegistered):
+			http.Error(w, err.Error(), http.StatusConflict)
+		default:
+			log.Println(err)
+			http.Error(w, "failed to accept invitation", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	json.Write(w, http.StatusCreated, map[string]any{
+		"id":      user.ID,
+		"name":    user.Name,
+		"email":   user.Email,
+		"role":    user.Role,
+		"message": "account created, please log in",
+	})
+}
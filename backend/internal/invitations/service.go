@@ -0,0 +1,303 @@
+package invitations
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	repo "hack4good-backend/db/sqlc"
+	"hack4good-backend/internal/auth"
+	"hack4good-backend/internal/phone"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+var (
+	ErrInvalidInvitation  = errors.New("name, email, a valid role and channel (email or sms) are required")
+	ErrInvitationNotFound = errors.New("invitation not found")
+	ErrInvitationClosed   = errors.New("invitation was already accepted or revoked")
+	ErrAlreadyInvited     = errors.New("an open invitation already exists for this email")
+	ErrAlreadyRegistered  = errors.New("an account already exists for this email")
+	ErrPhoneRequired      = errors.New("phone number required")
+	ErrPasswordRequired   = errors.New("password required")
+)
+
+var validRoles = map[string]bool{"participant": true, "caregiver": true, "volunteer": true, "staff": true}
+
+type Service interface {
+	CreateInvitation(ctx context.Context, req CreateInvitationRequest, invitedBy int32) (repo.Invitation, string, error)
+	ListInvitations(ctx context.Context) ([]repo.Invitation, error)
+	ResendInvitation(ctx context.Context, id int32) (repo.Invitation, string, error)
+	RevokeInvitation(ctx context.Context, id int32) error
+	PreviewInvitation(ctx context.Context, token string) (repo.Invitation, error)
+	AcceptInvitation(ctx context.Context, token string, params AcceptParams) (repo.User, error)
+	RevealPhone(inv repo.Invitation) (string, error)
+}
+
+type svc struct {
+	db     *pgxpool.Pool // for the accept transaction
+	repo   *repo.Queries
+	phones *phone.Protector
+	signer signer
+	ttl    time.Duration
+}
+
+func NewService(db *pgxpool.Pool, phones *phone.Protector, signingKey []byte, ttl time.Duration) Service {
+	return &svc{
+		db:     db,
+		repo:   repo.New(db),
+		phones: phones,
+		signer: signer{key: signingKey},
+		ttl:    ttl,
+	}
+}
+
+func isUniqueViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == "23505"
+}
+
+// random nonce for a new token, and the hash kept in the DB
+func (s *svc) newToken(id int32, expiresAt time.Time) (token, hash string, err error) {
+	nonce, hash, err := auth.NewOpaqueToken()
+	if err != nil {
+		return "", "", fmt.Errorf("failed to generate invitation token: %w", err)
+	}
+	return s.signer.sign(id, expiresAt, nonce), hash, nil
+}
+
+func (s *svc) CreateInvitation(ctx context.Context, req CreateInvitationRequest, invitedBy int32) (repo.Invitation, string, error) {
+	req.Email = strings.TrimSpace(req.Email)
+	if req.Channel == "" {
+		req.Channel = "email"
+	}
+	if req.Name == "" || req.Email == "" || !validRoles[req.Role] || (req.Channel != "email" && req.Channel != "sms") {
+		return repo.Invitation{}, "", ErrInvalidInvitation
+	}
+
+	if _, err := s.repo.GetUserByEmail(ctx, req.Email); err == nil {
+		return repo.Invitation{}, "", ErrAlreadyRegistered
+	} else if !errors.Is(err, pgx.ErrNoRows) {
+		return repo.Invitation{}, "", fmt.Errorf("failed to check email: %w", err)
+	}
+
+	var encrypted []byte
+	if req.Phone != "" {
+		_, enc, _, err := s.phones.Protect(req.Phone)
+		if err != nil {
+			return repo.Invitation{}, "", err
+		}
+		encrypted = enc
+	} else if req.Channel == "sms" {
+		return repo.Invitation{}, "", ErrPhoneRequired
+	}
+
+	// the token embeds the row ID, so insert with a placeholder hash and sign afterwards
+	expiresAt := time.Now().Add(s.ttl)
+	inv, err := s.repo.CreateInvitation(ctx, repo.CreateInvitationParams{
+		Name:           req.Name,
+		Email:          req.Email,
+		PhoneEncrypted: encrypted,
+		Role:           req.Role,
+		Channel:        req.Channel,
+		TokenHash:      "",
+		InvitedBy:      pgtype.Int4{Int32: invitedBy, Valid: invitedBy != 0},
+		ExpiresAt:      pgtype.Timestamp{Time: expiresAt, Valid: true},
+	})
+	if err != nil {
+		if isUniqueViolation(err) {
+			return repo.Invitation{}, "", ErrAlreadyInvited
+		}
+		return repo.Invitation{}, "", fmt.Errorf("failed to create invitation: %w", err)
+	}
+
+	return s.renew(ctx, inv.ID)
+}
+
+func (s *svc) ListInvitations(ctx context.Context) ([]repo.Invitation, error) {
+	invitations, err := s.repo.ListInvitations(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list invitations: %w", err)
+	}
+	return invitations, nil
+}
+
+// new token and expiry for an open invitation; earlier links stop working
+func (s *svc) ResendInvitation(ctx context.Context, id int32) (repo.Invitation, string, error) {
+	if _, err := s.getInvitation(ctx, id); err != nil {
+		return repo.Invitation{}, "", err
+	}
+	return s.renew(ctx, id)
+}
+
+func (s *svc) renew(ctx context.Context, id int32) (repo.Invitation, string, error) {
+	expiresAt := time.Now().Add(s.ttl)
+	token, hash, err := s.newToken(id, expiresAt)
+	if err != nil {
+		return repo.Invitation{}, "", err
+	}
+
+	inv, err := s.repo.RenewInvitationToken(ctx, repo.RenewInvitationTokenParams{
+		ID:        id,
+		TokenHash: hash,
+		ExpiresAt: pgtype.Timestamp{Time: expiresAt, Valid: true},
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return repo.Invitation{}, "", ErrInvitationClosed
+		}
+		return repo.Invitation{}, "", fmt.Errorf("failed to renew invitation: %w", err)
+	}
+	return inv, token, nil
+}
+
+func (s *svc) RevokeInvitation(ctx context.Context, id int32) error {
+	if _, err := s.getInvitation(ctx, id); err != nil {
+		return err
+	}
+	n, err := s.repo.RevokeInvitation(ctx, id)
+	if err != nil {
+		return fmt.Errorf("failed to revoke invitation: %w", err)
+	}
+	if n == 0 {
+		return ErrInvitationClosed
+	}
+	return nil
+}
+
+func (s *svc) getInvitation(ctx context.Context, id int32) (repo.Invitation, error) {
+	inv, err := s.repo.GetInvitationByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return repo.Invitation{}, ErrInvitationNotFound
+		}
+		return repo.Invitation{}, fmt.Errorf("failed to get invitation: %w", err)
+	}
+	return inv, nil
+}
+
+// open invitation for a valid, current token
+func (s *svc) PreviewInvitation(ctx context.Context, token string) (repo.Invitation, error) {
+	return s.openInvitation(ctx, s.repo, token)
+}
+
+func (s *svc) openInvitation(ctx context.Context, q *repo.Queries, token string) (repo.Invitation, error) {
+	id, nonce, err := s.signer.verify(token, time.Now())
+	if err != nil {
+		return repo.Invitation{}, err
+	}
+
+	inv, err := q.GetInvitationByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return repo.Invitation{}, ErrInvalidToken
+		}
+		return repo.Invitation{}, fmt.Errorf("failed to get invitation: %w", err)
+	}
+
+	// only the most recently sent link is valid
+	if subtle.ConstantTimeCompare([]byte(auth.HashToken(nonce)), []byte(inv.TokenHash)) != 1 ||
+		status(inv, time.Now()) != StatusPending {
+		return repo.Invitation{}, ErrInvalidToken
+	}
+	return inv, nil
+}
+
+// create the account from the invitation and close it, in one transaction
+func (s *svc) AcceptInvitation(ctx context.Context, token string, params AcceptParams) (repo.User, error) {
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return repo.User{}, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+	q := s.repo.WithTx(tx)
+
+	inv, err := s.openInvitation(ctx, q, token)
+	if err != nil {
+		return repo.User{}, err
+	}
+
+	name := strings.TrimSpace(params.Name)
+	if name == "" {
+		name = inv.Name
+	}
+
+	rawPhone := params.Phone
+	if rawPhone == "" && len(inv.PhoneEncrypted) > 0 {
+		if rawPhone, err = s.phones.Reveal(inv.PhoneEncrypted); err != nil {
+			return repo.User{}, err
+		}
+	}
+	// everyone but staff signs in with codes sent to their phone
+	if rawPhone == "" && inv.Role != "staff" {
+		return repo.User{}, ErrPhoneRequired
+	}
+	if inv.Role == "staff" && params.Password == "" {
+		return repo.User{}, ErrPasswordRequired
+	}
+
+	var encrypted []byte
+	var index pgtype.Text
+	if rawPhone != "" {
+		_, enc, idx, err := s.phones.Protect(rawPhone)
+		if err != nil {
+			return repo.User{}, err
+		}
+		encrypted, index = enc, pgtype.Text{String: idx, Valid: true}
+	}
+
+	user, err := q.CreateUser(ctx, repo.CreateUserParams{
+		Name:           name,
+		PhoneEncrypted: encrypted,
+		PhoneIndex:     index,
+		Email:          inv.Email,
+		Role:           inv.Role,
+	})
+	if err != nil {
+		if isUniqueViolation(err) {
+			return repo.User{}, ErrAlreadyRegistered
+		}
+		return repo.User{}, fmt.Errorf("failed to create user: %w", err)
+	}
+
+	if inv.Role == "staff" {
+		hash, err := auth.HashPassword(params.Password)
+		if err != nil {
+			return repo.User{}, fmt.Errorf("failed to hash password: %w", err)
+		}
+		if err := q.SetUserPassword(ctx, repo.SetUserPasswordParams{
+			ID:       user.ID,
+			Password: hash,
+		}); err != nil {
+			return repo.User{}, fmt.Errorf("failed to set password: %w", err)
+		}
+	}
+
+	n, err := q.AcceptInvitation(ctx, repo.AcceptInvitationParams{
+		ID:             inv.ID,
+		AcceptedUserID: pgtype.Int4{Int32: user.ID, Valid: true},
+	})
+	if err != nil {
+		return repo.User{}, fmt.Errorf("failed to accept invitation: %w", err)
+	}
+	if n == 0 {
+		return repo.User{}, ErrInvalidToken
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return repo.User{}, fmt.Errorf("failed to commit: %w", err)
+	}
+	return user, nil
+}
+
+func (s *svc) RevealPhone(inv repo.Invitation) (string, error) {
+	if len(inv.PhoneEncrypted) == 0 {
+		return "", nil
+	}
+	return s.phones.Reveal(inv.PhoneEncrypted)
+}
@@ -0,0 +1,65 @@
+package invitations
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var ErrInvalidToken = errors.New("invalid or expired invitation")
+
+// signs invitation tokens: base64(id.expires.nonce) + "." + base64(hmac)
+type signer struct {
+	key []byte
+}
+
+func (s signer) mac(payload string) []byte {
+	m := hmac.New(sha256.New, s.key)
+	m.Write([]byte(payload))
+	return m.Sum(nil)
+}
+
+func (s signer) sign(id int32, expiresAt time.Time, nonce string) string {
+	payload := fmt.Sprintf("%d.%d.%s", id, expiresAt.Unix(), nonce)
+	return base64.RawURLEncoding.EncodeToString([]byte(payload)) + "." +
+		base64.RawURLEncoding.EncodeToString(s.mac(payload))
+}
+
+// check signature and expiry, returns the invitation ID and nonce
+func (s signer) verify(token string, now time.Time) (int32, string, error) {
+	encPayload, encMAC, ok := strings.Cut(token, ".")
+	if !ok {
+		return 0, "", ErrInvalidToken
+	}
+	rawPayload, err := base64.RawURLEncoding.DecodeString(encPayload)
+	if err != nil {
+		return 0, "", ErrInvalidToken
+	}
+	mac, err := base64.RawURLEncoding.DecodeString(encMAC)
+	if err != nil {
+		return 0, "", ErrInvalidToken
+	}
+	payload := string(rawPayload)
+	if !hmac.Equal(mac, s.mac(payload)) {
+		return 0, "", ErrInvalidToken
+	}
+
+	parts := strings.SplitN(payload, ".", 3)
+	if len(parts) != 3 {
+		return 0, "", ErrInvalidToken
+	}
+	id, err := strconv.ParseInt(parts[0], 10, 32)
+	if err != nil {
+		return 0, "", ErrInvalidToken
+	}
+	expires, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil || now.After(time.Unix(expires, 0)) {
+		return 0, "", ErrInvalidToken
+	}
+	return int32(id), parts[2], nil
+}
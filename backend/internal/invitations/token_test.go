@@ -0,0 +1,83 @@
+package invitations
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	repo "hack4good-backend/db/sqlc"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+func TestSignerVerify(t *testing.T) {
+	s := signer{key: bytes.Repeat([]byte{1}, 32)}
+	now := time.Unix(1_800_000_000, 0)
+	valid := s.sign(42, now.Add(time.Hour), "nonce-1")
+
+	// a payload signed with the right key but in the wrong shape
+	forge := func(payload string) string {
+		return base64.RawURLEncoding.EncodeToString([]byte(payload)) + "." +
+			base64.RawURLEncoding.EncodeToString(s.mac(payload))
+	}
+	encPayload, encMAC, _ := strings.Cut(valid, ".")
+
+	tests := []struct {
+		name    string
+		token   string
+		now     time.Time
+		wantErr bool
+	}{
+		{"valid", valid, now, false},
+		{"at expiry", valid, now.Add(time.Hour), false},
+		{"expired", valid, now.Add(time.Hour + time.Second), true},
+		{"other key", signer{key: bytes.Repeat([]byte{2}, 32)}.sign(42, now.Add(time.Hour), "nonce-1"), now, true},
+		{"other id with the original signature", base64.RawURLEncoding.EncodeToString([]byte("43.1800003600.nonce-1")) + "." + encMAC, now, true},
+		{"signature cut short", valid[:len(valid)-4], now, true},
+		{"no signature", encPayload, now, true},
+		{"payload not base64", "***." + encMAC, now, true},
+		{"signature not base64", encPayload + ".***", now, true},
+		{"empty", "", now, true},
+		{"missing nonce", forge("42.1800003600"), now, true},
+		{"id not a number", forge("abc.1800003600.nonce"), now, true},
+		{"id out of range", forge("9999999999.1800003600.nonce"), now, true},
+		{"expiry not a number", forge("42.soon.nonce"), now, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			id, nonce, err := s.verify(tt.token, tt.now)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidToken) {
+					t.Errorf("verify = %d, %q, %v, want ErrInvalidToken", id, nonce, err)
+				}
+				return
+			}
+			if err != nil || id != 42 || nonce != "nonce-1" {
+				t.Errorf("verify = %d, %q, %v, want 42, nonce-1", id, nonce, err)
+			}
+		})
+	}
+}
+
+func TestStatus(t *testing.T) {
+	now := time.Now()
+	at := func(t time.Time) pgtype.Timestamp { return pgtype.Timestamp{Time: t, Valid: true} }
+	tests := []struct {
+		name string
+		inv  repo.Invitation
+		want string
+	}{
+		{"open", repo.Invitation{ExpiresAt: at(now.Add(time.Hour))}, StatusPending},
+		{"expired", repo.Invitation{ExpiresAt: at(now.Add(-time.Hour))}, StatusExpired},
+		{"accepted", repo.Invitation{ExpiresAt: at(now.Add(time.Hour)), AcceptedAt: at(now)}, StatusAccepted},
+		{"accepted before expiry", repo.Invitation{ExpiresAt: at(now.Add(-time.Hour)), AcceptedAt: at(now.Add(-2 * time.Hour))}, StatusAccepted},
+		{"revoked", repo.Invitation{ExpiresAt: at(now.Add(time.Hour)), RevokedAt: at(now)}, StatusRevoked},
+	}
+	for _, tt := range tests {
+		if got := status(tt.inv, now); got != tt.want {
+			t.Errorf("%s: status = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package invitations
+
+import (
+	repo "hack4good-backend/db/sqlc"
+	"hack4good-backend/internal/auth"
+	"time"
+)
+
+const (
+	StatusPending  = "pending"
+	StatusExpired  = "expired"
+	StatusAccepted = "accepted"
+	StatusRevoked  = "revoked"
+)
+
+type Config struct {
+	AppURL         string        // frontend base URL used in invitation links
+	TTL            time.Duration // how long an invitation link stays valid
+	PasswordPolicy auth.PasswordPolicy
+}
+
+// invitation as returned to staff
+type Invitation struct {
+	ID         int32      `json:"id"`
+	Name       string     `json:"name"`
+	Email      string     `json:"email"`
+	Phone      string     `json:"phone,omitempty"`
+	Role       string     `json:"role"`
+	Channel    string     `json:"channel"`
+	Status     string     `json:"status"`
+	InvitedBy  *int32     `json:"invited_by,omitempty"`
+	ExpiresAt  time.Time  `json:"expires_at"`
+	SentAt     time.Time  `json:"sent_at"`
+	AcceptedAt *time.Time `json:"accepted_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+func status(inv repo.Invitation, now time.Time) string {
+	switch {
+	case inv.AcceptedAt.Valid:
+		return StatusAccepted
+	case inv.RevokedAt.Valid:
+		return StatusRevoked
+	case now.After(inv.ExpiresAt.Time):
+		return StatusExpired
+	default:
+		return StatusPending
+	}
+}
+
+type CreateInvitationRequest struct {
+	Name    string `json:"name"`
+	Email   string `json:"email"`
+	Phone   string `json:"phone"` // raw number, required for sms
+	Role    string `json:"role"`
+	Channel string `json:"channel"` // email (default) or sms
+}
+
+type TokenRequest struct {
+	Token string `json:"token"`
+}
+
+// what the invitee sees before accepting
+type InvitationPreview struct {
+	Name  string `json:"name"`
+	Email string `json:"email"`
+	Role  string `json:"role"`
+}
+
+// invitee confirms their details; staff also choose a password
+type AcceptInvitationRequest struct {
+	Token    string `json:"token"`
+	Name     string `json:"name"`
+	Phone    string `json:"phone"`
+	Password string `json:"password"`
+}
+
+type AcceptParams struct {
+	Name     string
+	Phone    string
+	Password string
+}
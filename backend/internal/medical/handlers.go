@@ -0,0 +1,236 @@
+package medical
+
+import (
+	"errors"
+	"hack4good-backend/internal/auth"
+	"hack4good-backend/internal/json"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+)
+
+type Handler struct {
+	service Service
+}
+
+func NewHandler(service Service) *Handler {
+	return &Handler{
+		service: service,
+	}
+}
+
+// participant whose details are addressed: {id} in the path, or the signed-in user for /user/profile/medical
+// writes the error response if the signed-in user may not use perm on it
+func (h *Handler) subject(w http.ResponseWriter, r *http.Request, perm auth.ScopedPermission) (int32, Access, bool) {
+	claims, ok := auth.FromContext(r.Context())
+	if !ok {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return 0, Access{}, false
+	}
+
+	userID := claims.ID
+	if idStr := chi.URLParam(r, "id"); idStr != "" {
+		id, err := strconv.Atoi(idStr)
+		if err != nil {
+			http.Error(w, "invalid user id", http.StatusBadRequest)
+			return 0, Access{}, false
+		}
+		userID = int32(id)
+	}
+
+	ok, err := auth.CanActFor(r.Context(), perm, userID, h.service.IsCaregiverOf)
+	if err != nil {
+		log.Println(err)
+		http.Error(w, "failed to check permissions", http.StatusInternalServerError)
+		return 0, Access{}, false
+	}
+	if !ok {
+		http.Error(w, "forbidden", http.StatusForbidden)
+		return 0, Access{}, false
+	}
+
+	// same order as CanActFor
+	basis := BasisDependent
+	switch {
+	case auth.Can(r.Context(), perm.Any):
+		basis = BasisAny
+	case userID == claims.ID:
+		basis = BasisOwn
+	}
+	return userID, accessFor(claims, basis), true
+}
+
+func accessFor(claims *auth.UserClaims, basis string) Access {
+	return Access{
+		ViewerID:       claims.ID,
+		ImpersonatorID: claims.ImpersonatorID,
+		APIKeyID:       claims.APIKeyID,
+		Basis:          basis,
+	}
+}
+
+func activityIDParam(w http.ResponseWriter, r *http.Request) (int32, bool) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "invalid activity id", http.StatusBadRequest)
+		return 0, false
+	}
+	return int32(id), true
+}
+
+// Get a participant's emergency contacts and medical notes (logged)
+func (h *Handler) Get(w http.ResponseWriter, r *http.Request) {
+	userID, access, ok := h.subject(w, r, auth.MedicalRead)
+	if !ok {
+		return
+	}
+
+	record, err := h.service.Get(r.Context(), userID, access)
+	if err != nil {
+		writeError(w, err, "failed to get medical details")
+		return
+	}
+
+	json.Write(w, http.StatusOK, record)
+}
+
+// Replace a participant's emergency contacts and/or medical notes (the record is only returned to staff)
+func (h *Handler) Update(w http.ResponseWriter, r *http.Request) {
+	userID, access, ok := h.subject(w, r, auth.MedicalWrite)
+	if !ok {
+		return
+	}
+
+	var req RecordRequest
+	if err := json.Read(r, &req); err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	record, err := h.service.Update(r.Context(), userID, req, access)
+	if err != nil {
+		writeError(w, err, "failed to update medical details")
+		return
+	}
+
+	// participants and caregivers may write the details but not read them back
+	if access.Basis != BasisAny {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	json.Write(w, http.StatusOK, record)
+}
+
+// Who has read a participant's medical details, newest first (by staff)
+func (h *Handler) AccessLog(w http.ResponseWriter, r *http.Request) {
+	userID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "invalid user id", http.StatusBadRequest)
+		return
+	}
+
+	logs, err := h.service.AccessLogs(r.Context(), int32(userID))
+	if err != nil {
+		writeError(w, err, "failed to list access log")
+		return
+	}
+
+	json.Write(w, http.StatusOK, logs)
+}
+
+// Medical details of the participants booked on an activity, for its volunteer lead on the day (logged)
+func (h *Handler) ForActivity(w http.ResponseWriter, r *http.Request) {
+	claims, ok := auth.FromContext(r.Context())
+	if !ok {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	activityID, ok := activityIDParam(w, r)
+	if !ok {
+		return
+	}
+
+	records, err := h.service.ForActivity(r.Context(), activityID, accessFor(claims, BasisVolunteerLead))
+	if err != nil {
+		writeError(w, err, "failed to get medical details")
+		return
+	}
+
+	json.Write(w, http.StatusOK, records)
+}
+
+// Volunteer lead of an activity (by staff)
+func (h *Handler) GetLead(w http.ResponseWriter, r *http.Request) {
+	activityID, ok := activityIDParam(w, r)
+	if !ok {
+		return
+	}
+
+	lead, err := h.service.GetLead(r.Context(), activityID)
+	if err != nil {
+		writeError(w, err, "failed to get volunteer lead")
+		return
+	}
+
+	json.Write(w, http.StatusOK, lead)
+}
+
+// Make a volunteer the lead of an activity, replacing any other (by staff)
+func (h *Handler) SetLead(w http.ResponseWriter, r *http.Request) {
+	claims, ok := auth.FromContext(r.Context())
+	if !ok {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	activityID, ok := activityIDParam(w, r)
+	if !ok {
+		return
+	}
+
+	var req LeadRequest
+	if err := json.Read(r, &req); err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	lead, err := h.service.SetLead(r.Context(), activityID, req.UserID, claims.ID)
+	if err != nil {
+		writeError(w, err, "failed to set volunteer lead")
+		return
+	}
+
+	json.Write(w, http.StatusOK, lead)
+}
+
+// Remove an activity's volunteer lead (by staff)
+func (h *Handler) RemoveLead(w http.ResponseWriter, r *http.Request) {
+	activityID, ok := activityIDParam(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.service.RemoveLead(r.Context(), activityID); err != nil {
+		writeError(w, err, "failed to remove volunteer lead")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func writeError(w http.ResponseWriter, err error, msg string) {
+	switch {
+	case errors.Is(err, ErrUserNotFound), errors.Is(err, ErrRecordNotFound),
+		errors.Is(err, ErrActivityNotFound), errors.Is(err, ErrLeadNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, ErrNotParticipant), errors.Is(err, ErrInvalidContact),
+		errors.Is(err, ErrNotesTooLong), errors.Is(err, ErrNotVolunteer):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, ErrNotLead):
+		http.Error(w, err.Error(), http.StatusForbidden)
+	default:
+		log.Println(err)
+		http.Error(w, msg, http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,374 @@
+package medical
+
+import (
+	"context"
+	stdjson "encoding/json"
+	"errors"
+	"fmt"
+	repo "hack4good-backend/db/sqlc"
+	"hack4good-backend/internal/phone"
+	"hack4good-backend/internal/secure"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const (
+	maxContacts    = 5
+	maxNotesLength = 4000
+)
+
+var (
+	ErrUserNotFound     = errors.New("user not found")
+	ErrNotParticipant   = errors.New("medical details are only for participants")
+	ErrRecordNotFound   = errors.New("no medical details recorded")
+	ErrInvalidContact   = errors.New("emergency contacts need a name and a valid phone number, at most 5 of them")
+	ErrNotesTooLong     = errors.New("allergies, medications and conditions are limited to 4000 characters each")
+	ErrActivityNotFound = errors.New("activity not found")
+	ErrNotVolunteer     = errors.New("the volunteer lead must be an active volunteer")
+	ErrLeadNotFound     = errors.New("activity has no volunteer lead")
+	ErrNotLead          = errors.New("only the activity's volunteer lead can see this, on the day of the activity")
+)
+
+type Service interface {
+	// every successful read is logged with access
+	Get(ctx context.Context, userID int32, access Access) (Record, error)
+	Update(ctx context.Context, userID int32, req RecordRequest, access Access) (Record, error)
+	ForActivity(ctx context.Context, activityID int32, access Access) ([]Record, error)
+	AccessLogs(ctx context.Context, userID int32) ([]AccessLog, error)
+	GetLead(ctx context.Context, activityID int32) (Lead, error)
+	SetLead(ctx context.Context, activityID, volunteerID, staffID int32) (Lead, error)
+	RemoveLead(ctx context.Context, activityID int32) error
+	IsCaregiverOf(ctx context.Context, caregiverID, participantID int32) (bool, error)
+}
+
+type svc struct {
+	db                 *pgxpool.Pool // records and their access log entries are written together
+	repo               *repo.Queries
+	cipher             *secure.Cipher
+	defaultCountryCode string
+}
+
+func NewService(db *pgxpool.Pool, cipher *secure.Cipher, defaultCountryCode string) Service {
+	return &svc{
+		db:                 db,
+		repo:               repo.New(db),
+		cipher:             cipher,
+		defaultCountryCode: defaultCountryCode,
+	}
+}
+
+func (s *svc) Get(ctx context.Context, userID int32, access Access) (Record, error) {
+	row, err := s.repo.GetParticipantMedical(ctx, userID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return Record{}, ErrRecordNotFound
+		}
+		return Record{}, fmt.Errorf("failed to get medical details: %w", err)
+	}
+
+	record, err := s.decrypt(row.UserID, row.EmergencyContactsEncrypted, row.MedicalNotesEncrypted, row.UpdatedBy, row.UpdatedAt)
+	if err != nil {
+		return Record{}, err
+	}
+	// nothing is returned unless the read was logged
+	if err := logAccess(ctx, s.repo, userID, access); err != nil {
+		return Record{}, err
+	}
+	return record, nil
+}
+
+func (s *svc) Update(ctx context.Context, userID int32, req RecordRequest, access Access) (Record, error) {
+	if err := s.checkParticipant(ctx, userID); err != nil {
+		return Record{}, err
+	}
+
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return Record{}, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+	q := s.repo.WithTx(tx)
+
+	current := Record{EmergencyContacts: []EmergencyContact{}}
+	row, err := q.GetParticipantMedical(ctx, userID)
+	switch {
+	case err == nil:
+		current, err = s.decrypt(row.UserID, row.EmergencyContactsEncrypted, row.MedicalNotesEncrypted, row.UpdatedBy, row.UpdatedAt)
+		if err != nil {
+			return Record{}, err
+		}
+	case !errors.Is(err, pgx.ErrNoRows):
+		return Record{}, fmt.Errorf("failed to get medical details: %w", err)
+	}
+
+	if req.EmergencyContacts != nil {
+		contacts, err := s.contacts(*req.EmergencyContacts)
+		if err != nil {
+			return Record{}, err
+		}
+		current.EmergencyContacts = contacts
+	}
+	for _, f := range []struct {
+		value *string
+		field *string
+	}{
+		{req.Allergies, &current.Allergies},
+		{req.Medications, &current.Medications},
+		{req.Conditions, &current.Conditions},
+	} {
+		if f.value == nil {
+			continue
+		}
+		v := strings.TrimSpace(*f.value)
+		if utf8.RuneCountInString(v) > maxNotesLength {
+			return Record{}, ErrNotesTooLong
+		}
+		*f.field = v
+	}
+
+	contacts, err := s.encrypt(current.EmergencyContacts)
+	if err != nil {
+		return Record{}, err
+	}
+	notes, err := s.encrypt(current.Notes)
+	if err != nil {
+		return Record{}, err
+	}
+	saved, err := q.UpsertParticipantMedical(ctx, repo.UpsertParticipantMedicalParams{
+		UserID:                     userID,
+		EmergencyContactsEncrypted: contacts,
+		MedicalNotesEncrypted:      notes,
+		UpdatedBy:                  optInt4(access.ViewerID),
+	})
+	if err != nil {
+		return Record{}, fmt.Errorf("failed to save medical details: %w", err)
+	}
+
+	// staff get the whole record back, so for them it counts as a read
+	if access.Basis == BasisAny {
+		if err := logAccess(ctx, q, userID, access); err != nil {
+			return Record{}, err
+		}
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return Record{}, fmt.Errorf("failed to commit medical details: %w", err)
+	}
+
+	current.UserID = userID
+	current.UpdatedBy = int4Ptr(saved.UpdatedBy)
+	current.UpdatedAt = &saved.UpdatedAt.Time
+	return current, nil
+}
+
+func (s *svc) ForActivity(ctx context.Context, activityID int32, access Access) ([]Record, error) {
+	lead, err := s.repo.IsActivityLeadOn(ctx, repo.IsActivityLeadOnParams{
+		ActivityID: activityID,
+		UserID:     access.ViewerID,
+		OnDate:     today(),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to check volunteer lead: %w", err)
+	}
+	if !lead {
+		return nil, ErrNotLead
+	}
+
+	rows, err := s.repo.ListActivityParticipantMedical(ctx, activityID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list medical details: %w", err)
+	}
+
+	records := make([]Record, 0, len(rows))
+	for _, row := range rows {
+		record, err := s.decrypt(row.ID, row.EmergencyContactsEncrypted, row.MedicalNotesEncrypted, row.UpdatedBy, row.UpdatedAt)
+		if err != nil {
+			return nil, err
+		}
+		record.Name = row.Name
+		records = append(records, record)
+	}
+
+	// one log entry per participant shown, all or none
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+	q := s.repo.WithTx(tx)
+	access.ActivityID = activityID
+	for _, record := range records {
+		if err := logAccess(ctx, q, record.UserID, access); err != nil {
+			return nil, err
+		}
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit access log: %w", err)
+	}
+	return records, nil
+}
+
+func (s *svc) AccessLogs(ctx context.Context, userID int32) ([]AccessLog, error) {
+	rows, err := s.repo.ListMedicalAccessLogs(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list access log: %w", err)
+	}
+	out := make([]AccessLog, 0, len(rows))
+	for _, row := range rows {
+		out = append(out, toAccessLog(row))
+	}
+	return out, nil
+}
+
+func (s *svc) GetLead(ctx context.Context, activityID int32) (Lead, error) {
+	lead, err := s.repo.GetActivityVolunteerLead(ctx, activityID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return Lead{}, ErrLeadNotFound
+		}
+		return Lead{}, fmt.Errorf("failed to get volunteer lead: %w", err)
+	}
+	return toLead(lead), nil
+}
+
+func (s *svc) SetLead(ctx context.Context, activityID, volunteerID, staffID int32) (Lead, error) {
+	if _, err := s.repo.GetActivityByID(ctx, activityID); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return Lead{}, ErrActivityNotFound
+		}
+		return Lead{}, fmt.Errorf("failed to get activity: %w", err)
+	}
+	user, err := s.repo.GetUserByID(ctx, volunteerID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return Lead{}, ErrNotVolunteer
+		}
+		return Lead{}, fmt.Errorf("failed to get user: %w", err)
+	}
+	if user.Role != "volunteer" || user.Status != "active" {
+		return Lead{}, ErrNotVolunteer
+	}
+
+	lead, err := s.repo.SetActivityVolunteerLead(ctx, repo.SetActivityVolunteerLeadParams{
+		ActivityID: activityID,
+		UserID:     volunteerID,
+		AssignedBy: optInt4(staffID),
+	})
+	if err != nil {
+		return Lead{}, fmt.Errorf("failed to set volunteer lead: %w", err)
+	}
+	return toLead(lead), nil
+}
+
+func (s *svc) RemoveLead(ctx context.Context, activityID int32) error {
+	n, err := s.repo.DeleteActivityVolunteerLead(ctx, activityID)
+	if err != nil {
+		return fmt.Errorf("failed to remove volunteer lead: %w", err)
+	}
+	if n == 0 {
+		return ErrLeadNotFound
+	}
+	return nil
+}
+
+func (s *svc) IsCaregiverOf(ctx context.Context, caregiverID, participantID int32) (bool, error) {
+	return s.repo.IsCaregiverOf(ctx, repo.IsCaregiverOfParams{
+		CaregiverID:   caregiverID,
+		ParticipantID: participantID,
+	})
+}
+
+func (s *svc) checkParticipant(ctx context.Context, userID int32) error {
+	user, err := s.repo.GetUserByID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrUserNotFound
+		}
+		return fmt.Errorf("failed to get user: %w", err)
+	}
+	if user.Role != "participant" {
+		return ErrNotParticipant
+	}
+	return nil
+}
+
+// trimmed, with phone numbers in E.164
+func (s *svc) contacts(in []EmergencyContact) ([]EmergencyContact, error) {
+	if len(in) > maxContacts {
+		return nil, ErrInvalidContact
+	}
+	out := make([]EmergencyContact, 0, len(in))
+	for _, c := range in {
+		name := strings.TrimSpace(c.Name)
+		number, err := phone.Normalize(c.Phone, s.defaultCountryCode)
+		if err != nil || name == "" {
+			return nil, ErrInvalidContact
+		}
+		out = append(out, EmergencyContact{
+			Name:         name,
+			Relationship: strings.TrimSpace(c.Relationship),
+			Phone:        number,
+		})
+	}
+	return out, nil
+}
+
+func (s *svc) encrypt(v any) ([]byte, error) {
+	data, err := stdjson.Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode medical details: %w", err)
+	}
+	return s.cipher.Encrypt(data)
+}
+
+func (s *svc) decrypt(userID int32, contacts, notes []byte, updatedBy pgtype.Int4, updatedAt pgtype.Timestamp) (Record, error) {
+	record := Record{
+		UserID:    userID,
+		UpdatedBy: int4Ptr(updatedBy),
+		UpdatedAt: &updatedAt.Time,
+	}
+	for _, f := range []struct {
+		data []byte
+		into any
+	}{
+		{contacts, &record.EmergencyContacts},
+		{notes, &record.Notes},
+	} {
+		plain, err := s.cipher.Decrypt(f.data)
+		if err != nil {
+			return Record{}, fmt.Errorf("failed to decrypt medical details of user %d: %w", userID, err)
+		}
+		if err := stdjson.Unmarshal(plain, f.into); err != nil {
+			return Record{}, fmt.Errorf("failed to decode medical details of user %d: %w", userID, err)
+		}
+	}
+	if record.EmergencyContacts == nil {
+		record.EmergencyContacts = []EmergencyContact{}
+	}
+	return record, nil
+}
+
+func logAccess(ctx context.Context, q *repo.Queries, participantID int32, access Access) error {
+	err := q.CreateMedicalAccessLog(ctx, repo.CreateMedicalAccessLogParams{
+		ParticipantID:  participantID,
+		ViewerID:       optInt4(access.ViewerID),
+		ImpersonatorID: optInt4(access.ImpersonatorID),
+		ApiKeyID:       optInt4(access.APIKeyID),
+		ActivityID:     optInt4(access.ActivityID),
+		Basis:          access.Basis,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to log medical access: %w", err)
+	}
+	return nil
+}
+
+// activities are stored in local time, so "the day" is the local date
+func today() pgtype.Date {
+	now := time.Now()
+	return pgtype.Date{Time: time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC), Valid: true}
+}
@@ -0,0 +1,114 @@
+package medical
+
+import (
+	repo "hack4good-backend/db/sqlc"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// why a reader was allowed to see a participant's medical details (medical_access_logs.basis)
+const (
+	BasisOwn           = "own"
+	BasisDependent     = "dependent"
+	BasisAny           = "any"
+	BasisVolunteerLead = "volunteer_lead"
+)
+
+type EmergencyContact struct {
+	Name         string `json:"name"`
+	Relationship string `json:"relationship,omitempty"`
+	Phone        string `json:"phone"` // E.164
+}
+
+// free text, stored encrypted together
+type Notes struct {
+	Allergies   string `json:"allergies"`
+	Medications string `json:"medications"`
+	Conditions  string `json:"conditions"`
+}
+
+type Record struct {
+	UserID            int32              `json:"user_id"`
+	Name              string             `json:"name,omitempty"` // on activity lists
+	EmergencyContacts []EmergencyContact `json:"emergency_contacts"`
+	Notes
+	UpdatedBy *int32     `json:"updated_by,omitempty"`
+	UpdatedAt *time.Time `json:"updated_at"`
+}
+
+// PUT replaces the fields that are sent and keeps the others
+type RecordRequest struct {
+	EmergencyContacts *[]EmergencyContact `json:"emergency_contacts"`
+	Allergies         *string             `json:"allergies"`
+	Medications       *string             `json:"medications"`
+	Conditions        *string             `json:"conditions"`
+}
+
+// who is reading, for the access log
+type Access struct {
+	ViewerID       int32
+	ImpersonatorID int32
+	APIKeyID       int32
+	ActivityID     int32
+	Basis          string
+}
+
+type AccessLog struct {
+	ID             int32     `json:"id"`
+	ViewerID       *int32    `json:"viewer_id"`
+	ViewerName     *string   `json:"viewer_name"`
+	ImpersonatorID *int32    `json:"impersonator_id,omitempty"`
+	APIKeyID       *int32    `json:"api_key_id,omitempty"`
+	ActivityID     *int32    `json:"activity_id,omitempty"`
+	Basis          string    `json:"basis"`
+	ReadAt         time.Time `json:"read_at"`
+}
+
+type Lead struct {
+	ActivityID int32     `json:"activity_id"`
+	UserID     int32     `json:"user_id"`
+	AssignedBy *int32    `json:"assigned_by,omitempty"`
+	AssignedAt time.Time `json:"assigned_at"`
+}
+
+type LeadRequest struct {
+	UserID int32 `json:"user_id"`
+}
+
+func toAccessLog(l repo.ListMedicalAccessLogsRow) AccessLog {
+	out := AccessLog{
+		ID:             l.ID,
+		ViewerID:       int4Ptr(l.ViewerID),
+		ImpersonatorID: int4Ptr(l.ImpersonatorID),
+		APIKeyID:       int4Ptr(l.ApiKeyID),
+		ActivityID:     int4Ptr(l.ActivityID),
+		Basis:          l.Basis,
+		ReadAt:         l.CreatedAt.Time,
+	}
+	if l.ViewerName.Valid {
+		out.ViewerName = &l.ViewerName.String
+	}
+	return out
+}
+
+func toLead(l repo.ActivityVolunteerLead) Lead {
+	return Lead{
+		ActivityID: l.ActivityID,
+		UserID:     l.UserID,
+		AssignedBy: int4Ptr(l.AssignedBy),
+		AssignedAt: l.AssignedAt.Time,
+	}
+}
+
+func int4Ptr(v pgtype.Int4) *int32 {
+	if !v.Valid {
+		return nil
+	}
+	return &v.Int32
+}
+
+// zero means none
+func optInt4(v int32) pgtype.Int4 {
+	return pgtype.Int4{Int32: v, Valid: v != 0}
+}
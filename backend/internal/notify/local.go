@@ -0,0 +1,49 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+)
+
+// logs messages instead of delivering them (development only)
+type ConsoleSender struct {
+	channel string
+}
+
+func NewConsoleSender(channel string) *ConsoleSender {
+	return &ConsoleSender{channel: channel}
+}
+
+func (s *ConsoleSender) Send(ctx context.Context, msg Message) error {
+	log.Printf("[%s] to=%s subject=%q\n%s", s.channel, msg.To, msg.Subject, msg.Body)
+	return nil
+}
+
+// appends messages to a local file (development only)
+type FileSender struct {
+	mu      sync.Mutex
+	path    string
+	channel string
+}
+
+func NewFileSender(path, channel string) *FileSender {
+	return &FileSender{path: path, channel: channel}
+}
+
+func (s *FileSender) Send(ctx context.Context, msg Message) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	f, err := os.OpenFile(s.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return fmt.Errorf("failed to open %s: %w", s.path, err)
+	}
+	defer f.Close()
+
+	_, err = fmt.Fprintf(f, "%s [%s] to=%s subject=%q\n%s\n\n", time.Now().Format(time.RFC3339), s.channel, msg.To, msg.Subject, msg.Body)
+	return err
+}
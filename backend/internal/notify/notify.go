@@ -0,0 +1,61 @@
+package notify
+
+import (
+	"context"
+	"hack4good-backend/internal/env"
+)
+
+// a message to one recipient (email address or phone number)
+type Message struct {
+	To      string
+	Subject string // ignored by SMS senders
+	Body    string
+}
+
+// delivers messages over one channel
+type Sender interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// senders for each channel we can reach users on
+type Notifier struct {
+	Email Sender
+	SMS   Sender
+}
+
+// build senders from env, falling back to the console for local development
+//
+//	NOTIFY_EMAIL_SENDER = smtp | file | console
+//	NOTIFY_SMS_SENDER   = gateway | file | console
+func FromEnv() *Notifier {
+	file := env.GetString("NOTIFY_FILE", "notifications.log")
+
+	n := &Notifier{}
+	switch env.GetString("NOTIFY_EMAIL_SENDER", "console") {
+	case "smtp":
+		n.Email = NewSMTPSender(
+			env.GetString("SMTP_ADDR", "localhost:587"),
+			env.GetString("SMTP_USERNAME", ""),
+			env.GetString("SMTP_PASSWORD", ""),
+			env.GetString("SMTP_FROM", "no-reply@localhost"),
+		)
+	case "file":
+		n.Email = NewFileSender(file, "email")
+	default:
+		n.Email = NewConsoleSender("email")
+	}
+
+	switch env.GetString("NOTIFY_SMS_SENDER", "console") {
+	case "gateway":
+		n.SMS = NewSMSGatewaySender(
+			env.GetString("SMS_GATEWAY_URL", ""),
+			env.GetString("SMS_GATEWAY_TOKEN", ""),
+			env.GetString("SMS_SENDER_ID", ""),
+		)
+	case "file":
+		n.SMS = NewFileSender(file, "sms")
+	default:
+		n.SMS = NewConsoleSender("sms")
+	}
+	return n
+}
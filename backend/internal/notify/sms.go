@@ -0,0 +1,58 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// sends SMS through an HTTP gateway accepting {"to", "from", "message"} as JSON
+type SMSGatewaySender struct {
+	url      string
+	token    string
+	senderID string
+	client   *http.Client
+}
+
+func NewSMSGatewaySender(url, token, senderID string) *SMSGatewaySender {
+	return &SMSGatewaySender{
+		url:      url,
+		token:    token,
+		senderID: senderID,
+		client:   &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+func (s *SMSGatewaySender) Send(ctx context.Context, msg Message) error {
+	payload, err := json.Marshal(map[string]string{
+		"to":      msg.To,
+		"from":    s.senderID,
+		"message": msg.Body,
+	})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, bytes.NewReader(payload))
+	if err != nil {
+		return fmt.Errorf("failed to build SMS request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if s.token != "" {
+		req.Header.Set("Authorization", "Bearer "+s.token)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send SMS: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("SMS gateway returned %s", resp.Status)
+	}
+	return nil
+}
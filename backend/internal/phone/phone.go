@@ -0,0 +1,85 @@
+package phone
+
+import (
+	"errors"
+	"fmt"
+	"hack4good-backend/internal/secure"
+	"strings"
+)
+
+var ErrInvalidPhone = errors.New("invalid phone number")
+
+// normalise a phone number to E.164 (+<country><number>); numbers without a
+// country prefix get defaultCountryCode (e.g. "65")
+func Normalize(raw, defaultCountryCode string) (string, error) {
+	s := strings.TrimSpace(raw)
+	s = strings.NewReplacer(" ", "", "-", "", "(", "", ")", "", ".", "").Replace(s)
+
+	switch {
+	case strings.HasPrefix(s, "+"):
+		s = s[1:]
+	case strings.HasPrefix(s, "00"):
+		s = s[2:]
+	default:
+		s = defaultCountryCode + strings.TrimPrefix(s, "0")
+	}
+
+	if len(s) < 8 || len(s) > 15 || s[0] == '0' {
+		return "", ErrInvalidPhone
+	}
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return "", ErrInvalidPhone
+		}
+	}
+	return "+" + s, nil
+}
+
+// encrypts phone numbers at rest and derives a blind index for lookups
+type Protector struct {
+	cipher             *secure.Cipher
+	index              *secure.BlindIndex
+	defaultCountryCode string
+}
+
+func NewProtector(encryptionKey, indexKey []byte, defaultCountryCode string) (*Protector, error) {
+	c, err := secure.NewCipher(encryptionKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create phone cipher: %w", err)
+	}
+	return &Protector{
+		cipher:             c,
+		index:              secure.NewBlindIndex(indexKey),
+		defaultCountryCode: defaultCountryCode,
+	}, nil
+}
+
+// normalise, then return the E.164 number, its ciphertext and blind index
+func (p *Protector) Protect(raw string) (e164 string, encrypted []byte, index string, err error) {
+	e164, err = Normalize(raw, p.defaultCountryCode)
+	if err != nil {
+		return "", nil, "", err
+	}
+	encrypted, err = p.cipher.Encrypt([]byte(e164))
+	if err != nil {
+		return "", nil, "", err
+	}
+	return e164, encrypted, p.index.Sum(e164), nil
+}
+
+// blind index for looking up a raw phone number
+func (p *Protector) Index(raw string) (string, error) {
+	e164, err := Normalize(raw, p.defaultCountryCode)
+	if err != nil {
+		return "", err
+	}
+	return p.index.Sum(e164), nil
+}
+
+func (p *Protector) Reveal(encrypted []byte) (string, error) {
+	plain, err := p.cipher.Decrypt(encrypted)
+	if err != nil {
+		return "", err
+	}
+	return string(plain), nil
+}
@@ -0,0 +1,86 @@
+package phone
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		raw     string
+		want    string
+		wantErr bool
+	}{
+		{"91234567", "+6591234567", false},
+		{" 9123 4567 ", "+6591234567", false},
+		{"9123-4567", "+6591234567", false},
+		{"+65 9123 4567", "+6591234567", false},
+		{"+65 (9123) 4567", "+6591234567", false},
+		{"006591234567", "+6591234567", false},
+		{"091234567", "+6591234567", false}, // trunk prefix dropped
+		{"+44 20 7946 0958", "+442079460958", false},
+		{"+1.415.555.2671", "+14155552671", false},
+		{"", "", true},
+		{"1234", "", true},
+		{"+1234567", "", true},          // under 8 digits
+		{"+1234567890123456", "", true}, // over 15 digits
+		{"+0123456789", "", true},       // country code cannot start with 0
+		{"9123 456a", "", true},
+		{"+65 9123 4567 ext 2", "", true},
+	}
+	for _, tt := range tests {
+		got, err := Normalize(tt.raw, "65")
+		if tt.wantErr {
+			if !errors.Is(err, ErrInvalidPhone) {
+				t.Errorf("Normalize(%q) = %q, %v, want ErrInvalidPhone", tt.raw, got, err)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("Normalize(%q) = %q, %v, want %q", tt.raw, got, err, tt.want)
+		}
+	}
+}
+
+func newTestProtector(t *testing.T) *Protector {
+	t.Helper()
+	p, err := NewProtector(bytes.Repeat([]byte{1}, 32), bytes.Repeat([]byte{2}, 32), "65")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+func TestProtector(t *testing.T) {
+	p := newTestProtector(t)
+
+	e164, encrypted, index, err := p.Protect("9123 4567")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e164 != "+6591234567" {
+		t.Errorf("Protect e164 = %q", e164)
+	}
+	if bytes.Contains(encrypted, []byte("91234567")) {
+		t.Error("ciphertext contains the number")
+	}
+	revealed, err := p.Reveal(encrypted)
+	if err != nil || revealed != "+6591234567" {
+		t.Errorf("Reveal = %q, %v", revealed, err)
+	}
+
+	// every way of writing the number finds the same account
+	for _, raw := range []string{"91234567", "+65 9123 4567", "006591234567"} {
+		got, err := p.Index(raw)
+		if err != nil || got != index {
+			t.Errorf("Index(%q) = %q, %v, want %q", raw, got, err, index)
+		}
+	}
+	if other, _ := p.Index("91234568"); other == index {
+		t.Error("different numbers share an index")
+	}
+	if _, err := p.Index("not a phone"); !errors.Is(err, ErrInvalidPhone) {
+		t.Errorf("Index of an invalid number error = %v, want ErrInvalidPhone", err)
+	}
+}
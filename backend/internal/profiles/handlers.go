@@ -0,0 +1,128 @@
+package profiles
+
+import (
+	"errors"
+	"hack4good-backend/internal/auth"
+	"hack4good-backend/internal/json"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+)
+
+type Handler struct {
+	service Service
+}
+
+func NewHandler(service Service) *Handler {
+	return &Handler{
+		service: service,
+	}
+}
+
+// participant whose profile is addressed: {id} in the path, or the signed-in user for /user/profile
+// writes the error response if the signed-in user may not use perm on it
+func (h *Handler) subject(w http.ResponseWriter, r *http.Request, perm auth.ScopedPermission) (int32, bool) {
+	claims, ok := auth.FromContext(r.Context())
+	if !ok {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return 0, false
+	}
+
+	userID := claims.ID
+	if idStr := chi.URLParam(r, "id"); idStr != "" {
+		id, err := strconv.Atoi(idStr)
+		if err != nil {
+			http.Error(w, "invalid user id", http.StatusBadRequest)
+			return 0, false
+		}
+		userID = int32(id)
+	}
+
+	ok, err := auth.CanActFor(r.Context(), perm, userID, h.service.IsCaregiverOf)
+	if err != nil {
+		log.Println(err)
+		http.Error(w, "failed to check permissions", http.StatusInternalServerError)
+		return 0, false
+	}
+	if !ok {
+		http.Error(w, "forbidden", http.StatusForbidden)
+		return 0, false
+	}
+	return userID, true
+}
+
+// Get a participant's profile (own, linked participant's or any)
+func (h *Handler) GetProfile(w http.ResponseWriter, r *http.Request) {
+	userID, ok := h.subject(w, r, auth.ProfilesRead)
+	if !ok {
+		return
+	}
+
+	profile, err := h.service.GetProfile(r.Context(), userID)
+	if err != nil {
+		writeError(w, err, "failed to get profile")
+		return
+	}
+
+	json.Write(w, http.StatusOK, ToProfile(profile))
+}
+
+// Create a participant's profile
+func (h *Handler) CreateProfile(w http.ResponseWriter, r *http.Request) {
+	userID, ok := h.subject(w, r, auth.ProfilesWrite)
+	if !ok {
+		return
+	}
+
+	var req ProfileRequest
+	if err := json.Read(r, &req); err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	profile, err := h.service.CreateProfile(r.Context(), userID, req)
+	if err != nil {
+		writeError(w, err, "failed to create profile")
+		return
+	}
+
+	json.Write(w, http.StatusCreated, ToProfile(profile))
+}
+
+// Update a participant's profile (only the fields sent)
+func (h *Handler) UpdateProfile(w http.ResponseWriter, r *http.Request) {
+	userID, ok := h.subject(w, r, auth.ProfilesWrite)
+	if !ok {
+		return
+	}
+
+	var req ProfileRequest
+	if err := json.Read(r, &req); err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	profile, err := h.service.UpdateProfile(r.Context(), userID, req)
+	if err != nil {
+		writeError(w, err, "failed to update profile")
+		return
+	}
+
+	json.Write(w, http.StatusOK, ToProfile(profile))
+}
+
+func writeError(w http.ResponseWriter, err error, msg string) {
+	switch {
+	case errors.Is(err, ErrProfileNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, ErrProfileExists):
+		http.Error(w, err.Error(), http.StatusConflict)
+	case errors.Is(err, ErrNotParticipant), errors.Is(err, ErrInvalidAge), errors.Is(err, ErrInvalidMembership):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	default:
+		log.Println(err)
+		http.Error(w, msg, http.StatusInternalServerError)
+	}
+}
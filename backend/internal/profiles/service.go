@@ -0,0 +1,163 @@
+package profiles
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	repo "hack4good-backend/db/sqlc"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+var (
+	ErrProfileNotFound   = errors.New("profile not found")
+	ErrProfileExists     = errors.New("profile already exists")
+	ErrNotParticipant    = errors.New("profiles are only for participants")
+	ErrInvalidAge        = errors.New("age must be between 0 and 130")
+	ErrInvalidMembership = errors.New("membership_type must be one of: Ad hoc, once a week, twice a week, 3 or more times a week")
+)
+
+type Service interface {
+	GetProfile(ctx context.Context, userID int32) (repo.ParticipantProfile, error)
+	CreateProfile(ctx context.Context, userID int32, req ProfileRequest) (repo.ParticipantProfile, error)
+	UpdateProfile(ctx context.Context, userID int32, req ProfileRequest) (repo.ParticipantProfile, error)
+	IsCaregiverOf(ctx context.Context, caregiverID, participantID int32) (bool, error)
+}
+
+type svc struct {
+	repo *repo.Queries
+}
+
+func NewService(repo *repo.Queries) Service {
+	return &svc{repo: repo}
+}
+
+func (s *svc) GetProfile(ctx context.Context, userID int32) (repo.ParticipantProfile, error) {
+	profile, err := s.repo.GetParticipantProfile(ctx, userID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return repo.ParticipantProfile{}, ErrProfileNotFound
+		}
+		return repo.ParticipantProfile{}, fmt.Errorf("failed to get profile: %w", err)
+	}
+	return profile, nil
+}
+
+func (s *svc) CreateProfile(ctx context.Context, userID int32, req ProfileRequest) (repo.ParticipantProfile, error) {
+	if err := s.checkParticipant(ctx, userID); err != nil {
+		return repo.ParticipantProfile{}, err
+	}
+
+	params := repo.CreateParticipantProfileParams{UserID: userID}
+	if err := apply(&params, req); err != nil {
+		return repo.ParticipantProfile{}, err
+	}
+
+	profile, err := s.repo.CreateParticipantProfile(ctx, params)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+			return repo.ParticipantProfile{}, ErrProfileExists
+		}
+		return repo.ParticipantProfile{}, fmt.Errorf("failed to create profile: %w", err)
+	}
+	return profile, nil
+}
+
+// merge the set fields into the stored profile
+func (s *svc) UpdateProfile(ctx context.Context, userID int32, req ProfileRequest) (repo.ParticipantProfile, error) {
+	current, err := s.GetProfile(ctx, userID)
+	if err != nil {
+		return repo.ParticipantProfile{}, err
+	}
+
+	params := repo.CreateParticipantProfileParams{
+		UserID:              current.UserID,
+		Age:                 current.Age,
+		MembershipType:      current.MembershipType,
+		Wheelchair:          current.Wheelchair,
+		SignLanguage:        current.SignLanguage,
+		OtherNeed:           current.OtherNeed,
+		NeedsSeatedActivity: current.NeedsSeatedActivity,
+		SensitiveToLight:    current.SensitiveToLight,
+		SensitiveToNoise:    current.SensitiveToNoise,
+	}
+	if err := apply(&params, req); err != nil {
+		return repo.ParticipantProfile{}, err
+	}
+
+	profile, err := s.repo.UpdateParticipantProfile(ctx, repo.UpdateParticipantProfileParams(params))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return repo.ParticipantProfile{}, ErrProfileNotFound
+		}
+		return repo.ParticipantProfile{}, fmt.Errorf("failed to update profile: %w", err)
+	}
+	return profile, nil
+}
+
+func (s *svc) IsCaregiverOf(ctx context.Context, caregiverID, participantID int32) (bool, error) {
+	return s.repo.IsCaregiverOf(ctx, repo.IsCaregiverOfParams{
+		CaregiverID:   caregiverID,
+		ParticipantID: participantID,
+	})
+}
+
+func (s *svc) checkParticipant(ctx context.Context, userID int32) error {
+	user, err := s.repo.GetUserByID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrProfileNotFound
+		}
+		return fmt.Errorf("failed to get user: %w", err)
+	}
+	if user.Role != "participant" {
+		return ErrNotParticipant
+	}
+	return nil
+}
+
+// validated insert params, for callers that create the participant in their own transaction
+func NewProfileParams(userID int32, req ProfileRequest) (repo.CreateParticipantProfileParams, error) {
+	params := repo.CreateParticipantProfileParams{UserID: userID}
+	err := apply(&params, req)
+	return params, err
+}
+
+// validate the set fields of req and copy them into params
+func apply(params *repo.CreateParticipantProfileParams, req ProfileRequest) error {
+	if req.Age != nil {
+		if *req.Age < 0 || *req.Age > 130 {
+			return ErrInvalidAge
+		}
+		params.Age = pgtype.Int4{Int32: *req.Age, Valid: true}
+	}
+	if req.MembershipType != nil {
+		if !membershipTypes[*req.MembershipType] {
+			return ErrInvalidMembership
+		}
+		params.MembershipType = pgtype.Text{String: *req.MembershipType, Valid: true}
+	}
+	if req.OtherNeed != nil {
+		need := strings.TrimSpace(*req.OtherNeed)
+		params.OtherNeed = pgtype.Text{String: need, Valid: need != ""}
+	}
+	for _, f := range []struct {
+		value *bool
+		dst   *bool
+	}{
+		{req.Wheelchair, &params.Wheelchair},
+		{req.SignLanguage, &params.SignLanguage},
+		{req.NeedsSeatedActivity, &params.NeedsSeatedActivity},
+		{req.SensitiveToLight, &params.SensitiveToLight},
+		{req.SensitiveToNoise, &params.SensitiveToNoise},
+	} {
+		if f.value != nil {
+			*f.dst = *f.value
+		}
+	}
+	return nil
+}
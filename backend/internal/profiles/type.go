@@ -0,0 +1,65 @@
+package profiles
+
+import (
+	repo "hack4good-backend/db/sqlc"
+	"time"
+)
+
+// allowed values of participant_profiles.membership_type
+var membershipTypes = map[string]bool{
+	"Ad hoc":                 true,
+	"once a week":            true,
+	"twice a week":           true,
+	"3 or more times a week": true,
+}
+
+// participant profile as returned by the API
+type Profile struct {
+	UserID              int32     `json:"user_id"`
+	Age                 *int32    `json:"age"`
+	MembershipType      *string   `json:"membership_type"`
+	Wheelchair          bool      `json:"wheelchair"`
+	SignLanguage        bool      `json:"sign_language"`
+	NeedsSeatedActivity bool      `json:"needs_seated_activity"`
+	SensitiveToLight    bool      `json:"sensitive_to_light"`
+	SensitiveToNoise    bool      `json:"sensitive_to_noise"`
+	OtherNeed           *string   `json:"other_need"`
+	CreatedAt           time.Time `json:"created_at"`
+	UpdatedAt           time.Time `json:"updated_at"`
+}
+
+// convert DB profile to response profile
+func ToProfile(p repo.ParticipantProfile) Profile {
+	out := Profile{
+		UserID:              p.UserID,
+		Wheelchair:          p.Wheelchair,
+		SignLanguage:        p.SignLanguage,
+		NeedsSeatedActivity: p.NeedsSeatedActivity,
+		SensitiveToLight:    p.SensitiveToLight,
+		SensitiveToNoise:    p.SensitiveToNoise,
+		CreatedAt:           p.CreatedAt.Time,
+		UpdatedAt:           p.UpdatedAt.Time,
+	}
+	if p.Age.Valid {
+		out.Age = &p.Age.Int32
+	}
+	if p.MembershipType.Valid {
+		out.MembershipType = &p.MembershipType.String
+	}
+	if p.OtherNeed.Valid {
+		out.OtherNeed = &p.OtherNeed.String
+	}
+	return out
+}
+
+// POST creates with these values, PATCH only changes the fields that are set
+type ProfileRequest struct {
+	Age                 *int32  `json:"age"`
+	MembershipType      *string `json:"membership_type"`
+	Wheelchair          *bool   `json:"wheelchair"`
+	SignLanguage        *bool   `json:"sign_language"`
+	NeedsSeatedActivity *bool   `json:"needs_seated_activity"`
+	SensitiveToLight    *bool   `json:"sensitive_to_light"`
+	SensitiveToNoise    *bool   `json:"sensitive_to_noise"`
+	OtherNeed           *string `json:"other_need"` // empty string clears it
+}
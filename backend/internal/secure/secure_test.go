@@ -0,0 +1,154 @@
+package secure
+
+import (
+	"bytes"
+	"encoding/base64"
+	"strings"
+	"testing"
+)
+
+func testKey(b byte) []byte {
+	return bytes.Repeat([]byte{b}, 32)
+}
+
+func newTestCipher(t *testing.T, key []byte) *Cipher {
+	t.Helper()
+	c, err := NewCipher(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+func TestCipherRoundTrip(t *testing.T) {
+	c := newTestCipher(t, testKey(1))
+	for _, plain := range []string{"", "+6591234567", strings.Repeat("allergic to peanuts; ", 100)} {
+		sealed, err := c.Encrypt([]byte(plain))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if plain != "" && bytes.Contains(sealed, []byte(plain)) {
+			t.Errorf("ciphertext contains the plaintext %q", plain)
+		}
+		got, err := c.Decrypt(sealed)
+		if err != nil {
+			t.Fatalf("Decrypt: %v", err)
+		}
+		if string(got) != plain {
+			t.Errorf("Decrypt = %q, want %q", got, plain)
+		}
+	}
+}
+
+func TestCipherRandomNonce(t *testing.T) {
+	c := newTestCipher(t, testKey(1))
+	a, err := c.Encrypt([]byte("same"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := c.Encrypt([]byte("same"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Equal(a, b) {
+		t.Error("encrypting the same plaintext twice gave the same ciphertext")
+	}
+}
+
+func TestCipherDecryptFails(t *testing.T) {
+	c := newTestCipher(t, testKey(1))
+	sealed, err := c.Encrypt([]byte("+6591234567"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tampered := bytes.Clone(sealed)
+	tampered[len(tampered)-1] ^= 1
+
+	tests := []struct {
+		name       string
+		cipher     *Cipher
+		ciphertext []byte
+	}{
+		{"tampered", c, tampered},
+		{"other key", newTestCipher(t, testKey(2)), sealed},
+		{"too short", c, sealed[:5]},
+		{"empty", c, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := tt.cipher.Decrypt(tt.ciphertext); err == nil {
+				t.Error("Decrypt: want error")
+			}
+		})
+	}
+}
+
+func TestNewCipherKeySize(t *testing.T) {
+	if _, err := NewCipher(make([]byte, 7)); err == nil {
+		t.Error("NewCipher with a 7 byte key: want error")
+	}
+}
+
+func TestBlindIndex(t *testing.T) {
+	a := NewBlindIndex(testKey(1))
+	if a.Sum("+6591234567") != a.Sum("+6591234567") {
+		t.Error("Sum is not deterministic")
+	}
+	if a.Sum("+6591234567") == a.Sum("+6591234568") {
+		t.Error("different values share a Sum")
+	}
+	if a.Sum("+6591234567") == NewBlindIndex(testKey(2)).Sum("+6591234567") {
+		t.Error("Sum does not depend on the key")
+	}
+	if got := len(a.Sum("x")); got != 64 {
+		t.Errorf("Sum length = %d, want 64 hex characters", got)
+	}
+}
+
+func TestKeyFromEnv(t *testing.T) {
+	valid := base64.StdEncoding.EncodeToString(testKey(1))
+	tests := []struct {
+		name    string
+		value   string
+		wantErr string
+	}{
+		{"valid", valid, ""},
+		{"missing", "", "is not set"},
+		{"not base64", "not*base64", "is not valid base64"},
+		{"too short", base64.StdEncoding.EncodeToString(make([]byte, 16)), "must decode to 32 bytes"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("TEST_SECURE_KEY", tt.value)
+			key, err := KeyFromEnv("TEST_SECURE_KEY")
+			if tt.wantErr == "" {
+				if err != nil || !bytes.Equal(key, testKey(1)) {
+					t.Errorf("KeyFromEnv = %x, %v", key, err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("KeyFromEnv error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCheckKeysFromEnv(t *testing.T) {
+	t.Setenv("TEST_KEY_SET", base64.StdEncoding.EncodeToString(testKey(1)))
+	t.Setenv("TEST_KEY_MISSING", "")
+	t.Setenv("TEST_KEY_BAD", "abc")
+
+	if err := CheckKeysFromEnv("TEST_KEY_SET"); err != nil {
+		t.Errorf("CheckKeysFromEnv with a valid key: %v", err)
+	}
+	err := CheckKeysFromEnv("TEST_KEY_SET", "TEST_KEY_MISSING", "TEST_KEY_BAD")
+	if err == nil {
+		t.Fatal("CheckKeysFromEnv: want error")
+	}
+	for _, name := range []string{"TEST_KEY_MISSING", "TEST_KEY_BAD"} {
+		if !strings.Contains(err.Error(), name) {
+			t.Errorf("CheckKeysFromEnv error %q does not name %s", err, name)
+		}
+	}
+}
@@ -0,0 +1,79 @@
+package userimport
+
+import (
+	"errors"
+	"hack4good-backend/internal/auth"
+	"hack4good-backend/internal/json"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+const maxUploadSize = 5 << 20
+
+type Handler struct {
+	service Service
+}
+
+func NewHandler(service Service) *Handler {
+	return &Handler{
+		service: service,
+	}
+}
+
+// Import users from CSV (by staff); the file is the request body or the "file"
+// field of a multipart form. ?dry_run=true only validates.
+func (h *Handler) Import(w http.ResponseWriter, r *http.Request) {
+	claims, ok := auth.FromContext(r.Context())
+	if !ok {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var dryRun bool
+	if v := r.URL.Query().Get("dry_run"); v != "" {
+		var err error
+		if dryRun, err = strconv.ParseBool(v); err != nil {
+			http.Error(w, "invalid dry_run", http.StatusBadRequest)
+			return
+		}
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxUploadSize)
+	var body io.Reader = r.Body
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/form-data") {
+		file, _, err := r.FormFile("file")
+		if err != nil {
+			http.Error(w, "file is required", http.StatusBadRequest)
+			return
+		}
+		defer file.Close()
+		body = file
+	}
+
+	report, err := h.service.Import(r.Context(), body, Options{DryRun: dryRun, ImportedBy: claims.ID})
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		switch {
+		case errors.Is(err, ErrInvalidCSV):
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		case errors.As(err, &tooLarge):
+			http.Error(w, "file is too large", http.StatusRequestEntityTooLarge)
+		default:
+			log.Println(err)
+			http.Error(w, "failed to import users", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	status := http.StatusOK
+	switch {
+	case len(report.Errors) > 0:
+		status = http.StatusUnprocessableEntity
+	case report.Applied:
+		status = http.StatusCreated
+	}
+	json.Write(w, status, report)
+}
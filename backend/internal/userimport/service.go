@@ -0,0 +1,445 @@
+package userimport
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	repo "hack4good-backend/db/sqlc"
+	"hack4good-backend/internal/phone"
+	"hack4good-backend/internal/profiles"
+	"hack4good-backend/internal/users"
+	"io"
+	"net/mail"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const maxRows = 5000
+
+var ErrInvalidCSV = errors.New("invalid CSV")
+
+// staff accounts need a password setup link, so they are created one by one
+var importRoles = map[string]bool{"participant": true, "caregiver": true, "volunteer": true}
+
+var requiredColumns = []string{"name", "email", "phone", "role"}
+
+// participant profile columns, all optional
+var profileColumns = []string{
+	"age", "membership_type", "wheelchair", "sign_language", "needs_seated_activity",
+	"sensitive_to_light", "sensitive_to_noise", "other_need",
+}
+
+// caregiver_email links a participant to caregivers (";"-separated), either
+// rows in the same file or existing caregiver accounts
+const caregiverColumn = "caregiver_email"
+
+type Service interface {
+	Import(ctx context.Context, r io.Reader, opts Options) (Report, error)
+}
+
+type svc struct {
+	db     *pgxpool.Pool // the whole file is applied in one transaction
+	repo   *repo.Queries
+	phones *phone.Protector
+}
+
+func NewService(db *pgxpool.Pool, phones *phone.Protector) Service {
+	return &svc{
+		db:     db,
+		repo:   repo.New(db),
+		phones: phones,
+	}
+}
+
+// a row that passed its own checks, ready to insert
+type row struct {
+	line           int
+	name           string
+	email          string
+	role           string
+	phoneEncrypted []byte
+	phoneIndex     string
+	profile        repo.CreateParticipantProfileParams
+	caregivers     []string // lower-cased emails
+}
+
+// validate every row and, unless it is a dry run or something is wrong, create
+// the users, participant profiles and caregiver links together
+func (s *svc) Import(ctx context.Context, r io.Reader, opts Options) (Report, error) {
+	report := Report{
+		DryRun: opts.DryRun,
+		Users:  []ImportedUser{},
+		Errors: []RowError{},
+	}
+
+	rows, err := s.parse(r, &report)
+	if err != nil {
+		return report, err
+	}
+	existing, err := s.check(ctx, rows, &report)
+	if err != nil {
+		return report, err
+	}
+	if len(report.Errors) > 0 || opts.DryRun {
+		return report, nil
+	}
+
+	if err := s.apply(ctx, rows, existing, opts, &report); err != nil {
+		return report, err
+	}
+	return report, nil
+}
+
+// read the file and check each row on its own (format, required fields,
+// duplicates within the file)
+func (s *svc) parse(r io.Reader, report *Report) ([]row, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1 // checked per row so the error names the row
+
+	header, err := cr.Read()
+	if err == io.EOF {
+		return nil, fmt.Errorf("%w: file is empty", ErrInvalidCSV)
+	}
+	if err != nil {
+		return nil, csvError(err)
+	}
+	index, err := columnIndex(header)
+	if err != nil {
+		return nil, err
+	}
+
+	var rows []row
+	emails := make(map[string]int) // lower-cased email -> line
+	phones := make(map[string]int) // phone index -> line
+	for {
+		record, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, csvError(err)
+		}
+		if blank(record) {
+			continue
+		}
+
+		report.Rows++
+		if report.Rows > maxRows {
+			return nil, fmt.Errorf("%w: more than %d rows", ErrInvalidCSV, maxRows)
+		}
+
+		line, _ := cr.FieldPos(0)
+		valid := true
+		fail := func(column, msg string) {
+			report.Errors = append(report.Errors, RowError{Row: line, Column: column, Message: msg})
+			valid = false
+		}
+		if len(record) != len(header) {
+			fail("", fmt.Sprintf("expected %d columns, got %d", len(header), len(record)))
+			continue
+		}
+		get := func(column string) string {
+			i, ok := index[column]
+			if !ok {
+				return ""
+			}
+			return strings.TrimSpace(record[i])
+		}
+
+		rw := row{
+			line:  line,
+			name:  get("name"),
+			email: get("email"),
+			role:  strings.ToLower(get("role")),
+		}
+
+		if rw.name == "" {
+			fail("name", "name is required")
+		}
+
+		if addr, err := mail.ParseAddress(rw.email); err != nil || addr.Address != rw.email {
+			fail("email", "invalid email")
+		} else if prev, ok := emails[strings.ToLower(rw.email)]; ok {
+			fail("email", fmt.Sprintf("same email as row %d", prev))
+		} else {
+			emails[strings.ToLower(rw.email)] = line
+		}
+
+		if !importRoles[rw.role] {
+			fail("role", "role must be participant, caregiver or volunteer")
+		}
+
+		if raw := get("phone"); raw == "" {
+			fail("phone", "phone is required")
+		} else if _, encrypted, idx, err := s.phones.Protect(raw); err != nil {
+			fail("phone", err.Error())
+		} else if prev, ok := phones[idx]; ok {
+			fail("phone", fmt.Sprintf("same phone as row %d", prev))
+		} else {
+			phones[idx] = line
+			rw.phoneEncrypted = encrypted
+			rw.phoneIndex = idx
+		}
+
+		if rw.role == "participant" {
+			req, column, err := profileRequest(get)
+			if err != nil {
+				fail(column, err.Error())
+			} else if rw.profile, err = profiles.NewProfileParams(0, req); err != nil {
+				column = "membership_type"
+				if errors.Is(err, profiles.ErrInvalidAge) {
+					column = "age"
+				}
+				fail(column, err.Error())
+			}
+
+			seen := make(map[string]bool)
+			for _, email := range strings.Split(get(caregiverColumn), ";") {
+				email = strings.ToLower(strings.TrimSpace(email))
+				if email != "" && !seen[email] {
+					seen[email] = true
+					rw.caregivers = append(rw.caregivers, email)
+				}
+			}
+		} else {
+			for _, column := range append(profileColumns, caregiverColumn) {
+				if get(column) != "" {
+					fail(column, "only allowed for participants")
+				}
+			}
+		}
+
+		if valid {
+			rows = append(rows, rw)
+			report.Users = append(report.Users, ImportedUser{Row: line, Name: rw.name, Email: rw.email, Role: rw.role})
+		}
+	}
+
+	if report.Rows == 0 {
+		return nil, fmt.Errorf("%w: no rows", ErrInvalidCSV)
+	}
+	return rows, nil
+}
+
+// compare the rows with the database: emails and phones must be new, and
+// caregiver references must resolve. returns the existing caregivers by email
+func (s *svc) check(ctx context.Context, rows []row, report *Report) (map[string]int32, error) {
+	fileCaregivers := make(map[string]bool)
+	for _, rw := range rows {
+		if rw.role == "caregiver" {
+			fileCaregivers[strings.ToLower(rw.email)] = true
+		}
+	}
+
+	existing := make(map[string]int32)
+	for _, rw := range rows {
+		if _, err := s.repo.GetUserByEmail(ctx, rw.email); err == nil {
+			report.Errors = append(report.Errors, RowError{Row: rw.line, Column: "email", Message: "email is already registered"})
+		} else if !errors.Is(err, pgx.ErrNoRows) {
+			return nil, err
+		}
+
+		if _, err := s.repo.GetUserByPhone(ctx, pgtype.Text{String: rw.phoneIndex, Valid: true}); err == nil {
+			report.Errors = append(report.Errors, RowError{Row: rw.line, Column: "phone", Message: "phone is already registered"})
+		} else if !errors.Is(err, pgx.ErrNoRows) {
+			return nil, err
+		}
+
+		for _, email := range rw.caregivers {
+			report.Links++
+			if _, ok := existing[email]; ok || fileCaregivers[email] {
+				continue
+			}
+			user, err := s.repo.GetUserByEmail(ctx, email)
+			if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+				return nil, err
+			}
+			if err != nil || user.Role != "caregiver" || user.Status != users.StatusActive {
+				report.Errors = append(report.Errors, RowError{
+					Row:     rw.line,
+					Column:  caregiverColumn,
+					Message: email + " is not a caregiver in this file or an active caregiver account",
+				})
+				continue
+			}
+			existing[email] = user.ID
+		}
+	}
+	return existing, nil
+}
+
+func (s *svc) apply(ctx context.Context, rows []row, existing map[string]int32, opts Options, report *Report) error {
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+	q := s.repo.WithTx(tx)
+
+	ids := make(map[string]int32, len(rows)) // lower-cased email -> new user id
+	for _, rw := range rows {
+		user, err := q.CreateUser(ctx, repo.CreateUserParams{
+			Name:           rw.name,
+			PhoneEncrypted: rw.phoneEncrypted,
+			PhoneIndex:     pgtype.Text{String: rw.phoneIndex, Valid: true},
+			Email:          rw.email,
+			Role:           rw.role,
+		})
+		if err != nil {
+			// someone registered the same email or phone since the check
+			var pgErr *pgconn.PgError
+			if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+				report.Errors = append(report.Errors, RowError{Row: rw.line, Message: "email or phone is already registered"})
+				return nil
+			}
+			return fmt.Errorf("row %d: %w", rw.line, err)
+		}
+		ids[strings.ToLower(rw.email)] = user.ID
+
+		if rw.role == "participant" {
+			rw.profile.UserID = user.ID
+			if _, err := q.CreateParticipantProfile(ctx, rw.profile); err != nil {
+				return fmt.Errorf("row %d: %w", rw.line, err)
+			}
+		}
+	}
+
+	var staff pgtype.Int4
+	if opts.ImportedBy != 0 {
+		staff = pgtype.Int4{Int32: opts.ImportedBy, Valid: true}
+	}
+	now := pgtype.Timestamp{Time: time.Now().UTC(), Valid: true}
+	for _, rw := range rows {
+		for _, email := range rw.caregivers {
+			caregiverID, ok := ids[email]
+			if !ok {
+				caregiverID = existing[email]
+			}
+			// imported links are vouched for by staff, so they start active
+			if _, err := q.CreateCareRelationship(ctx, repo.CreateCareRelationshipParams{
+				ParticipantID: ids[strings.ToLower(rw.email)],
+				CaregiverID:   caregiverID,
+				Status:        "active",
+				RequestedBy:   staff,
+				ApprovedBy:    staff,
+				ApprovedAt:    now,
+			}); err != nil {
+				return fmt.Errorf("row %d: %w", rw.line, err)
+			}
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return err
+	}
+	// only now, so a rolled-back import reports no ids
+	for i, rw := range rows {
+		report.Users[i].ID = ids[strings.ToLower(rw.email)]
+	}
+	report.Applied = true
+	return nil
+}
+
+// lower-cased header -> column position; unknown or missing columns reject the file
+func columnIndex(header []string) (map[string]int, error) {
+	known := map[string]bool{caregiverColumn: true}
+	for _, c := range append(requiredColumns, profileColumns...) {
+		known[c] = true
+	}
+
+	index := make(map[string]int, len(header))
+	for i, name := range header {
+		if i == 0 {
+			name = strings.TrimPrefix(name, "\ufeff") // Excel's byte order mark
+		}
+		name = strings.ToLower(strings.TrimSpace(name))
+		if !known[name] {
+			return nil, fmt.Errorf("%w: unknown column %q", ErrInvalidCSV, name)
+		}
+		if _, dup := index[name]; dup {
+			return nil, fmt.Errorf("%w: column %q appears twice", ErrInvalidCSV, name)
+		}
+		index[name] = i
+	}
+	for _, c := range requiredColumns {
+		if _, ok := index[c]; !ok {
+			return nil, fmt.Errorf("%w: missing column %q", ErrInvalidCSV, c)
+		}
+	}
+	return index, nil
+}
+
+// profile columns of a participant row; the column is returned with the error
+func profileRequest(get func(string) string) (profiles.ProfileRequest, string, error) {
+	var req profiles.ProfileRequest
+	if v := get("age"); v != "" {
+		age, err := strconv.Atoi(v)
+		if err != nil {
+			return req, "age", errors.New("age must be a whole number")
+		}
+		age32 := int32(age)
+		req.Age = &age32
+	}
+	if v := get("membership_type"); v != "" {
+		req.MembershipType = &v
+	}
+	if v := get("other_need"); v != "" {
+		req.OtherNeed = &v
+	}
+	for _, f := range []struct {
+		column string
+		dst    **bool
+	}{
+		{"wheelchair", &req.Wheelchair},
+		{"sign_language", &req.SignLanguage},
+		{"needs_seated_activity", &req.NeedsSeatedActivity},
+		{"sensitive_to_light", &req.SensitiveToLight},
+		{"sensitive_to_noise", &req.SensitiveToNoise},
+	} {
+		v := get(f.column)
+		if v == "" {
+			continue
+		}
+		b, ok := parseYesNo(v)
+		if !ok {
+			return req, f.column, errors.New("expected yes or no")
+		}
+		*f.dst = &b
+	}
+	return req, "", nil
+}
+
+// spreadsheet-friendly booleans
+func parseYesNo(v string) (bool, bool) {
+	switch strings.ToLower(v) {
+	case "yes", "y", "true", "1":
+		return true, true
+	case "no", "n", "false", "0":
+		return false, true
+	}
+	return false, false
+}
+
+func blank(record []string) bool {
+	for _, field := range record {
+		if strings.TrimSpace(field) != "" {
+			return false
+		}
+	}
+	return true
+}
+
+func csvError(err error) error {
+	var parseErr *csv.ParseError
+	if errors.As(err, &parseErr) {
+		return fmt.Errorf("%w: %v", ErrInvalidCSV, err)
+	}
+	return err
+}
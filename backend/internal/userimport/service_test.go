@@ -0,0 +1,173 @@
+package userimport
+
+import (
+	"bytes"
+	"errors"
+	"hack4good-backend/internal/phone"
+	"strings"
+	"testing"
+)
+
+func newTestService(t *testing.T) *svc {
+	t.Helper()
+	phones, err := phone.NewProtector(bytes.Repeat([]byte{1}, 32), bytes.Repeat([]byte{2}, 32), "65")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &svc{phones: phones}
+}
+
+func TestColumnIndex(t *testing.T) {
+	tests := []struct {
+		name    string
+		header  string
+		wantErr string
+	}{
+		{"required only", "name,email,phone,role", ""},
+		{"any order and case", " Role ,EMAIL,phone,Name", ""},
+		{"byte order mark", "\ufeffname,email,phone,role", ""},
+		{"profile columns", "name,email,phone,role,age,membership_type,wheelchair,caregiver_email", ""},
+		{"missing column", "name,email,role", `missing column "phone"`},
+		{"unknown column", "name,email,phone,role,nickname", `unknown column "nickname"`},
+		{"duplicate column", "name,email,phone,role,Email", `column "email" appears twice`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			index, err := columnIndex(strings.Split(tt.header, ","))
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("columnIndex: %v", err)
+				}
+				if _, ok := index["name"]; !ok {
+					t.Errorf("columnIndex = %v, missing name", index)
+				}
+				return
+			}
+			if !errors.Is(err, ErrInvalidCSV) || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("columnIndex error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestParseYesNo(t *testing.T) {
+	tests := []struct {
+		in     string
+		want   bool
+		wantOK bool
+	}{
+		{"yes", true, true},
+		{"Y", true, true},
+		{"TRUE", true, true},
+		{"1", true, true},
+		{"no", false, true},
+		{"n", false, true},
+		{"False", false, true},
+		{"0", false, true},
+		{"maybe", false, false},
+		{"", false, false},
+	}
+	for _, tt := range tests {
+		got, ok := parseYesNo(tt.in)
+		if got != tt.want || ok != tt.wantOK {
+			t.Errorf("parseYesNo(%q) = %v, %v, want %v, %v", tt.in, got, ok, tt.want, tt.wantOK)
+		}
+	}
+}
+
+const header = "name,email,phone,role,age,membership_type,wheelchair,caregiver_email\n"
+
+func TestParseValidRows(t *testing.T) {
+	csv := header +
+		"Ann Tan,ann@example.org,9123 4567,Participant,72,once a week,yes,bob@example.org; BOB@example.org\n" +
+		",,,,,,,\n" + // blank rows are skipped
+		"Bob Lim,bob@example.org,+65 9123 4568,caregiver,,,,\n"
+
+	var report Report
+	rows, err := newTestService(t).parse(strings.NewReader(csv), &report)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Errors) != 0 {
+		t.Fatalf("parse errors = %+v", report.Errors)
+	}
+	if report.Rows != 2 || len(rows) != 2 {
+		t.Fatalf("parse rows = %d (report %d), want 2", len(rows), report.Rows)
+	}
+
+	ann := rows[0]
+	if ann.line != 2 || ann.role != "participant" || ann.phoneIndex == "" || len(ann.phoneEncrypted) == 0 {
+		t.Errorf("first row = %+v", ann)
+	}
+	if ann.profile.Age.Int32 != 72 || ann.profile.MembershipType.String != "once a week" || !ann.profile.Wheelchair {
+		t.Errorf("first row profile = %+v", ann.profile)
+	}
+	if len(ann.caregivers) != 1 || ann.caregivers[0] != "bob@example.org" {
+		t.Errorf("first row caregivers = %v, want [bob@example.org]", ann.caregivers)
+	}
+	if rows[1].line != 4 {
+		t.Errorf("second row line = %d, want 4", rows[1].line)
+	}
+}
+
+func TestParseRowErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		rows   string
+		column string
+		msg    string
+	}{
+		{"missing name", ",ann@example.org,91234567,participant,,,,\n", "name", "name is required"},
+		{"invalid email", "Ann,not-an-email,91234567,participant,,,,\n", "email", "invalid email"},
+		{"email with display name", "Ann,Ann <ann@example.org>,91234567,participant,,,,\n", "email", "invalid email"},
+		{"staff role", "Ann,ann@example.org,91234567,staff,,,,\n", "role", "role must be"},
+		{"missing phone", "Ann,ann@example.org,,participant,,,,\n", "phone", "phone is required"},
+		{"invalid phone", "Ann,ann@example.org,12ab,participant,,,,\n", "phone", "invalid phone number"},
+		{"age not a number", "Ann,ann@example.org,91234567,participant,old,,,\n", "age", "whole number"},
+		{"age out of range", "Ann,ann@example.org,91234567,participant,200,,,\n", "age", ""},
+		{"unknown membership", "Ann,ann@example.org,91234567,participant,,daily,,\n", "membership_type", ""},
+		{"not yes or no", "Ann,ann@example.org,91234567,participant,,,sometimes,\n", "wheelchair", "expected yes or no"},
+		{"profile for volunteer", "Vic,vic@example.org,91234567,volunteer,30,,,\n", "age", "only allowed for participants"},
+		{"caregiver for caregiver", "Cat,cat@example.org,91234567,caregiver,,,,x@example.org\n", "caregiver_email", "only allowed for participants"},
+		{"too few columns", "Ann,ann@example.org,91234567\n", "", "expected 8 columns, got 3"},
+		{"same email", "Ann,ann@example.org,91234567,participant,,,,\nAnn,ANN@example.org,91234568,participant,,,,\n", "email", "same email as row 2"},
+		{"same phone spelled differently", "Ann,ann@example.org,91234567,participant,,,,\nBen,ben@example.org,+65 9123 4567,participant,,,,\n", "phone", "same phone as row 2"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var report Report
+			if _, err := newTestService(t).parse(strings.NewReader(header+tt.rows), &report); err != nil {
+				t.Fatal(err)
+			}
+			if len(report.Errors) != 1 {
+				t.Fatalf("parse errors = %+v, want one", report.Errors)
+			}
+			got := report.Errors[0]
+			if got.Column != tt.column || !strings.Contains(got.Message, tt.msg) {
+				t.Errorf("parse error = %+v, want column %q with %q", got, tt.column, tt.msg)
+			}
+		})
+	}
+}
+
+func TestParseFileErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		csv  string
+	}{
+		{"empty", ""},
+		{"header only", header},
+		{"only blank rows", header + ",,,,,,,\n"},
+		{"unknown column", "name,email,phone,role,shoe_size\n"},
+		{"broken quoting", header + "\"Ann,ann@example.org,91234567,participant,,,,\n"},
+		{"too many rows", header + strings.Repeat("Ann,ann@example.org,91234567,participant,,,,\n", maxRows+1)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var report Report
+			if _, err := newTestService(t).parse(strings.NewReader(tt.csv), &report); !errors.Is(err, ErrInvalidCSV) {
+				t.Errorf("parse error = %v, want ErrInvalidCSV", err)
+			}
+		})
+	}
+}
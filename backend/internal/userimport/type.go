@@ -0,0 +1,32 @@
+package userimport
+
+// import options; ImportedBy is the staff member, 0 when run from the CLI
+type Options struct {
+	DryRun     bool
+	ImportedBy int32
+}
+
+// outcome of an import; nothing is written when Errors is not empty
+type Report struct {
+	DryRun  bool           `json:"dry_run"`
+	Rows    int            `json:"rows"`
+	Users   []ImportedUser `json:"users"`
+	Links   int            `json:"care_links"`
+	Errors  []RowError     `json:"errors"`
+	Applied bool           `json:"applied"`
+}
+
+type ImportedUser struct {
+	Row   int    `json:"row"`
+	ID    int32  `json:"id,omitempty"` // only set once applied
+	Name  string `json:"name"`
+	Email string `json:"email"`
+	Role  string `json:"role"`
+}
+
+// Row is the line number in the file (the header is line 1)
+type RowError struct {
+	Row     int    `json:"row"`
+	Column  string `json:"column,omitempty"`
+	Message string `json:"message"`
+}
@@ -0,0 +1,107 @@
+package volunteers
+
+import (
+	"fmt"
+	"hack4good-backend/internal/pdf"
+	"strconv"
+	"time"
+)
+
+// rows that fit in the breakdown table
+const certificateRows = 14
+
+const longDate = "2 January 2006"
+
+// one-page A4 certificate of the hours in h
+func renderCertificate(h Hours, orgName string, issued time.Time) []byte {
+	page := pdf.NewPage("Certificate of Volunteer Service - " + h.Name)
+	const margin = 36.0
+
+	page.Rect(margin, margin, pdf.PageWidth-2*margin, pdf.PageHeight-2*margin, 1.5)
+	page.Rect(margin+8, margin+8, pdf.PageWidth-2*margin-16, pdf.PageHeight-2*margin-16, 0.5)
+
+	page.Gray(0.35)
+	page.TextCentered(745, pdf.Bold, 14, orgName)
+	page.Gray(0)
+	page.TextCentered(695, pdf.Bold, 26, "Certificate of Volunteer Service")
+	page.Line(200, 678, pdf.PageWidth-200, 678, 1)
+
+	page.TextCentered(635, pdf.Regular, 13, "This is to certify that")
+	page.TextCentered(600, pdf.Bold, 22, h.Name)
+	page.TextCentered(565, pdf.Regular, 13, "has contributed")
+	page.TextCentered(522, pdf.Bold, 30, formatHours(h.TotalHours))
+
+	summary := "of volunteer service with " + orgName + " " + describePeriod(h)
+	switch h.Activities {
+	case 0:
+		summary += "."
+	case 1:
+		summary += ", across 1 activity."
+	default:
+		summary += fmt.Sprintf(", across %d activities.", h.Activities)
+	}
+	y := 485.0
+	for _, line := range pdf.Wrap(pdf.Regular, 13, 400, summary) {
+		page.TextCentered(y, pdf.Regular, 13, line)
+		y -= 18
+	}
+
+	// months when they fit, otherwise years
+	title, totals, label := "Hours by month", h.Months, monthLabel
+	if len(totals) > certificateRows {
+		title, totals, label = "Hours by year", h.Years, func(s string) string { return s }
+	}
+	if len(totals) > 0 && len(totals) <= certificateRows {
+		const left, right = 170.0, pdf.PageWidth - 170
+		y -= 22
+		page.Text(left, y, pdf.Bold, 11, title)
+		page.TextRight(right, y, pdf.Bold, 11, "Hours")
+		page.Line(left, y-6, right, y-6, 0.5)
+		y -= 22
+		for _, t := range totals {
+			page.Text(left, y, pdf.Regular, 11, label(t.Period))
+			page.TextRight(right, y, pdf.Regular, 11, strconv.FormatFloat(t.Hours, 'f', -1, 64))
+			y -= 16
+		}
+	}
+
+	page.Text(80, 130, pdf.Regular, 11, "Issued on "+issued.Format(longDate))
+	page.Line(360, 140, 515, 140, 0.75)
+	page.Text(360, 125, pdf.Regular, 10, "For "+orgName)
+
+	page.Gray(0.4)
+	page.TextCentered(70, pdf.Regular, 8.5,
+		"Hours are taken from attendance records of volunteer shifts, plus any adjustments recorded by staff.")
+	page.TextCentered(58, pdf.Regular, 8.5,
+		fmt.Sprintf("Reference VH-%d-%s", h.UserID, issued.Format("20060102")))
+
+	return page.Bytes()
+}
+
+func formatHours(hours float64) string {
+	if hours == 1 {
+		return "1 hour"
+	}
+	return strconv.FormatFloat(hours, 'f', -1, 64) + " hours"
+}
+
+func describePeriod(h Hours) string {
+	to, _ := time.Parse(time.DateOnly, h.To)
+	if h.From == nil {
+		return "up to " + to.Format(longDate)
+	}
+	from, _ := time.Parse(time.DateOnly, *h.From)
+	if from.Month() == time.January && from.Day() == 1 && to == from.AddDate(1, 0, -1) {
+		return "in " + strconv.Itoa(from.Year())
+	}
+	return "from " + from.Format(longDate) + " to " + to.Format(longDate)
+}
+
+// YYYY-MM as "January 2026"
+func monthLabel(s string) string {
+	t, err := time.Parse("2006-01", s)
+	if err != nil {
+		return s
+	}
+	return t.Format("January 2006")
+}
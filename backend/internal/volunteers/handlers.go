@@ -0,0 +1,152 @@
+package volunteers
+
+import (
+	"errors"
+	"hack4good-backend/internal/auth"
+	"hack4good-backend/internal/json"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+)
+
+type Handler struct {
+	service Service
+	orgName string // printed on hours certificates
+}
+
+func NewHandler(service Service, orgName string) *Handler {
+	return &Handler{
+		service: service,
+		orgName: orgName,
+	}
+}
+
+// volunteer whose profile is addressed: {id} in the path, or the signed-in user for /user/volunteer-profile
+// writes the error response if the signed-in user may not use perm on it
+func (h *Handler) subject(w http.ResponseWriter, r *http.Request, perm auth.ScopedPermission) (int32, bool) {
+	claims, ok := auth.FromContext(r.Context())
+	if !ok {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return 0, false
+	}
+
+	userID := claims.ID
+	if idStr := chi.URLParam(r, "id"); idStr != "" {
+		id, err := strconv.Atoi(idStr)
+		if err != nil {
+			http.Error(w, "invalid user id", http.StatusBadRequest)
+			return 0, false
+		}
+		userID = int32(id)
+	}
+
+	// no dependents scope, so the link checker is never consulted
+	ok, err := auth.CanActFor(r.Context(), perm, userID, nil)
+	if err != nil {
+		log.Println(err)
+		http.Error(w, "failed to check permissions", http.StatusInternalServerError)
+		return 0, false
+	}
+	if !ok {
+		http.Error(w, "forbidden", http.StatusForbidden)
+		return 0, false
+	}
+	return userID, true
+}
+
+// Get a volunteer's skills, certifications, availability and venues
+func (h *Handler) GetProfile(w http.ResponseWriter, r *http.Request) {
+	userID, ok := h.subject(w, r, auth.VolunteersRead)
+	if !ok {
+		return
+	}
+
+	profile, err := h.service.GetProfile(r.Context(), userID)
+	if err != nil {
+		writeError(w, err, "failed to get volunteer profile")
+		return
+	}
+
+	json.Write(w, http.StatusOK, profile)
+}
+
+// Replace a volunteer's skills, venues and/or availability
+func (h *Handler) UpdateProfile(w http.ResponseWriter, r *http.Request) {
+	userID, ok := h.subject(w, r, auth.VolunteersWrite)
+	if !ok {
+		return
+	}
+
+	var req ProfileRequest
+	if err := json.Read(r, &req); err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	profile, err := h.service.UpdateProfile(r.Context(), userID, req)
+	if err != nil {
+		writeError(w, err, "failed to update volunteer profile")
+		return
+	}
+
+	json.Write(w, http.StatusOK, profile)
+}
+
+// Add a certification
+func (h *Handler) AddCertification(w http.ResponseWriter, r *http.Request) {
+	userID, ok := h.subject(w, r, auth.VolunteersWrite)
+	if !ok {
+		return
+	}
+
+	var req CertificationRequest
+	if err := json.Read(r, &req); err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	cert, err := h.service.AddCertification(r.Context(), userID, req)
+	if err != nil {
+		writeError(w, err, "failed to add certification")
+		return
+	}
+
+	json.Write(w, http.StatusCreated, cert)
+}
+
+// Remove a certification
+func (h *Handler) DeleteCertification(w http.ResponseWriter, r *http.Request) {
+	userID, ok := h.subject(w, r, auth.VolunteersWrite)
+	if !ok {
+		return
+	}
+
+	certID, err := strconv.Atoi(chi.URLParam(r, "certID"))
+	if err != nil {
+		http.Error(w, "invalid certification id", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.service.DeleteCertification(r.Context(), userID, int32(certID)); err != nil {
+		writeError(w, err, "failed to delete certification")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func writeError(w http.ResponseWriter, err error, msg string) {
+	switch {
+	case errors.Is(err, ErrUserNotFound), errors.Is(err, ErrCertificationNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, ErrNotVolunteer), errors.Is(err, ErrInvalidSkill),
+		errors.Is(err, ErrInvalidWindow), errors.Is(err, ErrInvalidCertification),
+		errors.Is(err, ErrInvalidAdjustment):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	default:
+		log.Println(err)
+		http.Error(w, msg, http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,141 @@
+package volunteers
+
+import (
+	"context"
+	"fmt"
+	repo "hack4good-backend/db/sqlc"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// larger adjustments are almost certainly typos
+const maxAdjustmentMinutes = 1000 * 60
+
+func (s *svc) Hours(ctx context.Context, userID int32, period Period) (Hours, error) {
+	user, err := s.getVolunteer(ctx, userID)
+	if err != nil {
+		return Hours{}, err
+	}
+
+	rows, err := s.repo.ListVolunteerHourEntries(ctx, repo.ListVolunteerHourEntriesParams{
+		UserID:    userID,
+		FromDate:  fromDate(period),
+		UntilDate: untilDate(period),
+	})
+	if err != nil {
+		return Hours{}, fmt.Errorf("failed to list volunteer hours: %w", err)
+	}
+
+	out := Hours{
+		UserID:  user.ID,
+		Name:    user.Name,
+		To:      period.To.Format(time.DateOnly),
+		Years:   []HoursTotal{},
+		Months:  []HoursTotal{},
+		Entries: []HoursEntry{},
+	}
+	if !period.From.IsZero() {
+		from := period.From.Format(time.DateOnly)
+		out.From = &from
+	}
+
+	// entries come ordered by date, so each year and month is one run
+	for _, row := range rows {
+		e := toHoursEntry(row)
+		activities := 0
+		if e.Kind == "activity" {
+			activities = 1
+		}
+		out.TotalMinutes += e.Minutes
+		out.Activities += activities
+		out.Years = addTotal(out.Years, e.Date[:4], e.Minutes, activities)
+		out.Months = addTotal(out.Months, e.Date[:7], e.Minutes, activities)
+		out.Entries = append(out.Entries, e)
+	}
+	out.TotalHours = hoursOf(out.TotalMinutes)
+	for i := range out.Years {
+		out.Years[i].Hours = hoursOf(out.Years[i].Minutes)
+	}
+	for i := range out.Months {
+		out.Months[i].Hours = hoursOf(out.Months[i].Minutes)
+	}
+	return out, nil
+}
+
+func (s *svc) ListHours(ctx context.Context, period Period) ([]VolunteerHours, error) {
+	rows, err := s.repo.ListVolunteerHourTotals(ctx, repo.ListVolunteerHourTotalsParams{
+		FromDate:  fromDate(period),
+		UntilDate: untilDate(period),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list volunteer hours: %w", err)
+	}
+
+	out := make([]VolunteerHours, 0, len(rows))
+	for _, row := range rows {
+		out = append(out, VolunteerHours{
+			UserID:     row.UserID,
+			Name:       row.Name,
+			Minutes:    int(row.Minutes),
+			Hours:      hoursOf(int(row.Minutes)),
+			Activities: int(row.Activities),
+		})
+	}
+	return out, nil
+}
+
+func (s *svc) AddHoursAdjustment(ctx context.Context, userID, staffID int32, req AdjustmentRequest) (HoursEntry, error) {
+	if err := s.checkVolunteer(ctx, userID); err != nil {
+		return HoursEntry{}, err
+	}
+
+	reason := strings.TrimSpace(req.Reason)
+	date, err := parseDate(req.Date)
+	if err != nil || !date.Valid || reason == "" ||
+		req.Minutes == 0 || req.Minutes > maxAdjustmentMinutes || req.Minutes < -maxAdjustmentMinutes {
+		return HoursEntry{}, ErrInvalidAdjustment
+	}
+
+	adj, err := s.repo.CreateVolunteerHourAdjustment(ctx, repo.CreateVolunteerHourAdjustmentParams{
+		UserID:    userID,
+		Minutes:   int32(req.Minutes),
+		WorkedOn:  date,
+		Reason:    reason,
+		CreatedBy: pgtype.Int4{Int32: staffID, Valid: true},
+	})
+	if err != nil {
+		return HoursEntry{}, fmt.Errorf("failed to add hours adjustment: %w", err)
+	}
+
+	return toHoursEntry(repo.ListVolunteerHourEntriesRow{
+		Kind:        "adjustment",
+		ID:          adj.ID,
+		WorkedOn:    adj.WorkedOn,
+		Description: adj.Reason,
+		Minutes:     adj.Minutes,
+		CreatedBy:   adj.CreatedBy,
+	}), nil
+}
+
+func addTotal(totals []HoursTotal, period string, minutes, activities int) []HoursTotal {
+	if n := len(totals); n > 0 && totals[n-1].Period == period {
+		totals[n-1].Minutes += minutes
+		totals[n-1].Activities += activities
+		return totals
+	}
+	return append(totals, HoursTotal{Period: period, Minutes: minutes, Activities: activities})
+}
+
+func fromDate(p Period) pgtype.Date {
+	if p.From.IsZero() {
+		return pgtype.Date{InfinityModifier: pgtype.NegativeInfinity, Valid: true}
+	}
+	return pgtype.Date{Time: p.From, Valid: true}
+}
+
+// the day after the period, as queries take a half-open range
+func untilDate(p Period) pgtype.Date {
+	return pgtype.Date{Time: p.To.AddDate(0, 0, 1), Valid: true}
+}
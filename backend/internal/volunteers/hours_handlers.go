@@ -0,0 +1,144 @@
+package volunteers
+
+import (
+	"errors"
+	"fmt"
+	"hack4good-backend/internal/auth"
+	"hack4good-backend/internal/json"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+var errInvalidPeriod = errors.New("use year=YYYY, or from and/or to as YYYY-MM-DD with from before to")
+
+// ?year=YYYY, or ?from=YYYY-MM-DD&to=YYYY-MM-DD (both inclusive, either optional);
+// without them, everything up to today
+func parsePeriod(r *http.Request) (Period, error) {
+	q := r.URL.Query()
+	year, from, to := q.Get("year"), q.Get("from"), q.Get("to")
+
+	if year != "" {
+		if from != "" || to != "" {
+			return Period{}, errInvalidPeriod
+		}
+		y, err := strconv.Atoi(year)
+		if err != nil || y < 1900 || y > 9999 {
+			return Period{}, errInvalidPeriod
+		}
+		start := time.Date(y, time.January, 1, 0, 0, 0, 0, time.UTC)
+		return Period{From: start, To: start.AddDate(1, 0, -1)}, nil
+	}
+
+	p := Period{To: today()}
+	if from != "" {
+		t, err := time.Parse(time.DateOnly, from)
+		if err != nil {
+			return Period{}, errInvalidPeriod
+		}
+		p.From = t
+	}
+	if to != "" {
+		t, err := time.Parse(time.DateOnly, to)
+		if err != nil {
+			return Period{}, errInvalidPeriod
+		}
+		p.To = t
+	}
+	if !p.From.IsZero() && p.To.Before(p.From) {
+		return Period{}, errInvalidPeriod
+	}
+	return p, nil
+}
+
+// Hours ledger with monthly and yearly totals, ?year= or ?from=&to=
+func (h *Handler) GetHours(w http.ResponseWriter, r *http.Request) {
+	userID, ok := h.subject(w, r, auth.VolunteersRead)
+	if !ok {
+		return
+	}
+	period, err := parsePeriod(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	hours, err := h.service.Hours(r.Context(), userID, period)
+	if err != nil {
+		writeError(w, err, "failed to get volunteer hours")
+		return
+	}
+
+	json.Write(w, http.StatusOK, hours)
+}
+
+// PDF certificate of service hours, ?year= or ?from=&to=
+func (h *Handler) HoursCertificate(w http.ResponseWriter, r *http.Request) {
+	userID, ok := h.subject(w, r, auth.VolunteersRead)
+	if !ok {
+		return
+	}
+	period, err := parsePeriod(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	hours, err := h.service.Hours(r.Context(), userID, period)
+	if err != nil {
+		writeError(w, err, "failed to get volunteer hours")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/pdf")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="volunteer-hours-%d.pdf"`, userID))
+	w.WriteHeader(http.StatusOK)
+	if _, err := w.Write(renderCertificate(hours, h.orgName, time.Now())); err != nil {
+		log.Println(err)
+	}
+}
+
+// Add a signed correction to a volunteer's hours (by staff)
+func (h *Handler) AddHoursAdjustment(w http.ResponseWriter, r *http.Request) {
+	claims, ok := auth.FromContext(r.Context())
+	if !ok {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	userID, ok := h.subject(w, r, auth.VolunteersWrite)
+	if !ok {
+		return
+	}
+
+	var req AdjustmentRequest
+	if err := json.Read(r, &req); err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	entry, err := h.service.AddHoursAdjustment(r.Context(), userID, claims.ID, req)
+	if err != nil {
+		writeError(w, err, "failed to add hours adjustment")
+		return
+	}
+
+	json.Write(w, http.StatusCreated, entry)
+}
+
+// Hours per volunteer, most first (by staff), ?year= or ?from=&to=
+func (h *Handler) ListHours(w http.ResponseWriter, r *http.Request) {
+	period, err := parsePeriod(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	totals, err := h.service.ListHours(r.Context(), period)
+	if err != nil {
+		writeError(w, err, "failed to list volunteer hours")
+		return
+	}
+
+	json.Write(w, http.StatusOK, totals)
+}
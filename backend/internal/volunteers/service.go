@@ -0,0 +1,258 @@
+package volunteers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	repo "hack4good-backend/db/sqlc"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+var (
+	ErrUserNotFound          = errors.New("user not found")
+	ErrNotVolunteer          = errors.New("volunteer profiles are only for volunteers")
+	ErrInvalidSkill          = errors.New("skills must be from: first_aid, sign_language, driving, wheelchair_handling")
+	ErrInvalidWindow         = errors.New("availability windows need a weekday from 0 (Sunday) to 6 and a start before the end as HH:MM")
+	ErrInvalidCertification  = errors.New("certification needs a name, dates as YYYY-MM-DD and an expiry after the issue date")
+	ErrCertificationNotFound = errors.New("certification not found")
+	ErrInvalidAdjustment     = errors.New("adjustment needs non-zero minutes (at most 1000 hours either way), a date as YYYY-MM-DD and a reason")
+)
+
+type Service interface {
+	GetProfile(ctx context.Context, userID int32) (Profile, error)
+	UpdateProfile(ctx context.Context, userID int32, req ProfileRequest) (Profile, error)
+	AddCertification(ctx context.Context, userID int32, req CertificationRequest) (Certification, error)
+	DeleteCertification(ctx context.Context, userID, certID int32) error
+	Hours(ctx context.Context, userID int32, period Period) (Hours, error)
+	ListHours(ctx context.Context, period Period) ([]VolunteerHours, error)
+	AddHoursAdjustment(ctx context.Context, userID, staffID int32, req AdjustmentRequest) (HoursEntry, error)
+}
+
+type svc struct {
+	db   *pgxpool.Pool // availability is replaced as a whole
+	repo *repo.Queries
+}
+
+func NewService(db *pgxpool.Pool) Service {
+	return &svc{
+		db:   db,
+		repo: repo.New(db),
+	}
+}
+
+func (s *svc) GetProfile(ctx context.Context, userID int32) (Profile, error) {
+	if err := s.checkVolunteer(ctx, userID); err != nil {
+		return Profile{}, err
+	}
+	return s.profile(ctx, s.repo, userID)
+}
+
+func (s *svc) UpdateProfile(ctx context.Context, userID int32, req ProfileRequest) (Profile, error) {
+	if err := s.checkVolunteer(ctx, userID); err != nil {
+		return Profile{}, err
+	}
+
+	var windows []repo.CreateVolunteerAvailabilityParams
+	if req.Availability != nil {
+		for _, w := range *req.Availability {
+			start, err := ParseClock(w.Start)
+			if err != nil {
+				return Profile{}, ErrInvalidWindow
+			}
+			end, err := ParseClock(w.End)
+			if err != nil || w.Weekday < 0 || w.Weekday > 6 || end.Microseconds <= start.Microseconds {
+				return Profile{}, ErrInvalidWindow
+			}
+			windows = append(windows, repo.CreateVolunteerAvailabilityParams{
+				UserID:    userID,
+				Weekday:   int16(w.Weekday),
+				StartTime: start,
+				EndTime:   end,
+			})
+		}
+	}
+
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return Profile{}, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+	q := s.repo.WithTx(tx)
+
+	current, err := q.GetVolunteerProfile(ctx, userID)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return Profile{}, fmt.Errorf("failed to get volunteer profile: %w", err)
+	}
+	params := repo.UpsertVolunteerProfileParams{
+		UserID:          userID,
+		Skills:          nonNil(current.Skills),
+		PreferredVenues: nonNil(current.PreferredVenues),
+	}
+	if req.Skills != nil {
+		params.Skills = []string{}
+		for _, skill := range dedupe(*req.Skills) {
+			if !ValidSkill(skill) {
+				return Profile{}, ErrInvalidSkill
+			}
+			params.Skills = append(params.Skills, skill)
+		}
+	}
+	if req.PreferredVenues != nil {
+		params.PreferredVenues = dedupe(*req.PreferredVenues)
+	}
+	if _, err := q.UpsertVolunteerProfile(ctx, params); err != nil {
+		return Profile{}, fmt.Errorf("failed to save volunteer profile: %w", err)
+	}
+
+	if req.Availability != nil {
+		if err := q.DeleteVolunteerAvailability(ctx, userID); err != nil {
+			return Profile{}, fmt.Errorf("failed to clear availability: %w", err)
+		}
+		for _, w := range windows {
+			if err := q.CreateVolunteerAvailability(ctx, w); err != nil {
+				return Profile{}, fmt.Errorf("failed to save availability: %w", err)
+			}
+		}
+	}
+
+	profile, err := s.profile(ctx, q, userID)
+	if err != nil {
+		return Profile{}, err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return Profile{}, fmt.Errorf("failed to commit volunteer profile: %w", err)
+	}
+	return profile, nil
+}
+
+func (s *svc) AddCertification(ctx context.Context, userID int32, req CertificationRequest) (Certification, error) {
+	if err := s.checkVolunteer(ctx, userID); err != nil {
+		return Certification{}, err
+	}
+
+	name := strings.TrimSpace(req.Name)
+	issued, err := parseDate(req.IssuedOn)
+	if err != nil || name == "" {
+		return Certification{}, ErrInvalidCertification
+	}
+	expires, err := parseDate(req.ExpiresOn)
+	if err != nil || (issued.Valid && expires.Valid && expires.Time.Before(issued.Time)) {
+		return Certification{}, ErrInvalidCertification
+	}
+	issuer := strings.TrimSpace(req.Issuer)
+
+	cert, err := s.repo.CreateVolunteerCertification(ctx, repo.CreateVolunteerCertificationParams{
+		UserID:    userID,
+		Name:      name,
+		Issuer:    pgtype.Text{String: issuer, Valid: issuer != ""},
+		IssuedOn:  issued,
+		ExpiresOn: expires,
+	})
+	if err != nil {
+		return Certification{}, fmt.Errorf("failed to add certification: %w", err)
+	}
+	return toCertification(cert, today()), nil
+}
+
+func (s *svc) DeleteCertification(ctx context.Context, userID, certID int32) error {
+	n, err := s.repo.DeleteVolunteerCertification(ctx, repo.DeleteVolunteerCertificationParams{
+		ID:     certID,
+		UserID: userID,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to delete certification: %w", err)
+	}
+	if n == 0 {
+		return ErrCertificationNotFound
+	}
+	return nil
+}
+
+func (s *svc) profile(ctx context.Context, q *repo.Queries, userID int32) (Profile, error) {
+	out := Profile{
+		UserID:          userID,
+		Skills:          []string{},
+		PreferredVenues: []string{},
+		Availability:    []Window{},
+		Certifications:  []Certification{},
+	}
+
+	vp, err := q.GetVolunteerProfile(ctx, userID)
+	switch {
+	case err == nil:
+		out.Skills = nonNil(vp.Skills)
+		out.PreferredVenues = nonNil(vp.PreferredVenues)
+		out.UpdatedAt = &vp.UpdatedAt.Time
+	case !errors.Is(err, pgx.ErrNoRows):
+		return Profile{}, fmt.Errorf("failed to get volunteer profile: %w", err)
+	}
+
+	windows, err := q.ListVolunteerAvailability(ctx, userID)
+	if err != nil {
+		return Profile{}, fmt.Errorf("failed to list availability: %w", err)
+	}
+	for _, w := range windows {
+		out.Availability = append(out.Availability, toWindow(w))
+	}
+
+	certs, err := q.ListVolunteerCertifications(ctx, userID)
+	if err != nil {
+		return Profile{}, fmt.Errorf("failed to list certifications: %w", err)
+	}
+	now := today()
+	for _, c := range certs {
+		out.Certifications = append(out.Certifications, toCertification(c, now))
+	}
+	return out, nil
+}
+
+func (s *svc) checkVolunteer(ctx context.Context, userID int32) error {
+	_, err := s.getVolunteer(ctx, userID)
+	return err
+}
+
+func (s *svc) getVolunteer(ctx context.Context, userID int32) (repo.User, error) {
+	user, err := s.repo.GetUserByID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return repo.User{}, ErrUserNotFound
+		}
+		return repo.User{}, fmt.Errorf("failed to get user: %w", err)
+	}
+	if user.Role != "volunteer" {
+		return repo.User{}, ErrNotVolunteer
+	}
+	return user, nil
+}
+
+// trimmed, without blanks or repeats, in the order given
+func dedupe(values []string) []string {
+	out := []string{}
+	seen := make(map[string]bool, len(values))
+	for _, v := range values {
+		v = strings.TrimSpace(v)
+		if v != "" && !seen[v] {
+			seen[v] = true
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+func nonNil(values []string) []string {
+	if values == nil {
+		return []string{}
+	}
+	return values
+}
+
+// start of the current day; DATE values come back as midnight UTC
+func today() time.Time {
+	now := time.Now()
+	return time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+}
@@ -0,0 +1,205 @@
+package volunteers
+
+import (
+	"fmt"
+	repo "hack4good-backend/db/sqlc"
+	"math"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// skills a volunteer can list (volunteer_profiles.skills)
+const (
+	SkillFirstAid           = "first_aid"
+	SkillSignLanguage       = "sign_language"
+	SkillDriving            = "driving"
+	SkillWheelchairHandling = "wheelchair_handling"
+)
+
+var skills = map[string]bool{
+	SkillFirstAid:           true,
+	SkillSignLanguage:       true,
+	SkillDriving:            true,
+	SkillWheelchairHandling: true,
+}
+
+func ValidSkill(s string) bool {
+	return skills[s]
+}
+
+// volunteer profile as returned by the API; volunteers without one get an empty profile
+type Profile struct {
+	UserID          int32           `json:"user_id"`
+	Skills          []string        `json:"skills"`
+	PreferredVenues []string        `json:"preferred_venues"`
+	Availability    []Window        `json:"availability"`
+	Certifications  []Certification `json:"certifications"`
+	UpdatedAt       *time.Time      `json:"updated_at"`
+}
+
+// weekly availability in local time; weekday 0 is Sunday, times are HH:MM
+type Window struct {
+	Weekday int    `json:"weekday"`
+	Start   string `json:"start"`
+	End     string `json:"end"`
+}
+
+type Certification struct {
+	ID        int32   `json:"id"`
+	Name      string  `json:"name"`
+	Issuer    *string `json:"issuer"`
+	IssuedOn  *string `json:"issued_on"`  // YYYY-MM-DD
+	ExpiresOn *string `json:"expires_on"` // YYYY-MM-DD, null if it does not expire
+	Expired   bool    `json:"expired"`
+}
+
+// PUT replaces the lists that are sent and keeps the others
+type ProfileRequest struct {
+	Skills          *[]string `json:"skills"`
+	PreferredVenues *[]string `json:"preferred_venues"`
+	Availability    *[]Window `json:"availability"`
+}
+
+type CertificationRequest struct {
+	Name      string `json:"name"`
+	Issuer    string `json:"issuer,omitempty"`
+	IssuedOn  string `json:"issued_on,omitempty"`
+	ExpiresOn string `json:"expires_on,omitempty"`
+}
+
+// whole days from From to To inclusive; a zero From means since records began
+type Period struct {
+	From time.Time
+	To   time.Time
+}
+
+// volunteer hours over a period, from attended shifts and staff adjustments
+type Hours struct {
+	UserID       int32        `json:"user_id"`
+	Name         string       `json:"name"`
+	From         *string      `json:"from"` // YYYY-MM-DD, null for all time
+	To           string       `json:"to"`   // YYYY-MM-DD
+	TotalMinutes int          `json:"total_minutes"`
+	TotalHours   float64      `json:"total_hours"`
+	Activities   int          `json:"activities"`
+	Years        []HoursTotal `json:"years"`
+	Months       []HoursTotal `json:"months"`
+	Entries      []HoursEntry `json:"entries"`
+}
+
+type HoursTotal struct {
+	Period     string  `json:"period"` // YYYY or YYYY-MM
+	Minutes    int     `json:"minutes"`
+	Hours      float64 `json:"hours"`
+	Activities int     `json:"activities"`
+}
+
+// one line of the ledger: an attended shift or a staff adjustment
+type HoursEntry struct {
+	Kind         string  `json:"kind"` // activity or adjustment
+	BookingID    *int32  `json:"booking_id,omitempty"`
+	AdjustmentID *int32  `json:"adjustment_id,omitempty"`
+	Date         string  `json:"date"`
+	Description  string  `json:"description"` // activity title or adjustment reason
+	Minutes      int     `json:"minutes"`
+	Hours        float64 `json:"hours"`
+	RecordedBy   *int32  `json:"recorded_by,omitempty"`
+}
+
+// totals per volunteer for the staff overview
+type VolunteerHours struct {
+	UserID     int32   `json:"user_id"`
+	Name       string  `json:"name"`
+	Minutes    int     `json:"minutes"`
+	Hours      float64 `json:"hours"`
+	Activities int     `json:"activities"`
+}
+
+type AdjustmentRequest struct {
+	Minutes int    `json:"minutes"` // negative to take hours off
+	Date    string `json:"date"`    // YYYY-MM-DD the hours count towards
+	Reason  string `json:"reason"`
+}
+
+func toWindow(a repo.VolunteerAvailability) Window {
+	return Window{
+		Weekday: int(a.Weekday),
+		Start:   formatClock(a.StartTime),
+		End:     formatClock(a.EndTime),
+	}
+}
+
+func toCertification(c repo.VolunteerCertification, today time.Time) Certification {
+	out := Certification{
+		ID:        c.ID,
+		Name:      c.Name,
+		IssuedOn:  formatDate(c.IssuedOn),
+		ExpiresOn: formatDate(c.ExpiresOn),
+		Expired:   c.ExpiresOn.Valid && c.ExpiresOn.Time.Before(today),
+	}
+	if c.Issuer.Valid {
+		out.Issuer = &c.Issuer.String
+	}
+	return out
+}
+
+func formatClock(t pgtype.Time) string {
+	minutes := t.Microseconds / int64(time.Minute/time.Microsecond)
+	return fmt.Sprintf("%02d:%02d", minutes/60, minutes%60)
+}
+
+// HH:MM as a TIME value
+func ParseClock(s string) (pgtype.Time, error) {
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return pgtype.Time{}, err
+	}
+	d := time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute
+	return pgtype.Time{Microseconds: d.Microseconds(), Valid: true}, nil
+}
+
+func formatDate(d pgtype.Date) *string {
+	if !d.Valid {
+		return nil
+	}
+	s := d.Time.Format(time.DateOnly)
+	return &s
+}
+
+// optional YYYY-MM-DD
+func parseDate(s string) (pgtype.Date, error) {
+	if s == "" {
+		return pgtype.Date{}, nil
+	}
+	t, err := time.Parse(time.DateOnly, s)
+	if err != nil {
+		return pgtype.Date{}, err
+	}
+	return pgtype.Date{Time: t, Valid: true}, nil
+}
+
+func toHoursEntry(e repo.ListVolunteerHourEntriesRow) HoursEntry {
+	out := HoursEntry{
+		Kind:        e.Kind,
+		Date:        e.WorkedOn.Time.Format(time.DateOnly),
+		Description: e.Description,
+		Minutes:     int(e.Minutes),
+		Hours:       hoursOf(int(e.Minutes)),
+	}
+	id := e.ID
+	if e.Kind == "activity" {
+		out.BookingID = &id
+	} else {
+		out.AdjustmentID = &id
+	}
+	if e.CreatedBy.Valid {
+		out.RecordedBy = &e.CreatedBy.Int32
+	}
+	return out
+}
+
+// rounded to two decimals
+func hoursOf(minutes int) float64 {
+	return math.Round(float64(minutes)/60*100) / 100
+}